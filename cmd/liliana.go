@@ -2,12 +2,27 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/josofm/liliana/config"
 	"github.com/josofm/liliana/internal/app"
 )
 
 func main() {
+	// Subcomandos não precisam da configuração da API
+	if len(os.Args) > 1 && os.Args[1] == "catalog" {
+		if err := app.RunCatalog(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("Catalog error: %s", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "user" {
+		if err := app.RunUser(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("User error: %s", err)
+		}
+		return
+	}
+
 	// Configuration
 	cfg, err := config.NewConfig()
 	if err != nil {
@@ -2,18 +2,26 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	App  AppConfig  `yaml:"app"`
-	HTTP HTTPConfig `yaml:"http"`
-	Log  LogConfig  `yaml:"logger"`
-	JWT  JWTConfig  `yaml:"jwt"`
-	DB   DBConfig   `yaml:"database"`
+	App     AppConfig     `yaml:"app"`
+	HTTP    HTTPConfig    `yaml:"http"`
+	Log     LogConfig     `yaml:"logger"`
+	JWT     JWTConfig     `yaml:"jwt"`
+	DB      DBConfig      `yaml:"database"`
+	Deck    DeckConfig    `yaml:"deck"`
+	Mail    MailConfig    `yaml:"mail"`
+	Account AccountConfig `yaml:"account"`
+	OIDC    OIDCConfig    `yaml:"oidc"`
+	Login   LoginConfig   `yaml:"login"`
 }
 
 type AppConfig struct {
@@ -25,6 +33,10 @@ type AppConfig struct {
 type HTTPConfig struct {
 	Port               string `yaml:"port" env:"HTTP_PORT"`
 	CORSAllowedOrigins string `yaml:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	// TrustedProxies lista (separados por vírgula) os proxies cujo
+	// X-Forwarded-For define o IP do cliente, usado nos limites de login.
+	// Vazio não confia em nenhum proxy: o IP é o da conexão.
+	TrustedProxies string `yaml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES"`
 }
 
 type LogConfig struct {
@@ -33,7 +45,16 @@ type LogConfig struct {
 }
 
 type JWTConfig struct {
-	SecretKey     string        `yaml:"secret_key" env:"JWT_SECRET_KEY"`
+	// SecretKey assina os tokens em HS256 enquanto não há ActiveKeyID; com uma
+	// chave ativa, só verifica os tokens HS256 emitidos antes da troca.
+	SecretKey string `yaml:"secret_key" env:"JWT_SECRET_KEY"`
+	// KeysDir guarda as chaves RSA (RS256) ou Ed25519 (EdDSA), uma por arquivo
+	// <kid>.pem. Chaves privadas assinam; chaves públicas só verificam.
+	KeysDir string `yaml:"keys_dir" env:"JWT_KEYS_DIR"`
+	// ActiveKeyID é o kid da chave que assina os tokens novos.
+	ActiveKeyID   string        `yaml:"active_key_id" env:"JWT_ACTIVE_KEY_ID"`
+	Issuer        string        `yaml:"issuer" env:"JWT_ISSUER"`
+	Audience      string        `yaml:"audience" env:"JWT_AUDIENCE"`
 	AccessExpiry  time.Duration `yaml:"access_expiry" env:"JWT_ACCESS_EXPIRY"`
 	RefreshExpiry time.Duration `yaml:"refresh_expiry" env:"JWT_REFRESH_EXPIRY"`
 }
@@ -43,6 +64,88 @@ type DBConfig struct {
 	AutoMigrate bool   `yaml:"auto_migrate" env:"AUTO_MIGRATE"`
 }
 
+// Card sources for deck validation.
+const (
+	CardSourceScryfall = "scryfall"
+	CardSourceCatalog  = "catalog"
+)
+
+type DeckConfig struct {
+	EnforceLegality bool `yaml:"enforce_legality" env:"DECK_ENFORCE_LEGALITY"`
+	// CardSource escolhe onde as cartas são validadas: "scryfall" (API) ou
+	// "catalog" (tabela cards preenchida por `liliana catalog sync`).
+	CardSource string `yaml:"card_source" env:"DECK_CARD_SOURCE"`
+}
+
+// Mail drivers.
+const (
+	MailDriverLog  = "log"
+	MailDriverFile = "file"
+	MailDriverSMTP = "smtp"
+)
+
+type MailConfig struct {
+	// Driver escolhe como os emails saem: "log" (logger da aplicação), "file"
+	// (anexados em FilePath, para testes locais) ou "smtp".
+	Driver       string `yaml:"driver" env:"MAIL_DRIVER"`
+	From         string `yaml:"from" env:"MAIL_FROM"`
+	FilePath     string `yaml:"file_path" env:"MAIL_FILE_PATH"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+}
+
+type AccountConfig struct {
+	// LinkBaseURL é o endereço do front-end usado nos links enviados por
+	// email; vazio envia apenas o token.
+	LinkBaseURL             string        `yaml:"link_base_url" env:"ACCOUNT_LINK_BASE_URL"`
+	PasswordResetExpiry     time.Duration `yaml:"password_reset_expiry" env:"ACCOUNT_PASSWORD_RESET_EXPIRY"`
+	EmailVerificationExpiry time.Duration `yaml:"email_verification_expiry" env:"ACCOUNT_EMAIL_VERIFICATION_EXPIRY"`
+}
+
+type OIDCConfig struct {
+	// IssuerURL habilita o login OIDC; os endpoints do provedor vêm da
+	// descoberta em <issuer>/.well-known/openid-configuration.
+	IssuerURL    string `yaml:"issuer_url" env:"OIDC_ISSUER_URL"`
+	ClientID     string `yaml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string `yaml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	// RedirectURL é o endereço de GET /auth/oidc/callback registrado no provedor.
+	RedirectURL string        `yaml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	Scopes      []string      `yaml:"scopes" env:"OIDC_SCOPES" env-separator:" "`
+	LoginExpiry time.Duration `yaml:"login_expiry" env:"OIDC_LOGIN_EXPIRY"`
+}
+
+// Enabled diz se o login OIDC está configurado
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
+}
+
+// Login attempt stores.
+const (
+	LoginStoreMemory   = "memory"
+	LoginStorePostgres = "postgres"
+)
+
+type LoginConfig struct {
+	// Store guarda os contadores de falhas: "memory" (por instância) ou
+	// "postgres" (compartilhado entre instâncias).
+	Store string `yaml:"store" env:"LOGIN_STORE"`
+	// MaxFailures e IPMaxFailures são as falhas seguidas que bloqueiam um email
+	// ou um IP por LockoutDuration.
+	MaxFailures     int           `yaml:"max_failures" env:"LOGIN_MAX_FAILURES"`
+	IPMaxFailures   int           `yaml:"ip_max_failures" env:"LOGIN_IP_MAX_FAILURES"`
+	LockoutDuration time.Duration `yaml:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION"`
+	// A partir de BackoffAfter falhas, cada tentativa espera BaseDelay, dobrando
+	// a cada falha até MaxDelay.
+	BackoffAfter int           `yaml:"backoff_after" env:"LOGIN_BACKOFF_AFTER"`
+	BaseDelay    time.Duration `yaml:"base_delay" env:"LOGIN_BASE_DELAY"`
+	MaxDelay     time.Duration `yaml:"max_delay" env:"LOGIN_MAX_DELAY"`
+	// FailureWindow é quanto tempo sem falhas zera o contador. Um bloqueio
+	// vale por LockoutDuration inteiro, mesmo quando dura mais que a janela.
+	FailureWindow time.Duration `yaml:"failure_window" env:"LOGIN_FAILURE_WINDOW"`
+}
+
 func NewConfig() (*Config, error) {
 	cfg := &Config{}
 	if path, ok := configPath(); ok {
@@ -74,6 +177,37 @@ func NewConfig() (*Config, error) {
 	if cfg.JWT.RefreshExpiry == 0 {
 		cfg.JWT.RefreshExpiry = 7 * 24 * time.Hour // 7 dias
 	}
+	if cfg.JWT.Issuer == "" {
+		cfg.JWT.Issuer = "liliana"
+	}
+	if cfg.JWT.Audience == "" {
+		cfg.JWT.Audience = cfg.JWT.Issuer
+	}
+	if cfg.Deck.CardSource == "" {
+		cfg.Deck.CardSource = CardSourceScryfall
+	}
+	if cfg.Mail.Driver == "" {
+		cfg.Mail.Driver = MailDriverLog
+	}
+	if cfg.Mail.From == "" {
+		cfg.Mail.From = "no-reply@liliana.local"
+	}
+	if cfg.Mail.SMTPPort == "" {
+		cfg.Mail.SMTPPort = "587"
+	}
+	if cfg.Account.PasswordResetExpiry == 0 {
+		cfg.Account.PasswordResetExpiry = time.Hour
+	}
+	if cfg.Account.EmailVerificationExpiry == 0 {
+		cfg.Account.EmailVerificationExpiry = 48 * time.Hour
+	}
+	if len(cfg.OIDC.Scopes) == 0 {
+		cfg.OIDC.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.OIDC.LoginExpiry == 0 {
+		cfg.OIDC.LoginExpiry = 10 * time.Minute
+	}
+	setLoginDefaults(&cfg.Login)
 	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
 		cfg.DB.URL = databaseURL
 	}
@@ -84,6 +218,33 @@ func NewConfig() (*Config, error) {
 	return cfg, nil
 }
 
+func setLoginDefaults(cfg *LoginConfig) {
+	if cfg.Store == "" {
+		cfg.Store = LoginStoreMemory
+	}
+	if cfg.MaxFailures == 0 {
+		cfg.MaxFailures = 5
+	}
+	if cfg.IPMaxFailures == 0 {
+		cfg.IPMaxFailures = 20
+	}
+	if cfg.LockoutDuration == 0 {
+		cfg.LockoutDuration = 15 * time.Minute
+	}
+	if cfg.BackoffAfter == 0 {
+		cfg.BackoffAfter = 3
+	}
+	if cfg.BaseDelay == 0 {
+		cfg.BaseDelay = time.Second
+	}
+	if cfg.MaxDelay == 0 {
+		cfg.MaxDelay = 30 * time.Second
+	}
+	if cfg.FailureWindow == 0 {
+		cfg.FailureWindow = 15 * time.Minute
+	}
+}
+
 func configPath() (string, bool) {
 	if _, err := os.Stat("config/config.yaml"); err == nil {
 		return "config/config.yaml", true
@@ -99,8 +260,50 @@ func (c *Config) Validate() error {
 	if c.App.Environment == "production" && c.DB.URL == "" {
 		return fmt.Errorf("DATABASE_URL is required in production")
 	}
-	if c.JWT.SecretKey == "" || c.JWT.SecretKey == "dev-secret-change-me" || c.JWT.SecretKey == "your-super-secret-jwt-key-change-in-production" {
-		return fmt.Errorf("JWT_SECRET_KEY must be set to a safe value")
+	if c.JWT.ActiveKeyID != "" && c.JWT.KeysDir == "" {
+		return fmt.Errorf("JWT_KEYS_DIR is required when JWT_ACTIVE_KEY_ID is set")
+	}
+	// Com uma chave ativa o segredo é opcional, mas, se vier, não pode ser conhecido
+	if c.JWT.ActiveKeyID == "" || c.JWT.SecretKey != "" {
+		if c.JWT.SecretKey == "" || c.JWT.SecretKey == "dev-secret-change-me" || c.JWT.SecretKey == "your-super-secret-jwt-key-change-in-production" {
+			return fmt.Errorf("JWT_SECRET_KEY must be set to a safe value")
+		}
+	}
+	if c.Deck.CardSource != "" && c.Deck.CardSource != CardSourceScryfall && c.Deck.CardSource != CardSourceCatalog {
+		return fmt.Errorf("DECK_CARD_SOURCE must be %q or %q", CardSourceScryfall, CardSourceCatalog)
+	}
+	switch c.Mail.Driver {
+	case "", MailDriverLog:
+	case MailDriverFile:
+		if c.Mail.FilePath == "" {
+			return fmt.Errorf("MAIL_FILE_PATH is required when MAIL_DRIVER is %q", MailDriverFile)
+		}
+	case MailDriverSMTP:
+		if c.Mail.SMTPHost == "" {
+			return fmt.Errorf("SMTP_HOST is required when MAIL_DRIVER is %q", MailDriverSMTP)
+		}
+	default:
+		return fmt.Errorf("MAIL_DRIVER must be %q, %q or %q", MailDriverLog, MailDriverFile, MailDriverSMTP)
+	}
+	for _, proxy := range strings.Split(c.HTTP.TrustedProxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if _, _, err := net.ParseCIDR(proxy); proxy != "" && err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("HTTP_TRUSTED_PROXIES has an invalid address: %q", proxy)
+		}
+	}
+	if c.Login.Store != "" && c.Login.Store != LoginStoreMemory && c.Login.Store != LoginStorePostgres {
+		return fmt.Errorf("LOGIN_STORE must be %q or %q", LoginStoreMemory, LoginStorePostgres)
+	}
+	if c.Login.MaxFailures < 0 || c.Login.IPMaxFailures < 0 || c.Login.BackoffAfter < 0 {
+		return fmt.Errorf("LOGIN_MAX_FAILURES, LOGIN_IP_MAX_FAILURES and LOGIN_BACKOFF_AFTER must not be negative")
+	}
+	if c.OIDC.Enabled() {
+		if c.OIDC.ClientID == "" || c.OIDC.RedirectURL == "" {
+			return fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER_URL is set")
+		}
+		if len(c.OIDC.Scopes) > 0 && !slices.Contains(c.OIDC.Scopes, "openid") {
+			return fmt.Errorf("OIDC_SCOPES must include %q", "openid")
+		}
 	}
 
 	return nil
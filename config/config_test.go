@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -86,3 +87,131 @@ func TestNewConfig_ProductionFromEnvWithoutConfigFile(t *testing.T) {
 	assert.Equal(t, "postgres://example", cfg.DB.URL)
 	assert.Equal(t, "production-secret", cfg.JWT.SecretKey)
 }
+
+func TestNewConfig_DeckCardSource(t *testing.T) {
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	t.Setenv("DECK_CARD_SOURCE", "catalog")
+
+	cfg, err := NewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, CardSourceCatalog, cfg.Deck.CardSource)
+
+	t.Setenv("DECK_CARD_SOURCE", "gatherer")
+	_, err = NewConfig()
+	assert.Error(t, err)
+}
+
+func TestNewConfig_Mail(t *testing.T) {
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	t.Setenv("MAIL_DRIVER", "smtp")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+
+	cfg, err := NewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, MailDriverSMTP, cfg.Mail.Driver)
+	assert.Equal(t, time.Hour, cfg.Account.PasswordResetExpiry)
+
+	t.Setenv("SMTP_HOST", "")
+	_, err = NewConfig()
+	assert.Error(t, err, "smtp needs a host")
+
+	t.Setenv("MAIL_DRIVER", "file")
+	_, err = NewConfig()
+	assert.Error(t, err, "file needs a path")
+
+	t.Setenv("MAIL_DRIVER", "pigeon")
+	_, err = NewConfig()
+	assert.Error(t, err)
+}
+
+func TestNewConfig_OIDC(t *testing.T) {
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	cfg, err := NewConfig()
+	require.NoError(t, err)
+	assert.False(t, cfg.OIDC.Enabled())
+
+	t.Setenv("OIDC_ISSUER_URL", "https://accounts.example.com")
+	_, err = NewConfig()
+	assert.Error(t, err, "oidc needs a client and a redirect url")
+
+	t.Setenv("OIDC_CLIENT_ID", "liliana")
+	t.Setenv("OIDC_REDIRECT_URL", "https://api.example.com/auth/oidc/callback")
+	t.Setenv("OIDC_SCOPES", "openid email")
+	cfg, err = NewConfig()
+	require.NoError(t, err)
+	assert.True(t, cfg.OIDC.Enabled())
+	assert.Equal(t, []string{"openid", "email"}, cfg.OIDC.Scopes)
+	assert.Equal(t, 10*time.Minute, cfg.OIDC.LoginExpiry)
+
+	t.Setenv("OIDC_SCOPES", "email profile")
+	_, err = NewConfig()
+	assert.Error(t, err, "the openid scope is required")
+}
+
+func TestNewConfig_Login(t *testing.T) {
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	cfg, err := NewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, LoginStoreMemory, cfg.Login.Store)
+	assert.Equal(t, 5, cfg.Login.MaxFailures)
+	assert.Equal(t, 15*time.Minute, cfg.Login.LockoutDuration)
+
+	t.Setenv("LOGIN_STORE", "postgres")
+	t.Setenv("LOGIN_MAX_FAILURES", "3")
+	cfg, err = NewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, LoginStorePostgres, cfg.Login.Store)
+	assert.Equal(t, 3, cfg.Login.MaxFailures)
+
+	t.Setenv("LOGIN_STORE", "redis")
+	_, err = NewConfig()
+	assert.Error(t, err)
+}
+
+func TestNewConfig_TrustedProxies(t *testing.T) {
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	t.Setenv("HTTP_TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.1")
+
+	cfg, err := NewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.0/8, 192.0.2.1", cfg.HTTP.TrustedProxies)
+
+	t.Setenv("HTTP_TRUSTED_PROXIES", "load-balancer")
+	_, err = NewConfig()
+	assert.Error(t, err)
+}
+
+func TestNewConfig_JWTKeys(t *testing.T) {
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	cfg, err := NewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "liliana", cfg.JWT.Issuer)
+	assert.Equal(t, "liliana", cfg.JWT.Audience)
+
+	t.Setenv("JWT_ISSUER", "https://api.example.com")
+	t.Setenv("JWT_ACTIVE_KEY_ID", "2030-01")
+	_, err = NewConfig()
+	assert.ErrorContains(t, err, "JWT_KEYS_DIR")
+
+	// Com uma chave ativa o segredo é opcional
+	t.Setenv("JWT_KEYS_DIR", "/etc/liliana/keys")
+	t.Setenv("JWT_SECRET_KEY", "")
+	cfg, err = NewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/liliana/keys", cfg.JWT.KeysDir)
+	assert.Equal(t, "2030-01", cfg.JWT.ActiveKeyID)
+	assert.Equal(t, "https://api.example.com", cfg.JWT.Audience, "the audience defaults to the issuer")
+
+	t.Setenv("JWT_SECRET_KEY", "dev-secret-change-me")
+	_, err = NewConfig()
+	assert.ErrorContains(t, err, "JWT_SECRET_KEY")
+}
@@ -25,11 +25,12 @@ func main() {
 		}
 	}
 
+	// Packages share the test database and truncate its tables, so they run one at a time.
 	if status == 0 {
 		err := run(
 			"docker", "compose", "-f", "docker-compose.yaml",
 			"run", "--rm", "--no-deps",
-			"liliana", "go", "test", "-race", "-timeout", "60s", "-tags", "integration", "./...",
+			"liliana", "go", "test", "-race", "-p", "1", "-timeout", "60s", "-tags", "integration", "./...",
 		)
 		if err != nil {
 			status = exitCode(err)
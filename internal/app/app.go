@@ -12,7 +12,17 @@ import (
 	"github.com/josofm/liliana/config"
 	v1 "github.com/josofm/liliana/internal/controller/http/v1"
 	"github.com/josofm/liliana/internal/migration"
+	apiKeyRepo "github.com/josofm/liliana/internal/repository/apikey"
+	auditRepo "github.com/josofm/liliana/internal/repository/audit"
+	cardRepo "github.com/josofm/liliana/internal/repository/card"
+	collectionRepo "github.com/josofm/liliana/internal/repository/collection"
 	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	gameRepo "github.com/josofm/liliana/internal/repository/game"
+	identityRepo "github.com/josofm/liliana/internal/repository/identity"
+	loginAttemptRepo "github.com/josofm/liliana/internal/repository/loginattempt"
+	playgroupRepo "github.com/josofm/liliana/internal/repository/playgroup"
+	refreshTokenRepo "github.com/josofm/liliana/internal/repository/refreshtoken"
+	tokenRepo "github.com/josofm/liliana/internal/repository/token"
 	userRepo "github.com/josofm/liliana/internal/repository/user"
 	"github.com/josofm/liliana/pkg/httpserver"
 	"github.com/josofm/liliana/pkg/logger"
@@ -34,11 +44,23 @@ func Run(cfg *config.Config) {
 		}
 	}
 
-	userRepo := userRepo.NewPostgresRepo(db)
-	deckRepo := deckRepo.NewPostgresRepo(db)
+	repos := v1.Repositories{
+		Users:         userRepo.NewPostgresRepo(db),
+		Decks:         deckRepo.NewPostgresRepo(db),
+		Cards:         cardRepo.NewPostgresRepo(db),
+		Collection:    collectionRepo.NewPostgresRepo(db),
+		Games:         gameRepo.NewPostgresRepo(db),
+		Playgroups:    playgroupRepo.NewPostgresRepo(db),
+		Tokens:        tokenRepo.NewPostgresRepo(db),
+		RefreshTokens: refreshTokenRepo.NewPostgresRepo(db),
+		APIKeys:       apiKeyRepo.NewPostgresRepo(db),
+		Identities:    identityRepo.NewPostgresRepo(db),
+		LoginAttempts: newLoginAttemptRepo(cfg.Login, db),
+		Audit:         auditRepo.NewPostgresRepo(db),
+	}
 
 	// Passar a configuração para o router
-	v1.NewRouter(handler, l, userRepo, deckRepo, cfg)
+	v1.NewRouter(handler, l, repos, cfg)
 
 	httpServer := httpserver.New(handler, cfg.HTTP.Port)
 
@@ -75,3 +97,12 @@ func openDatabase(cfg *config.Config) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// newLoginAttemptRepo keeps the failed login counters in memory unless they
+// must be shared between instances.
+func newLoginAttemptRepo(cfg config.LoginConfig, db *sql.DB) loginAttemptRepo.Repository {
+	if cfg.Store == config.LoginStorePostgres {
+		return loginAttemptRepo.NewPostgresRepo(db)
+	}
+	return loginAttemptRepo.NewInMemoryRepo()
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	cardRepo "github.com/josofm/liliana/internal/repository/card"
+	deckService "github.com/josofm/liliana/internal/service/deck"
+)
+
+const catalogUsage = "usage: liliana catalog sync <oracle-cards.json>"
+
+// RunCatalog executa `liliana catalog <comando>`. O único comando é "sync",
+// que carrega o arquivo "Oracle Cards" do bulk data do Scryfall na tabela
+// cards. Use "-" para ler da entrada padrão. Precisa de DATABASE_URL.
+func RunCatalog(args []string, out io.Writer) error {
+	if len(args) != 2 || args[0] != "sync" {
+		return errors.New(catalogUsage)
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return errors.New("DATABASE_URL is required")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		return err
+	}
+
+	return syncCatalog(args[1], cardRepo.NewPostgresRepo(db), out)
+}
+
+func syncCatalog(path string, repo cardRepo.Repository, out io.Writer) error {
+	var source io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		source = file
+	}
+
+	count, err := deckService.SyncCatalog(source, repo)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "catalog synced: %d cards\n", count)
+	return nil
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	userEntity "github.com/josofm/liliana/internal/entity/user"
+	userRepo "github.com/josofm/liliana/internal/repository/user"
+)
+
+const userUsage = "usage: liliana user role <email> <user|admin>"
+
+// RunUser executa `liliana user <comando>`. O único comando é "role", que
+// muda o papel de um usuário pelo email; serve para criar o primeiro admin de
+// uma instalação que já tem usuários. Precisa de DATABASE_URL.
+func RunUser(args []string, out io.Writer) error {
+	if len(args) != 3 || args[0] != "role" {
+		return errors.New(userUsage)
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return errors.New("DATABASE_URL is required")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		return err
+	}
+
+	return setUserRole(userRepo.NewPostgresRepo(db), args[1], args[2], out)
+}
+
+func setUserRole(repo userRepo.Repository, email, role string, out io.Writer) error {
+	if role != userEntity.RoleUser && role != userEntity.RoleAdmin {
+		return errors.New(userUsage)
+	}
+	u, err := repo.GetByEmail(email)
+	if err != nil {
+		return err
+	}
+	if err := repo.SetRole(u.ID, role); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "user %s is now %s\n", u.Email, role)
+	return nil
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	deckService "github.com/josofm/liliana/internal/service/deck"
+)
+
+type AnalyticsHandler struct {
+	service *deckService.Service
+}
+
+func NewAnalyticsHandler(r *gin.Engine, repo deckRepo.Repository) {
+	h := &AnalyticsHandler{service: deckService.NewService(repo)}
+	h.registerRoutes(r)
+}
+
+func (h *AnalyticsHandler) registerRoutes(rg RouterGroup) {
+	rg.Group("/cards").GET("/:oracle_id/usage", h.cardUsage)
+	analytics := rg.Group("/analytics")
+	analytics.GET("/top-cards", h.topCards)
+	analytics.GET("/commanders/:name/staples", h.commanderStaples)
+}
+
+// cardUsage returns how many decks play a card and under which commanders.
+// Private and unlisted decks of other users are not counted.
+func (h *AnalyticsHandler) cardUsage(c *gin.Context) {
+	viewerID, _ := GetUserIDFromContext(c)
+	usage, err := h.service.CardUsage(c.Request.Context(), c.Param("oracle_id"), viewerID)
+	if err != nil {
+		if err.Error() == "card not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load card usage"})
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+// topCards returns the most played cards, optionally of one format or color.
+func (h *AnalyticsHandler) topCards(c *gin.Context) {
+	filter, ok := inclusionFilterQuery(c)
+	if !ok {
+		return
+	}
+	viewerID, _ := GetUserIDFromContext(c)
+	report, err := h.service.TopCards(c.Request.Context(), viewerID, filter)
+	respondInclusion(c, report, err)
+}
+
+// commanderStaples returns the most played cards in the decks of a commander.
+func (h *AnalyticsHandler) commanderStaples(c *gin.Context) {
+	filter, ok := inclusionFilterQuery(c)
+	if !ok {
+		return
+	}
+	viewerID, _ := GetUserIDFromContext(c)
+	report, err := h.service.CommanderStaples(c.Request.Context(), viewerID, c.Param("name"), filter)
+	respondInclusion(c, report, err)
+}
+
+func inclusionFilterQuery(c *gin.Context) (deckRepo.InclusionFilter, bool) {
+	filter := deckRepo.InclusionFilter{Format: c.Query("format"), Color: c.Query("color")}
+	var err error
+	if filter.IncludeBasicLands, err = boolQuery(c, "include_basics"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	if limit := c.Query("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return filter, false
+		}
+	}
+	return filter, true
+}
+
+func respondInclusion(c *gin.Context, report *deckRepo.InclusionReport, err error) {
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, report)
+	case errors.Is(err, deckRepo.ErrInvalidFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load card inclusion"})
+	}
+}
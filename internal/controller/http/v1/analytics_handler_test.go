@@ -0,0 +1,114 @@
+//go:build integration
+
+package v1_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/josofm/liliana/internal/controller/http/v1"
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAnalyticsHandler(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	repo := deckRepo.NewInMemoryRepo()
+	solRing := deckEntity.Card{OracleID: "sol-ring", Name: "Sol Ring", Quantity: 1}
+	signet := deckEntity.Card{OracleID: "arcane-signet", Name: "Arcane Signet", Quantity: 1}
+	island := deckEntity.Card{OracleID: "island", Name: "Island", Quantity: 30, TypeLine: "Basic Land — Island"}
+	for _, d := range []*deckEntity.Deck{
+		{Name: "Krenko", Color: "R", Format: "commander", Commander: "Krenko, Mob Boss", Cards: []deckEntity.Card{solRing}},
+		{Name: "Partners", Color: "UW", Format: "commander", Commander: "Krenko, Mob Boss / Thrasios, Triton Hero", Cards: []deckEntity.Card{solRing, signet, island}},
+		{Name: "Talrand", Color: "U", Format: "commander", Commander: "Talrand, Sky Summoner", Cards: []deckEntity.Card{signet, island}},
+	} {
+		require.NoError(t, repo.Create(d))
+	}
+	v1.NewAnalyticsHandler(router, repo)
+	return router
+}
+
+func getAnalytics(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAnalyticsHandler_CardUsage(t *testing.T) {
+	router := setupAnalyticsHandler(t)
+
+	w := getAnalytics(router, "/cards/sol-ring/usage")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var usage deckRepo.CardUsage
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, 2, usage.Decks)
+	assert.Equal(t, []deckRepo.CommanderUsage{
+		{Commander: "Krenko, Mob Boss", Decks: 1},
+		{Commander: "Krenko, Mob Boss / Thrasios, Triton Hero", Decks: 1},
+	}, usage.Commanders)
+
+	assert.Equal(t, http.StatusNotFound, getAnalytics(router, "/cards/unknown/usage").Code)
+}
+
+func TestAnalyticsHandler_TopCards(t *testing.T) {
+	router := setupAnalyticsHandler(t)
+
+	w := getAnalytics(router, "/analytics/top-cards?format=commander")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"decks":3,"cards":[
+		{"oracle_id":"arcane-signet","name":"Arcane Signet","decks":2,"inclusion_rate":0.6666666666666666},
+		{"oracle_id":"sol-ring","name":"Sol Ring","decks":2,"inclusion_rate":0.6666666666666666}]}`, w.Body.String())
+
+	w = getAnalytics(router, "/analytics/top-cards?color=u&include_basics=true&limit=1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"decks":1,"cards":[{"oracle_id":"arcane-signet","name":"Arcane Signet","decks":1,"inclusion_rate":1}]}`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, getAnalytics(router, "/analytics/top-cards?limit=0").Code)
+	assert.Equal(t, http.StatusBadRequest, getAnalytics(router, "/analytics/top-cards?limit=101").Code)
+	assert.Equal(t, http.StatusBadRequest, getAnalytics(router, "/analytics/top-cards?include_basics=maybe").Code)
+}
+
+func TestAnalyticsHandler_CommanderStaples(t *testing.T) {
+	router := setupAnalyticsHandler(t)
+
+	w := getAnalytics(router, "/analytics/commanders/Krenko,%20Mob%20Boss/staples")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var report deckRepo.InclusionReport
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 2, report.Decks)
+	require.Len(t, report.Cards, 2)
+	assert.Equal(t, deckRepo.CardInclusion{OracleID: "sol-ring", Name: "Sol Ring", Decks: 2, InclusionRate: 1}, report.Cards[0])
+
+	w = getAnalytics(router, "/analytics/commanders/Nobody/staples")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"decks":0,"cards":[]}`, w.Body.String())
+}
+
+func TestAnalyticsHandler_SkipsPrivateDecks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", int64(3))
+		c.Next()
+	})
+	repo := deckRepo.NewInMemoryRepo()
+	solRing := deckEntity.Card{OracleID: "sol-ring", Name: "Sol Ring", Quantity: 1}
+	require.NoError(t, repo.Create(&deckEntity.Deck{Name: "Secret", Color: "R", Format: "commander", Commander: "Krenko, Mob Boss", OwnerID: 1, Visibility: deckEntity.VisibilityPrivate, Cards: []deckEntity.Card{solRing}}))
+	v1.NewAnalyticsHandler(router, repo)
+
+	assert.Equal(t, http.StatusNotFound, getAnalytics(router, "/cards/sol-ring/usage").Code)
+	w := getAnalytics(router, "/analytics/commanders/Krenko,%20Mob%20Boss/staples")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"decks":0,"cards":[]}`, w.Body.String())
+	w = getAnalytics(router, "/analytics/top-cards")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"decks":0,"cards":[]}`, w.Body.String())
+}
@@ -1,11 +1,16 @@
 package v1
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/josofm/liliana/internal/entity/auth"
 	authService "github.com/josofm/liliana/internal/service/auth"
+	userService "github.com/josofm/liliana/internal/service/user"
 	"github.com/josofm/liliana/internal/validator"
 )
 
@@ -64,8 +69,15 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Fazer login
+	request.IP = c.ClientIP()
 	response, err := h.service.Login(&request)
 	if err != nil {
+		var blocked *authService.LoginBlockedError
+		if errors.As(err, &blocked) {
+			c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(blocked.RetryAfter)))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
 		if err.Error() == "invalid credentials" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
@@ -97,13 +109,51 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	// Renovar token
 	response, err := h.service.RefreshToken(request.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		respondRefreshTokenError(c, err, "failed to refresh token")
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// Logout encerra a sessão do refresh token
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var request auth.LogoutRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validar request
+	if validationErrors := h.validator.ValidateAndGetErrors(&request); validationErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+		return
+	}
+
+	if err := h.service.Logout(request.RefreshToken); err != nil {
+		respondRefreshTokenError(c, err, "failed to logout")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll encerra todas as sessões do usuário autenticado
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	if err := h.service.LogoutAll(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // Me retorna informações do usuário autenticado
 func (h *AuthHandler) Me(c *gin.Context) {
 	userID, exists := GetUserIDFromContext(c)
@@ -120,8 +170,313 @@ func (h *AuthHandler) Me(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"id":    user.ID,
-		"name":  user.Name,
-		"email": user.Email,
+		"id":             user.ID,
+		"name":           user.Name,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"role":           user.Role,
 	})
 }
+
+// UpdateMe altera os dados do usuário autenticado. Trocar a senha ou o email
+// encerra as outras sessões dele.
+func (h *AuthHandler) UpdateMe(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var request auth.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validar request
+	if validationErrors := h.validator.ValidateAndGetErrors(&request); validationErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+		return
+	}
+
+	sessionID, _ := GetSessionIDFromContext(c)
+	user, err := h.service.UpdateProfile(userID, sessionID, &request)
+	if err != nil {
+		if errors.Is(err, authService.ErrWrongCurrentPassword) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		switch err.Error() {
+		case "email already exists":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteMe remove a conta do usuário autenticado e encerra as sessões dele
+func (h *AuthHandler) DeleteMe(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	if err := h.service.DeleteAccount(userID); err != nil {
+		if errors.Is(err, userService.ErrLastAdmin) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete user"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ForgotPassword envia o link de redefinição de senha. A resposta é a mesma
+// para emails com e sem conta.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var request auth.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validar request
+	if validationErrors := h.validator.ValidateAndGetErrors(&request); validationErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+		return
+	}
+
+	if err := h.service.ForgotPassword(c.Request.Context(), request.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send password reset email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the email has an account, a password reset link was sent"})
+}
+
+// ResetPassword troca a senha usando o token recebido por email
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var request auth.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validar request
+	if validationErrors := h.validator.ValidateAndGetErrors(&request); validationErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+		return
+	}
+
+	if err := h.service.ResetPassword(request.Token, request.Password); err != nil {
+		respondAccountTokenError(c, err, "failed to reset password")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyEmail confirma o email usando o token recebido por email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var request auth.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validar request
+	if validationErrors := h.validator.ValidateAndGetErrors(&request); validationErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+		return
+	}
+
+	if err := h.service.VerifyEmail(request.Token); err != nil {
+		respondAccountTokenError(c, err, "failed to verify email")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SendVerification envia outro link de verificação para o usuário autenticado
+func (h *AuthHandler) SendVerification(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	if err := h.service.SendVerification(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, authService.ErrEmailAlreadyVerified) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send verification email"})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// CreateAPIKey cria uma chave de API para o usuário autenticado. A chave
+// completa só aparece nesta resposta.
+func (h *AuthHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var request auth.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validar request
+	if validationErrors := h.validator.ValidateAndGetErrors(&request); validationErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+		return
+	}
+
+	response, err := h.service.CreateAPIKey(userID, &request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create api key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListAPIKeys lista as chaves de API do usuário autenticado
+func (h *AuthHandler) ListAPIKeys(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	keys, err := h.service.ListAPIKeys(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list api keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey apaga uma chave de API do usuário autenticado
+func (h *AuthHandler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(userID, id); err != nil {
+		if err.Error() == "api key not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not revoke api key"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// OIDCLogin redireciona para o login do provedor OIDC
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	authURL, err := h.service.OIDCAuthURL(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, authService.ErrOIDCDisabled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to start oidc login"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback recebe o retorno do provedor e emite os tokens do usuário
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "oidc provider returned " + providerError})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	response, err := h.service.OIDCCallback(c.Request.Context(), code, state)
+	if err != nil {
+		switch {
+		case errors.Is(err, authService.ErrOIDCDisabled):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, authService.ErrInvalidOIDCLogin):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, authService.ErrOIDCAuthentication):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": authService.ErrOIDCAuthentication.Error()})
+		case errors.Is(err, authService.ErrOIDCEmailRequired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, authService.ErrOIDCEmailNotVerified):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to complete oidc login"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// JWKS publica as chaves públicas dos tokens, para outros serviços os
+// verificarem sem o segredo
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.service.JWKS())
+}
+
+// retryAfterSeconds arredonda para cima, para o cliente não voltar cedo demais
+func retryAfterSeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
+
+func respondRefreshTokenError(c *gin.Context, err error, message string) {
+	if errors.Is(err, authService.ErrInvalidRefreshToken) || errors.Is(err, authService.ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+func respondAccountTokenError(c *gin.Context, err error, message string) {
+	if errors.Is(err, authService.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
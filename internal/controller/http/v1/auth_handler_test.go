@@ -0,0 +1,422 @@
+//go:build integration
+
+package v1_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josofm/liliana/config"
+	v1 "github.com/josofm/liliana/internal/controller/http/v1"
+	apiKeyRepo "github.com/josofm/liliana/internal/repository/apikey"
+	auditRepo "github.com/josofm/liliana/internal/repository/audit"
+	cardRepo "github.com/josofm/liliana/internal/repository/card"
+	collectionRepo "github.com/josofm/liliana/internal/repository/collection"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	gameRepo "github.com/josofm/liliana/internal/repository/game"
+	identityRepo "github.com/josofm/liliana/internal/repository/identity"
+	loginAttemptRepo "github.com/josofm/liliana/internal/repository/loginattempt"
+	playgroupRepo "github.com/josofm/liliana/internal/repository/playgroup"
+	refreshTokenRepo "github.com/josofm/liliana/internal/repository/refreshtoken"
+	tokenRepo "github.com/josofm/liliana/internal/repository/token"
+	userRepo "github.com/josofm/liliana/internal/repository/user"
+	"github.com/josofm/liliana/pkg/logger"
+	"github.com/josofm/liliana/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAccountRouter writes the emails to a file, so the tests read the links.
+func setupAccountRouter(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mailPath := filepath.Join(t.TempDir(), "mail.log")
+	cfg := &config.Config{
+		JWT:     config.JWTConfig{SecretKey: "test-secret", AccessExpiry: 15 * time.Minute, RefreshExpiry: 24 * time.Hour},
+		Deck:    config.DeckConfig{CardSource: config.CardSourceCatalog},
+		Mail:    config.MailConfig{Driver: config.MailDriverFile, FilePath: mailPath},
+		Account: config.AccountConfig{LinkBaseURL: "https://liliana.example.com"},
+	}
+	v1.NewRouter(router, logger.New("error"), inMemoryRepositories(), cfg)
+	return router, mailPath
+}
+
+func inMemoryRepositories() v1.Repositories {
+	return v1.Repositories{
+		Users:         userRepo.NewInMemoryRepo(),
+		Decks:         deckRepo.NewInMemoryRepo(),
+		Cards:         cardRepo.NewInMemoryRepo(),
+		Collection:    collectionRepo.NewInMemoryRepo(),
+		Games:         gameRepo.NewInMemoryRepo(),
+		Playgroups:    playgroupRepo.NewInMemoryRepo(),
+		Tokens:        tokenRepo.NewInMemoryRepo(),
+		RefreshTokens: refreshTokenRepo.NewInMemoryRepo(),
+		APIKeys:       apiKeyRepo.NewInMemoryRepo(),
+		Identities:    identityRepo.NewInMemoryRepo(),
+		LoginAttempts: loginAttemptRepo.NewInMemoryRepo(),
+		Audit:         auditRepo.NewInMemoryRepo(),
+	}
+}
+
+func sendAuth(t *testing.T, router *gin.Engine, method, path, accessToken string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	checkErr(t, err)
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(payload))
+	checkErr(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// lastMailedToken returns the token of the last link sent to path.
+func lastMailedToken(t *testing.T, mailPath, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(mailPath)
+	require.NoError(t, err)
+	marker := "https://liliana.example.com/" + path + "?token="
+	index := strings.LastIndex(string(content), marker)
+	require.NotEqual(t, -1, index, string(content))
+	escaped, _, _ := strings.Cut(string(content[index+len(marker):]), "\n")
+	token, err := url.QueryUnescape(escaped)
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthHandler_VerifyEmail(t *testing.T) {
+	router, mailPath := setupAccountRouter(t)
+	w := sendAuth(t, router, http.MethodPost, "/auth/register", "", map[string]string{"name": "Liliana", "email": "liliana@example.com", "password": "password123"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var registered map[string]any
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &registered))
+	accessToken := registered["access_token"].(string)
+	token := lastMailedToken(t, mailPath, "verify-email")
+
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodPost, "/auth/verify-email", "", map[string]string{}).Code)
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodPost, "/auth/verify-email", "", map[string]string{"token": "unknown"}).Code)
+	assert.Equal(t, http.StatusNoContent, sendAuth(t, router, http.MethodPost, "/auth/verify-email", "", map[string]string{"token": token}).Code)
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodPost, "/auth/verify-email", "", map[string]string{"token": token}).Code)
+
+	w = sendAuth(t, router, http.MethodGet, "/me", accessToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"email_verified":true`)
+	assert.Equal(t, http.StatusConflict, sendAuth(t, router, http.MethodPost, "/me/verification", accessToken, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodPost, "/me/verification", "", nil).Code)
+}
+
+func TestAuthHandler_ResetPassword(t *testing.T) {
+	router, mailPath := setupAccountRouter(t)
+	w := sendAuth(t, router, http.MethodPost, "/auth/register", "", map[string]string{"name": "Liliana", "email": "liliana@example.com", "password": "password123"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = sendAuth(t, router, http.MethodPost, "/auth/forgot-password", "", map[string]string{"email": "nobody@example.com"})
+	assert.Equal(t, http.StatusAccepted, w.Code, "unknown emails get the same answer")
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodPost, "/auth/forgot-password", "", map[string]string{"email": "not-an-email"}).Code)
+	require.Equal(t, http.StatusAccepted, sendAuth(t, router, http.MethodPost, "/auth/forgot-password", "", map[string]string{"email": "liliana@example.com"}).Code)
+	token := lastMailedToken(t, mailPath, "reset-password")
+
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodPost, "/auth/reset-password", "", map[string]string{"token": token, "password": "short"}).Code)
+	assert.Equal(t, http.StatusNoContent, sendAuth(t, router, http.MethodPost, "/auth/reset-password", "", map[string]string{"token": token, "password": "new-password"}).Code)
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodPost, "/auth/reset-password", "", map[string]string{"token": token, "password": "other-password"}).Code)
+
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "liliana@example.com", "password": "password123"}).Code)
+	assert.Equal(t, http.StatusOK, sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "liliana@example.com", "password": "new-password"}).Code)
+}
+
+func TestAuthHandler_RefreshAndLogout(t *testing.T) {
+	router, _ := setupAccountRouter(t)
+	w := sendAuth(t, router, http.MethodPost, "/auth/register", "", map[string]string{"name": "Liliana", "email": "liliana@example.com", "password": "password123"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var registered map[string]any
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &registered))
+	accessToken := registered["access_token"].(string)
+	refreshToken := registered["refresh_token"].(string)
+
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodGet, "/me", refreshToken, nil).Code, "refresh tokens are not bearer tokens")
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": accessToken}).Code)
+
+	w = sendAuth(t, router, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": refreshToken})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var refreshed map[string]any
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &refreshed))
+	w = sendAuth(t, router, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": refreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "refresh token reuse detected")
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": refreshed["refresh_token"].(string)}).Code)
+
+	w = sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "liliana@example.com", "password": "password123"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var login map[string]any
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &login))
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodPost, "/auth/logout", "", map[string]string{}).Code)
+	assert.Equal(t, http.StatusNoContent, sendAuth(t, router, http.MethodPost, "/auth/logout", "", map[string]string{"refresh_token": login["refresh_token"].(string)}).Code)
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": login["refresh_token"].(string)}).Code)
+
+	w = sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "liliana@example.com", "password": "password123"})
+	require.Equal(t, http.StatusOK, w.Code)
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &login))
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodPost, "/auth/logout-all", "", nil).Code)
+	assert.Equal(t, http.StatusNoContent, sendAuth(t, router, http.MethodPost, "/auth/logout-all", login["access_token"].(string), nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": login["refresh_token"].(string)}).Code)
+}
+
+func TestAuthHandler_RolesAndProfile(t *testing.T) {
+	router, _ := setupAccountRouter(t)
+	register := func(name, email string) map[string]any {
+		w := sendAuth(t, router, http.MethodPost, "/auth/register", "", map[string]string{"name": name, "email": email, "password": "password123"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var response map[string]any
+		checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	admin := register("Liliana", "liliana@example.com")
+	player := register("Jace", "jace@example.com")
+	adminToken := admin["access_token"].(string)
+	playerToken := player["access_token"].(string)
+	assert.Equal(t, "admin", admin["user"].(map[string]any)["role"], "the first user is the admin")
+	assert.Equal(t, "user", player["user"].(map[string]any)["role"])
+
+	assert.Equal(t, http.StatusForbidden, sendAuth(t, router, http.MethodGet, "/users/", playerToken, nil).Code)
+	assert.Equal(t, http.StatusForbidden, sendAuth(t, router, http.MethodDelete, "/users/1", playerToken, nil).Code)
+	assert.Equal(t, http.StatusOK, sendAuth(t, router, http.MethodGet, "/users/", adminToken, nil).Code)
+
+	w := sendAuth(t, router, http.MethodPut, "/me", playerToken, map[string]string{"name": "Jace Beleren", "email": "liliana@example.com"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "jace@example.com", "password": "password123"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var otherSession map[string]any
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &otherSession))
+	w = sendAuth(t, router, http.MethodPut, "/me", playerToken, map[string]string{"name": "Jace Beleren", "email": "jace@example.com", "password": "new-password"})
+	assert.Equal(t, http.StatusForbidden, w.Code, "changing the password needs the current one")
+	w = sendAuth(t, router, http.MethodPut, "/me", playerToken, map[string]string{"name": "Jace Beleren", "email": "jace@example.com", "password": "new-password", "current_password": "password123"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"name":"Jace Beleren"`)
+	assert.Contains(t, w.Body.String(), `"role":"user"`)
+	assert.NotContains(t, w.Body.String(), "password")
+	assert.Equal(t, http.StatusOK, sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "jace@example.com", "password": "new-password"}).Code)
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": otherSession["refresh_token"].(string)}).Code, "other sessions are revoked")
+	assert.Equal(t, http.StatusOK, sendAuth(t, router, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": player["refresh_token"].(string)}).Code, "the current session is kept")
+
+	w = sendAuth(t, router, http.MethodPut, "/users/1/role", adminToken, map[string]string{"role": "user"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the last admin cannot be demoted")
+	assert.Contains(t, w.Body.String(), "last admin")
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodPut, "/users/2/role", adminToken, map[string]string{"role": "owner"}).Code)
+	assert.Equal(t, http.StatusNotFound, sendAuth(t, router, http.MethodPut, "/users/99/role", adminToken, map[string]string{"role": "admin"}).Code)
+	w = sendAuth(t, router, http.MethodDelete, "/users/1", adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the last admin cannot be deleted")
+	assert.Contains(t, w.Body.String(), "last admin")
+	w = sendAuth(t, router, http.MethodDelete, "/me", adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the last admin cannot delete their account")
+	assert.Contains(t, w.Body.String(), "last admin")
+	assert.Equal(t, http.StatusNotFound, sendAuth(t, router, http.MethodDelete, "/users/99", adminToken, nil).Code)
+	w = sendAuth(t, router, http.MethodPut, "/users/2/role", adminToken, map[string]string{"role": "admin"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"role":"admin"`)
+
+	// O papel novo vale a partir da próxima renovação
+	w = sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "jace@example.com", "password": "new-password"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var login map[string]any
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &login))
+	assert.Equal(t, http.StatusOK, sendAuth(t, router, http.MethodGet, "/users/", login["access_token"].(string), nil).Code)
+
+	assert.Equal(t, http.StatusNoContent, sendAuth(t, router, http.MethodDelete, "/me", playerToken, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": login["refresh_token"].(string)}).Code)
+	assert.Equal(t, http.StatusNotFound, sendAuth(t, router, http.MethodGet, "/users/2", adminToken, nil).Code)
+}
+
+func TestAuthHandler_APIKeys(t *testing.T) {
+	router, _ := setupAccountRouter(t)
+	w := sendAuth(t, router, http.MethodPost, "/auth/register", "", map[string]string{"name": "Liliana", "email": "liliana@example.com", "password": "password123"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var registered map[string]any
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &registered))
+	accessToken := registered["access_token"].(string)
+
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodPost, "/me/api-keys/", accessToken, map[string]any{"name": "Bot", "scopes": []string{"cards:write"}}).Code)
+	createKey := func(name string, scopes []string) (int64, string) {
+		w := sendAuth(t, router, http.MethodPost, "/me/api-keys/", accessToken, map[string]any{"name": name, "scopes": scopes})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created map[string]any
+		checkErr(t, json.Unmarshal(w.Body.Bytes(), &created))
+		return int64(created["id"].(float64)), created["key"].(string)
+	}
+	readID, readKey := createKey("Discord bot", []string{"decks:read"})
+	_, fullKey := createKey("Spreadsheet", nil)
+
+	w = sendAuth(t, router, http.MethodGet, "/me/api-keys/", accessToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Discord bot"`)
+	assert.NotContains(t, w.Body.String(), readKey, "listings never show the key")
+
+	// Chave no header X-API-Key
+	req, err := http.NewRequest(http.MethodGet, "/decks/", nil)
+	checkErr(t, err)
+	req.Header.Set("X-API-Key", readKey)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Chave como bearer token, limitada pelos escopos
+	assert.Equal(t, http.StatusOK, sendAuth(t, router, http.MethodGet, "/decks/", readKey, nil).Code)
+	w = sendAuth(t, router, http.MethodPost, "/decks/", readKey, map[string]string{"name": "Bot deck"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusForbidden, sendAuth(t, router, http.MethodGet, "/collection/", readKey, nil).Code)
+	assert.Equal(t, http.StatusOK, sendAuth(t, router, http.MethodGet, "/collection/", fullKey, nil).Code, "keys without scopes act as the user")
+	w = sendAuth(t, router, http.MethodGet, "/me", fullKey, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "liliana@example.com")
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodGet, "/decks/", "lil_unknown", nil).Code)
+
+	assert.Equal(t, http.StatusForbidden, sendAuth(t, router, http.MethodPost, "/me/api-keys/", fullKey, map[string]any{"name": "Another"}).Code, "keys cannot create keys")
+	assert.Equal(t, http.StatusForbidden, sendAuth(t, router, http.MethodPut, "/me", fullKey, map[string]string{"name": "Bot", "email": "bot@example.com"}).Code, "keys cannot change the account")
+	assert.Equal(t, http.StatusForbidden, sendAuth(t, router, http.MethodDelete, "/me", fullKey, nil).Code)
+	assert.Equal(t, http.StatusForbidden, sendAuth(t, router, http.MethodGet, "/users/", fullKey, nil).Code, "keys of admins cannot manage users")
+	assert.Equal(t, http.StatusForbidden, sendAuth(t, router, http.MethodPost, "/auth/logout-all", fullKey, nil).Code)
+	assert.Equal(t, http.StatusNotFound, sendAuth(t, router, http.MethodDelete, "/me/api-keys/99", accessToken, nil).Code)
+	assert.Equal(t, http.StatusNoContent, sendAuth(t, router, http.MethodDelete, "/me/api-keys/"+strconv.FormatInt(readID, 10), accessToken, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodGet, "/decks/", readKey, nil).Code)
+}
+
+func TestAuthHandler_OIDC(t *testing.T) {
+	provider := oidctest.NewProvider("liliana", "client-secret")
+	t.Cleanup(provider.Close)
+	provider.SetIdentity(oidctest.Identity{Subject: "abc", Email: "liliana@example.com", EmailVerified: true, Name: "Liliana"})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	cfg := &config.Config{
+		JWT:  config.JWTConfig{SecretKey: "test-secret", AccessExpiry: 15 * time.Minute, RefreshExpiry: 24 * time.Hour},
+		Deck: config.DeckConfig{CardSource: config.CardSourceCatalog},
+		OIDC: config.OIDCConfig{
+			IssuerURL:    provider.Issuer(),
+			ClientID:     "liliana",
+			ClientSecret: "client-secret",
+			RedirectURL:  "https://api.liliana.example.com/auth/oidc/callback",
+		},
+	}
+	v1.NewRouter(router, logger.New("error"), inMemoryRepositories(), cfg)
+
+	w := sendAuth(t, router, http.MethodGet, "/auth/oidc/login", "", nil)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	callback, err := provider.Authorize(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "/auth/oidc/callback", callback.Path)
+
+	w = sendAuth(t, router, http.MethodGet, callback.RequestURI(), "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response map[string]any
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "liliana@example.com", response["user"].(map[string]any)["email"])
+	w = sendAuth(t, router, http.MethodGet, "/me", response["access_token"].(string), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodGet, callback.RequestURI(), "", nil).Code, "states are single use")
+	assert.Equal(t, http.StatusBadRequest, sendAuth(t, router, http.MethodGet, "/auth/oidc/callback", "", nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, sendAuth(t, router, http.MethodGet, "/auth/oidc/callback?error=access_denied&state=x", "", nil).Code)
+
+	w = sendAuth(t, router, http.MethodGet, "/auth/oidc/login", "", nil)
+	require.Equal(t, http.StatusFound, w.Code)
+	callback, err = provider.Authorize(w.Header().Get("Location"))
+	require.NoError(t, err)
+	query := callback.Query()
+	query.Set("code", "forged")
+	w = sendAuth(t, router, http.MethodGet, "/auth/oidc/callback?"+query.Encode(), "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error":"oidc authentication failed"}`, w.Body.String())
+}
+
+func TestAuthHandler_OIDCDisabled(t *testing.T) {
+	router, _ := setupAccountRouter(t)
+
+	assert.Equal(t, http.StatusNotFound, sendAuth(t, router, http.MethodGet, "/auth/oidc/login", "", nil).Code)
+	assert.Equal(t, http.StatusNotFound, sendAuth(t, router, http.MethodGet, "/auth/oidc/callback?code=a&state=b", "", nil).Code)
+}
+
+func TestAuthHandler_LoginThrottling(t *testing.T) {
+	router, _ := setupAccountRouter(t)
+	w := sendAuth(t, router, http.MethodPost, "/auth/register", "", map[string]string{"name": "Liliana", "email": "liliana@example.com", "password": "password123"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	for range 3 {
+		w = sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "liliana@example.com", "password": "wrong-password"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	w = sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "liliana@example.com", "password": "password123"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.JSONEq(t, `{"error":"too many failed login attempts"}`, w.Body.String())
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.Positive(t, retryAfter)
+}
+
+func TestAuthHandler_JWKS(t *testing.T) {
+	keysDir := t.TempDir()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(keysDir, "2030-01.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	cfg := &config.Config{
+		JWT:  config.JWTConfig{KeysDir: keysDir, ActiveKeyID: "2030-01", Issuer: "https://api.liliana.example.com", AccessExpiry: 15 * time.Minute, RefreshExpiry: 24 * time.Hour},
+		Deck: config.DeckConfig{CardSource: config.CardSourceCatalog},
+	}
+	v1.NewRouter(router, logger.New("error"), inMemoryRepositories(), cfg)
+
+	w := sendAuth(t, router, http.MethodGet, "/.well-known/jwks.json", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &jwks))
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "2030-01", jwks.Keys[0]["kid"])
+	assert.Equal(t, "OKP", jwks.Keys[0]["kty"])
+	assert.Empty(t, jwks.Keys[0]["d"], "private keys are never published")
+
+	// Outro serviço verifica o access token só com o JWKS
+	w = sendAuth(t, router, http.MethodPost, "/auth/register", "", map[string]string{"name": "Liliana", "email": "liliana@example.com", "password": "password123"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response map[string]any
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+	accessToken := response["access_token"].(string)
+
+	publicKey, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0]["x"])
+	require.NoError(t, err)
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(accessToken, claims, func(token *jwt.Token) (any, error) {
+		assert.Equal(t, "2030-01", token.Header["kid"])
+		return ed25519.PublicKey(publicKey), nil
+	}, jwt.WithValidMethods([]string{"EdDSA"}), jwt.WithIssuer("https://api.liliana.example.com"), jwt.WithAudience("https://api.liliana.example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, "liliana@example.com", claims["email"])
+	assert.NotEmpty(t, claims["sub"])
+	assert.NotEmpty(t, claims["jti"])
+
+	assert.Equal(t, http.StatusOK, sendAuth(t, router, http.MethodGet, "/me", accessToken, nil).Code)
+}
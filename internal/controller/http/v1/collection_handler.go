@@ -0,0 +1,205 @@
+package v1
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	collectionRepo "github.com/josofm/liliana/internal/repository/collection"
+	collectionService "github.com/josofm/liliana/internal/service/collection"
+	deckService "github.com/josofm/liliana/internal/service/deck"
+	"github.com/josofm/liliana/internal/validator"
+)
+
+// maxUploadSize limits uploaded card lists; a large collection export is a few megabytes.
+const maxUploadSize = 16 << 20
+
+// CollectionRequest adds a card list with the same foil flag and condition
+type CollectionRequest struct {
+	Cards     string `json:"cards" validate:"required"`
+	Foil      bool   `json:"foil"`
+	Condition string `json:"condition"`
+}
+
+type CollectionItemRequest struct {
+	Quantity  int    `json:"quantity" validate:"gt=0"`
+	Foil      bool   `json:"foil"`
+	Condition string `json:"condition"`
+}
+
+type CollectionHandler struct {
+	service   *collectionService.Service
+	validator *validator.Validator
+}
+
+func NewCollectionHandler(r *gin.Engine, repo collectionRepo.Repository, cardValidator deckService.CardValidator) {
+	h := &CollectionHandler{service: collectionService.NewService(repo, cardValidator), validator: validator.New()}
+	h.registerRoutes(r.Group("/collection"))
+}
+
+func (h *CollectionHandler) registerRoutes(group *gin.RouterGroup) {
+	group.GET("/", h.list)
+	group.POST("/", h.add)
+	group.POST("/import", h.importCSV)
+	group.GET("/:id", h.getByID)
+	group.PUT("/:id", h.update)
+	group.DELETE("/:id", h.delete)
+}
+
+func (h *CollectionHandler) list(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	items, err := h.service.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list collection"})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *CollectionHandler) add(c *gin.Context) {
+	var request CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if validationErrors := h.validator.ValidateAndGetErrors(&request); validationErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+		return
+	}
+	cards, err := deckService.ParseCardList(request.Cards)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	items, err := h.service.Add(userID, cards, request.Foil, request.Condition)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, items)
+}
+
+// importCSV reads a collection CSV sent as the "file" field of a multipart
+// form or as the raw request body.
+func (h *CollectionHandler) importCSV(c *gin.Context) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	body, err := uploadBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+	rows, err := deckService.ParseCardCSV(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	items, err := h.service.Import(userID, rows)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, items)
+}
+
+// uploadBody returns the "file" field of a multipart form, or the raw
+// request body, limited to maxUploadSize.
+func uploadBody(c *gin.Context) (io.ReadCloser, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New(`multipart form must have a "file" field`)
+		}
+		return header.Open()
+	}
+	return c.Request.Body, nil
+}
+
+func (h *CollectionHandler) getByID(c *gin.Context) {
+	userID, id, ok := collectionItemParams(c)
+	if !ok {
+		return
+	}
+	item, err := h.service.GetByID(userID, id)
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *CollectionHandler) update(c *gin.Context) {
+	userID, id, ok := collectionItemParams(c)
+	if !ok {
+		return
+	}
+	var request CollectionItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if validationErrors := h.validator.ValidateAndGetErrors(&request); validationErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+		return
+	}
+	item, err := h.service.Update(userID, id, request.Quantity, request.Foil, request.Condition)
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *CollectionHandler) delete(c *gin.Context) {
+	userID, id, ok := collectionItemParams(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(userID, id); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func collectionItemParams(c *gin.Context) (int64, int64, bool) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return 0, 0, false
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection item id"})
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+func respondCollectionError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "collection item not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, collectionService.ErrInvalidCondition):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not change collection"})
+	}
+}
@@ -0,0 +1,111 @@
+//go:build integration
+
+package v1_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/josofm/liliana/internal/controller/http/v1"
+	"github.com/josofm/liliana/internal/entity/collection"
+	collectionRepo "github.com/josofm/liliana/internal/repository/collection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCollectionHandler() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64)
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	v1.NewCollectionHandler(router, collectionRepo.NewInMemoryRepo(), testCardValidator{})
+	return router
+}
+
+func sendCollection(router *gin.Engine, method, path, userID, contentType string, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Test-User", userID)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCollectionHandler_AddListUpdateDelete(t *testing.T) {
+	router := setupCollectionHandler()
+
+	w := sendCollection(router, http.MethodPost, "/collection/", "1", "application/json", []byte(`{"cards":"2 Aqueous Form\n1 Vorrac Battlehorns","foil":true,"condition":"NM"}`))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var added []collection.Item
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &added))
+	require.Len(t, added, 2)
+	assert.Equal(t, "oracle-Aqueous Form", added[0].OracleID)
+	assert.Equal(t, "{U}", added[0].ManaCost)
+	assert.True(t, added[0].Foil)
+	assert.Equal(t, collection.ConditionNearMint, added[0].Condition)
+
+	w = sendCollection(router, http.MethodGet, "/collection/", "2", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	itemPath := "/collection/" + strconv.FormatInt(added[0].ID, 10)
+	assert.Equal(t, http.StatusNotFound, sendCollection(router, http.MethodGet, itemPath, "2", "", nil).Code)
+
+	w = sendCollection(router, http.MethodPut, itemPath, "1", "application/json", []byte(`{"quantity":3,"foil":false,"condition":"lightly_played"}`))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated collection.Item
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, 3, updated.Quantity)
+	assert.False(t, updated.Foil)
+
+	assert.Equal(t, http.StatusBadRequest, sendCollection(router, http.MethodPut, itemPath, "1", "application/json", []byte(`{"quantity":0}`)).Code)
+	assert.Equal(t, http.StatusBadRequest, sendCollection(router, http.MethodPut, itemPath, "1", "application/json", []byte(`{"quantity":1,"condition":"sleeved"}`)).Code)
+	assert.Equal(t, http.StatusBadRequest, sendCollection(router, http.MethodPost, "/collection/", "1", "application/json", []byte(`{"cards":"1 Sol Ring","condition":"sleeved"}`)).Code)
+
+	assert.Equal(t, http.StatusNotFound, sendCollection(router, http.MethodDelete, itemPath, "2", "", nil).Code)
+	assert.Equal(t, http.StatusNoContent, sendCollection(router, http.MethodDelete, itemPath, "1", "", nil).Code)
+
+	w = sendCollection(router, http.MethodGet, "/collection/", "1", "", nil)
+	var items []collection.Item
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &items))
+	assert.Len(t, items, 1)
+}
+
+func TestCollectionHandler_ImportCSV(t *testing.T) {
+	router := setupCollectionHandler()
+	csv := []byte("Count,Name,Foil,Condition\n4,Aqueous Form,,Near Mint\n1,Vorrac Battlehorns,foil,Played\n")
+
+	w := sendCollection(router, http.MethodPost, "/collection/import", "1", "text/csv", csv)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "collection.csv")
+	checkErr(t, err)
+	_, err = file.Write(csv)
+	checkErr(t, err)
+	checkErr(t, form.Close())
+	w = sendCollection(router, http.MethodPost, "/collection/import", "1", form.FormDataContentType(), body.Bytes())
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = sendCollection(router, http.MethodGet, "/collection/", "1", "", nil)
+	var items []collection.Item
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &items))
+	require.Len(t, items, 2)
+	assert.Equal(t, 8, items[0].Quantity)
+	assert.Equal(t, collection.ConditionModeratelyPlayed, items[1].Condition)
+	assert.True(t, items[1].Foil)
+
+	w = sendCollection(router, http.MethodPost, "/collection/import", "1", "text/csv", []byte("Count,Edition\n1,c21\n"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"csv has no name column"}`, w.Body.String())
+}
@@ -1,8 +1,12 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	deckEntity "github.com/josofm/liliana/internal/entity/deck"
@@ -22,12 +26,28 @@ type DeckRequest struct {
 	OwnerID    int64  `json:"-"`
 	SourceLink string `json:"source_link" validate:"omitempty,url"`
 	Cards      string `json:"cards"`
+	// Visibility is private, unlisted or public. Empty creates a public deck
+	// and keeps the current visibility on update.
+	Visibility string `json:"visibility" validate:"omitempty,oneof=private unlisted public"`
 }
 
 type DeckCardsRequest struct {
 	Cards string `json:"cards" validate:"required"`
 }
 
+// DeckCardChangesRequest takes either a card list, one "<quantity> <name>"
+// per line, or JSON operations.
+type DeckCardChangesRequest struct {
+	Cards      string                      `json:"cards"`
+	Operations []deckService.CardOperation `json:"operations"`
+}
+
+// DeckBuyListRequest sends the owned cards as JSON, one "<quantity> <name>"
+// per line.
+type DeckBuyListRequest struct {
+	Cards string `json:"cards"`
+}
+
 type DeckHandler struct {
 	service   *deckService.Service
 	validator *validator.Validator
@@ -41,17 +61,46 @@ func NewDeckHandler(r *gin.Engine, repo deckRepo.Repository) {
 func NewDeckHandlerWithService(r *gin.Engine, service *deckService.Service) {
 	validator := validator.New()
 	h := &DeckHandler{service: service, validator: validator}
+	h.registerRoutes(r.Group("/decks"))
+	h.registerSharedRoutes(r.Group("/shared/decks"))
+}
 
-	group := r.Group("/decks")
-	{
-		group.GET("/commanders", h.searchCommanders)
-		group.POST("/", h.create)
-		group.GET("/", h.getAll)
-		group.GET("/:id", h.getByID)
-		group.PUT("/:id", h.update)
-		group.POST("/:id/cards", h.addCards)
-		group.DELETE("/:id", h.delete)
-	}
+// registerRoutes is shared by NewDeckHandlerWithService and the main router so
+// both expose the same deck endpoints.
+func (h *DeckHandler) registerRoutes(group *gin.RouterGroup) {
+	group.GET("/commanders", h.searchCommanders)
+	group.GET("/sources", h.sources)
+	group.GET("/compare", h.compare)
+	group.POST("/", h.create)
+	group.GET("/", h.getAll)
+	group.GET("/:id", h.getByID)
+	group.GET("/:id/export", h.export)
+	group.GET("/:id/legality", h.legality)
+	group.GET("/:id/stats", h.stats)
+	group.GET("/:id/similar", h.similar)
+	group.GET("/:id/revisions", h.revisions)
+	group.GET("/:id/revisions/:rev", h.revision)
+	group.POST("/:id/revisions/:rev/restore", h.restoreRevision)
+	group.GET("/:id/diff", h.diff)
+	group.GET("/:id/share", h.shareLink)
+	group.POST("/:id/share", h.share)
+	group.DELETE("/:id/share", h.unshare)
+	group.PUT("/:id", h.update)
+	group.POST("/:id/cards", h.addCards)
+	group.DELETE("/:id/cards", h.removeCards)
+	group.PATCH("/:id/cards", h.setCards)
+	group.POST("/:id/buylist", h.buyList)
+	group.DELETE("/:id", h.delete)
+}
+
+// registerSharedRoutes exposes the decks read through share links, which
+// the main router serves without requiring authentication.
+func (h *DeckHandler) registerSharedRoutes(group *gin.RouterGroup) {
+	group.GET("/:token", h.sharedDeck)
+}
+
+func (h *DeckHandler) sources(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"hosts": h.service.SupportedSources()})
 }
 
 func (h *DeckHandler) searchCommanders(c *gin.Context) {
@@ -92,6 +141,7 @@ func (h *DeckHandler) create(c *gin.Context) {
 		Commander:  request.Commander,
 		OwnerID:    ownerID,
 		SourceLink: request.SourceLink,
+		Visibility: request.Visibility,
 	}
 	if request.Cards != "" {
 		cards, err := deckService.ParseCardList(request.Cards)
@@ -102,7 +152,7 @@ func (h *DeckHandler) create(c *gin.Context) {
 		deck.Cards = cards
 	}
 	if err := h.service.Prepare(&deck); err != nil {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		respondPrepareError(c, err)
 		return
 	}
 	if validationErrors := h.validator.ValidateAndGetErrors(&deck); validationErrors != nil {
@@ -118,21 +168,372 @@ func (h *DeckHandler) create(c *gin.Context) {
 	c.JSON(http.StatusCreated, deck)
 }
 
+func respondPrepareError(c *gin.Context, err error) {
+	var illegal *deckService.LegalityError
+	if errors.As(err, &illegal) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "deck is not legal", "legality": illegal.Report})
+		return
+	}
+	var unsupported *deckService.UnsupportedSourceError
+	if errors.As(err, &unsupported) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":           deckService.ErrUnsupportedSource.Error(),
+			"host":            unsupported.Host,
+			"supported_hosts": unsupported.SupportedHosts,
+		})
+		return
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+}
+
+// getAll lists the public decks and the decks of the user one page at a time.
+// The body stays a plain array for older clients; the cursor of the next page
+// goes in the X-Next-Cursor header.
 func (h *DeckHandler) getAll(c *gin.Context) {
-	decks, _ := h.service.GetAll()
-	c.JSON(http.StatusOK, decks)
+	filter, err := deckListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.ViewerID, _ = GetUserIDFromContext(c)
+	page, err := h.service.List(c.Request.Context(), filter)
+	if errors.Is(err, deckRepo.ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list decks"})
+		return
+	}
+	if page.NextCursor != "" {
+		c.Header("X-Next-Cursor", page.NextCursor)
+	}
+	c.JSON(http.StatusOK, page.Decks)
+}
+
+func deckListFilter(c *gin.Context) (deckRepo.ListFilter, error) {
+	filter := deckRepo.ListFilter{
+		Format:    c.Query("format"),
+		Color:     c.Query("color"),
+		Commander: c.Query("commander"),
+		Query:     c.Query("q"),
+		Sort:      c.Query("sort"),
+		Cursor:    c.Query("cursor"),
+	}
+	ownerID, err := ownerQuery(c)
+	if err != nil {
+		return filter, err
+	}
+	filter.OwnerID = ownerID
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = value
+	}
+	if cards := c.Query("cards"); cards != "" {
+		include, err := strconv.ParseBool(cards)
+		if err != nil {
+			return filter, errors.New("cards must be true or false")
+		}
+		filter.WithoutCards = !include
+	}
+	return filter, nil
+}
+
+// ownerQuery reads the "owner" query parameter, a user id or "me". Zero means
+// no owner was given.
+func ownerQuery(c *gin.Context) (int64, error) {
+	switch owner := c.Query("owner"); owner {
+	case "":
+		return 0, nil
+	case "me":
+		userID, exists := GetUserIDFromContext(c)
+		if !exists {
+			return 0, errors.New("owner=me requires an authenticated user")
+		}
+		return userID, nil
+	default:
+		ownerID, err := strconv.ParseInt(owner, 10, 64)
+		if err != nil || ownerID <= 0 {
+			return 0, errors.New("invalid owner")
+		}
+		return ownerID, nil
+	}
 }
 
 func (h *DeckHandler) getByID(c *gin.Context) {
 	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
-	deck, err := h.service.GetByID(id)
+	userID, _ := GetUserIDFromContext(c)
+	deck, err := h.service.GetByID(id, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
 	}
+	respondDeck(c, deck)
+}
+
+// sharedDeck reads a deck through its share link. Signing in is optional.
+func (h *DeckHandler) sharedDeck(c *gin.Context) {
+	userID, _ := GetUserIDFromContext(c)
+	deck, err := h.service.Shared(c.Param("token"), userID)
+	if err != nil {
+		if err.Error() == "deck not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "deck not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load deck"})
+		return
+	}
+	respondDeck(c, deck)
+}
+
+// respondDeck writes the deck with only the cards of the ?board= boards.
+func respondDeck(c *gin.Context, deck *deckEntity.Deck) {
+	cards, err := deckService.FilterCards(deck.Cards, splitCSV(c.Query("board")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filtered := *deck
+	filtered.Cards = cards
+	c.JSON(http.StatusOK, filtered)
+}
+
+func (h *DeckHandler) export(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return
+	}
+	userID, _ := GetUserIDFromContext(c)
+	export, err := h.service.Export(id, userID, c.Query("format"))
+	if err != nil {
+		if errors.Is(err, deckService.ErrUnsupportedExportFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "deck not found"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="deck-%d.%s"`, id, export.Extension))
+	c.Data(http.StatusOK, export.ContentType, export.Body)
+}
+
+func (h *DeckHandler) legality(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return
+	}
+	userID, _ := GetUserIDFromContext(c)
+	report, err := h.service.Legality(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "deck not found"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *DeckHandler) stats(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return
+	}
+	userID, _ := GetUserIDFromContext(c)
+	stats, err := h.service.Stats(id, userID, splitCSV(c.Query("board")))
+	if err != nil {
+		if err.Error() == "deck not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "deck not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// similar lists the decks with the most cards in common with a deck.
+func (h *DeckHandler) similar(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return
+	}
+	var filter deckRepo.SimilarFilter
+	if filter.IgnoreBasicLands, err = ignoreBasicsQuery(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+	userID, _ := GetUserIDFromContext(c)
+	decks, err := h.service.Similar(c.Request.Context(), id, userID, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, deckRepo.ErrInvalidFilter):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "deck not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "deck not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not find similar decks"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, decks)
+}
+
+// compare lists the shared and unique cards of decks a and b.
+func (h *DeckHandler) compare(c *gin.Context) {
+	a, errA := strconv.ParseInt(c.Query("a"), 10, 64)
+	b, errB := strconv.ParseInt(c.Query("b"), 10, 64)
+	if errA != nil || errB != nil || a <= 0 || b <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a and b must be deck ids"})
+		return
+	}
+	ignoreBasics, err := ignoreBasicsQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _ := GetUserIDFromContext(c)
+	comparison, err := h.service.Compare(a, b, userID, ignoreBasics)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "deck not found"})
+		return
+	}
+	c.JSON(http.StatusOK, comparison)
+}
+
+func ignoreBasicsQuery(c *gin.Context) (bool, error) {
+	return boolQuery(c, "ignore_basics")
+}
+
+func boolQuery(c *gin.Context, name string) (bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return parsed, nil
+}
+
+func (h *DeckHandler) revisions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return
+	}
+	userID, _ := GetUserIDFromContext(c)
+	revisions, err := h.service.Revisions(id, userID)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *DeckHandler) revision(c *gin.Context) {
+	id, number, ok := revisionParams(c)
+	if !ok {
+		return
+	}
+	userID, _ := GetUserIDFromContext(c)
+	revision, err := h.service.Revision(id, userID, number)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revision)
+}
+
+func (h *DeckHandler) restoreRevision(c *gin.Context) {
+	id, number, ok := revisionParams(c)
+	if !ok {
+		return
+	}
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	deck, err := h.service.RestoreRevision(id, userID, number)
+	if err != nil {
+		if respondOwnershipError(c, err) {
+			return
+		}
+		if err.Error() == "revision not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not restore revision"})
+		return
+	}
 	c.JSON(http.StatusOK, deck)
 }
 
+// diff compares ?from= with ?to=, or with the latest revision when to is omitted.
+func (h *DeckHandler) diff(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return
+	}
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from revision"})
+		return
+	}
+	to := 0
+	if value := c.Query("to"); value != "" {
+		to, err = strconv.Atoi(value)
+		if err != nil || to <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to revision"})
+			return
+		}
+	}
+	userID, _ := GetUserIDFromContext(c)
+	diff, err := h.service.DiffRevisions(id, userID, from, to)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+func revisionParams(c *gin.Context) (int64, int, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return 0, 0, false
+	}
+	number, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || number <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return 0, 0, false
+	}
+	return id, number, true
+}
+
+func respondRevisionError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "deck not found", "revision not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load revisions"})
+	}
+}
+
 func (h *DeckHandler) update(c *gin.Context) {
 	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
 	ownerID, exists := GetUserIDFromContext(c)
@@ -151,6 +552,15 @@ func (h *DeckHandler) update(c *gin.Context) {
 		return
 	}
 
+	// Checked before Prepare, which imports the source link and looks cards up
+	if err := h.service.Authorize(id, ownerID); err != nil {
+		if respondOwnershipError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update deck"})
+		return
+	}
+
 	// Convert to entity
 	deck := deckEntity.Deck{
 		Name:       request.Name,
@@ -159,9 +569,18 @@ func (h *DeckHandler) update(c *gin.Context) {
 		Commander:  request.Commander,
 		OwnerID:    ownerID,
 		SourceLink: request.SourceLink,
+		Visibility: request.Visibility,
+	}
+	if request.Cards != "" {
+		cards, err := deckService.ParseCardList(request.Cards)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		deck.Cards = cards
 	}
 	if err := h.service.Prepare(&deck); err != nil {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		respondPrepareError(c, err)
 		return
 	}
 	if validationErrors := h.validator.ValidateAndGetErrors(&deck); validationErrors != nil {
@@ -169,20 +588,115 @@ func (h *DeckHandler) update(c *gin.Context) {
 		return
 	}
 
-	err := h.service.Update(id, &deck)
+	err := h.service.Update(id, ownerID, &deck)
 	if err != nil {
+		if respondOwnershipError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update deck"})
 		return
 	}
 	c.JSON(http.StatusOK, deck)
 }
 
+// share creates the share link of an unlisted or public deck, or returns the
+// existing one.
+func (h *DeckHandler) share(c *gin.Context) {
+	id, userID, ok := deckOwnerParams(c)
+	if !ok {
+		return
+	}
+	link, err := h.service.Share(id, userID)
+	if err != nil {
+		respondShareError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+func (h *DeckHandler) shareLink(c *gin.Context) {
+	id, userID, ok := deckOwnerParams(c)
+	if !ok {
+		return
+	}
+	link, err := h.service.ShareLink(id, userID)
+	if err != nil {
+		respondShareError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// unshare revokes the share link; the deck keeps its visibility.
+func (h *DeckHandler) unshare(c *gin.Context) {
+	id, userID, ok := deckOwnerParams(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Unshare(id, userID); err != nil {
+		respondShareError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func deckOwnerParams(c *gin.Context) (int64, int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return 0, 0, false
+	}
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return 0, 0, false
+	}
+	return id, userID, true
+}
+
+func respondShareError(c *gin.Context, err error) {
+	switch {
+	case respondOwnershipError(c, err):
+	case errors.Is(err, deckService.ErrPrivateDeck):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "share link not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not change share link"})
+	}
+}
+
 func (h *DeckHandler) delete(c *gin.Context) {
 	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
-	h.service.Delete(id)
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	if err := h.service.Delete(id, userID); err != nil {
+		if respondOwnershipError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete deck"})
+		return
+	}
 	c.Status(http.StatusNoContent)
 }
 
+// respondOwnershipError writes the response for a missing deck or a deck of
+// another user, and reports whether err was one of them.
+func respondOwnershipError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, deckService.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "deck not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "deck not found"})
+	default:
+		return false
+	}
+	return true
+}
+
 func (h *DeckHandler) addCards(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil || id <= 0 {
@@ -203,7 +717,105 @@ func (h *DeckHandler) addCards(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	d, err := h.service.AddCards(id, cards)
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	d, err := h.service.AddCards(id, userID, cards)
+	if err != nil {
+		if respondOwnershipError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// removeCards subtracts the listed quantities from the deck.
+func (h *DeckHandler) removeCards(c *gin.Context) {
+	h.changeCards(c, deckService.CardOpRemove)
+}
+
+// setCards sets the exact quantity of the listed cards.
+func (h *DeckHandler) setCards(c *gin.Context) {
+	h.changeCards(c, deckService.CardOpSet)
+}
+
+// changeCards applies a card list with listOp, or the JSON operations as given.
+func (h *DeckHandler) changeCards(c *gin.Context, listOp string) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return
+	}
+	var request DeckCardChangesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (request.Cards == "") == (len(request.Operations) == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "send either cards or operations"})
+		return
+	}
+	operations := request.Operations
+	if request.Cards != "" {
+		cards, err := deckService.ParseCardList(request.Cards)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		operations = deckService.CardOperations(listOp, cards)
+	}
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	d, err := h.service.ChangeCards(id, userID, operations)
+	if err != nil {
+		if respondOwnershipError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// buyList compares the deck with the cards the user owns. With format=text
+// the missing cards are downloaded as a card list.
+func (h *DeckHandler) buyList(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return
+	}
+	options := deckService.BuyListOptions{Boards: splitCSV(c.Query("board"))}
+	if subtract := c.Query("subtract_decks"); subtract != "" {
+		options.SubtractDecks, err = strconv.ParseBool(subtract)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "subtract_decks must be true or false"})
+			return
+		}
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != deckService.ExportText {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or text"})
+		return
+	}
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	owned, err := ownedCards(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	list, err := h.service.BuyList(c.Request.Context(), id, userID, owned, options)
 	if err != nil {
 		if err.Error() == "deck not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "deck not found"})
@@ -212,5 +824,44 @@ func (h *DeckHandler) addCards(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, d)
+	if format == deckService.ExportText {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="deck-%d-buylist.txt"`, id))
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", list.Text())
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// ownedCards reads the cards a user owns from a DeckBuyListRequest, a CSV
+// exported by a collection tracker (text/csv or a multipart file) or a plain
+// "<quantity> <name>" list.
+func ownedCards(c *gin.Context) ([]deckEntity.Card, error) {
+	if c.ContentType() == "application/json" {
+		var request DeckBuyListRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			return nil, err
+		}
+		return deckService.ParseCardList(request.Cards)
+	}
+	body, err := uploadBody(c)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	if c.ContentType() == "text/csv" || strings.HasPrefix(c.ContentType(), "multipart/") {
+		rows, err := deckService.ParseCardCSV(body)
+		if err != nil {
+			return nil, err
+		}
+		cards := make([]deckEntity.Card, len(rows))
+		for index, row := range rows {
+			cards[index] = row.Card
+		}
+		return cards, nil
+	}
+	text, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("read card list: %w", err)
+	}
+	return deckService.ParseCardList(string(text))
 }
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -68,7 +69,7 @@ func setupDeckHandlerWithCardValidation() *gin.Engine {
 	router := gin.New()
 	router.Use(func(c *gin.Context) { c.Set("user_id", int64(1)); c.Next() })
 	repo := deckRepo.NewInMemoryRepo()
-	service := deckService.NewServiceWithDependencies(repo, deckService.NewArchidektImporter(), testCardValidator{})
+	service := deckService.NewServiceWithDependencies(repo, deckService.NewDefaultImporter(), testCardValidator{})
 	v1.NewDeckHandlerWithService(router, service)
 	return router
 }
@@ -127,7 +128,7 @@ func TestDeckHandler_Create_RequiresAuthenticatedUser(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	repo := deckRepo.NewInMemoryRepo()
-	service := deckService.NewServiceWithDependencies(repo, deckService.NewArchidektImporter(), testCardValidator{})
+	service := deckService.NewServiceWithDependencies(repo, deckService.NewDefaultImporter(), testCardValidator{})
 	v1.NewDeckHandlerWithService(router, service)
 	body := []byte(`{"name":"Test Deck","format":"commander","commander":"Atraxa, Praetors' Voice"}`)
 	req, err := http.NewRequest(http.MethodPost, "/decks/", bytes.NewReader(body))
@@ -343,6 +344,54 @@ func TestDeckHandler_GetAll(t *testing.T) {
 	assert.Len(t, response, 2)
 }
 
+func TestDeckHandler_GetAll_FiltersAndPages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", int64(2)); c.Next() })
+	repo := deckRepo.NewInMemoryRepo()
+	v1.NewDeckHandler(router, repo)
+	card := deckEntity.Card{OracleID: "c2a4d9e1-3b5f-4a6d-8e7f-9a0b1c2d3e4f", Name: "Sol Ring", Quantity: 1}
+	require.NoError(t, repo.Create(&deckEntity.Deck{Name: "Zombies", Color: "B", Format: "commander", OwnerID: 1, Cards: []deckEntity.Card{card}}))
+	require.NoError(t, repo.Create(&deckEntity.Deck{Name: "Artifacts", Color: "U", Format: "commander", OwnerID: 2, Cards: []deckEntity.Card{card}}))
+	require.NoError(t, repo.Create(&deckEntity.Deck{Name: "Burn", Color: "R", Format: "modern", OwnerID: 2}))
+	get := func(path string) (*httptest.ResponseRecorder, []deckEntity.Deck) {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		checkErr(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var decks []deckEntity.Deck
+		if w.Code == http.StatusOK {
+			checkErr(t, json.Unmarshal(w.Body.Bytes(), &decks))
+		}
+		return w, decks
+	}
+
+	w, decks := get("/decks/?owner=me&sort=name&limit=1&cards=false")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, decks, 1)
+	assert.Equal(t, "Artifacts", decks[0].Name)
+	assert.Empty(t, decks[0].Cards)
+	cursor := w.Header().Get("X-Next-Cursor")
+	require.NotEmpty(t, cursor)
+
+	w, decks = get("/decks/?owner=me&sort=name&limit=1&cards=false&cursor=" + cursor)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, decks, 1)
+	assert.Equal(t, "Burn", decks[0].Name)
+	assert.Empty(t, w.Header().Get("X-Next-Cursor"))
+
+	w, decks = get("/decks/?owner=1&format=commander")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, decks, 1)
+	assert.Equal(t, "Zombies", decks[0].Name)
+	assert.Len(t, decks[0].Cards, 1)
+
+	for _, path := range []string{"/decks/?owner=someone", "/decks/?limit=0", "/decks/?limit=1000", "/decks/?sort=color", "/decks/?cards=maybe", "/decks/?cursor=bogus"} {
+		w, _ := get(path)
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+}
+
 func TestDeckHandler_GetByID(t *testing.T) {
 	router := setupDeckHandlerWithCardValidation()
 
@@ -469,3 +518,555 @@ func TestDeckHandler_Delete(t *testing.T) {
 	router.ServeHTTP(w3, req3)
 	assert.Equal(t, http.StatusNotFound, w3.Code)
 }
+
+func TestDeckHandler_Sources(t *testing.T) {
+	router := setupDeckHandlerWithCardValidation()
+	req, err := http.NewRequest(http.MethodGet, "/decks/sources", nil)
+	checkErr(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"hosts":["archidekt.com","moxfield.com"]}`, w.Body.String())
+}
+
+func TestDeckHandler_Create_UnsupportedSourceListsHosts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", int64(1)); c.Next() })
+	service := deckService.NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), deckService.NewDefaultImporter(), testCardValidator{})
+	v1.NewDeckHandlerWithService(router, service)
+	body := []byte(`{"source_link":"https://tappedout.net/mtg-decks/example"}`)
+	req, err := http.NewRequest(http.MethodPost, "/decks/", bytes.NewReader(body))
+	checkErr(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"error":"unsupported deck source","host":"tappedout.net","supported_hosts":["archidekt.com","moxfield.com"]}`, w.Body.String())
+}
+
+func TestDeckHandler_Export(t *testing.T) {
+	router := setupDeckHandlerWithCardValidation()
+	body, err := json.Marshal(v1.DeckRequest{Name: "Auras", Format: "commander", Commander: "Thassa", Cards: "1 Aqueous Form"})
+	checkErr(t, err)
+	createRequest, err := http.NewRequest(http.MethodPost, "/decks/", bytes.NewBuffer(body))
+	checkErr(t, err)
+	createRequest.Header.Set("Content-Type", "application/json")
+	createResponse := httptest.NewRecorder()
+	router.ServeHTTP(createResponse, createRequest)
+	require.Equal(t, http.StatusCreated, createResponse.Code, createResponse.Body.String())
+
+	req, err := http.NewRequest(http.MethodGet, "/decks/1/export?format=arena", nil)
+	checkErr(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="deck-1.txt"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "Commander\n1 Thassa\n\nDeck\n1 Aqueous Form\n", w.Body.String())
+
+	req, err = http.NewRequest(http.MethodGet, "/decks/1/export?format=pdf", nil)
+	checkErr(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req, err = http.NewRequest(http.MethodGet, "/decks/999/export", nil)
+	checkErr(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeckHandler_GetByID_FiltersBoards(t *testing.T) {
+	router := setupDeckHandlerWithCardValidation()
+	body, err := json.Marshal(v1.DeckRequest{Name: "Boards", Color: "U", Format: "modern", Cards: "1 Aqueous Form\nSideboard:\n1 Vorrac Battlehorns"})
+	checkErr(t, err)
+	createRequest, err := http.NewRequest(http.MethodPost, "/decks/", bytes.NewBuffer(body))
+	checkErr(t, err)
+	createRequest.Header.Set("Content-Type", "application/json")
+	createResponse := httptest.NewRecorder()
+	router.ServeHTTP(createResponse, createRequest)
+	require.Equal(t, http.StatusCreated, createResponse.Code, createResponse.Body.String())
+
+	req, err := http.NewRequest(http.MethodGet, "/decks/1?board=side", nil)
+	checkErr(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response deckEntity.Deck
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Cards, 1)
+	assert.Equal(t, "Vorrac Battlehorns", response.Cards[0].Name)
+	assert.Equal(t, deckEntity.BoardSide, response.Cards[0].Board)
+
+	req, err = http.NewRequest(http.MethodGet, "/decks/1", nil)
+	checkErr(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Cards, 2)
+
+	req, err = http.NewRequest(http.MethodGet, "/decks/1?board=graveyard", nil)
+	checkErr(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeckHandler_Legality(t *testing.T) {
+	router := setupDeckHandlerWithCardValidation()
+	body, err := json.Marshal(v1.DeckRequest{Name: "Auras", Format: "commander", Commander: "Thassa", Cards: "1 Aqueous Form\n1 Vorrac Battlehorns"})
+	checkErr(t, err)
+	createRequest, err := http.NewRequest(http.MethodPost, "/decks/", bytes.NewBuffer(body))
+	checkErr(t, err)
+	createRequest.Header.Set("Content-Type", "application/json")
+	createResponse := httptest.NewRecorder()
+	router.ServeHTTP(createResponse, createRequest)
+	require.Equal(t, http.StatusCreated, createResponse.Code, createResponse.Body.String())
+
+	req, err := http.NewRequest(http.MethodGet, "/decks/1/legality", nil)
+	checkErr(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"format":"commander","legal":false,"violations":[{"rule":"deck_size","message":"deck has 3 cards; commander requires exactly 100"}]}`, w.Body.String())
+
+	req, err = http.NewRequest(http.MethodGet, "/decks/999/legality", nil)
+	checkErr(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeckHandler_Create_EnforcesLegality(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", int64(1)); c.Next() })
+	service := deckService.NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), deckService.NewDefaultImporter(), testCardValidator{})
+	service.EnforceLegality(true)
+	v1.NewDeckHandlerWithService(router, service)
+	body, err := json.Marshal(v1.DeckRequest{Name: "Burn", Color: "R", Format: "modern", Cards: "4 Lightning Bolt"})
+	checkErr(t, err)
+	req, err := http.NewRequest(http.MethodPost, "/decks/", bytes.NewBuffer(body))
+	checkErr(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"error":"deck is not legal","legality":{"format":"modern","legal":false,"violations":[{"rule":"deck_size","message":"deck has 4 cards; modern requires at least 60"}]}}`, w.Body.String())
+}
+
+func TestDeckHandler_Stats(t *testing.T) {
+	router := setupDeckHandlerWithCardValidation()
+	body, err := json.Marshal(v1.DeckRequest{Name: "Auras", Color: "U", Format: "modern", Cards: "2 Aqueous Form\nSideboard:\n1 Vorrac Battlehorns"})
+	checkErr(t, err)
+	createRequest, err := http.NewRequest(http.MethodPost, "/decks/", bytes.NewBuffer(body))
+	checkErr(t, err)
+	createRequest.Header.Set("Content-Type", "application/json")
+	createResponse := httptest.NewRecorder()
+	router.ServeHTTP(createResponse, createRequest)
+	require.Equal(t, http.StatusCreated, createResponse.Code, createResponse.Body.String())
+
+	req, err := http.NewRequest(http.MethodGet, "/decks/1/stats", nil)
+	checkErr(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var stats deckService.DeckStats
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, 2, stats.Cards)
+	assert.Equal(t, []deckService.CurvePoint{{ManaValue: 1, Permanent: 2}}, stats.ManaCurve)
+	assert.Equal(t, 2, stats.ColorPips["U"])
+	assert.Equal(t, 2, stats.Types["enchantment"])
+
+	req, err = http.NewRequest(http.MethodGet, "/decks/1/stats?board=main,side", nil)
+	checkErr(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, 3, stats.Cards)
+	assert.Equal(t, 1, stats.Types["artifact"])
+
+	req, err = http.NewRequest(http.MethodGet, "/decks/1/stats?board=graveyard", nil)
+	checkErr(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req, err = http.NewRequest(http.MethodGet, "/decks/999/stats", nil)
+	checkErr(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeckHandler_OnlyOwnerChangesDeck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64)
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	service := deckService.NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), deckService.NewDefaultImporter(), testCardValidator{})
+	v1.NewDeckHandlerWithService(router, service)
+	send := func(method, path string, userID string, body []byte) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+		checkErr(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	deckBody, err := json.Marshal(v1.DeckRequest{Name: "Owned", Color: "U", Format: "modern"})
+	checkErr(t, err)
+	require.Equal(t, http.StatusCreated, send(http.MethodPost, "/decks/", "1", deckBody).Code)
+
+	stolenBody, err := json.Marshal(v1.DeckRequest{Name: "Stolen", Color: "R", Format: "modern"})
+	checkErr(t, err)
+	w := send(http.MethodPut, "/decks/1", "2", stolenBody)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"deck belongs to another user"}`, w.Body.String())
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/decks/1/cards", "2", []byte(`{"cards":"1 Aqueous Form"}`)).Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, "/decks/1", "2", nil).Code)
+
+	w = send(http.MethodGet, "/decks/1", "2", nil)
+	var response deckEntity.Deck
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Owned", response.Name)
+	assert.Equal(t, int64(1), response.OwnerID)
+
+	assert.Equal(t, http.StatusNotFound, send(http.MethodPut, "/decks/999", "1", stolenBody).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/decks/999", "1", nil).Code)
+	assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/decks/1", "1", nil).Code)
+}
+
+// countingImporter records how many source links were imported
+type countingImporter struct{ calls int }
+
+func (i *countingImporter) Import(string) (*deckEntity.Deck, error) {
+	i.calls++
+	return &deckEntity.Deck{Name: "Imported", Color: "U", Format: "modern"}, nil
+}
+
+func TestDeckHandler_UpdateChecksOwnerBeforeImport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64)
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	importer := &countingImporter{}
+	service := deckService.NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), importer, testCardValidator{})
+	v1.NewDeckHandlerWithService(router, service)
+	send := func(userID string, method, path string, body any) *httptest.ResponseRecorder {
+		payload, err := json.Marshal(body)
+		checkErr(t, err)
+		req, err := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		checkErr(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	require.Equal(t, http.StatusCreated, send("1", http.MethodPost, "/decks/", v1.DeckRequest{Name: "Owned", Color: "U", Format: "modern"}).Code)
+
+	sourceBody := v1.DeckRequest{SourceLink: "https://archidekt.com/decks/123"}
+	assert.Equal(t, http.StatusForbidden, send("2", http.MethodPut, "/decks/1", sourceBody).Code)
+	assert.Equal(t, http.StatusNotFound, send("1", http.MethodPut, "/decks/999", sourceBody).Code)
+	assert.Zero(t, importer.calls, "other users cannot make the server fetch source links")
+
+	assert.Equal(t, http.StatusOK, send("1", http.MethodPut, "/decks/1", sourceBody).Code)
+	assert.Equal(t, 1, importer.calls)
+}
+
+func TestDeckHandler_Revisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64)
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	service := deckService.NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), deckService.NewDefaultImporter(), testCardValidator{})
+	v1.NewDeckHandlerWithService(router, service)
+	send := func(method, path string, body []byte) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+		checkErr(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", "1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	deckBody, err := json.Marshal(v1.DeckRequest{Name: "History", Color: "U", Format: "modern", Cards: "2 Aqueous Form"})
+	checkErr(t, err)
+	require.Equal(t, http.StatusCreated, send(http.MethodPost, "/decks/", deckBody).Code)
+	require.Equal(t, http.StatusOK, send(http.MethodPost, "/decks/1/cards", []byte(`{"cards":"1 Aqueous Form\n1 Vorrac Battlehorns"}`)).Code)
+
+	w := send(http.MethodGet, "/decks/1/revisions", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var revisions []deckService.RevisionSummary
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &revisions))
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 4, revisions[1].Cards)
+
+	w = send(http.MethodGet, "/decks/1/revisions/1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var revision deckEntity.Revision
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &revision))
+	assert.Equal(t, 1, revision.Number)
+	require.Len(t, revision.Deck.Cards, 1)
+
+	w = send(http.MethodGet, "/decks/1/diff?from=1&to=2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var diff deckService.RevisionDiff
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &diff))
+	assert.Equal(t, []deckService.CardChange{{Name: "Vorrac Battlehorns", Board: deckEntity.BoardMain, To: 1}}, diff.Added)
+	assert.Equal(t, []deckService.CardChange{{Name: "Aqueous Form", Board: deckEntity.BoardMain, From: 2, To: 3}}, diff.Changed)
+
+	w = send(http.MethodPost, "/decks/1/revisions/1/restore", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var restored deckEntity.Deck
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &restored))
+	require.Len(t, restored.Cards, 1)
+	assert.Equal(t, 2, restored.Cards[0].Quantity)
+
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/decks/1/revisions/9", nil).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/decks/9/revisions", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/decks/1/revisions/first", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodGet, "/decks/1/diff", nil).Code)
+}
+
+func TestDeckHandler_RemoveAndSetCards(t *testing.T) {
+	router := setupDeckHandlerWithCardValidation()
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		checkErr(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	deckBody, err := json.Marshal(v1.DeckRequest{Name: "Cards", Color: "U", Format: "modern", Cards: "4 Aqueous Form\n2 Vorrac Battlehorns\nSB: 3 Negate"})
+	checkErr(t, err)
+	require.Equal(t, http.StatusCreated, send(http.MethodPost, "/decks/", string(deckBody)).Code)
+
+	w := send(http.MethodDelete, "/decks/1/cards", `{"cards":"1 Aqueous Form\n2 Vorrac Battlehorns"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response deckEntity.Deck
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Cards, 2)
+	assert.Equal(t, 3, response.Cards[0].Quantity)
+	assert.Equal(t, "Negate", response.Cards[1].Name)
+
+	w = send(http.MethodPatch, "/decks/1/cards", `{"cards":"SB: 1 Negate\n2 Island"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	response = deckEntity.Deck{}
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Cards, 3)
+	assert.Equal(t, 1, response.Cards[1].Quantity)
+	assert.Equal(t, "Island", response.Cards[2].Name)
+
+	w = send(http.MethodPatch, "/decks/1/cards", `{"operations":[{"op":"remove","name":"Negate","board":"side"},{"op":"add","name":"Island","quantity":1}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	response = deckEntity.Deck{}
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Cards, 2)
+	assert.Equal(t, 3, response.Cards[1].Quantity)
+
+	w = send(http.MethodDelete, "/decks/1/cards", `{"cards":"1 Brainstorm"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"cards not in deck: Brainstorm"}`, w.Body.String())
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPatch, "/decks/1/cards", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPatch, "/decks/1/cards", `{"cards":"1 Island","operations":[{"op":"add","name":"Island","quantity":1}]}`).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/decks/9/cards", `{"cards":"1 Island"}`).Code)
+}
+
+func TestDeckHandler_UpdateReplacesCards(t *testing.T) {
+	router := setupDeckHandlerWithCardValidation()
+	send := func(method, path string, request v1.DeckRequest) deckEntity.Deck {
+		body, err := json.Marshal(request)
+		checkErr(t, err)
+		req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+		checkErr(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Less(t, w.Code, 300, w.Body.String())
+		var response deckEntity.Deck
+		checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	send(http.MethodPost, "/decks/", v1.DeckRequest{Name: "Cards", Color: "U", Format: "modern", Cards: "4 Aqueous Form"})
+
+	updated := send(http.MethodPut, "/decks/1", v1.DeckRequest{Name: "Renamed", Color: "U", Format: "modern"})
+	require.Len(t, updated.Cards, 1, "an update without cards keeps the list")
+
+	updated = send(http.MethodPut, "/decks/1", v1.DeckRequest{Name: "Renamed", Color: "U", Format: "modern", Cards: "2 Negate"})
+	require.Len(t, updated.Cards, 1)
+	assert.Equal(t, "Negate", updated.Cards[0].Name)
+}
+
+func TestDeckHandler_BuyList(t *testing.T) {
+	router := setupDeckHandlerWithCardValidation()
+	send := func(path, contentType, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		checkErr(t, err)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	deckBody, err := json.Marshal(v1.DeckRequest{Name: "Cards", Color: "U", Format: "modern", Cards: "4 Aqueous Form\n2 Vorrac Battlehorns\nSB: 3 Negate"})
+	checkErr(t, err)
+	require.Equal(t, http.StatusCreated, send("/decks/", "application/json", string(deckBody)).Code)
+	deckBody, err = json.Marshal(v1.DeckRequest{Name: "Other", Color: "U", Format: "modern", Cards: "2 Aqueous Form"})
+	checkErr(t, err)
+	require.Equal(t, http.StatusCreated, send("/decks/", "application/json", string(deckBody)).Code)
+
+	w := send("/decks/1/buylist", "application/json", `{"cards":"3 Aqueous Form\n2 Vorrac Battlehorns"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response deckService.BuyList
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 4, response.Missing)
+	require.Len(t, response.Cards, 2)
+	assert.Equal(t, deckService.BuyListCard{OracleID: "oracle-Aqueous Form", Name: "Aqueous Form", Needed: 4, Owned: 3, Missing: 1}, response.Cards[0])
+	assert.Equal(t, "Negate", response.Cards[1].Name)
+
+	w = send("/decks/1/buylist?subtract_decks=true&board=main&format=text", "text/csv", "Count,Name,Foil\n3,Aqueous Form,foil\n2,Vorrac Battlehorns,\n")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "3 Aqueous Form\n", w.Body.String())
+	assert.Equal(t, `attachment; filename="deck-1-buylist.txt"`, w.Header().Get("Content-Disposition"))
+
+	w = send("/decks/1/buylist?format=text", "text/plain", "4 Aqueous Form\n2 Vorrac Battlehorns\n3 Negate\n")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, send("/decks/1/buylist", "text/plain", "Aqueous Form").Code)
+	assert.Equal(t, http.StatusBadRequest, send("/decks/1/buylist?format=csv", "text/plain", "").Code)
+	assert.Equal(t, http.StatusBadRequest, send("/decks/1/buylist?subtract_decks=maybe", "text/plain", "").Code)
+	assert.Equal(t, http.StatusNotFound, send("/decks/9/buylist", "text/plain", "").Code)
+}
+
+func TestDeckHandler_SimilarAndCompare(t *testing.T) {
+	router := setupDeckHandlerWithCardValidation()
+	get := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		checkErr(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	for _, cards := range []string{"4 Aqueous Form\n10 Island", "4 Aqueous Form\n2 Vorrac Battlehorns\n10 Island", "20 Island"} {
+		body, err := json.Marshal(v1.DeckRequest{Name: "Deck", Color: "U", Format: "modern", Cards: cards})
+		checkErr(t, err)
+		req, err := http.NewRequest(http.MethodPost, "/decks/", bytes.NewBuffer(body))
+		checkErr(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w := get("/decks/1/similar")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var similar []deckRepo.SimilarDeck
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &similar))
+	require.Len(t, similar, 2)
+	assert.Equal(t, int64(2), similar[0].Deck.ID)
+	assert.InDelta(t, 14.0/16, similar[0].Score, 1e-9)
+
+	w = get("/decks/1/similar?ignore_basics=true&limit=5")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	similar = nil
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &similar))
+	require.Len(t, similar, 1, "decks sharing only basic lands are left out")
+
+	assert.Equal(t, http.StatusNotFound, get("/decks/9/similar").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/decks/1/similar?limit=500").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/decks/1/similar?ignore_basics=maybe").Code)
+
+	w = get("/decks/compare?a=1&b=2")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var comparison deckService.DeckComparison
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &comparison))
+	assert.Len(t, comparison.Shared, 2)
+	assert.Empty(t, comparison.OnlyA)
+	require.Len(t, comparison.OnlyB, 1)
+	assert.Equal(t, "Vorrac Battlehorns", comparison.OnlyB[0].Name)
+
+	assert.Equal(t, http.StatusBadRequest, get("/decks/compare?a=1").Code)
+	assert.Equal(t, http.StatusNotFound, get("/decks/compare?a=1&b=9").Code)
+}
+
+func TestDeckHandler_VisibilityAndShare(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID, err := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64); err == nil {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+	service := deckService.NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), deckService.NewDefaultImporter(), testCardValidator{})
+	v1.NewDeckHandlerWithService(router, service)
+	send := func(method, path string, userID string, body []byte) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+		checkErr(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	for _, visibility := range []string{"", deckEntity.VisibilityUnlisted, deckEntity.VisibilityPrivate} {
+		body, err := json.Marshal(v1.DeckRequest{Name: "Deck " + visibility, Color: "U", Format: "modern", Visibility: visibility})
+		checkErr(t, err)
+		w := send(http.MethodPost, "/decks/", "1", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	invalid, err := json.Marshal(v1.DeckRequest{Name: "Deck", Color: "U", Format: "modern", Visibility: "friends"})
+	checkErr(t, err)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/decks/", "1", invalid).Code)
+
+	var decks []deckEntity.Deck
+	checkErr(t, json.Unmarshal(send(http.MethodGet, "/decks/", "2", nil).Body.Bytes(), &decks))
+	require.Len(t, decks, 1, "other users only list public decks")
+	assert.Equal(t, deckEntity.VisibilityPublic, decks[0].Visibility)
+	decks = nil
+	checkErr(t, json.Unmarshal(send(http.MethodGet, "/decks/", "1", nil).Body.Bytes(), &decks))
+	assert.Len(t, decks, 3)
+
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/decks/3", "1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/decks/3", "2", nil).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/decks/2", "2", nil).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/decks/2/export", "2", nil).Code)
+
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/decks/2/share", "1", nil).Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/decks/2/share", "2", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/decks/3/share", "1", nil).Code)
+	w := send(http.MethodPost, "/decks/2/share", "1", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var link deckService.ShareLink
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &link))
+	assert.NotEmpty(t, link.Token)
+	assert.Equal(t, deckEntity.VisibilityUnlisted, link.Visibility)
+
+	w = send(http.MethodGet, "/shared/decks/"+link.Token, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var shared deckEntity.Deck
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &shared))
+	assert.Equal(t, "Deck unlisted", shared.Name)
+	assert.NotContains(t, w.Body.String(), link.Token, "the token is only shown to the owner")
+
+	assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/decks/2/share", "1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/shared/decks/"+link.Token, "", nil).Code)
+}
@@ -0,0 +1,197 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	gameEntity "github.com/josofm/liliana/internal/entity/game"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	gameRepo "github.com/josofm/liliana/internal/repository/game"
+	gameService "github.com/josofm/liliana/internal/service/game"
+)
+
+// GameRequest records a game. PlayedAt defaults to now.
+type GameRequest struct {
+	PlayedAt time.Time           `json:"played_at"`
+	Turns    int                 `json:"turns"`
+	Players  []GamePlayerRequest `json:"players"`
+}
+
+// GamePlayerRequest is a deck in a game; Eliminated is the order in which it
+// was knocked out, starting at 1.
+type GamePlayerRequest struct {
+	DeckID     int64 `json:"deck_id"`
+	Winner     bool  `json:"winner"`
+	Eliminated int   `json:"eliminated"`
+}
+
+func (r GameRequest) game() *gameEntity.Game {
+	g := &gameEntity.Game{PlayedAt: r.PlayedAt, Turns: r.Turns, Players: make([]gameEntity.Player, len(r.Players))}
+	for index, player := range r.Players {
+		g.Players[index] = gameEntity.Player{DeckID: player.DeckID, Winner: player.Winner, Eliminated: player.Eliminated}
+	}
+	return g
+}
+
+type GameHandler struct {
+	service *gameService.Service
+}
+
+func NewGameHandler(r *gin.Engine, repo gameRepo.Repository, decks deckRepo.Repository) {
+	h := &GameHandler{service: gameService.NewService(repo, decks)}
+	h.registerRoutes(r.Group("/games"))
+}
+
+func (h *GameHandler) registerRoutes(group *gin.RouterGroup) {
+	group.GET("/", h.list)
+	group.POST("/", h.create)
+	group.GET("/decks/:id/stats", h.deckStats)
+	group.GET("/:id", h.getByID)
+	group.PUT("/:id", h.update)
+	group.DELETE("/:id", h.delete)
+}
+
+// list returns games, most recent first, optionally of one deck or owner.
+func (h *GameHandler) list(c *gin.Context) {
+	var filter gameRepo.ListFilter
+	if deck := c.Query("deck"); deck != "" {
+		deckID, err := strconv.ParseInt(deck, 10, 64)
+		if err != nil || deckID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck"})
+			return
+		}
+		filter.DeckID = deckID
+	}
+	ownerID, err := ownerQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.OwnerID = ownerID
+	userID, _ := GetUserIDFromContext(c)
+	games, err := h.service.List(filter, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list games"})
+		return
+	}
+	c.JSON(http.StatusOK, games)
+}
+
+func (h *GameHandler) create(c *gin.Context) {
+	var request GameRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	g := request.game()
+	if err := h.service.Create(userID, g); err != nil {
+		respondGameError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, g)
+}
+
+func (h *GameHandler) getByID(c *gin.Context) {
+	id, ok := gameID(c)
+	if !ok {
+		return
+	}
+	userID, _ := GetUserIDFromContext(c)
+	g, err := h.service.GetByID(id, userID)
+	if err != nil {
+		respondGameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+func (h *GameHandler) update(c *gin.Context) {
+	id, ok := gameID(c)
+	if !ok {
+		return
+	}
+	var request GameRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	g := request.game()
+	if err := h.service.Update(id, userID, g); err != nil {
+		respondGameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+func (h *GameHandler) delete(c *gin.Context) {
+	id, ok := gameID(c)
+	if !ok {
+		return
+	}
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	if err := h.service.Delete(id, userID); err != nil {
+		respondGameError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// deckStats returns the win rate and head-to-head record of a deck the user
+// may view.
+func (h *GameHandler) deckStats(c *gin.Context) {
+	deckID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || deckID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid deck id"})
+		return
+	}
+	userID, _ := GetUserIDFromContext(c)
+	record, err := h.service.DeckRecord(deckID, userID)
+	if err != nil {
+		if err.Error() == "deck not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load games"})
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+func gameID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid game id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func respondGameError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gameService.ErrInvalidGame):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gameService.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "game not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save game"})
+	}
+}
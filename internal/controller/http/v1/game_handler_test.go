@@ -0,0 +1,152 @@
+//go:build integration
+
+package v1_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/josofm/liliana/internal/controller/http/v1"
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	gameEntity "github.com/josofm/liliana/internal/entity/game"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	gameRepo "github.com/josofm/liliana/internal/repository/game"
+	gameService "github.com/josofm/liliana/internal/service/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupGameHandler(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64)
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	decks := deckRepo.NewInMemoryRepo()
+	for index, name := range []string{"Atraxa", "Thassa", "Krenko"} {
+		require.NoError(t, decks.Create(&deckEntity.Deck{Name: name, Color: "U", Format: "commander", OwnerID: int64(index + 1)}))
+	}
+	require.NoError(t, decks.Create(&deckEntity.Deck{Name: "Secret", Color: "B", Format: "commander", OwnerID: 3, Visibility: deckEntity.VisibilityPrivate}))
+	v1.NewGameHandler(router, gameRepo.NewInMemoryRepo(), decks)
+	return router
+}
+
+func sendGame(router *gin.Engine, method, path, userID, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", userID)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGameHandler_CRUD(t *testing.T) {
+	router := setupGameHandler(t)
+
+	w := sendGame(router, http.MethodPost, "/games/", "1", `{"played_at":"2026-03-05T20:00:00Z","turns":9,"players":[
+		{"deck_id":1,"winner":true},{"deck_id":2,"eliminated":2},{"deck_id":3,"eliminated":1}]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created gameEntity.Game
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, int64(1), created.CreatedBy)
+	assert.Equal(t, "Thassa", created.Players[1].DeckName)
+	assert.Equal(t, int64(2), created.Players[1].OwnerID)
+
+	w = sendGame(router, http.MethodPost, "/games/", "2", `{"players":[{"deck_id":1},{"deck_id":2,"winner":true}]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = sendGame(router, http.MethodGet, "/games/?deck=3", "1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var games []gameEntity.Game
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &games))
+	require.Len(t, games, 1)
+	assert.Equal(t, created.ID, games[0].ID)
+
+	w = sendGame(router, http.MethodGet, "/games/?owner=me", "2", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	games = nil
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &games))
+	assert.Len(t, games, 2)
+
+	gamePath := "/games/" + strconv.FormatInt(created.ID, 10)
+	update := `{"played_at":"2026-03-05T20:00:00Z","turns":10,"players":[{"deck_id":1,"winner":true},{"deck_id":2}]}`
+	assert.Equal(t, http.StatusForbidden, sendGame(router, http.MethodPut, gamePath, "2", update).Code)
+	w = sendGame(router, http.MethodPut, gamePath, "1", update)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = sendGame(router, http.MethodGet, gamePath, "3", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var found gameEntity.Game
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &found))
+	assert.Equal(t, 10, found.Turns)
+	assert.Len(t, found.Players, 2)
+
+	assert.Equal(t, http.StatusForbidden, sendGame(router, http.MethodDelete, gamePath, "2", "").Code)
+	assert.Equal(t, http.StatusNoContent, sendGame(router, http.MethodDelete, gamePath, "1", "").Code)
+	assert.Equal(t, http.StatusNotFound, sendGame(router, http.MethodGet, gamePath, "1", "").Code)
+	assert.Equal(t, http.StatusBadRequest, sendGame(router, http.MethodGet, "/games/abc", "1", "").Code)
+}
+
+func TestGameHandler_Rejects(t *testing.T) {
+	router := setupGameHandler(t)
+
+	w := sendGame(router, http.MethodPost, "/games/", "1", `{"players":[{"deck_id":1},{"deck_id":2}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"invalid game: a game needs at least one winner"}`, w.Body.String())
+	assert.Equal(t, http.StatusBadRequest, sendGame(router, http.MethodPost, "/games/", "1", `{"players":[{"deck_id":1,"winner":true},{"deck_id":9}]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, sendGame(router, http.MethodPost, "/games/", "1", `{"players":`).Code)
+	assert.Equal(t, http.StatusBadRequest, sendGame(router, http.MethodGet, "/games/?deck=x", "1", "").Code)
+	assert.Equal(t, http.StatusNotFound, sendGame(router, http.MethodPut, "/games/9", "1", `{"players":[{"deck_id":1,"winner":true},{"deck_id":2}]}`).Code)
+}
+
+func TestGameHandler_DeckStats(t *testing.T) {
+	router := setupGameHandler(t)
+	for _, body := range []string{
+		`{"turns":8,"players":[{"deck_id":1,"winner":true},{"deck_id":2},{"deck_id":3}]}`,
+		`{"turns":12,"players":[{"deck_id":1},{"deck_id":2,"winner":true}]}`,
+	} {
+		require.Equal(t, http.StatusCreated, sendGame(router, http.MethodPost, "/games/", "1", body).Code)
+	}
+
+	w := sendGame(router, http.MethodGet, "/games/decks/1/stats", "2", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var record gameService.DeckRecord
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &record))
+	assert.Equal(t, 2, record.Games)
+	assert.Equal(t, 0.5, record.WinRate)
+	assert.Equal(t, 10.0, record.AverageTurns)
+	assert.Equal(t, []gameService.HeadToHead{
+		{DeckID: 2, DeckName: "Thassa", OwnerID: 2, Games: 2, Wins: 1, Losses: 1},
+		{DeckID: 3, DeckName: "Krenko", OwnerID: 3, Games: 1, Wins: 1},
+	}, record.HeadToHead)
+
+	assert.Equal(t, http.StatusBadRequest, sendGame(router, http.MethodGet, "/games/decks/x/stats", "1", "").Code)
+}
+
+func TestGameHandler_HidesPrivateDecks(t *testing.T) {
+	router := setupGameHandler(t)
+	body := `{"players":[{"deck_id":1,"winner":true},{"deck_id":4}]}`
+	assert.Equal(t, http.StatusBadRequest, sendGame(router, http.MethodPost, "/games/", "1", body).Code, "private decks of others cannot be recorded")
+	w := sendGame(router, http.MethodPost, "/games/", "3", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created gameEntity.Game
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = sendGame(router, http.MethodGet, "/games/"+strconv.FormatInt(created.ID, 10), "1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var found gameEntity.Game
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &found))
+	assert.Equal(t, gameEntity.Player{OwnerID: 3}, found.Players[1])
+	assert.NotContains(t, sendGame(router, http.MethodGet, "/games/", "1", "").Body.String(), "Secret")
+	assert.Equal(t, "[]", sendGame(router, http.MethodGet, "/games/?deck=4", "1", "").Body.String())
+	assert.Equal(t, http.StatusNotFound, sendGame(router, http.MethodGet, "/games/decks/4/stats", "1", "").Code)
+	assert.Equal(t, http.StatusOK, sendGame(router, http.MethodGet, "/games/decks/4/stats", "3", "").Code)
+}
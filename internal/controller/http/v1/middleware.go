@@ -2,15 +2,25 @@ package v1
 
 import (
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/josofm/liliana/internal/entity/auth"
 	authService "github.com/josofm/liliana/internal/service/auth"
 )
 
-// AuthMiddleware verifica se o usuário está autenticado
+// AuthMiddleware verifica se o usuário está autenticado, por access token ou
+// por chave de API (header X-API-Key ou Bearer)
 func AuthMiddleware(authService *authService.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			if authorizeAPIKey(c, authService, key) {
+				c.Next()
+			}
+			return
+		}
+
 		// Extrair token do header Authorization
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -29,6 +39,13 @@ func AuthMiddleware(authService *authService.Service) gin.HandlerFunc {
 
 		token := tokenParts[1]
 
+		if authService.IsAPIKey(token) {
+			if authorizeAPIKey(c, authService, token) {
+				c.Next()
+			}
+			return
+		}
+
 		// Validar token
 		claims, err := authService.ValidateToken(token)
 		if err != nil {
@@ -38,17 +55,93 @@ func AuthMiddleware(authService *authService.Service) gin.HandlerFunc {
 		}
 
 		// Adicionar claims ao contexto para uso posterior
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("claims", claims)
+		setClaims(c, claims)
+
+		c.Next()
+	}
+}
+
+// authorizeAPIKey valida a chave e os escopos dela para a rota. Quando a
+// chave não serve, responde e aborta a requisição.
+func authorizeAPIKey(c *gin.Context, authService *authService.Service, key string) bool {
+	claims, apiKey, err := authService.AuthenticateAPIKey(key)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+		c.Abort()
+		return false
+	}
+	if !apiKeyAllows(apiKey.Scopes, c.Request.Method, c.FullPath()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "api key scopes do not allow this request"})
+		c.Abort()
+		return false
+	}
+	setClaims(c, claims)
+	c.Set("api_key_id", apiKey.ID)
+	return true
+}
 
+// apiKeyAllows diz se os escopos cobrem a rota. Chaves sem escopos valem para
+// todas as rotas; com escopos, somente para as de decks.
+func apiKeyAllows(scopes []string, method, path string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	if !strings.HasPrefix(path, "/decks") && !strings.HasPrefix(path, "/shared/decks") {
+		return false
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return slices.Contains(scopes, auth.ScopeDecksRead)
+	}
+	return slices.Contains(scopes, auth.ScopeDecksWrite)
+}
+
+func setClaims(c *gin.Context, claims *auth.Claims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("user_email", claims.Email)
+	c.Set("user_role", claims.Role)
+	c.Set("session_id", claims.SessionID)
+	c.Set("claims", claims)
+}
+
+// DenyAPIKeys recusa requisições autenticadas por chave de API, para uma
+// chave não criar nem revogar outras
+func DenyAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("api_key_id"); exists {
+			c.JSON(http.StatusForbidden, gin.H{"error": "api keys cannot be used here"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
+// RequireRole só deixa passar usuários com um dos papéis. Deve vir depois do
+// AuthMiddleware; o papel é o do access token, então uma mudança de papel vale
+// a partir da próxima renovação.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := GetUserRoleFromContext(c)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		c.Abort()
+	}
+}
+
 // OptionalAuthMiddleware verifica autenticação mas não falha se não houver token
 func OptionalAuthMiddleware(authService *authService.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			setOptionalAPIKey(c, authService, key)
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.Next()
@@ -64,16 +157,25 @@ func OptionalAuthMiddleware(authService *authService.Service) gin.HandlerFunc {
 		token := tokenParts[1]
 
 		// Tentar validar token, mas não falhar se inválido
-		if claims, err := authService.ValidateToken(token); err == nil {
-			c.Set("user_id", claims.UserID)
-			c.Set("user_email", claims.Email)
-			c.Set("claims", claims)
+		if authService.IsAPIKey(token) {
+			setOptionalAPIKey(c, authService, token)
+		} else if claims, err := authService.ValidateToken(token); err == nil {
+			setClaims(c, claims)
 		}
 
 		c.Next()
 	}
 }
 
+// setOptionalAPIKey autentica pela chave quando ela é válida para a rota
+func setOptionalAPIKey(c *gin.Context, authService *authService.Service, key string) {
+	claims, apiKey, err := authService.AuthenticateAPIKey(key)
+	if err == nil && apiKeyAllows(apiKey.Scopes, c.Request.Method, c.FullPath()) {
+		setClaims(c, claims)
+		c.Set("api_key_id", apiKey.ID)
+	}
+}
+
 // GetUserIDFromContext extrai o ID do usuário do contexto
 func GetUserIDFromContext(c *gin.Context) (int64, bool) {
 	userID, exists := c.Get("user_id")
@@ -101,3 +203,31 @@ func GetUserEmailFromContext(c *gin.Context) (string, bool) {
 
 	return "", false
 }
+
+// GetUserRoleFromContext extrai o papel do usuário do contexto
+func GetUserRoleFromContext(c *gin.Context) (string, bool) {
+	role, exists := c.Get("user_role")
+	if !exists {
+		return "", false
+	}
+
+	if r, ok := role.(string); ok {
+		return r, true
+	}
+
+	return "", false
+}
+
+// GetSessionIDFromContext extrai a sessão do access token do contexto
+func GetSessionIDFromContext(c *gin.Context) (string, bool) {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		return "", false
+	}
+
+	if id, ok := sessionID.(string); ok {
+		return id, true
+	}
+
+	return "", false
+}
@@ -46,7 +46,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 	})
 
 	// Gerar token válido
-	tokenPair, err := jwtService.GenerateTokenPair(123, "test@example.com")
+	tokenPair, err := jwtService.GenerateTokenPair(123, "test@example.com", "user")
 	require.NoError(t, err)
 
 	// Fazer request com token válido
@@ -127,6 +127,34 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "invalid or expired token")
 }
 
+func TestAuthMiddleware_RefreshToken(t *testing.T) {
+	router := setupTestRouterMiddleware()
+
+	jwtService := auth.NewJWTService(auth.JWTConfig{
+		SecretKey:     "test-secret",
+		AccessExpiry:  15 * time.Minute,
+		RefreshExpiry: 24 * time.Hour,
+	})
+	authService := auth.NewService(nil, jwtService)
+
+	router.Use(v1.AuthMiddleware(authService))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	tokenPair, err := jwtService.GenerateTokenPair(123, "test@example.com", "user")
+	require.NoError(t, err)
+
+	// Refresh token não serve como bearer token
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenPair.RefreshToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid or expired token")
+}
+
 func TestGetUserIDFromContext(t *testing.T) {
 	router := setupTestRouterMiddleware()
 
@@ -150,7 +178,7 @@ func TestGetUserIDFromContext(t *testing.T) {
 		})
 	})
 
-	tokenPair, err := jwtService.GenerateTokenPair(123, "test@example.com")
+	tokenPair, err := jwtService.GenerateTokenPair(123, "test@example.com", "user")
 	require.NoError(t, err)
 
 	req, _ := http.NewRequest("GET", "/test", nil)
@@ -162,3 +190,37 @@ func TestGetUserIDFromContext(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "123")
 	assert.Contains(t, w.Body.String(), "test@example.com")
 }
+
+func TestRequireRole(t *testing.T) {
+	router := setupTestRouterMiddleware()
+
+	jwtService := auth.NewJWTService(auth.JWTConfig{
+		SecretKey:     "test-secret",
+		AccessExpiry:  15 * time.Minute,
+		RefreshExpiry: 24 * time.Hour,
+	})
+	authService := auth.NewService(nil, jwtService)
+
+	router.GET("/admin", v1.AuthMiddleware(authService), v1.RequireRole("admin"), func(c *gin.Context) {
+		role, _ := v1.GetUserRoleFromContext(c)
+		c.JSON(http.StatusOK, gin.H{"role": role})
+	})
+
+	request := func(role string) *httptest.ResponseRecorder {
+		tokenPair, err := jwtService.GenerateTokenPair(123, "test@example.com", role)
+		require.NoError(t, err)
+		req, _ := http.NewRequest("GET", "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenPair.AccessToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("user")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "forbidden")
+
+	w = request("admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "admin")
+}
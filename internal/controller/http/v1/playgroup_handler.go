@@ -0,0 +1,334 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	playgroupEntity "github.com/josofm/liliana/internal/entity/playgroup"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	playgroupRepo "github.com/josofm/liliana/internal/repository/playgroup"
+	userRepo "github.com/josofm/liliana/internal/repository/user"
+	playgroupService "github.com/josofm/liliana/internal/service/playgroup"
+	"github.com/josofm/liliana/internal/validator"
+)
+
+// PlaygroupRequest creates or renames a playgroup.
+type PlaygroupRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+// PlaygroupInvitationRequest invites an email to a playgroup.
+type PlaygroupInvitationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// PlaygroupMemberRequest changes the role of a member.
+type PlaygroupMemberRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+type PlaygroupHandler struct {
+	service   *playgroupService.Service
+	validator *validator.Validator
+}
+
+func NewPlaygroupHandler(r *gin.Engine, repo playgroupRepo.Repository, users userRepo.Repository, decks deckRepo.Repository) {
+	h := &PlaygroupHandler{service: playgroupService.NewService(repo, users, decks), validator: validator.New()}
+	h.registerRoutes(r.Group("/playgroups"))
+}
+
+func (h *PlaygroupHandler) registerRoutes(group *gin.RouterGroup) {
+	group.GET("/", h.list)
+	group.POST("/", h.create)
+	group.GET("/invitations", h.receivedInvitations)
+	group.POST("/invitations/:id/accept", h.acceptInvitation)
+	group.DELETE("/invitations/:id", h.deleteInvitation)
+	group.GET("/:id", h.getByID)
+	group.PUT("/:id", h.update)
+	group.DELETE("/:id", h.delete)
+	group.GET("/:id/decks", h.decks)
+	group.GET("/:id/invitations", h.invitations)
+	group.POST("/:id/invitations", h.invite)
+	group.PUT("/:id/members/:user_id", h.setRole)
+	group.DELETE("/:id/members/:user_id", h.removeMember)
+}
+
+// list returns the playgroups of the authenticated user.
+func (h *PlaygroupHandler) list(c *gin.Context) {
+	userID, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+	playgroups, err := h.service.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list playgroups"})
+		return
+	}
+	c.JSON(http.StatusOK, playgroups)
+}
+
+func (h *PlaygroupHandler) create(c *gin.Context) {
+	request, ok := bindPlaygroupRequest[PlaygroupRequest](c, h.validator)
+	if !ok {
+		return
+	}
+	userID, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+	p := &playgroupEntity.Playgroup{Name: request.Name, Description: request.Description}
+	if err := h.service.Create(userID, p); err != nil {
+		respondPlaygroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, p)
+}
+
+func (h *PlaygroupHandler) getByID(c *gin.Context) {
+	id, userID, ok := playgroupIDAndUser(c)
+	if !ok {
+		return
+	}
+	p, err := h.service.GetByID(id, userID)
+	if err != nil {
+		respondPlaygroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *PlaygroupHandler) update(c *gin.Context) {
+	id, userID, ok := playgroupIDAndUser(c)
+	if !ok {
+		return
+	}
+	request, ok := bindPlaygroupRequest[PlaygroupRequest](c, h.validator)
+	if !ok {
+		return
+	}
+	p := &playgroupEntity.Playgroup{Name: request.Name, Description: request.Description}
+	if err := h.service.Update(id, userID, p); err != nil {
+		respondPlaygroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *PlaygroupHandler) delete(c *gin.Context) {
+	id, userID, ok := playgroupIDAndUser(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(id, userID); err != nil {
+		respondPlaygroupError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// decks lists the decks of every member, with the filters of GET /decks.
+func (h *PlaygroupHandler) decks(c *gin.Context) {
+	id, userID, ok := playgroupIDAndUser(c)
+	if !ok {
+		return
+	}
+	filter, err := deckListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := h.service.Decks(c.Request.Context(), id, userID, filter)
+	if err != nil {
+		if errors.Is(err, deckRepo.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondPlaygroupError(c, err)
+		return
+	}
+	if page.NextCursor != "" {
+		c.Header("X-Next-Cursor", page.NextCursor)
+	}
+	c.JSON(http.StatusOK, page.Decks)
+}
+
+// invitations lists the pending invitations of a playgroup, for its owners.
+func (h *PlaygroupHandler) invitations(c *gin.Context) {
+	id, userID, ok := playgroupIDAndUser(c)
+	if !ok {
+		return
+	}
+	invitations, err := h.service.Invitations(id, userID)
+	if err != nil {
+		respondPlaygroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *PlaygroupHandler) invite(c *gin.Context) {
+	id, userID, ok := playgroupIDAndUser(c)
+	if !ok {
+		return
+	}
+	request, ok := bindPlaygroupRequest[PlaygroupInvitationRequest](c, h.validator)
+	if !ok {
+		return
+	}
+	invitation, err := h.service.Invite(id, userID, request.Email)
+	if err != nil {
+		respondPlaygroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// receivedInvitations lists the invitations sent to the authenticated user.
+func (h *PlaygroupHandler) receivedInvitations(c *gin.Context) {
+	userID, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+	invitations, err := h.service.ReceivedInvitations(userID)
+	if err != nil {
+		if errors.Is(err, playgroupService.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list invitations"})
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *PlaygroupHandler) acceptInvitation(c *gin.Context) {
+	id, userID, ok := invitationIDAndUser(c)
+	if !ok {
+		return
+	}
+	p, err := h.service.AcceptInvitation(id, userID)
+	if err != nil {
+		respondPlaygroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// deleteInvitation declines an invitation or, for owners, revokes it.
+func (h *PlaygroupHandler) deleteInvitation(c *gin.Context) {
+	id, userID, ok := invitationIDAndUser(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteInvitation(id, userID); err != nil {
+		respondPlaygroupError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PlaygroupHandler) setRole(c *gin.Context) {
+	id, userID, ok := playgroupIDAndUser(c)
+	if !ok {
+		return
+	}
+	memberID, ok := memberUserID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindPlaygroupRequest[PlaygroupMemberRequest](c, h.validator)
+	if !ok {
+		return
+	}
+	member, err := h.service.SetRole(id, userID, memberID, request.Role)
+	if err != nil {
+		respondPlaygroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// removeMember removes a member; members remove themselves to leave.
+func (h *PlaygroupHandler) removeMember(c *gin.Context) {
+	id, userID, ok := playgroupIDAndUser(c)
+	if !ok {
+		return
+	}
+	memberID, ok := memberUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.RemoveMember(id, userID, memberID); err != nil {
+		respondPlaygroupError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func bindPlaygroupRequest[T any](c *gin.Context, v *validator.Validator) (*T, bool) {
+	var request T
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if validationErrors := v.ValidateAndGetErrors(&request); validationErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+		return nil, false
+	}
+	return &request, true
+}
+
+func authenticatedUser(c *gin.Context) (int64, bool) {
+	userID, exists := GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+	}
+	return userID, exists
+}
+
+func playgroupIDAndUser(c *gin.Context) (int64, int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid playgroup id"})
+		return 0, 0, false
+	}
+	userID, ok := authenticatedUser(c)
+	return id, userID, ok
+}
+
+func invitationIDAndUser(c *gin.Context) (int64, int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation id"})
+		return 0, 0, false
+	}
+	userID, ok := authenticatedUser(c)
+	return id, userID, ok
+}
+
+func memberUserID(c *gin.Context) (int64, bool) {
+	memberID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil || memberID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, false
+	}
+	return memberID, true
+}
+
+func respondPlaygroupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, playgroupService.ErrInvalidPlaygroup):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, playgroupService.ErrNotMember), errors.Is(err, playgroupService.ErrForbidden), errors.Is(err, playgroupService.ErrEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "playgroup not found", err.Error() == "invitation not found", err.Error() == "member not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "invitation already exists":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save playgroup"})
+	}
+}
@@ -0,0 +1,124 @@
+//go:build integration
+
+package v1_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/josofm/liliana/internal/controller/http/v1"
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	playgroupEntity "github.com/josofm/liliana/internal/entity/playgroup"
+	userEntity "github.com/josofm/liliana/internal/entity/user"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	playgroupRepo "github.com/josofm/liliana/internal/repository/playgroup"
+	userRepo "github.com/josofm/liliana/internal/repository/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPlaygroupHandler(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64)
+		c.Set("user_id", userID)
+		c.Next()
+	})
+	users := userRepo.NewInMemoryRepo()
+	decks := deckRepo.NewInMemoryRepo()
+	for index, name := range []string{"liliana", "thassa", "krenko"} {
+		require.NoError(t, users.Create(&userEntity.User{Name: name, Email: name + "@example.com", EmailVerified: true}))
+		require.NoError(t, decks.Create(&deckEntity.Deck{Name: name, Color: "B", Format: "commander", OwnerID: int64(index + 1)}))
+	}
+	v1.NewPlaygroupHandler(router, playgroupRepo.NewInMemoryRepo(), users, decks)
+	return router
+}
+
+func sendPlaygroup(router *gin.Engine, method, path, userID, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", userID)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPlaygroupHandler_Invitations(t *testing.T) {
+	router := setupPlaygroupHandler(t)
+
+	w := sendPlaygroup(router, http.MethodPost, "/playgroups/", "1", `{"name":"Thursday pod"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var pod playgroupEntity.Playgroup
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &pod))
+	podPath := "/playgroups/" + strconv.FormatInt(pod.ID, 10)
+
+	assert.Equal(t, http.StatusForbidden, sendPlaygroup(router, http.MethodGet, podPath+"/decks", "2", "").Code)
+	assert.Equal(t, http.StatusForbidden, sendPlaygroup(router, http.MethodPost, podPath+"/invitations", "2", `{"email":"thassa@example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, sendPlaygroup(router, http.MethodPost, podPath+"/invitations", "1", `{"email":"thassa"}`).Code)
+
+	w = sendPlaygroup(router, http.MethodPost, podPath+"/invitations", "1", `{"email":"thassa@example.com"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var invitation playgroupEntity.Invitation
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &invitation))
+	assert.Equal(t, http.StatusConflict, sendPlaygroup(router, http.MethodPost, podPath+"/invitations", "1", `{"email":"thassa@example.com"}`).Code)
+
+	w = sendPlaygroup(router, http.MethodGet, "/playgroups/invitations", "2", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"email":"thassa@example.com"`)
+
+	acceptPath := "/playgroups/invitations/" + strconv.FormatInt(invitation.ID, 10) + "/accept"
+	assert.Equal(t, http.StatusNotFound, sendPlaygroup(router, http.MethodPost, acceptPath, "3", "").Code)
+	require.Equal(t, http.StatusOK, sendPlaygroup(router, http.MethodPost, acceptPath, "2", "").Code)
+
+	w = sendPlaygroup(router, http.MethodGet, podPath+"/decks?sort=name", "2", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var decks []deckEntity.Deck
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &decks))
+	require.Len(t, decks, 2)
+	assert.Equal(t, "liliana", decks[0].Name)
+	assert.Equal(t, "thassa", decks[1].Name)
+
+	w = sendPlaygroup(router, http.MethodGet, "/playgroups/", "2", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var playgroups []playgroupEntity.Playgroup
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &playgroups))
+	require.Len(t, playgroups, 1)
+	assert.Len(t, playgroups[0].Members, 2)
+}
+
+func TestPlaygroupHandler_Members(t *testing.T) {
+	router := setupPlaygroupHandler(t)
+	w := sendPlaygroup(router, http.MethodPost, "/playgroups/", "1", `{"name":"Pod"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var pod playgroupEntity.Playgroup
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &pod))
+	podPath := "/playgroups/" + strconv.FormatInt(pod.ID, 10)
+	w = sendPlaygroup(router, http.MethodPost, podPath+"/invitations", "1", `{"email":"thassa@example.com"}`)
+	var invitation playgroupEntity.Invitation
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &invitation))
+	require.Equal(t, http.StatusOK, sendPlaygroup(router, http.MethodPost, "/playgroups/invitations/"+strconv.FormatInt(invitation.ID, 10)+"/accept", "2", "").Code)
+
+	assert.Equal(t, http.StatusForbidden, sendPlaygroup(router, http.MethodPut, podPath, "2", `{"name":"Mine"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, sendPlaygroup(router, http.MethodPut, podPath+"/members/1", "1", `{"role":"member"}`).Code)
+	assert.Equal(t, http.StatusNotFound, sendPlaygroup(router, http.MethodPut, podPath+"/members/3", "1", `{"role":"member"}`).Code)
+
+	w = sendPlaygroup(router, http.MethodPut, podPath+"/members/2", "1", `{"role":"owner"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"role":"owner"`)
+	w = sendPlaygroup(router, http.MethodPut, podPath, "2", `{"name":"Friday pod","description":"Casual"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"name":"Friday pod"`)
+
+	assert.Equal(t, http.StatusNoContent, sendPlaygroup(router, http.MethodDelete, podPath+"/members/1", "1", "").Code)
+	assert.Equal(t, http.StatusForbidden, sendPlaygroup(router, http.MethodGet, podPath, "1", "").Code)
+	assert.Equal(t, http.StatusNoContent, sendPlaygroup(router, http.MethodDelete, podPath, "2", "").Code)
+	assert.Equal(t, http.StatusNotFound, sendPlaygroup(router, http.MethodGet, podPath, "2", "").Code)
+	assert.Equal(t, http.StatusBadRequest, sendPlaygroup(router, http.MethodGet, "/playgroups/x", "2", "").Code)
+}
@@ -1,18 +1,35 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/josofm/liliana/config"
+	userEntity "github.com/josofm/liliana/internal/entity/user"
+	apiKeyRepo "github.com/josofm/liliana/internal/repository/apikey"
+	auditRepo "github.com/josofm/liliana/internal/repository/audit"
+	cardRepo "github.com/josofm/liliana/internal/repository/card"
+	collectionRepo "github.com/josofm/liliana/internal/repository/collection"
 	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	gameRepo "github.com/josofm/liliana/internal/repository/game"
+	identityRepo "github.com/josofm/liliana/internal/repository/identity"
+	loginAttemptRepo "github.com/josofm/liliana/internal/repository/loginattempt"
+	playgroupRepo "github.com/josofm/liliana/internal/repository/playgroup"
+	refreshTokenRepo "github.com/josofm/liliana/internal/repository/refreshtoken"
+	tokenRepo "github.com/josofm/liliana/internal/repository/token"
 	userRepo "github.com/josofm/liliana/internal/repository/user"
 	"github.com/josofm/liliana/internal/service/auth"
+	collectionService "github.com/josofm/liliana/internal/service/collection"
 	deckService "github.com/josofm/liliana/internal/service/deck"
+	gameService "github.com/josofm/liliana/internal/service/game"
+	playgroupService "github.com/josofm/liliana/internal/service/playgroup"
 	userService "github.com/josofm/liliana/internal/service/user"
 	"github.com/josofm/liliana/internal/validator"
 	"github.com/josofm/liliana/pkg/logger"
+	"github.com/josofm/liliana/pkg/mailer"
+	"github.com/josofm/liliana/pkg/oidc"
 )
 
 // RouterGroup é uma interface que tanto gin.Engine quanto gin.RouterGroup implementam
@@ -21,51 +38,153 @@ type RouterGroup interface {
 	Use(middleware ...gin.HandlerFunc) gin.IRoutes
 }
 
-func NewRouter(handler *gin.Engine, l logger.Interface, userRepo userRepo.Repository, deckRepo deckRepo.Repository, cfg *config.Config) {
+// Repositories agrupa os repositórios usados pelas rotas
+type Repositories struct {
+	Users         userRepo.Repository
+	Decks         deckRepo.Repository
+	Cards         cardRepo.Repository
+	Collection    collectionRepo.Repository
+	Games         gameRepo.Repository
+	Playgroups    playgroupRepo.Repository
+	Tokens        tokenRepo.Repository
+	RefreshTokens refreshTokenRepo.Repository
+	APIKeys       apiKeyRepo.Repository
+	Identities    identityRepo.Repository
+	LoginAttempts loginAttemptRepo.Repository
+	Audit         auditRepo.Repository
+}
+
+func NewRouter(handler *gin.Engine, l logger.Interface, repos Repositories, cfg *config.Config) {
 	// Options
 	handler.Use(gin.Logger())
 	handler.Use(gin.Recovery())
 	handler.Use(corsMiddleware(cfg.HTTP.CORSAllowedOrigins))
 
+	// IP do cliente, usado nos limites de falhas de login. Sem proxies
+	// confiáveis, o X-Forwarded-For é ignorado e vale o IP da conexão.
+	if err := handler.SetTrustedProxies(splitCSV(cfg.HTTP.TrustedProxies)); err != nil {
+		l.Error(fmt.Errorf("v1 - NewRouter - SetTrustedProxies: %w", err))
+	}
+
 	// Health check
 	handler.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
 
 	// Validator
 	validator := validator.New()
 
-	// JWT Service
+	// JWT Service, com as chaves assimétricas de JWT_KEYS_DIR
+	signingKeys, err := auth.LoadSigningKeys(cfg.JWT.KeysDir, cfg.JWT.ActiveKeyID)
+	if err != nil {
+		l.Fatal(fmt.Errorf("v1 - NewRouter - LoadSigningKeys: %w", err))
+	}
 	jwtService := auth.NewJWTService(auth.JWTConfig{
 		SecretKey:     cfg.JWT.SecretKey,
+		Keys:          signingKeys,
+		ActiveKeyID:   cfg.JWT.ActiveKeyID,
+		Issuer:        cfg.JWT.Issuer,
+		Audience:      cfg.JWT.Audience,
 		AccessExpiry:  cfg.JWT.AccessExpiry,
 		RefreshExpiry: cfg.JWT.RefreshExpiry,
 	})
 
 	// Auth Service
-	authService := auth.NewService(userRepo, jwtService)
+	stores := auth.Stores{
+		AccountTokens: repos.Tokens,
+		RefreshTokens: repos.RefreshTokens,
+		APIKeys:       repos.APIKeys,
+		Identities:    repos.Identities,
+		LoginAttempts: repos.LoginAttempts,
+		Audit:         repos.Audit,
+	}
+	authService := auth.NewServiceWithDependencies(repos.Users, jwtService, stores, newMailer(cfg.Mail, l), auth.AccountConfig{
+		LinkBaseURL:             cfg.Account.LinkBaseURL,
+		PasswordResetExpiry:     cfg.Account.PasswordResetExpiry,
+		EmailVerificationExpiry: cfg.Account.EmailVerificationExpiry,
+	})
+	authService.ConfigureLoginProtection(auth.LoginProtectionConfig{
+		MaxFailures:     cfg.Login.MaxFailures,
+		IPMaxFailures:   cfg.Login.IPMaxFailures,
+		LockoutDuration: cfg.Login.LockoutDuration,
+		BackoffAfter:    cfg.Login.BackoffAfter,
+		BaseDelay:       cfg.Login.BaseDelay,
+		MaxDelay:        cfg.Login.MaxDelay,
+		FailureWindow:   cfg.Login.FailureWindow,
+	})
+	if cfg.OIDC.Enabled() {
+		authService.EnableOIDC(oidc.New(oidc.Config{
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		}), cfg.OIDC.LoginExpiry)
+	}
 
 	// Auth Handler
 	authHandler := NewAuthHandler(authService, validator)
 
+	// Chaves públicas dos tokens
+	handler.GET("/.well-known/jwks.json", authHandler.JWKS)
+
 	// Auth routes (públicas)
 	auth := handler.Group("/auth")
 	{
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
 		auth.POST("/refresh", authHandler.RefreshToken)
+		auth.POST("/logout", authHandler.Logout)
+		auth.POST("/logout-all", AuthMiddleware(authService), DenyAPIKeys(), authHandler.LogoutAll)
+		auth.POST("/forgot-password", authHandler.ForgotPassword)
+		auth.POST("/reset-password", authHandler.ResetPassword)
+		auth.POST("/verify-email", authHandler.VerifyEmail)
+		auth.GET("/oidc/login", authHandler.OIDCLogin)
+		auth.GET("/oidc/callback", authHandler.OIDCCallback)
 	}
 
+	// Decks compartilhados por link (públicos, autenticação opcional)
+	shared := handler.Group("/shared")
+	shared.Use(OptionalAuthMiddleware(authService))
+	setupSharedDeckRoutes(shared, repos.Decks)
+
 	// Rotas protegidas
 	protected := handler.Group("/")
 	protected.Use(AuthMiddleware(authService))
 	{
-		// User profile
+		// User profile; a conta só muda com access token
 		protected.GET("/me", authHandler.Me)
+		protected.PUT("/me", DenyAPIKeys(), authHandler.UpdateMe)
+		protected.DELETE("/me", DenyAPIKeys(), authHandler.DeleteMe)
+		protected.POST("/me/verification", DenyAPIKeys(), authHandler.SendVerification)
+
+		// Chaves de API do usuário, geridas somente com access token
+		apiKeys := protected.Group("/me/api-keys")
+		apiKeys.Use(DenyAPIKeys())
+		{
+			apiKeys.POST("/", authHandler.CreateAPIKey)
+			apiKeys.GET("/", authHandler.ListAPIKeys)
+			apiKeys.DELETE("/:id", authHandler.RevokeAPIKey)
+		}
 
-		// User management (protegido)
-		setupUserRoutes(protected, userRepo)
+		// User management (somente admins, com access token)
+		setupUserRoutes(protected, repos.Users, jwtService)
+
+		// Decks e coleção usam o mesmo validador de cartas
+		cardValidator := newCardValidator(repos.Cards, cfg.Deck)
 
 		// Deck management (protegido)
-		setupDeckRoutes(protected, deckRepo)
+		setupDeckRoutes(protected, repos, cardValidator, cfg.Deck)
+
+		// Coleção de cartas do usuário (protegido)
+		setupCollectionRoutes(protected, repos.Collection, cardValidator)
+
+		// Partidas e estatísticas por deck (protegido)
+		setupGameRoutes(protected, repos)
+
+		// Uso de cartas e staples por formato, cor e comandante (protegido)
+		setupAnalyticsRoutes(protected, repos.Decks)
+
+		// Grupos de jogo, convites e decks dos membros (protegido)
+		setupPlaygroupRoutes(protected, repos)
 	}
 }
 
@@ -81,8 +200,9 @@ func corsMiddleware(allowedOriginsConfig string) gin.HandlerFunc {
 				c.Header("Access-Control-Allow-Origin", origin)
 				c.Header("Vary", "Origin")
 			}
-			c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Authorization,Content-Type")
+			c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Authorization,Content-Type,X-API-Key")
+			c.Header("Access-Control-Expose-Headers", "Content-Disposition,Retry-After,X-Next-Cursor")
 		}
 
 		if c.Request.Method == http.MethodOptions {
@@ -125,36 +245,91 @@ func contains(items []string, want string) bool {
 	return false
 }
 
-// setupUserRoutes configura as rotas de usuário
-func setupUserRoutes(rg RouterGroup, userRepo userRepo.Repository) {
-	service := userService.NewService(userRepo)
+// newMailer escolhe como os emails saem: SMTP, arquivo local ou o log da aplicação
+func newMailer(cfg config.MailConfig, l logger.Interface) mailer.Mailer {
+	switch cfg.Driver {
+	case config.MailDriverSMTP:
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		})
+	case config.MailDriverFile:
+		return mailer.NewFileMailer(cfg.FilePath)
+	default:
+		return mailer.NewLogMailer(l)
+	}
+}
+
+// setupUserRoutes configura as rotas de usuário. As senhas definidas pelos
+// admins passam pelo mesmo hash do cadastro.
+func setupUserRoutes(rg RouterGroup, userRepo userRepo.Repository, passwords userService.PasswordHasher) {
+	service := userService.NewService(userRepo, passwords)
 	validator := validator.New()
 	h := &UserHandler{service: service, validator: validator}
 
 	group := rg.Group("/users")
+	group.Use(DenyAPIKeys(), RequireRole(userEntity.RoleAdmin))
 	{
 		group.POST("/", h.create)
 		group.GET("/", h.getAll)
 		group.GET("/:id", h.getByID)
 		group.PUT("/:id", h.update)
 		group.DELETE("/:id", h.delete)
+		group.PUT("/:id/role", h.setRole)
 	}
 }
 
+// newCardValidator escolhe a fonte das cartas: a API do Scryfall ou o catálogo offline
+func newCardValidator(cards cardRepo.Repository, cfg config.DeckConfig) deckService.CardValidator {
+	if cfg.CardSource == config.CardSourceCatalog {
+		return deckService.NewCatalogValidator(cards)
+	}
+	return deckService.NewScryfallValidator()
+}
+
 // setupDeckRoutes configura as rotas de deck
-func setupDeckRoutes(rg RouterGroup, deckRepo deckRepo.Repository) {
-	service := deckService.NewService(deckRepo)
+func setupDeckRoutes(rg RouterGroup, repos Repositories, cardValidator deckService.CardValidator, cfg config.DeckConfig) {
+	service := deckService.NewServiceWithDependencies(repos.Decks, deckService.NewDefaultImporter(), cardValidator)
+	service.EnforceLegality(cfg.EnforceLegality)
+	service.ShareWithPlaygroups(repos.Playgroups)
 	validator := validator.New()
 	h := &DeckHandler{service: service, validator: validator}
+	h.registerRoutes(rg.Group("/decks"))
+}
 
-	group := rg.Group("/decks")
-	{
-		group.GET("/commanders", h.searchCommanders)
-		group.POST("/", h.create)
-		group.GET("/", h.getAll)
-		group.GET("/:id", h.getByID)
-		group.PUT("/:id", h.update)
-		group.POST("/:id/cards", h.addCards)
-		group.DELETE("/:id", h.delete)
-	}
+// setupSharedDeckRoutes configura a leitura de decks por link de compartilhamento
+func setupSharedDeckRoutes(rg RouterGroup, repo deckRepo.Repository) {
+	h := &DeckHandler{service: deckService.NewService(repo), validator: validator.New()}
+	h.registerSharedRoutes(rg.Group("/decks"))
+}
+
+// setupCollectionRoutes configura as rotas da coleção
+func setupCollectionRoutes(rg RouterGroup, repo collectionRepo.Repository, cardValidator deckService.CardValidator) {
+	service := collectionService.NewService(repo, cardValidator)
+	h := &CollectionHandler{service: service, validator: validator.New()}
+	h.registerRoutes(rg.Group("/collection"))
+}
+
+// setupGameRoutes configura as rotas de partidas
+func setupGameRoutes(rg RouterGroup, repos Repositories) {
+	service := gameService.NewService(repos.Games, repos.Decks)
+	service.ShareWithPlaygroups(repos.Playgroups)
+	h := &GameHandler{service: service}
+	h.registerRoutes(rg.Group("/games"))
+}
+
+// setupAnalyticsRoutes configura as rotas de análise das cartas dos decks
+func setupAnalyticsRoutes(rg RouterGroup, repo deckRepo.Repository) {
+	h := &AnalyticsHandler{service: deckService.NewService(repo)}
+	h.registerRoutes(rg)
+}
+
+// setupPlaygroupRoutes configura as rotas de grupos de jogo
+func setupPlaygroupRoutes(rg RouterGroup, repos Repositories) {
+	service := playgroupService.NewService(repos.Playgroups, repos.Users, repos.Decks)
+	h := &PlaygroupHandler{service: service, validator: validator.New()}
+	h.registerRoutes(rg.Group("/playgroups"))
 }
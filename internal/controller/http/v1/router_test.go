@@ -15,7 +15,17 @@ import (
 	v1 "github.com/josofm/liliana/internal/controller/http/v1"
 	authEntity "github.com/josofm/liliana/internal/entity/auth"
 	userEntity "github.com/josofm/liliana/internal/entity/user"
+	apiKeyRepo "github.com/josofm/liliana/internal/repository/apikey"
+	auditRepo "github.com/josofm/liliana/internal/repository/audit"
+	cardRepo "github.com/josofm/liliana/internal/repository/card"
+	collectionRepo "github.com/josofm/liliana/internal/repository/collection"
 	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	gameRepo "github.com/josofm/liliana/internal/repository/game"
+	identityRepo "github.com/josofm/liliana/internal/repository/identity"
+	loginAttemptRepo "github.com/josofm/liliana/internal/repository/loginattempt"
+	playgroupRepo "github.com/josofm/liliana/internal/repository/playgroup"
+	refreshTokenRepo "github.com/josofm/liliana/internal/repository/refreshtoken"
+	tokenRepo "github.com/josofm/liliana/internal/repository/token"
 	userRepo "github.com/josofm/liliana/internal/repository/user"
 	"github.com/josofm/liliana/internal/service/auth"
 	"github.com/josofm/liliana/pkg/logger"
@@ -23,6 +33,12 @@ import (
 )
 
 func setupTestRouterV1() *gin.Engine {
+	return setupTestRouterV1WithConfig(nil)
+}
+
+// setupTestRouterV1WithConfig deixa configure alterar a config de teste
+// antes de montar as rotas
+func setupTestRouterV1WithConfig(configure func(*config.Config)) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
@@ -37,9 +53,14 @@ func setupTestRouterV1() *gin.Engine {
 			AccessExpiry:  15 * time.Minute,
 			RefreshExpiry: 24 * time.Hour,
 		},
+		// Catálogo em memória para os testes não dependerem do Scryfall
+		Deck: config.DeckConfig{CardSource: config.CardSourceCatalog},
+	}
+	if configure != nil {
+		configure(cfg)
 	}
 
-	v1.NewRouter(router, l, userRepo, deckRepo, cfg)
+	v1.NewRouter(router, l, v1.Repositories{Users: userRepo, Decks: deckRepo, Cards: cardRepo.NewInMemoryRepo(), Collection: collectionRepo.NewInMemoryRepo(), Games: gameRepo.NewInMemoryRepo(), Playgroups: playgroupRepo.NewInMemoryRepo(), Tokens: tokenRepo.NewInMemoryRepo(), RefreshTokens: refreshTokenRepo.NewInMemoryRepo(), APIKeys: apiKeyRepo.NewInMemoryRepo(), Identities: identityRepo.NewInMemoryRepo(), LoginAttempts: loginAttemptRepo.NewInMemoryRepo(), Audit: auditRepo.NewInMemoryRepo()}, cfg)
 
 	// Criar usuário de teste para autenticação
 	testUser := &userEntity.User{
@@ -285,3 +306,52 @@ func TestRouter_NotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+func TestRouter_SharedDeckWithoutToken(t *testing.T) {
+	router := setupTestRouterV1()
+
+	// Links de compartilhamento não exigem autenticação
+	req, err := http.NewRequest("GET", "/shared/decks/unknown", nil)
+	checkErr(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":"deck not found"}`, w.Body.String())
+}
+
+func TestRouter_CORSAllowsAPIKeyHeader(t *testing.T) {
+	router := setupTestRouterV1WithConfig(func(cfg *config.Config) {
+		cfg.HTTP.CORSAllowedOrigins = "https://app.example.com"
+	})
+
+	req, err := http.NewRequest(http.MethodOptions, "/decks/", nil)
+	checkErr(t, err)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "X-API-Key")
+}
+
+func TestRouter_TrustedProxies(t *testing.T) {
+	clientIP := func(trustedProxies string) string {
+		router := setupTestRouterV1WithConfig(func(cfg *config.Config) {
+			cfg.HTTP.TrustedProxies = trustedProxies
+		})
+		router.GET("/client-ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+		req, err := http.NewRequest(http.MethodGet, "/client-ip", nil)
+		checkErr(t, err)
+		req.RemoteAddr = "10.0.0.1:4321"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "10.0.0.1", clientIP(""), "without trusted proxies X-Forwarded-For is ignored")
+	assert.Equal(t, "203.0.113.7", clientIP("10.0.0.0/8"))
+}
@@ -1,12 +1,12 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	userEntity "github.com/josofm/liliana/internal/entity/user"
-	userRepo "github.com/josofm/liliana/internal/repository/user"
 	userService "github.com/josofm/liliana/internal/service/user"
 	"github.com/josofm/liliana/internal/validator"
 )
@@ -18,30 +18,16 @@ type UserRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 }
 
+// RoleRequest changes the role of a user
+type RoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
+
 type UserHandler struct {
 	service   *userService.Service
 	validator *validator.Validator
 }
 
-func NewUserHandler(r *gin.Engine, repo userRepo.Repository) {
-	service := userService.NewService(repo)
-	validator := validator.New()
-	h := &UserHandler{service: service, validator: validator}
-
-	group := r.Group("/users")
-	{
-		group.POST("/", h.create)
-		group.GET("/", h.getAll)
-		group.GET("/:id", h.getByID)
-		group.PUT("/:id", h.update)
-		group.DELETE("/:id", h.delete)
-		group.GET("/test", func(c *gin.Context) {
-			c.JSON(200, gin.H{"message": "funcionando"})
-		})
-
-	}
-}
-
 func (h *UserHandler) create(c *gin.Context) {
 	var request UserRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -117,6 +103,53 @@ func (h *UserHandler) update(c *gin.Context) {
 
 func (h *UserHandler) delete(c *gin.Context) {
 	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
-	h.service.Delete(id)
+	if err := h.service.Delete(id); err != nil {
+		if errors.Is(err, userService.ErrLastAdmin) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete user"})
+		return
+	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *UserHandler) setRole(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	var request RoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate request
+	if validationErrors := h.validator.ValidateAndGetErrors(&request); validationErrors != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": validationErrors})
+		return
+	}
+
+	if err := h.service.SetRole(id, request.Role); err != nil {
+		if errors.Is(err, userService.ErrLastAdmin) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update role"})
+		return
+	}
+
+	user, err := h.service.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
@@ -12,21 +12,25 @@ import (
 	"github.com/gin-gonic/gin"
 	v1 "github.com/josofm/liliana/internal/controller/http/v1"
 	userEntity "github.com/josofm/liliana/internal/entity/user"
-	userRepo "github.com/josofm/liliana/internal/repository/user"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func setupUserHandler() *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	repo := userRepo.NewInMemoryRepo()
-	v1.NewUserHandler(router, repo)
-	return router
+// setupUserHandler registers the first user, the admin with ID 1, on the
+// full router and returns their access token.
+func setupUserHandler(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+	router, _ := setupAccountRouter(t)
+	w := sendAuth(t, router, http.MethodPost, "/auth/register", "", map[string]string{"name": "Admin", "email": "admin@example.com", "password": "password123"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var registered map[string]any
+	checkErr(t, json.Unmarshal(w.Body.Bytes(), &registered))
+	return router, registered["access_token"].(string)
 }
 
 func TestUserHandler_Create(t *testing.T) {
-	router := setupUserHandler()
+	router, adminToken := setupUserHandler(t)
 
 	userRequest := v1.UserRequest{
 		Name:     "Test User",
@@ -34,32 +38,28 @@ func TestUserHandler_Create(t *testing.T) {
 		Password: "password123",
 	}
 
-	body, err := json.Marshal(userRequest)
-	checkErr(t, err)
-
-	req, err := http.NewRequest("POST", "/users/", bytes.NewBuffer(body))
-	checkErr(t, err)
-	req.Header.Set("Content-Type", "application/json")
-
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
-
+	w := sendAuth(t, router, http.MethodPost, "/users/", adminToken, userRequest)
 	assert.Equal(t, http.StatusCreated, w.Code)
 
 	var response userEntity.User
-	err = json.Unmarshal(w.Body.Bytes(), &response)
+	err := json.Unmarshal(w.Body.Bytes(), &response)
 	checkErr(t, err)
 	assert.Equal(t, userRequest.Name, response.Name)
 	assert.Equal(t, userRequest.Email, response.Email)
-	assert.Equal(t, int64(1), response.ID)
+	assert.Equal(t, int64(2), response.ID)
+
+	// The password is stored hashed, so the new user can log in
+	w = sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "test@example.com", "password": "password123"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
 }
 
 func TestUserHandler_Create_InvalidJSON(t *testing.T) {
-	router := setupUserHandler()
+	router, adminToken := setupUserHandler(t)
 
 	req, err := http.NewRequest("POST", "/users/", bytes.NewBuffer([]byte("invalid json")))
 	checkErr(t, err)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -68,126 +68,79 @@ func TestUserHandler_Create_InvalidJSON(t *testing.T) {
 }
 
 func TestUserHandler_GetAll(t *testing.T) {
-	router := setupUserHandler()
+	router, adminToken := setupUserHandler(t)
 
 	// Create test users via HTTP
 	userRequest1 := v1.UserRequest{Name: "User 1", Email: "user1@example.com", Password: "pass123"}
 	userRequest2 := v1.UserRequest{Name: "User 2", Email: "user2@example.com", Password: "pass456"}
-
-	// Create first user
-	body1, err := json.Marshal(userRequest1)
-	checkErr(t, err)
-	req1, err := http.NewRequest("POST", "/users/", bytes.NewBuffer(body1))
-	checkErr(t, err)
-	req1.Header.Set("Content-Type", "application/json")
-	w1 := httptest.NewRecorder()
-	router.ServeHTTP(w1, req1)
-	assert.Equal(t, http.StatusCreated, w1.Code)
-
-	// Create second user
-	body2, err := json.Marshal(userRequest2)
-	checkErr(t, err)
-	req2, err := http.NewRequest("POST", "/users/", bytes.NewBuffer(body2))
-	checkErr(t, err)
-	req2.Header.Set("Content-Type", "application/json")
-	w2 := httptest.NewRecorder()
-	router.ServeHTTP(w2, req2)
-	assert.Equal(t, http.StatusCreated, w2.Code)
+	assert.Equal(t, http.StatusCreated, sendAuth(t, router, http.MethodPost, "/users/", adminToken, userRequest1).Code)
+	assert.Equal(t, http.StatusCreated, sendAuth(t, router, http.MethodPost, "/users/", adminToken, userRequest2).Code)
 
 	// Get all users
-	req, err := http.NewRequest("GET", "/users/", nil)
-	checkErr(t, err)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
-
+	w := sendAuth(t, router, http.MethodGet, "/users/", adminToken, nil)
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var response []userEntity.User
-	err = json.Unmarshal(w.Body.Bytes(), &response)
+	err := json.Unmarshal(w.Body.Bytes(), &response)
 	checkErr(t, err)
-	assert.Len(t, response, 2)
+	assert.Len(t, response, 3, "the admin and the two new users")
 }
 
 func TestUserHandler_GetByID(t *testing.T) {
-	router := setupUserHandler()
+	router, adminToken := setupUserHandler(t)
 
 	// Create test user via HTTP
 	userRequest := v1.UserRequest{Name: "Test User", Email: "test@example.com", Password: "password123"}
-	body, err := json.Marshal(userRequest)
-	checkErr(t, err)
-	req1, err := http.NewRequest("POST", "/users/", bytes.NewBuffer(body))
-	checkErr(t, err)
-	req1.Header.Set("Content-Type", "application/json")
-	w1 := httptest.NewRecorder()
-	router.ServeHTTP(w1, req1)
-	assert.Equal(t, http.StatusCreated, w1.Code)
+	assert.Equal(t, http.StatusCreated, sendAuth(t, router, http.MethodPost, "/users/", adminToken, userRequest).Code)
 
 	// Get user by ID
-	req, err := http.NewRequest("GET", "/users/1", nil)
-	checkErr(t, err)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
-
+	w := sendAuth(t, router, http.MethodGet, "/users/2", adminToken, nil)
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var response userEntity.User
-	err = json.Unmarshal(w.Body.Bytes(), &response)
+	err := json.Unmarshal(w.Body.Bytes(), &response)
 	checkErr(t, err)
 	assert.Equal(t, userRequest.Name, response.Name)
 	assert.Equal(t, userRequest.Email, response.Email)
 }
 
 func TestUserHandler_GetByID_NotFound(t *testing.T) {
-	router := setupUserHandler()
-
-	req, err := http.NewRequest("GET", "/users/999", nil)
-	checkErr(t, err)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	router, adminToken := setupUserHandler(t)
 
+	w := sendAuth(t, router, http.MethodGet, "/users/999", adminToken, nil)
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 func TestUserHandler_Update(t *testing.T) {
-	router := setupUserHandler()
+	router, adminToken := setupUserHandler(t)
 
 	// Create user via HTTP
 	userRequest := v1.UserRequest{Name: "Original Name", Email: "original@example.com", Password: "password123"}
-	body1, err := json.Marshal(userRequest)
-	checkErr(t, err)
-	req1, err := http.NewRequest("POST", "/users/", bytes.NewBuffer(body1))
-	checkErr(t, err)
-	req1.Header.Set("Content-Type", "application/json")
-	w1 := httptest.NewRecorder()
-	router.ServeHTTP(w1, req1)
-	assert.Equal(t, http.StatusCreated, w1.Code)
+	assert.Equal(t, http.StatusCreated, sendAuth(t, router, http.MethodPost, "/users/", adminToken, userRequest).Code)
 
 	// Update user
 	updatedUserRequest := v1.UserRequest{Name: "Updated Name", Email: "updated@example.com", Password: "newpass"}
-	body, err := json.Marshal(updatedUserRequest)
-	checkErr(t, err)
-
-	req, err := http.NewRequest("PUT", "/users/1", bytes.NewBuffer(body))
-	checkErr(t, err)
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
-
+	w := sendAuth(t, router, http.MethodPut, "/users/2", adminToken, updatedUserRequest)
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var response userEntity.User
-	err = json.Unmarshal(w.Body.Bytes(), &response)
+	err := json.Unmarshal(w.Body.Bytes(), &response)
 	checkErr(t, err)
 	assert.Equal(t, "Updated Name", response.Name)
 	assert.Equal(t, "updated@example.com", response.Email)
+
+	// The new password is hashed too
+	w = sendAuth(t, router, http.MethodPost, "/auth/login", "", map[string]string{"email": "updated@example.com", "password": "newpass"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
 }
 
 func TestUserHandler_Update_InvalidJSON(t *testing.T) {
-	router := setupUserHandler()
+	router, adminToken := setupUserHandler(t)
 
 	req, err := http.NewRequest("PUT", "/users/1", bytes.NewBuffer([]byte("invalid json")))
 	checkErr(t, err)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -195,38 +148,18 @@ func TestUserHandler_Update_InvalidJSON(t *testing.T) {
 }
 
 func TestUserHandler_Delete(t *testing.T) {
-	router := setupUserHandler()
+	router, adminToken := setupUserHandler(t)
 
 	// Create user via HTTP
 	userRequest := v1.UserRequest{Name: "Test User", Email: "test@example.com", Password: "password123"}
-	body, err := json.Marshal(userRequest)
-	checkErr(t, err)
-	req1, err := http.NewRequest("POST", "/users/", bytes.NewBuffer(body))
-	checkErr(t, err)
-	req1.Header.Set("Content-Type", "application/json")
-	w1 := httptest.NewRecorder()
-	router.ServeHTTP(w1, req1)
-	assert.Equal(t, http.StatusCreated, w1.Code)
+	assert.Equal(t, http.StatusCreated, sendAuth(t, router, http.MethodPost, "/users/", adminToken, userRequest).Code)
 
 	// Verify user exists via HTTP
-	req2, err := http.NewRequest("GET", "/users/1", nil)
-	checkErr(t, err)
-	w2 := httptest.NewRecorder()
-	router.ServeHTTP(w2, req2)
-	assert.Equal(t, http.StatusOK, w2.Code)
+	assert.Equal(t, http.StatusOK, sendAuth(t, router, http.MethodGet, "/users/2", adminToken, nil).Code)
 
 	// Delete user
-	req, err := http.NewRequest("DELETE", "/users/1", nil)
-	checkErr(t, err)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
-
-	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusNoContent, sendAuth(t, router, http.MethodDelete, "/users/2", adminToken, nil).Code)
 
 	// Verify user is deleted via HTTP
-	req3, err := http.NewRequest("GET", "/users/1", nil)
-	checkErr(t, err)
-	w3 := httptest.NewRecorder()
-	router.ServeHTTP(w3, req3)
-	assert.Equal(t, http.StatusNotFound, w3.Code)
+	assert.Equal(t, http.StatusNotFound, sendAuth(t, router, http.MethodGet, "/users/2", adminToken, nil).Code)
 }
@@ -14,7 +14,6 @@ import (
 	deckEntity "github.com/josofm/liliana/internal/entity/deck"
 	userEntity "github.com/josofm/liliana/internal/entity/user"
 	deckRepo "github.com/josofm/liliana/internal/repository/deck"
-	userRepo "github.com/josofm/liliana/internal/repository/user"
 	deckService "github.com/josofm/liliana/internal/service/deck"
 
 	"github.com/stretchr/testify/assert"
@@ -24,16 +23,14 @@ func setupValidationTest() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	router.Use(func(c *gin.Context) { c.Set("user_id", int64(1)); c.Next() })
-	userRepository := userRepo.NewInMemoryRepo()
 	deckRepository := deckRepo.NewInMemoryRepo()
-	v1.NewUserHandler(router, userRepository)
 	deckSvc := deckService.NewServiceWithDependencies(deckRepository, deckService.NewArchidektImporter(), testCardValidator{})
 	v1.NewDeckHandlerWithService(router, deckSvc)
 	return router
 }
 
 func TestUserHandler_Validation(t *testing.T) {
-	router := setupValidationTest()
+	router, adminToken := setupUserHandler(t)
 
 	tests := []struct {
 		name           string
@@ -95,21 +92,13 @@ func TestUserHandler_Validation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			body, err := json.Marshal(tt.userRequest)
-			assert.NoError(t, err)
-
-			req, err := http.NewRequest("POST", "/users/", bytes.NewBuffer(body))
-			assert.NoError(t, err)
-			req.Header.Set("Content-Type", "application/json")
-
-			w := httptest.NewRecorder()
-			router.ServeHTTP(w, req)
+			w := sendAuth(t, router, http.MethodPost, "/users/", adminToken, tt.userRequest)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
 			if tt.shouldHaveID {
 				var response userEntity.User
-				err = json.Unmarshal(w.Body.Bytes(), &response)
+				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
 				assert.NotZero(t, response.ID)
 				assert.Equal(t, tt.userRequest.Name, response.Name)
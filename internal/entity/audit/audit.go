@@ -0,0 +1,19 @@
+package audit
+
+import "time"
+
+// Events recorded in the audit log.
+const (
+	// EventLoginLocked is recorded when repeated login failures lock an email or an IP.
+	EventLoginLocked = "login_locked"
+)
+
+// Entry is a security event. Email and IP identify who was involved when known.
+type Entry struct {
+	ID        int64     `json:"id"`
+	Event     string    `json:"event"`
+	Email     string    `json:"email,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -6,10 +6,12 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// LoginRequest representa a requisição de login
+// LoginRequest representa a requisição de login. IP é preenchido pelo
+// handler, para contar as falhas por endereço.
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
+	IP       string `json:"-"`
 }
 
 // RegisterRequest representa a requisição de registro
@@ -30,52 +32,180 @@ type AuthResponse struct {
 
 // User representa o usuário na resposta de auth (sem senha)
 type User struct {
-	ID    int64  `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
 }
 
-// Claims representa as claims do JWT e implementa jwt.Claims
-type Claims struct {
-	UserID int64  `json:"user_id"`
-	Email  string `json:"email"`
-	Exp    int64  `json:"exp"`
-	Iat    int64  `json:"iat"`
+// UpdateProfileRequest altera os dados do próprio usuário. A senha só muda
+// quando enviada; trocar a senha ou o email exige a senha atual.
+type UpdateProfileRequest struct {
+	Name            string `json:"name" validate:"required,min=2,max=50"`
+	Email           string `json:"email" validate:"required,email"`
+	Password        string `json:"password" validate:"omitempty,min=6"`
+	CurrentPassword string `json:"current_password"`
+}
+
+// ForgotPasswordRequest pede o envio de um link de redefinição de senha
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
 }
 
-// GetExpirationTime implementa jwt.Claims
-func (c Claims) GetExpirationTime() (*jwt.NumericDate, error) {
-	return jwt.NewNumericDate(time.Unix(c.Exp, 0)), nil
+// ResetPasswordRequest troca a senha usando o token recebido por email
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+// VerifyEmailRequest confirma o email usando o token recebido por email
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
 }
 
-// GetNotBefore implementa jwt.Claims
-func (c Claims) GetNotBefore() (*jwt.NumericDate, error) {
-	return nil, nil
+// Finalidades dos tokens de conta
+const (
+	PurposePasswordReset     = "password_reset"
+	PurposeEmailVerification = "email_verification"
+)
+
+// AccountToken é um token de uso único guardado pelo servidor. Somente o
+// hash do token é persistido; o token em si vai apenas no email.
+type AccountToken struct {
+	ID        int64
+	UserID    int64
+	Purpose   string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// RefreshToken é o registro de um refresh token emitido, identificado pela
+// claim jti. Todos os tokens renovados a partir de um mesmo login formam uma
+// família: reusar um token já renovado revoga a família inteira.
+type RefreshToken struct {
+	ID         string
+	UserID     int64
+	FamilyID   string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy string
+	CreatedAt  time.Time
+}
+
+// Escopos das chaves de API. Uma chave sem escopos age como o próprio usuário;
+// com escopos, só acessa as rotas cobertas por eles.
+const (
+	ScopeDecksRead  = "decks:read"
+	ScopeDecksWrite = "decks:write"
+)
+
+// APIKey é uma chave de longa duração para scripts e bots. Somente o hash é
+// guardado; a chave em si aparece uma única vez, na criação.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
 }
 
-// GetIssuedAt implementa jwt.Claims
-func (c Claims) GetIssuedAt() (*jwt.NumericDate, error) {
-	return jwt.NewNumericDate(time.Unix(c.Iat, 0)), nil
+// CreateAPIKeyRequest cria uma chave de API com nome e escopos opcionais
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"omitempty,dive,oneof=decks:read decks:write"`
 }
 
-// GetIssuer implementa jwt.Claims
-func (c Claims) GetIssuer() (string, error) {
-	return "", nil
+// CreateAPIKeyResponse traz a chave completa, mostrada somente nesta resposta
+type CreateAPIKeyResponse struct {
+	*APIKey
+	Key string `json:"key"`
 }
 
-// GetSubject implementa jwt.Claims
-func (c Claims) GetSubject() (string, error) {
-	return "", nil
+// LoginAttempt conta as falhas de login seguidas de uma chave ("email:..." ou
+// "ip:..."). LockedUntil é preenchido quando as falhas bloqueiam a chave.
+type LoginAttempt struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+	LockedUntil *time.Time
 }
 
-// GetAudience implementa jwt.Claims
-func (c Claims) GetAudience() (jwt.ClaimStrings, error) {
-	return nil, nil
+// OIDCLogin é um login OIDC em andamento, do redirecionamento ao provedor até
+// o callback. O state identifica o login e só pode ser usado uma vez.
+type OIDCLogin struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// Identity liga uma conta de um provedor OIDC (emissor + subject) a um usuário
+type Identity struct {
+	ID        int64
+	UserID    int64
+	Issuer    string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// Tipos de token, guardados na claim typ
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	// TokenTypeAPIKey marca as claims montadas a partir de uma chave de API
+	TokenTypeAPIKey = "api_key"
+)
+
+// Claims representa as claims do JWT. As claims registradas (iss, sub, aud,
+// exp, iat e jti) vêm de jwt.RegisteredClaims; sub é o ID do usuário.
+type Claims struct {
+	UserID int64  `json:"-"`
+	Email  string `json:"email"`
+	Role   string `json:"role,omitempty"`
+	Type   string `json:"typ"`
+	// SessionID é a sessão do access token: o FamilyID dos refresh tokens
+	// dela. Tokens emitidos antes da claim sid não têm sessão.
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// JSONWebKey é uma chave pública publicada no JWKS (RFC 7517)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// N e E são o módulo e o expoente das chaves RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Crv e X são a curva e a chave pública das chaves OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS é o conjunto de chaves públicas que verificam os tokens emitidos
+type JWKS struct {
+	Keys []JSONWebKey `json:"keys"`
 }
 
 // TokenPair representa um par de tokens
 type TokenPair struct {
-	AccessToken  string    `json:"access_token"`
-	RefreshToken string    `json:"refresh_token"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshTokenID   string    `json:"-"`
+	RefreshExpiresAt time.Time `json:"-"`
+}
+
+// LogoutRequest encerra a sessão do refresh token
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
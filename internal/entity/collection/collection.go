@@ -0,0 +1,72 @@
+package collection
+
+import "strings"
+
+// Conditions follow the grading scale used by card marketplaces, best first.
+const (
+	ConditionMint             = "mint"
+	ConditionNearMint         = "near_mint"
+	ConditionLightlyPlayed    = "lightly_played"
+	ConditionModeratelyPlayed = "moderately_played"
+	ConditionHeavilyPlayed    = "heavily_played"
+	ConditionDamaged          = "damaged"
+)
+
+// Conditions lists every valid condition in grading order.
+var Conditions = []string{ConditionMint, ConditionNearMint, ConditionLightlyPlayed, ConditionModeratelyPlayed, ConditionHeavilyPlayed, ConditionDamaged}
+
+// conditionAliases maps the names and abbreviations written by collection
+// trackers such as Moxfield, Deckbox and ManaBox.
+var conditionAliases = map[string]string{
+	"m":                     ConditionMint,
+	"nm":                    ConditionNearMint,
+	"near mint":             ConditionNearMint,
+	"lp":                    ConditionLightlyPlayed,
+	"lightly played":        ConditionLightlyPlayed,
+	"good (lightly played)": ConditionLightlyPlayed,
+	"excellent":             ConditionLightlyPlayed,
+	"mp":                    ConditionModeratelyPlayed,
+	"moderately played":     ConditionModeratelyPlayed,
+	"played":                ConditionModeratelyPlayed,
+	"hp":                    ConditionHeavilyPlayed,
+	"heavily played":        ConditionHeavilyPlayed,
+	"d":                     ConditionDamaged,
+	"dmg":                   ConditionDamaged,
+	"poor":                  ConditionDamaged,
+}
+
+// Item is a card owned by a user. A user has one item per card, finish and
+// condition; adding the same combination again increases the quantity.
+type Item struct {
+	ID            int64    `json:"id"`
+	UserID        int64    `json:"user_id"`
+	OracleID      string   `json:"oracle_id"`
+	Name          string   `json:"name"`
+	Quantity      int      `json:"quantity"`
+	Foil          bool     `json:"foil"`
+	Condition     string   `json:"condition"`
+	ManaCost      string   `json:"mana_cost,omitempty"`
+	TypeLine      string   `json:"type_line,omitempty"`
+	ColorIdentity []string `json:"color_identity,omitempty"`
+	ImageURI      string   `json:"image_uri,omitempty"`
+}
+
+// NormalizeCondition maps a condition or one of its aliases to a value of
+// Conditions. An empty value means ConditionNearMint.
+func NormalizeCondition(value string) (string, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return ConditionNearMint, true
+	}
+	value = strings.ReplaceAll(value, "-", " ")
+	if condition, ok := conditionAliases[value]; ok {
+		return condition, true
+	}
+	value = strings.ReplaceAll(value, " ", "_")
+	for _, condition := range Conditions {
+		if value == condition {
+			return condition, true
+		}
+	}
+	return "", false
+}
@@ -1,6 +1,32 @@
 // internal/entity/deck/deck.go
 package deck
 
+import "strings"
+
+// Boards group the cards of a deck. Cards without a board belong to the main deck.
+const (
+	BoardMain      = "main"
+	BoardSide      = "side"
+	BoardMaybe     = "maybe"
+	BoardCommander = "commander"
+	BoardCompanion = "companion"
+)
+
+// Boards lists every valid board in display order.
+var Boards = []string{BoardCommander, BoardCompanion, BoardMain, BoardSide, BoardMaybe}
+
+// Visibility levels. Private decks are seen only by their owner; unlisted
+// decks are left out of listings but open through a share link; public decks
+// are seen by everyone.
+const (
+	VisibilityPrivate  = "private"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPublic   = "public"
+)
+
+// Visibilities lists every valid visibility.
+var Visibilities = []string{VisibilityPrivate, VisibilityUnlisted, VisibilityPublic}
+
 type Deck struct {
 	ID                int64  `json:"id"`
 	Name              string `json:"name" validate:"required,min=1,max=100"`
@@ -10,15 +36,89 @@ type Deck struct {
 	CommanderImageURI string `json:"commander_image_uri" validate:"omitempty,url"`
 	OwnerID           int64  `json:"owner_id" validate:"required,gt=0"`
 	SourceLink        string `json:"source_link" validate:"omitempty,url"` // ex: https://archidekt.com/decks/123456
-	Cards             []Card `json:"cards"`
+	Visibility        string `json:"visibility" validate:"omitempty,oneof=private unlisted public"`
+	// ShareToken opens the deck through a share link; empty when the deck is
+	// not shared. Only its owner sees it.
+	ShareToken string `json:"-"`
+	Cards      []Card `json:"cards"`
 }
 
 type Card struct {
 	OracleID      string   `json:"oracle_id"`
 	Name          string   `json:"name"`
 	Quantity      int      `json:"quantity"`
+	Board         string   `json:"board"`
 	ManaCost      string   `json:"mana_cost,omitempty"`
 	TypeLine      string   `json:"type_line,omitempty"`
 	ColorIdentity []string `json:"color_identity,omitempty"`
 	ImageURI      string   `json:"image_uri,omitempty"`
+	// Legalities maps Scryfall format keys to "legal", "banned", "restricted"
+	// or "not_legal". Nil when unknown.
+	Legalities map[string]string `json:"-"`
+}
+
+// NormalizeVisibility maps an empty visibility to VisibilityPublic, the
+// visibility of decks created before visibility levels existed.
+func NormalizeVisibility(visibility string) string {
+	if visibility == "" {
+		return VisibilityPublic
+	}
+	return visibility
+}
+
+// CanView reports whether userID may open the deck by its id: owners open
+// every deck of theirs, everyone else only public decks. The deck service
+// also lets members of a playgroup of the owner open it.
+func (d *Deck) CanView(userID int64) bool {
+	return d.OwnerID == userID || NormalizeVisibility(d.Visibility) == VisibilityPublic
+}
+
+// NormalizeBoard lowercases a board name and maps an empty value to BoardMain.
+func NormalizeBoard(board string) string {
+	board = strings.ToLower(strings.TrimSpace(board))
+	if board == "" {
+		return BoardMain
+	}
+	return board
+}
+
+// IsBoard reports whether board, once normalized, is one of Boards.
+func IsBoard(board string) bool {
+	board = NormalizeBoard(board)
+	for _, valid := range Boards {
+		if board == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// BasicLandNames lists the basic lands in lower case, for cards saved without
+// a type line.
+var BasicLandNames = []string{
+	"plains", "island", "swamp", "mountain", "forest", "wastes",
+	"snow-covered plains", "snow-covered island", "snow-covered swamp",
+	"snow-covered mountain", "snow-covered forest", "snow-covered wastes",
+}
+
+// IsBasicLand reports whether a card is a basic land, by type line or by name.
+func IsBasicLand(card Card) bool {
+	if strings.Contains(card.TypeLine, "Basic") && strings.Contains(card.TypeLine, "Land") {
+		return true
+	}
+	name := strings.ToLower(card.Name)
+	for _, basic := range BasicLandNames {
+		if name == basic {
+			return true
+		}
+	}
+	return false
+}
+
+// CatalogCard is a card of the offline catalog. FaceNames lets cards be found
+// by the name of any face, as Scryfall does.
+type CatalogCard struct {
+	Card
+	FaceNames      []string `json:"face_names,omitempty"`
+	CanBeCommander bool     `json:"can_be_commander"`
 }
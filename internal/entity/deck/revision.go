@@ -0,0 +1,12 @@
+package deck
+
+import "time"
+
+// Revision is an immutable snapshot of a deck, recorded whenever the deck is
+// created or changed. Numbers start at 1 and increase by one per deck.
+type Revision struct {
+	DeckID    int64     `json:"deck_id"`
+	Number    int       `json:"number"`
+	CreatedAt time.Time `json:"created_at"`
+	Deck      Deck      `json:"deck"`
+}
@@ -0,0 +1,37 @@
+package game
+
+import "time"
+
+// Game is a recorded game between two or more decks.
+type Game struct {
+	ID       int64     `json:"id"`
+	PlayedAt time.Time `json:"played_at"`
+	// Turns is the number of turns played; zero when unknown.
+	Turns     int       `json:"turns"`
+	CreatedBy int64     `json:"created_by"`
+	Players   []Player  `json:"players"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Player is a deck in a game. OwnerID and DeckName are copied from the deck
+// when the game is recorded, so results survive changes to the deck.
+type Player struct {
+	DeckID   int64  `json:"deck_id"`
+	OwnerID  int64  `json:"owner_id"`
+	DeckName string `json:"deck_name"`
+	Winner   bool   `json:"winner"`
+	// Eliminated is the order in which the player was knocked out, starting
+	// at 1 for the first player out; zero for winners or when unknown.
+	Eliminated int `json:"eliminated"`
+}
+
+// Winners returns the players that won the game.
+func (g *Game) Winners() []Player {
+	winners := make([]Player, 0, 1)
+	for _, player := range g.Players {
+		if player.Winner {
+			winners = append(winners, player)
+		}
+	}
+	return winners
+}
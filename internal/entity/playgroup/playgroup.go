@@ -0,0 +1,85 @@
+package playgroup
+
+import (
+	"strings"
+	"time"
+)
+
+// Member roles. Owners manage the group, its members and invitations.
+const (
+	RoleOwner  = "owner"
+	RoleMember = "member"
+)
+
+// Roles lists the valid member roles.
+var Roles = []string{RoleOwner, RoleMember}
+
+// Playgroup is a group of users who play together and can see each other's
+// decks.
+type Playgroup struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedBy   int64     `json:"created_by"`
+	Members     []Member  `json:"members"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Member is a user in a playgroup.
+type Member struct {
+	UserID   int64     `json:"user_id"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Invitation invites an email address to a playgroup. The user registered
+// with that email accepts or declines it.
+type Invitation struct {
+	ID          int64     `json:"id"`
+	PlaygroupID int64     `json:"playgroup_id"`
+	Email       string    `json:"email"`
+	InvitedBy   int64     `json:"invited_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Member returns the member with userID, or nil when the user is not in the group.
+func (p *Playgroup) Member(userID int64) *Member {
+	for index := range p.Members {
+		if p.Members[index].UserID == userID {
+			return &p.Members[index]
+		}
+	}
+	return nil
+}
+
+// IsOwner reports whether userID is an owner of the group.
+func (p *Playgroup) IsOwner(userID int64) bool {
+	member := p.Member(userID)
+	return member != nil && member.Role == RoleOwner
+}
+
+// Owners counts the owners of the group.
+func (p *Playgroup) Owners() int {
+	owners := 0
+	for _, member := range p.Members {
+		if member.Role == RoleOwner {
+			owners++
+		}
+	}
+	return owners
+}
+
+// MemberIDs returns the user ids of every member.
+func (p *Playgroup) MemberIDs() []int64 {
+	ids := make([]int64, len(p.Members))
+	for index, member := range p.Members {
+		ids[index] = member.UserID
+	}
+	return ids
+}
+
+// NormalizeEmail lowercases and trims an email so invitations match the
+// address regardless of how it was typed.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -1,8 +1,18 @@
 package user
 
+// Roles of a user. Every user starts as RoleUser; admins manage the other users.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID       int64  `json:"id"`
 	Name     string `json:"name" validate:"required,min=2,max=50"`
 	Password string `json:"-"` // Password validation will be handled separately
 	Email    string `json:"email" validate:"required,email"`
+	// EmailVerified is only set by the verification flow and resets when the email changes.
+	EmailVerified bool `json:"email_verified"`
+	// Role is only changed by SetRole; Update keeps it.
+	Role string `json:"role"`
 }
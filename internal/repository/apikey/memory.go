@@ -0,0 +1,92 @@
+package apikey
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type inMemoryRepo struct {
+	mu     sync.RWMutex
+	keys   map[int64]*auth.APIKey
+	nextID int64
+}
+
+func NewInMemoryRepo() Repository {
+	return &inMemoryRepo{keys: make(map[int64]*auth.APIKey), nextID: 1}
+}
+
+func (r *inMemoryRepo) Create(k *auth.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.keys {
+		if existing.KeyHash == k.KeyHash {
+			return errors.New("api key already exists")
+		}
+	}
+	k.ID = r.nextID
+	r.nextID++
+	k.CreatedAt = time.Now().UTC()
+	r.keys[k.ID] = copyKey(k)
+	return nil
+}
+
+func (r *inMemoryRepo) ListByUser(userID int64) ([]*auth.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]*auth.APIKey, 0)
+	for _, k := range r.keys {
+		if k.UserID == userID {
+			keys = append(keys, copyKey(k))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (r *inMemoryRepo) GetByHash(keyHash string) (*auth.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, k := range r.keys {
+		if k.KeyHash == keyHash {
+			return copyKey(k), nil
+		}
+	}
+	return nil, errors.New("api key not found")
+}
+
+func (r *inMemoryRepo) Touch(id int64, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[id]
+	if !ok {
+		return errors.New("api key not found")
+	}
+	lastUsedAt := now
+	k.LastUsedAt = &lastUsedAt
+	return nil
+}
+
+func (r *inMemoryRepo) Delete(userID, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[id]
+	if !ok || k.UserID != userID {
+		return errors.New("api key not found")
+	}
+	delete(r.keys, id)
+	return nil
+}
+
+func copyKey(k *auth.APIKey) *auth.APIKey {
+	copied := *k
+	copied.Scopes = append([]string(nil), k.Scopes...)
+	if k.LastUsedAt != nil {
+		lastUsedAt := *k.LastUsedAt
+		copied.LastUsedAt = &lastUsedAt
+	}
+	return &copied
+}
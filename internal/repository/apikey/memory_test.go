@@ -0,0 +1,50 @@
+package apikey
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo(t *testing.T) {
+	testRepo(t, NewInMemoryRepo())
+}
+
+// testRepo is shared with the postgres tests.
+func testRepo(t *testing.T, repo Repository) {
+	t.Helper()
+	now := time.Date(2026, 3, 5, 20, 0, 0, 0, time.UTC)
+	bot := &auth.APIKey{UserID: 1, Name: "Discord bot", Prefix: "lil_abcd", KeyHash: "bot", Scopes: []string{auth.ScopeDecksRead}}
+	require.NoError(t, repo.Create(bot))
+	assert.NotZero(t, bot.ID)
+	assert.False(t, bot.CreatedAt.IsZero())
+	assert.Error(t, repo.Create(&auth.APIKey{UserID: 2, Name: "Copy", Prefix: "lil_abcd", KeyHash: "bot"}), "hashes are unique")
+	require.NoError(t, repo.Create(&auth.APIKey{UserID: 1, Name: "Spreadsheet", Prefix: "lil_efgh", KeyHash: "sheet"}))
+	require.NoError(t, repo.Create(&auth.APIKey{UserID: 2, Name: "Other", Prefix: "lil_ijkl", KeyHash: "other"}))
+
+	keys, err := repo.ListByUser(1)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "Discord bot", keys[0].Name)
+	assert.Equal(t, []string{auth.ScopeDecksRead}, keys[0].Scopes)
+	assert.Empty(t, keys[1].Scopes)
+	assert.Nil(t, keys[0].LastUsedAt)
+
+	_, err = repo.GetByHash("unknown")
+	assert.EqualError(t, err, "api key not found")
+	require.NoError(t, repo.Touch(bot.ID, now))
+	found, err := repo.GetByHash("bot")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), found.UserID)
+	require.NotNil(t, found.LastUsedAt)
+	assert.True(t, found.LastUsedAt.Equal(now))
+
+	assert.EqualError(t, repo.Delete(2, bot.ID), "api key not found", "users only revoke their own keys")
+	require.NoError(t, repo.Delete(1, bot.ID))
+	_, err = repo.GetByHash("bot")
+	assert.EqualError(t, err, "api key not found")
+	assert.EqualError(t, repo.Delete(1, bot.ID), "api key not found")
+}
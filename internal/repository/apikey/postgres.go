@@ -0,0 +1,94 @@
+package apikey
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type postgresRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresRepo(db *sql.DB) Repository {
+	return &postgresRepo{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at`
+
+func (r *postgresRepo) Create(k *auth.APIKey) error {
+	scopes, err := json.Marshal(k.Scopes)
+	if err != nil {
+		return err
+	}
+	if k.Scopes == nil {
+		scopes = []byte("[]")
+	}
+	const query = `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes) VALUES ($1,$2,$3,$4,$5) RETURNING id, created_at`
+	return r.db.QueryRow(query, k.UserID, k.Name, k.Prefix, k.KeyHash, scopes).Scan(&k.ID, &k.CreatedAt)
+}
+
+func (r *postgresRepo) ListByUser(userID int64) ([]*auth.APIKey, error) {
+	rows, err := r.db.Query(`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id=$1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*auth.APIKey, 0)
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *postgresRepo) GetByHash(keyHash string) (*auth.APIKey, error) {
+	k, err := scanAPIKey(r.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash=$1`, keyHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("api key not found")
+	}
+	return k, err
+}
+
+func (r *postgresRepo) Touch(id int64, now time.Time) error {
+	_, err := r.db.Exec(`UPDATE api_keys SET last_used_at=$1 WHERE id=$2`, now, id)
+	return err
+}
+
+func (r *postgresRepo) Delete(userID, id int64) error {
+	result, err := r.db.Exec(`DELETE FROM api_keys WHERE id=$1 AND user_id=$2`, id, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("api key not found")
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row scanner) (*auth.APIKey, error) {
+	k := &auth.APIKey{}
+	var scopes []byte
+	if err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.KeyHash, &scopes, &k.CreatedAt, &k.LastUsedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(scopes, &k.Scopes); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
@@ -0,0 +1,19 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type Repository interface {
+	Create(k *auth.APIKey) error
+	// ListByUser returns the keys of a user, oldest first.
+	ListByUser(userID int64) ([]*auth.APIKey, error)
+	// GetByHash fails with "api key not found" for unknown keys.
+	GetByHash(keyHash string) (*auth.APIKey, error)
+	// Touch records when the key was last used.
+	Touch(id int64, now time.Time) error
+	// Delete removes a key of the user; keys of other users are "api key not found".
+	Delete(userID, id int64) error
+}
@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/audit"
+)
+
+type inMemoryRepo struct {
+	mu      sync.RWMutex
+	entries []*audit.Entry
+	nextID  int64
+}
+
+func NewInMemoryRepo() Repository {
+	return &inMemoryRepo{nextID: 1}
+}
+
+func (r *inMemoryRepo) Create(e *audit.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.ID = r.nextID
+	r.nextID++
+	e.CreatedAt = time.Now().UTC()
+	copied := *e
+	r.entries = append(r.entries, &copied)
+	return nil
+}
+
+func (r *inMemoryRepo) List(limit int) ([]*audit.Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := make([]*audit.Entry, 0, limit)
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		copied := *r.entries[i]
+		entries = append(entries, &copied)
+	}
+	return entries, nil
+}
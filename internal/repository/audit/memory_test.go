@@ -0,0 +1,36 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/josofm/liliana/internal/entity/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo(t *testing.T) {
+	testRepo(t, NewInMemoryRepo())
+}
+
+// testRepo is shared with the postgres tests.
+func testRepo(t *testing.T, repo Repository) {
+	t.Helper()
+	first := &audit.Entry{Event: audit.EventLoginLocked, Email: "liliana@example.com", Detail: "5 failed attempts"}
+	require.NoError(t, repo.Create(first))
+	assert.NotZero(t, first.ID)
+	assert.False(t, first.CreatedAt.IsZero())
+	require.NoError(t, repo.Create(&audit.Entry{Event: audit.EventLoginLocked, IP: "203.0.113.7"}))
+	require.NoError(t, repo.Create(&audit.Entry{Event: audit.EventLoginLocked, IP: "203.0.113.8"}))
+
+	entries, err := repo.List(2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "203.0.113.8", entries[0].IP, "newest first")
+	assert.Equal(t, "203.0.113.7", entries[1].IP)
+
+	entries, err = repo.List(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "liliana@example.com", entries[2].Email)
+	assert.Equal(t, "5 failed attempts", entries[2].Detail)
+}
@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"database/sql"
+
+	"github.com/josofm/liliana/internal/entity/audit"
+)
+
+type postgresRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresRepo(db *sql.DB) Repository {
+	return &postgresRepo{db: db}
+}
+
+func (r *postgresRepo) Create(e *audit.Entry) error {
+	const query = `INSERT INTO audit_log (event, email, ip, detail) VALUES ($1,$2,$3,$4) RETURNING id, created_at`
+	return r.db.QueryRow(query, e.Event, e.Email, e.IP, e.Detail).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *postgresRepo) List(limit int) ([]*audit.Entry, error) {
+	rows, err := r.db.Query(`SELECT id, event, email, ip, detail, created_at FROM audit_log ORDER BY id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*audit.Entry, 0)
+	for rows.Next() {
+		e := &audit.Entry{}
+		if err := rows.Scan(&e.ID, &e.Event, &e.Email, &e.IP, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
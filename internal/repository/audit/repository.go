@@ -0,0 +1,9 @@
+package audit
+
+import "github.com/josofm/liliana/internal/entity/audit"
+
+type Repository interface {
+	Create(e *audit.Entry) error
+	// List returns the most recent entries first.
+	List(limit int) ([]*audit.Entry, error)
+}
@@ -0,0 +1,77 @@
+package card
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/josofm/liliana/internal/entity/deck"
+)
+
+type inMemoryRepo struct {
+	mu     sync.RWMutex
+	cards  map[string]deck.CatalogCard
+	lookup map[string]string
+}
+
+func NewInMemoryRepo() Repository {
+	return &inMemoryRepo{
+		cards:  make(map[string]deck.CatalogCard),
+		lookup: make(map[string]string),
+	}
+}
+
+func (r *inMemoryRepo) Upsert(cards []deck.CatalogCard) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, card := range cards {
+		card.Quantity = 0
+		card.Board = ""
+		r.cards[card.OracleID] = card
+		for _, name := range lookupNames(card) {
+			r.lookup[name] = card.OracleID
+		}
+	}
+	return nil
+}
+
+func (r *inMemoryRepo) FindByNames(names []string) (map[string]deck.CatalogCard, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make(map[string]deck.CatalogCard, len(names))
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if oracleID, ok := r.lookup[key]; ok {
+			result[key] = r.cards[oracleID]
+		}
+	}
+	return result, nil
+}
+
+func (r *inMemoryRepo) SearchCommanders(query string, limit int) ([]deck.CatalogCard, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	query = strings.ToLower(query)
+	result := make([]deck.CatalogCard, 0)
+	for _, card := range r.cards {
+		if card.CanBeCommander && strings.Contains(strings.ToLower(card.Name), query) {
+			result = append(result, card)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// lookupNames returns the lowercased card and face names a card can be found by.
+func lookupNames(card deck.CatalogCard) []string {
+	names := []string{strings.ToLower(card.Name)}
+	for _, face := range card.FaceNames {
+		if face != "" && !strings.EqualFold(face, card.Name) {
+			names = append(names, strings.ToLower(face))
+		}
+	}
+	return names
+}
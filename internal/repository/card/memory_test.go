@@ -0,0 +1,53 @@
+package card
+
+import (
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo_FindByNames(t *testing.T) {
+	repo := NewInMemoryRepo()
+	require.NoError(t, repo.Upsert([]deckEntity.CatalogCard{
+		{Card: deckEntity.Card{OracleID: "id-delver", Name: "Delver of Secrets // Insectile Aberration", Quantity: 4}, FaceNames: []string{"Delver of Secrets", "Insectile Aberration"}},
+		{Card: deckEntity.Card{OracleID: "id-bolt", Name: "Lightning Bolt"}},
+	}))
+
+	found, err := repo.FindByNames([]string{"insectile aberration", "Lightning Bolt", "Missing"})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "id-delver", found["insectile aberration"].OracleID)
+	assert.Zero(t, found["insectile aberration"].Quantity)
+	assert.Equal(t, "id-bolt", found["lightning bolt"].OracleID)
+}
+
+func TestInMemoryRepo_UpsertReplacesCard(t *testing.T) {
+	repo := NewInMemoryRepo()
+	require.NoError(t, repo.Upsert([]deckEntity.CatalogCard{{Card: deckEntity.Card{OracleID: "id-bolt", Name: "Lightning Bolt"}}}))
+	require.NoError(t, repo.Upsert([]deckEntity.CatalogCard{{Card: deckEntity.Card{OracleID: "id-bolt", Name: "Lightning Bolt", ManaCost: "{R}"}}}))
+
+	found, err := repo.FindByNames([]string{"Lightning Bolt"})
+	require.NoError(t, err)
+	assert.Equal(t, "{R}", found["lightning bolt"].ManaCost)
+}
+
+func TestInMemoryRepo_SearchCommanders(t *testing.T) {
+	repo := NewInMemoryRepo()
+	require.NoError(t, repo.Upsert([]deckEntity.CatalogCard{
+		{Card: deckEntity.Card{OracleID: "id-2", Name: "Thassa, God of the Sea"}, CanBeCommander: true},
+		{Card: deckEntity.Card{OracleID: "id-1", Name: "Kefnet the Mindful"}, CanBeCommander: true},
+		{Card: deckEntity.Card{OracleID: "id-3", Name: "Thassa's Oracle"}},
+	}))
+
+	commanders, err := repo.SearchCommanders("THASSA", 10)
+	require.NoError(t, err)
+	require.Len(t, commanders, 1)
+	assert.Equal(t, "Thassa, God of the Sea", commanders[0].Name)
+
+	commanders, err = repo.SearchCommanders("", 1)
+	require.NoError(t, err)
+	require.Len(t, commanders, 1)
+	assert.Equal(t, "Kefnet the Mindful", commanders[0].Name)
+}
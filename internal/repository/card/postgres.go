@@ -0,0 +1,136 @@
+package card
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/josofm/liliana/internal/entity/deck"
+)
+
+type postgresRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresRepo(db *sql.DB) Repository { return &postgresRepo{db: db} }
+
+const catalogColumns = `c.oracle_id,c.name,c.mana_cost,c.type_line,c.color_identity,c.image_uri,c.legalities,c.face_names,c.can_be_commander`
+
+func (r *postgresRepo) Upsert(cards []deck.CatalogCard) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`
+		INSERT INTO cards (oracle_id,name,mana_cost,type_line,color_identity,image_uri,legalities,face_names,lookup_names,can_be_commander,updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,NOW())
+		ON CONFLICT (oracle_id) DO UPDATE SET
+			name=EXCLUDED.name,
+			mana_cost=EXCLUDED.mana_cost,
+			type_line=EXCLUDED.type_line,
+			color_identity=EXCLUDED.color_identity,
+			image_uri=EXCLUDED.image_uri,
+			legalities=EXCLUDED.legalities,
+			face_names=EXCLUDED.face_names,
+			lookup_names=EXCLUDED.lookup_names,
+			can_be_commander=EXCLUDED.can_be_commander,
+			updated_at=NOW()`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, card := range cards {
+		if card.OracleID == "" {
+			return fmt.Errorf("card %q has no oracle id", card.Name)
+		}
+		colors, err := jsonOrEmpty(card.ColorIdentity, "[]")
+		if err != nil {
+			return err
+		}
+		legalities, err := jsonOrEmpty(card.Legalities, "{}")
+		if err != nil {
+			return err
+		}
+		faces, err := jsonOrEmpty(card.FaceNames, "[]")
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(card.OracleID, card.Name, card.ManaCost, card.TypeLine, colors, card.ImageURI, legalities, faces, lookupNames(card), card.CanBeCommander); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *postgresRepo) FindByNames(names []string) (map[string]deck.CatalogCard, error) {
+	keys := make([]string, len(names))
+	for index, name := range names {
+		keys[index] = strings.ToLower(name)
+	}
+	rows, err := r.db.Query(`SELECT n.name,`+catalogColumns+` FROM unnest($1::text[]) AS n(name) JOIN cards c ON c.lookup_names @> ARRAY[n.name]`, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make(map[string]deck.CatalogCard, len(names))
+	for rows.Next() {
+		var key string
+		card, err := scanCatalogCard(rows, &key)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = card
+	}
+	return result, rows.Err()
+}
+
+func (r *postgresRepo) SearchCommanders(query string, limit int) ([]deck.CatalogCard, error) {
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(query)) + "%"
+	rows, err := r.db.Query(`SELECT `+catalogColumns+` FROM cards c WHERE c.can_be_commander AND lower(c.name) LIKE $1 ORDER BY c.name LIMIT $2`, pattern, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]deck.CatalogCard, 0)
+	for rows.Next() {
+		card, err := scanCatalogCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, card)
+	}
+	return result, rows.Err()
+}
+
+// scanCatalogCard reads catalogColumns, after any leading destinations.
+func scanCatalogCard(rows *sql.Rows, leading ...any) (deck.CatalogCard, error) {
+	var card deck.CatalogCard
+	var colors, legalities, faces []byte
+	destinations := append(leading, &card.OracleID, &card.Name, &card.ManaCost, &card.TypeLine, &colors, &card.ImageURI, &legalities, &faces, &card.CanBeCommander)
+	if err := rows.Scan(destinations...); err != nil {
+		return card, err
+	}
+	if err := json.Unmarshal(colors, &card.ColorIdentity); err != nil {
+		return card, err
+	}
+	if err := json.Unmarshal(legalities, &card.Legalities); err != nil {
+		return card, err
+	}
+	if err := json.Unmarshal(faces, &card.FaceNames); err != nil {
+		return card, err
+	}
+	if len(card.Legalities) == 0 {
+		card.Legalities = nil
+	}
+	return card, nil
+}
+
+func jsonOrEmpty[T any](value T, empty string) ([]byte, error) {
+	body, err := json.Marshal(value)
+	if err != nil || string(body) == "null" {
+		return []byte(empty), err
+	}
+	return body, nil
+}
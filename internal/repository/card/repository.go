@@ -0,0 +1,12 @@
+package card
+
+import "github.com/josofm/liliana/internal/entity/deck"
+
+// Repository stores the offline card catalog.
+type Repository interface {
+	Upsert(cards []deck.CatalogCard) error
+	// FindByNames matches names case-insensitively against card and face
+	// names. The result is keyed by the lowercased requested name.
+	FindByNames(names []string) (map[string]deck.CatalogCard, error)
+	SearchCommanders(query string, limit int) ([]deck.CatalogCard, error)
+}
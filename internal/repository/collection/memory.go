@@ -0,0 +1,117 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/josofm/liliana/internal/entity/collection"
+)
+
+type inMemoryRepo struct {
+	mu     sync.RWMutex
+	items  map[int64]*collection.Item
+	nextID int64
+}
+
+func NewInMemoryRepo() Repository {
+	return &inMemoryRepo{
+		items:  make(map[int64]*collection.Item),
+		nextID: 1,
+	}
+}
+
+func (r *inMemoryRepo) Add(userID int64, items []collection.Item) ([]collection.Item, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]collection.Item, len(items))
+	for index, item := range items {
+		if item.OracleID == "" {
+			return nil, fmt.Errorf("card %q has no oracle id", item.Name)
+		}
+		item.UserID = userID
+		if existing := r.find(item, 0); existing != nil {
+			existing.Quantity += item.Quantity
+			result[index] = *existing
+			continue
+		}
+		item.ID = r.nextID
+		r.nextID++
+		stored := item
+		r.items[item.ID] = &stored
+		result[index] = item
+	}
+	return result, nil
+}
+
+// find returns the item of the same user, card, foil flag and condition as
+// item, other than the one with id except.
+func (r *inMemoryRepo) find(item collection.Item, except int64) *collection.Item {
+	for id, existing := range r.items {
+		if id != except && existing.UserID == item.UserID && existing.OracleID == item.OracleID && existing.Foil == item.Foil && existing.Condition == item.Condition {
+			return existing
+		}
+	}
+	return nil
+}
+
+func (r *inMemoryRepo) List(userID int64) ([]collection.Item, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]collection.Item, 0)
+	for _, item := range r.items {
+		if item.UserID == userID {
+			result = append(result, *item)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if a, b := strings.ToLower(result[i].Name), strings.ToLower(result[j].Name); a != b {
+			return a < b
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (r *inMemoryRepo) GetByID(userID, id int64) (*collection.Item, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	item, ok := r.items[id]
+	if !ok || item.UserID != userID {
+		return nil, errors.New("collection item not found")
+	}
+	result := *item
+	return &result, nil
+}
+
+func (r *inMemoryRepo) Update(userID int64, item *collection.Item) (*collection.Item, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.items[item.ID]
+	if !ok || stored.UserID != userID {
+		return nil, errors.New("collection item not found")
+	}
+	changed := *stored
+	changed.Quantity, changed.Foil, changed.Condition = item.Quantity, item.Foil, item.Condition
+	if existing := r.find(changed, changed.ID); existing != nil {
+		existing.Quantity += changed.Quantity
+		delete(r.items, changed.ID)
+		result := *existing
+		return &result, nil
+	}
+	*stored = changed
+	return &changed, nil
+}
+
+func (r *inMemoryRepo) Delete(userID, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	item, ok := r.items[id]
+	if !ok || item.UserID != userID {
+		return errors.New("collection item not found")
+	}
+	delete(r.items, id)
+	return nil
+}
@@ -0,0 +1,72 @@
+package collection
+
+import (
+	"testing"
+
+	"github.com/josofm/liliana/internal/entity/collection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo(t *testing.T) {
+	testRepo(t, NewInMemoryRepo())
+}
+
+// testRepo is shared with the postgres tests.
+func testRepo(t *testing.T, repo Repository) {
+	t.Helper()
+	solRing := collection.Item{OracleID: "6ad8011d-3471-4369-9d68-b264cc027487", Name: "Sol Ring", Quantity: 2, Condition: collection.ConditionNearMint, TypeLine: "Artifact", ImageURI: "https://example.com/sol-ring.jpg"}
+	island := collection.Item{OracleID: "b2c6aa39-2d2a-459c-a555-fb48ba993373", Name: "Island", Quantity: 10, Condition: collection.ConditionNearMint}
+	foilRing := solRing
+	foilRing.Quantity, foilRing.Foil = 1, true
+
+	added, err := repo.Add(1, []collection.Item{solRing, island, foilRing})
+	require.NoError(t, err)
+	require.Len(t, added, 3)
+	assert.NotZero(t, added[0].ID)
+	assert.NotEqual(t, added[0].ID, added[2].ID, "foil copies are a separate item")
+
+	again, err := repo.Add(1, []collection.Item{solRing})
+	require.NoError(t, err)
+	assert.Equal(t, added[0].ID, again[0].ID)
+	assert.Equal(t, 4, again[0].Quantity)
+
+	_, err = repo.Add(2, []collection.Item{island})
+	require.NoError(t, err)
+
+	items, err := repo.List(1)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, "Island", items[0].Name)
+	assert.Equal(t, "Sol Ring", items[1].Name)
+	assert.Equal(t, "Artifact", items[1].TypeLine)
+	assert.Equal(t, int64(1), items[1].UserID)
+
+	_, err = repo.GetByID(2, added[0].ID)
+	assert.EqualError(t, err, "collection item not found")
+	_, err = repo.Update(2, &collection.Item{ID: added[0].ID, Quantity: 1, Condition: collection.ConditionNearMint})
+	assert.EqualError(t, err, "collection item not found")
+	assert.EqualError(t, repo.Delete(2, added[0].ID), "collection item not found")
+
+	updated, err := repo.Update(1, &collection.Item{ID: added[1].ID, Quantity: 8, Condition: collection.ConditionLightlyPlayed})
+	require.NoError(t, err)
+	assert.Equal(t, added[1].ID, updated.ID)
+	assert.Equal(t, 8, updated.Quantity)
+	assert.Equal(t, collection.ConditionLightlyPlayed, updated.Condition)
+
+	// Making the foil copy non-foil merges it into the other Sol Ring item.
+	merged, err := repo.Update(1, &collection.Item{ID: added[2].ID, Quantity: 1, Condition: collection.ConditionNearMint})
+	require.NoError(t, err)
+	assert.Equal(t, added[0].ID, merged.ID)
+	assert.Equal(t, 5, merged.Quantity)
+	_, err = repo.GetByID(1, added[2].ID)
+	assert.EqualError(t, err, "collection item not found")
+
+	require.NoError(t, repo.Delete(1, added[1].ID))
+	items, err = repo.List(1)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	_, err = repo.Add(1, []collection.Item{{Name: "No Oracle", Quantity: 1, Condition: collection.ConditionNearMint}})
+	assert.EqualError(t, err, `card "No Oracle" has no oracle id`)
+}
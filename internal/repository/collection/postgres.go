@@ -0,0 +1,175 @@
+package collection
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/josofm/liliana/internal/entity/collection"
+)
+
+type postgresRepo struct{ db *sql.DB }
+
+func NewPostgresRepo(db *sql.DB) Repository { return &postgresRepo{db: db} }
+
+const itemColumns = `i.id,i.user_id,i.oracle_id,c.name,i.quantity,i.foil,i.condition,c.mana_cost,c.type_line,c.color_identity,c.image_uri`
+
+func (r *postgresRepo) Add(userID int64, items []collection.Item) ([]collection.Item, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	result := make([]collection.Item, len(items))
+	for index, item := range items {
+		if item.OracleID == "" {
+			return nil, fmt.Errorf("card %q has no oracle id", item.Name)
+		}
+		if err := saveCard(tx, item); err != nil {
+			return nil, err
+		}
+		var id int64
+		err := tx.QueryRow(`
+			INSERT INTO collection_items (user_id,oracle_id,quantity,foil,condition) VALUES ($1,$2,$3,$4,$5)
+			ON CONFLICT (user_id,oracle_id,foil,condition) DO UPDATE SET quantity=collection_items.quantity+EXCLUDED.quantity
+			RETURNING id`,
+			userID, item.OracleID, item.Quantity, item.Foil, item.Condition).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		stored, err := scanItem(tx.QueryRow(`SELECT `+itemColumns+` FROM collection_items i JOIN cards c ON c.oracle_id=i.oracle_id WHERE i.id=$1`, id))
+		if err != nil {
+			return nil, err
+		}
+		result[index] = *stored
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// saveCard adds the card of item to the shared cards table, filling in the
+// details the table does not have yet.
+func saveCard(tx *sql.Tx, item collection.Item) error {
+	colorIdentity := item.ColorIdentity
+	if colorIdentity == nil {
+		colorIdentity = []string{}
+	}
+	colors, err := json.Marshal(colorIdentity)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO cards (oracle_id,name,mana_cost,type_line,color_identity,image_uri,updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,NOW())
+		ON CONFLICT (oracle_id) DO UPDATE SET
+			mana_cost=CASE WHEN cards.mana_cost='' THEN EXCLUDED.mana_cost ELSE cards.mana_cost END,
+			type_line=CASE WHEN cards.type_line='' THEN EXCLUDED.type_line ELSE cards.type_line END,
+			color_identity=CASE WHEN jsonb_array_length(cards.color_identity)=0 THEN EXCLUDED.color_identity ELSE cards.color_identity END,
+			image_uri=CASE WHEN cards.image_uri='' THEN EXCLUDED.image_uri ELSE cards.image_uri END`,
+		item.OracleID, item.Name, item.ManaCost, item.TypeLine, colors, item.ImageURI)
+	return err
+}
+
+func (r *postgresRepo) List(userID int64) ([]collection.Item, error) {
+	rows, err := r.db.Query(`SELECT `+itemColumns+` FROM collection_items i JOIN cards c ON c.oracle_id=i.oracle_id WHERE i.user_id=$1 ORDER BY lower(c.name),i.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]collection.Item, 0)
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (r *postgresRepo) GetByID(userID, id int64) (*collection.Item, error) {
+	item, err := scanItem(r.db.QueryRow(`SELECT `+itemColumns+` FROM collection_items i JOIN cards c ON c.oracle_id=i.oracle_id WHERE i.id=$1 AND i.user_id=$2`, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("collection item not found")
+	}
+	return item, err
+}
+
+func (r *postgresRepo) Update(userID int64, item *collection.Item) (*collection.Item, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	var oracleID string
+	err = tx.QueryRow(`SELECT oracle_id FROM collection_items WHERE id=$1 AND user_id=$2 FOR UPDATE`, item.ID, userID).Scan(&oracleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("collection item not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id := item.ID
+	var mergedID int64
+	err = tx.QueryRow(`
+		UPDATE collection_items SET quantity=quantity+$5
+		WHERE user_id=$1 AND oracle_id=$2 AND foil=$3 AND condition=$4 AND id<>$6
+		RETURNING id`,
+		userID, oracleID, item.Foil, item.Condition, item.Quantity, item.ID).Scan(&mergedID)
+	switch {
+	case err == nil:
+		if _, err := tx.Exec(`DELETE FROM collection_items WHERE id=$1`, item.ID); err != nil {
+			return nil, err
+		}
+		id = mergedID
+	case errors.Is(err, sql.ErrNoRows):
+		if _, err := tx.Exec(`UPDATE collection_items SET quantity=$1,foil=$2,condition=$3 WHERE id=$4`, item.Quantity, item.Foil, item.Condition, item.ID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	updated, err := scanItem(tx.QueryRow(`SELECT `+itemColumns+` FROM collection_items i JOIN cards c ON c.oracle_id=i.oracle_id WHERE i.id=$1`, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (r *postgresRepo) Delete(userID, id int64) error {
+	result, err := r.db.Exec(`DELETE FROM collection_items WHERE id=$1 AND user_id=$2`, id, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("collection item not found")
+	}
+	return nil
+}
+
+func scanItem(row interface{ Scan(...any) error }) (*collection.Item, error) {
+	item := &collection.Item{}
+	var colors []byte
+	if err := row.Scan(&item.ID, &item.UserID, &item.OracleID, &item.Name, &item.Quantity, &item.Foil, &item.Condition, &item.ManaCost, &item.TypeLine, &colors, &item.ImageURI); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(colors, &item.ColorIdentity); err != nil {
+		return nil, err
+	}
+	if len(item.ColorIdentity) == 0 {
+		item.ColorIdentity = nil
+	}
+	return item, nil
+}
@@ -0,0 +1,17 @@
+package collection
+
+import "github.com/josofm/liliana/internal/entity/collection"
+
+// Repository stores the cards owned by each user. Every method is scoped to
+// userID, so items of other users are reported as not found.
+type Repository interface {
+	// Add stores items, adding to the quantity of the item with the same card,
+	// foil flag and condition. It returns the stored items, in order.
+	Add(userID int64, items []collection.Item) ([]collection.Item, error)
+	List(userID int64) ([]collection.Item, error)
+	GetByID(userID, id int64) (*collection.Item, error)
+	// Update changes the quantity, foil flag and condition of an item. When
+	// another item already has the new combination, both are merged into it.
+	Update(userID int64, item *collection.Item) (*collection.Item, error)
+	Delete(userID, id int64) error
+}
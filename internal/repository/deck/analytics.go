@@ -0,0 +1,113 @@
+package deck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/josofm/liliana/internal/entity/deck"
+)
+
+const (
+	DefaultInclusionLimit = 25
+	MaxInclusionLimit     = 100
+)
+
+// CardUsage tells how many decks play a card and under which commanders.
+// Maybeboard copies are not counted.
+type CardUsage struct {
+	OracleID   string           `json:"oracle_id"`
+	Name       string           `json:"name"`
+	Decks      int              `json:"decks"`
+	Copies     int              `json:"copies"`
+	Commanders []CommanderUsage `json:"commanders"`
+}
+
+// CommanderUsage counts the decks of one commander that play a card.
+type CommanderUsage struct {
+	Commander string `json:"commander"`
+	Decks     int    `json:"decks"`
+}
+
+// InclusionFilter selects the decks counted by CardInclusion. Format and
+// Color match exactly, ignoring case; Commander matches the commander or one
+// of the partners of "A / B" commanders.
+type InclusionFilter struct {
+	Format    string
+	Color     string
+	Commander string
+	// IncludeBasicLands keeps basic lands, which otherwise top every list.
+	IncludeBasicLands bool
+	Limit             int
+	// ViewerID, when set, keeps only the public decks and every deck of
+	// this user, as in ListFilter.
+	ViewerID int64
+}
+
+// InclusionReport lists the cards played by the most decks among Decks.
+type InclusionReport struct {
+	Decks int             `json:"decks"`
+	Cards []CardInclusion `json:"cards"`
+}
+
+// CardInclusion is a card and the share of decks that play it.
+type CardInclusion struct {
+	OracleID      string  `json:"oracle_id"`
+	Name          string  `json:"name"`
+	Decks         int     `json:"decks"`
+	InclusionRate float64 `json:"inclusion_rate"`
+}
+
+// Normalize applies the default limit and validates the filter.
+func (f InclusionFilter) Normalize() (InclusionFilter, error) {
+	if f.Limit == 0 {
+		f.Limit = DefaultInclusionLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxInclusionLimit {
+		return f, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, MaxInclusionLimit)
+	}
+	return f, nil
+}
+
+func (f InclusionFilter) matches(d *deck.Deck) bool {
+	switch {
+	case f.Format != "" && !strings.EqualFold(d.Format, f.Format):
+		return false
+	case f.Color != "" && !strings.EqualFold(d.Color, f.Color):
+		return false
+	case f.Commander != "" && !hasCommander(d.Commander, f.Commander):
+		return false
+	case !visibleTo(d, f.ViewerID):
+		return false
+	}
+	return true
+}
+
+func hasCommander(commander, name string) bool {
+	if strings.EqualFold(commander, name) {
+		return true
+	}
+	for _, partner := range strings.Split(commander, " / ") {
+		if strings.EqualFold(partner, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// countsCard reports whether a card counts toward inclusion: the
+// commanders and the maybeboard are left out, and basic lands unless asked.
+func (f InclusionFilter) countsCard(card deck.Card) bool {
+	board := deck.NormalizeBoard(card.Board)
+	if board == deck.BoardMaybe || board == deck.BoardCommander {
+		return false
+	}
+	return f.IncludeBasicLands || !deck.IsBasicLand(card)
+}
+
+// inclusionRate is decks over total, or zero without decks.
+func inclusionRate(decks, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(decks) / float64(total)
+}
@@ -0,0 +1,119 @@
+package deck
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/josofm/liliana/internal/entity/deck"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// List sort orders. A "-" prefix sorts in descending order; ties are broken by id.
+const (
+	SortID       = "id"
+	SortIDDesc   = "-id"
+	SortName     = "name"
+	SortNameDesc = "-name"
+)
+
+var listSorts = []string{SortID, SortIDDesc, SortName, SortNameDesc}
+
+// ErrInvalidFilter wraps every ListFilter validation error.
+var ErrInvalidFilter = errors.New("invalid deck filter")
+
+// ListFilter selects a page of decks. Empty fields match every deck; Commander
+// and Query match part of the commander and deck name, ignoring case.
+type ListFilter struct {
+	OwnerID   int64
+	Format    string
+	Color     string
+	Commander string
+	Query     string
+	Sort      string
+	Cursor    string
+	Limit     int
+	// OwnerIDs, when not empty, matches the decks of any of these users.
+	OwnerIDs []int64
+	// ViewerID, when set, keeps only the public decks and every deck of
+	// this user.
+	ViewerID int64
+	// WithoutCards leaves Deck.Cards empty, skipping the card query.
+	WithoutCards bool
+}
+
+// ListPage is a page of decks. NextCursor is empty on the last page.
+type ListPage struct {
+	Decks      []*deck.Deck
+	NextCursor string
+}
+
+// listCursor is the position after the last deck of a page, for keyset pagination.
+type listCursor struct {
+	Sort string `json:"s"`
+	Name string `json:"n,omitempty"`
+	ID   int64  `json:"i"`
+}
+
+// Normalize applies the default sort and limit and validates the filter.
+func (f ListFilter) Normalize() (ListFilter, error) {
+	if f.Sort == "" {
+		f.Sort = SortID
+	}
+	valid := false
+	for _, sort := range listSorts {
+		valid = valid || f.Sort == sort
+	}
+	if !valid {
+		return f, fmt.Errorf("%w: sort must be one of %s", ErrInvalidFilter, strings.Join(listSorts, ", "))
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultListLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxListLimit {
+		return f, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, MaxListLimit)
+	}
+	if f.Cursor != "" {
+		if _, err := f.cursor(); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+func (f ListFilter) cursor() (*listCursor, error) {
+	if f.Cursor == "" {
+		return nil, nil
+	}
+	body, err := base64.RawURLEncoding.DecodeString(f.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(body, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	if cursor.Sort != f.Sort {
+		return nil, fmt.Errorf("%w: cursor was created for sort %q", ErrInvalidFilter, cursor.Sort)
+	}
+	return &cursor, nil
+}
+
+func (f ListFilter) nextCursor(last *deck.Deck) string {
+	cursor := listCursor{Sort: f.Sort, ID: last.ID}
+	if f.Sort == SortName || f.Sort == SortNameDesc {
+		cursor.Name = strings.ToLower(last.Name)
+	}
+	body, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func (f ListFilter) descending() bool {
+	return strings.HasPrefix(f.Sort, "-")
+}
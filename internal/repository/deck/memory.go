@@ -1,22 +1,29 @@
 package deck
 
 import (
+	"context"
 	"errors"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/josofm/liliana/internal/entity/deck"
 )
 
 type inMemoryRepo struct {
-	mu     sync.RWMutex
-	decks  map[int64]*deck.Deck
-	nextID int64
+	mu        sync.RWMutex
+	decks     map[int64]*deck.Deck
+	revisions map[int64][]deck.Revision
+	nextID    int64
 }
 
 func NewInMemoryRepo() Repository {
 	return &inMemoryRepo{
-		decks:  make(map[int64]*deck.Deck),
-		nextID: 1,
+		decks:     make(map[int64]*deck.Deck),
+		revisions: make(map[int64][]deck.Revision),
+		nextID:    1,
 	}
 }
 
@@ -24,8 +31,10 @@ func (r *inMemoryRepo) Create(d *deck.Deck) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	d.ID = r.nextID
+	d.Visibility = deck.NormalizeVisibility(d.Visibility)
 	r.decks[d.ID] = d
 	r.nextID++
+	r.recordRevision(d)
 	return nil
 }
 
@@ -39,6 +48,80 @@ func (r *inMemoryRepo) GetAll() ([]*deck.Deck, error) {
 	return result, nil
 }
 
+func (r *inMemoryRepo) List(_ context.Context, filter ListFilter) (*ListPage, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	cursor, _ := filter.cursor()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	matches := make([]*deck.Deck, 0)
+	for _, d := range r.decks {
+		if matchesFilter(d, filter) && afterCursor(d, filter, cursor) {
+			matches = append(matches, d)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return listLess(matches[i], matches[j], filter) })
+
+	page := &ListPage{Decks: make([]*deck.Deck, 0, filter.Limit)}
+	for _, d := range matches {
+		if len(page.Decks) == filter.Limit {
+			page.NextCursor = filter.nextCursor(page.Decks[len(page.Decks)-1])
+			break
+		}
+		if filter.WithoutCards {
+			withoutCards := *d
+			withoutCards.Cards = nil
+			d = &withoutCards
+		}
+		page.Decks = append(page.Decks, d)
+	}
+	return page, nil
+}
+
+func matchesFilter(d *deck.Deck, filter ListFilter) bool {
+	switch {
+	case filter.OwnerID != 0 && d.OwnerID != filter.OwnerID:
+		return false
+	case len(filter.OwnerIDs) > 0 && !slices.Contains(filter.OwnerIDs, d.OwnerID):
+		return false
+	case !visibleTo(d, filter.ViewerID):
+		return false
+	case filter.Format != "" && !strings.EqualFold(d.Format, filter.Format):
+		return false
+	case filter.Color != "" && !strings.EqualFold(d.Color, filter.Color):
+		return false
+	case filter.Commander != "" && !strings.Contains(strings.ToLower(d.Commander), strings.ToLower(filter.Commander)):
+		return false
+	case filter.Query != "" && !strings.Contains(strings.ToLower(d.Name), strings.ToLower(filter.Query)):
+		return false
+	}
+	return true
+}
+
+// listLess orders decks the way postgresRepo.List does: by lowercased name
+// for the name sorts, then by id.
+func listLess(a, b *deck.Deck, filter ListFilter) bool {
+	if filter.descending() {
+		a, b = b, a
+	}
+	if filter.Sort == SortName || filter.Sort == SortNameDesc {
+		if nameA, nameB := strings.ToLower(a.Name), strings.ToLower(b.Name); nameA != nameB {
+			return nameA < nameB
+		}
+	}
+	return a.ID < b.ID
+}
+
+func afterCursor(d *deck.Deck, filter ListFilter, cursor *listCursor) bool {
+	if cursor == nil {
+		return true
+	}
+	return listLess(&deck.Deck{ID: cursor.ID, Name: cursor.Name}, d, filter)
+}
+
 func (r *inMemoryRepo) GetByID(id int64) (*deck.Deck, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -52,17 +135,228 @@ func (r *inMemoryRepo) GetByID(id int64) (*deck.Deck, error) {
 func (r *inMemoryRepo) Update(id int64, d *deck.Deck) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, exists := r.decks[id]; !exists {
+	existing, exists := r.decks[id]
+	if !exists {
 		return errors.New("deck not found")
 	}
 	d.ID = id
+	d.Visibility = deck.NormalizeVisibility(d.Visibility)
+	d.ShareToken = existing.ShareToken
 	r.decks[id] = d
+	r.recordRevision(d)
 	return nil
 }
 
+func (r *inMemoryRepo) Modify(id int64, modify func(d *deck.Deck) error) (*deck.Deck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.decks[id]
+	if !ok {
+		return nil, errors.New("deck not found")
+	}
+	modified := copyDeck(existing)
+	if err := modify(&modified); err != nil {
+		return nil, err
+	}
+	modified.ID = id
+	modified.Visibility = deck.NormalizeVisibility(modified.Visibility)
+	modified.ShareToken = existing.ShareToken
+	r.decks[id] = &modified
+	r.recordRevision(&modified)
+	return &modified, nil
+}
+
 func (r *inMemoryRepo) Delete(id int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	delete(r.decks, id)
+	delete(r.revisions, id)
 	return nil
 }
+
+func (r *inMemoryRepo) SetShareToken(id int64, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.decks[id]
+	if !ok {
+		return errors.New("deck not found")
+	}
+	d.ShareToken = token
+	return nil
+}
+
+func (r *inMemoryRepo) GetByShareToken(token string) (*deck.Deck, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, d := range r.decks {
+		if token != "" && d.ShareToken == token {
+			return d, nil
+		}
+	}
+	return nil, errors.New("deck not found")
+}
+
+// recordRevision copies d, since callers keep changing the decks they store.
+func (r *inMemoryRepo) recordRevision(d *deck.Deck) {
+	r.revisions[d.ID] = append(r.revisions[d.ID], deck.Revision{
+		DeckID:    d.ID,
+		Number:    len(r.revisions[d.ID]) + 1,
+		CreatedAt: time.Now().UTC(),
+		Deck:      copyDeck(d),
+	})
+}
+
+func copyDeck(d *deck.Deck) deck.Deck {
+	snapshot := *d
+	snapshot.Cards = append([]deck.Card(nil), d.Cards...)
+	return snapshot
+}
+
+func (r *inMemoryRepo) Revisions(deckID int64) ([]deck.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	revisions := make([]deck.Revision, len(r.revisions[deckID]))
+	for index, revision := range r.revisions[deckID] {
+		revision.Deck = copyDeck(&revision.Deck)
+		revisions[index] = revision
+	}
+	return revisions, nil
+}
+
+func (r *inMemoryRepo) Revision(deckID int64, number int) (*deck.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	revisions := r.revisions[deckID]
+	if number < 1 || number > len(revisions) {
+		return nil, errors.New("revision not found")
+	}
+	revision := revisions[number-1]
+	revision.Deck = copyDeck(&revision.Deck)
+	return &revision, nil
+}
+
+func (r *inMemoryRepo) Similar(_ context.Context, id int64, filter SimilarFilter) ([]SimilarDeck, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	target, ok := r.decks[id]
+	if !ok {
+		return nil, errors.New("deck not found")
+	}
+	quantities := CardQuantities(target.Cards, filter.IgnoreBasicLands)
+	result := make([]SimilarDeck, 0)
+	for _, d := range r.decks {
+		if d.ID == id || !visibleTo(d, filter.ViewerID) {
+			continue
+		}
+		score, shared := Similarity(quantities, CardQuantities(d.Cards, filter.IgnoreBasicLands))
+		if shared == 0 {
+			continue
+		}
+		withoutCards := *d
+		withoutCards.Cards = nil
+		result = append(result, SimilarDeck{Deck: &withoutCards, Score: score, SharedCards: shared})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Deck.ID < result[j].Deck.ID
+	})
+	if len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result, nil
+}
+
+func (r *inMemoryRepo) CardUsage(_ context.Context, oracleID string, viewerID int64) (*CardUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	usage := &CardUsage{OracleID: oracleID, Commanders: make([]CommanderUsage, 0)}
+	commanders := make(map[string]int)
+	for _, d := range r.decks {
+		if !visibleTo(d, viewerID) {
+			continue
+		}
+		copies := 0
+		for _, card := range d.Cards {
+			if card.OracleID == oracleID && deck.NormalizeBoard(card.Board) != deck.BoardMaybe {
+				usage.Name = card.Name
+				copies += card.Quantity
+			}
+		}
+		if copies == 0 {
+			continue
+		}
+		usage.Decks++
+		usage.Copies += copies
+		if d.Commander != "" {
+			commanders[d.Commander]++
+		}
+	}
+	if usage.Decks == 0 {
+		return nil, errors.New("card not found")
+	}
+	for commander, decks := range commanders {
+		usage.Commanders = append(usage.Commanders, CommanderUsage{Commander: commander, Decks: decks})
+	}
+	sort.Slice(usage.Commanders, func(i, j int) bool {
+		if usage.Commanders[i].Decks != usage.Commanders[j].Decks {
+			return usage.Commanders[i].Decks > usage.Commanders[j].Decks
+		}
+		return usage.Commanders[i].Commander < usage.Commanders[j].Commander
+	})
+	return usage, nil
+}
+
+func (r *inMemoryRepo) CardInclusion(_ context.Context, filter InclusionFilter) (*InclusionReport, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	report := &InclusionReport{Cards: make([]CardInclusion, 0)}
+	cards := make(map[string]*CardInclusion)
+	for _, d := range r.decks {
+		if !filter.matches(d) {
+			continue
+		}
+		report.Decks++
+		seen := make(map[string]bool)
+		for _, card := range d.Cards {
+			key := CardIdentity(card)
+			if !filter.countsCard(card) || seen[key] {
+				continue
+			}
+			seen[key] = true
+			inclusion, ok := cards[key]
+			if !ok {
+				inclusion = &CardInclusion{OracleID: card.OracleID, Name: card.Name}
+				cards[key] = inclusion
+			}
+			inclusion.Decks++
+		}
+	}
+	for _, inclusion := range cards {
+		inclusion.InclusionRate = inclusionRate(inclusion.Decks, report.Decks)
+		report.Cards = append(report.Cards, *inclusion)
+	}
+	sort.Slice(report.Cards, func(i, j int) bool {
+		a, b := report.Cards[i], report.Cards[j]
+		if a.Decks != b.Decks {
+			return a.Decks > b.Decks
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.OracleID < b.OracleID
+	})
+	if len(report.Cards) > filter.Limit {
+		report.Cards = report.Cards[:filter.Limit]
+	}
+	return report, nil
+}
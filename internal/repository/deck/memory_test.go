@@ -1,10 +1,14 @@
 package deck
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	deckEntity "github.com/josofm/liliana/internal/entity/deck"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewInMemoryRepo(t *testing.T) {
@@ -112,3 +116,394 @@ func TestInMemoryRepo_Delete(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, found)
 }
+
+func TestInMemoryRepo_List(t *testing.T) {
+	testRepoList(t, NewInMemoryRepo())
+}
+
+// testRepoList is shared with the postgres tests so both repositories filter,
+// sort and page the same way.
+func testRepoList(t *testing.T, repo Repository) {
+	t.Helper()
+	ctx := context.Background()
+	card := deckEntity.Card{OracleID: "c2a4d9e1-3b5f-4a6d-8e7f-9a0b1c2d3e4f", Name: "Sol Ring", Quantity: 1}
+	for _, d := range []*deckEntity.Deck{
+		{Name: "zombies", Color: "B", Format: "commander", Commander: "Wilhelt, the Rotcleaver", OwnerID: 1, Cards: []deckEntity.Card{card}},
+		{Name: "Artifacts", Color: "U", Format: "commander", Commander: "Urza, Lord High Artificer", OwnerID: 1, Cards: []deckEntity.Card{card}},
+		{Name: "Burn 100%", Color: "R", Format: "modern", OwnerID: 2},
+		{Name: "Mono Blue", Color: "U", Format: "pauper", OwnerID: 2},
+	} {
+		require.NoError(t, repo.Create(d))
+	}
+	names := func(page *ListPage) []string {
+		result := make([]string, len(page.Decks))
+		for index, d := range page.Decks {
+			result[index] = d.Name
+		}
+		return result
+	}
+
+	page, err := repo.List(ctx, ListFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zombies", "Artifacts", "Burn 100%", "Mono Blue"}, names(page))
+	assert.Empty(t, page.NextCursor)
+	assert.Len(t, page.Decks[0].Cards, 1)
+
+	page, err = repo.List(ctx, ListFilter{OwnerID: 1, Sort: SortName})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Artifacts", "zombies"}, names(page))
+
+	page, err = repo.List(ctx, ListFilter{OwnerIDs: []int64{2, 3}, Color: "U"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Mono Blue"}, names(page))
+
+	page, err = repo.List(ctx, ListFilter{Color: "u", Format: "Pauper"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Mono Blue"}, names(page))
+
+	page, err = repo.List(ctx, ListFilter{Commander: "urza"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Artifacts"}, names(page))
+
+	page, err = repo.List(ctx, ListFilter{Query: "100%"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Burn 100%"}, names(page))
+
+	page, err = repo.List(ctx, ListFilter{Query: "%"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Burn 100%"}, names(page), "LIKE wildcards are matched literally")
+
+	page, err = repo.List(ctx, ListFilter{OwnerID: 1, WithoutCards: true})
+	require.NoError(t, err)
+	require.Len(t, page.Decks, 2)
+	assert.Empty(t, page.Decks[0].Cards)
+	found, err := repo.GetByID(page.Decks[0].ID)
+	require.NoError(t, err)
+	assert.Len(t, found.Cards, 1, "omitting cards does not change the stored deck")
+
+	var pages [][]string
+	filter := ListFilter{Sort: SortNameDesc, Limit: 3}
+	for {
+		page, err := repo.List(ctx, filter)
+		require.NoError(t, err)
+		pages = append(pages, names(page))
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	assert.Equal(t, [][]string{{"zombies", "Mono Blue", "Burn 100%"}, {"Artifacts"}}, pages)
+
+	page, err = repo.List(ctx, ListFilter{Sort: SortIDDesc, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Mono Blue", "Burn 100%"}, names(page))
+	idCursor := page.NextCursor
+	page, err = repo.List(ctx, ListFilter{Sort: SortIDDesc, Limit: 2, Cursor: idCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Artifacts", "zombies"}, names(page))
+
+	for _, invalid := range []ListFilter{
+		{Sort: "owner"},
+		{Limit: MaxListLimit + 1},
+		{Cursor: "not a cursor"},
+		{Sort: SortName, Cursor: idCursor},
+	} {
+		_, err := repo.List(ctx, invalid)
+		assert.ErrorIs(t, err, ErrInvalidFilter)
+	}
+}
+
+func TestInMemoryRepo_Revisions(t *testing.T) {
+	testRepoRevisions(t, NewInMemoryRepo())
+}
+
+func testRepoRevisions(t *testing.T, repo Repository) {
+	t.Helper()
+	negate := deckEntity.Card{OracleID: "0c4d6b7e-4cb9-4d7c-a4c4-0b9f0d4a5a11", Name: "Negate", Quantity: 2, Board: deckEntity.BoardMain}
+	d := &deckEntity.Deck{Name: "Control", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{negate}}
+	require.NoError(t, repo.Create(d))
+
+	updated := &deckEntity.Deck{Name: "Control v2", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{negate}}
+	updated.Cards[0].Quantity = 3
+	require.NoError(t, repo.Update(d.ID, updated))
+	// Changing the stored deck afterwards must not rewrite history.
+	updated.Cards[0].Quantity = 4
+
+	revisions, err := repo.Revisions(d.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 1, revisions[0].Number)
+	assert.Equal(t, 2, revisions[1].Number)
+	assert.False(t, revisions[0].CreatedAt.IsZero())
+
+	first, err := repo.Revision(d.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, d.ID, first.DeckID)
+	assert.Equal(t, "Control", first.Deck.Name)
+	require.Len(t, first.Deck.Cards, 1)
+	assert.Equal(t, 2, first.Deck.Cards[0].Quantity)
+
+	second, err := repo.Revision(d.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Control v2", second.Deck.Name)
+	require.Len(t, second.Deck.Cards, 1)
+	assert.Equal(t, 3, second.Deck.Cards[0].Quantity)
+
+	_, err = repo.Revision(d.ID, 3)
+	assert.EqualError(t, err, "revision not found")
+
+	require.NoError(t, repo.Delete(d.ID))
+	revisions, err = repo.Revisions(d.ID)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+}
+
+func TestInMemoryRepo_Modify(t *testing.T) {
+	testRepoModify(t, NewInMemoryRepo())
+}
+
+func testRepoModify(t *testing.T, repo Repository) {
+	t.Helper()
+	negate := deckEntity.Card{OracleID: "0c4d6b7e-4cb9-4d7c-a4c4-0b9f0d4a5a11", Name: "Negate", Quantity: 2, Board: deckEntity.BoardMain}
+	d := &deckEntity.Deck{Name: "Control", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{negate}}
+	require.NoError(t, repo.Create(d))
+
+	modified, err := repo.Modify(d.ID, func(d *deckEntity.Deck) error {
+		d.Cards[0].Quantity = 3
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, modified.Cards[0].Quantity)
+
+	_, err = repo.Modify(d.ID, func(d *deckEntity.Deck) error {
+		d.Cards = nil
+		return errors.New("rejected")
+	})
+	assert.EqualError(t, err, "rejected")
+
+	found, err := repo.GetByID(d.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Cards, 1)
+	assert.Equal(t, 3, found.Cards[0].Quantity)
+	revisions, err := repo.Revisions(d.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 2, "a rejected change records no revision")
+
+	_, err = repo.Modify(999, func(*deckEntity.Deck) error { return nil })
+	assert.EqualError(t, err, "deck not found")
+}
+
+func TestInMemoryRepo_Similar(t *testing.T) {
+	testRepoSimilar(t, NewInMemoryRepo())
+}
+
+// testRepoSimilar is shared with the postgres tests so the SQL and in-memory
+// scores stay the same.
+func testRepoSimilar(t *testing.T, repo Repository) {
+	t.Helper()
+	ctx := context.Background()
+	card := func(oracleID, name string, quantity int, board string) deckEntity.Card {
+		typeLine := "Instant"
+		if name == "Island" {
+			typeLine = "Basic Land — Island"
+		}
+		return deckEntity.Card{OracleID: oracleID, Name: name, Quantity: quantity, Board: board, TypeLine: typeLine}
+	}
+	solRing := card("6ad8011d-3471-4369-9d68-b264cc027487", "Sol Ring", 1, deckEntity.BoardMain)
+	island := card("b2c6aa39-2d2a-459c-a555-fb48ba993373", "Island", 10, deckEntity.BoardMain)
+	counterspell := card("7a4c5a0b-3b1a-4fd9-8a5c-e3e5f1a3a2c1", "Counterspell", 1, deckEntity.BoardSide)
+	negate := card("f1c7a7d6-1a4b-4c3e-9a0e-5d8e8b6f1e2a", "Negate", 2, deckEntity.BoardMaybe)
+	signet := card("4e5b8a4c-2d3f-4a6b-9c8d-7e6f5a4b3c2d", "Arcane Signet", 1, deckEntity.BoardMain)
+	mountain := card("a3fb7228-e76b-4e96-a40e-20b5fed75685", "Mountain", 10, deckEntity.BoardMain)
+	with := func(c deckEntity.Card, quantity int, board string) deckEntity.Card {
+		c.Quantity, c.Board = quantity, board
+		return c
+	}
+
+	target := &deckEntity.Deck{Name: "Target", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{solRing, island, counterspell, negate}}
+	for _, d := range []*deckEntity.Deck{
+		target,
+		{Name: "Close", Color: "U", Format: "modern", OwnerID: 2, Cards: []deckEntity.Card{solRing, with(island, 20, deckEntity.BoardMain), with(counterspell, 1, deckEntity.BoardMain)}},
+		{Name: "Distant", Color: "U", Format: "modern", OwnerID: 2, Cards: []deckEntity.Card{solRing, signet, with(negate, 2, deckEntity.BoardMain)}},
+		{Name: "Islands", Color: "U", Format: "modern", OwnerID: 3, Cards: []deckEntity.Card{with(island, 5, deckEntity.BoardMain)}},
+		{Name: "Mountains", Color: "R", Format: "modern", OwnerID: 3, Cards: []deckEntity.Card{mountain}},
+	} {
+		require.NoError(t, repo.Create(d))
+	}
+	summary := func(decks []SimilarDeck) []string {
+		result := make([]string, len(decks))
+		for index, similar := range decks {
+			result[index] = fmt.Sprintf("%s %.4f %d", similar.Deck.Name, similar.Score, similar.SharedCards)
+		}
+		return result
+	}
+
+	similar, err := repo.Similar(ctx, target.ID, SimilarFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Close 0.5455 3", "Islands 0.4167 1", "Distant 0.0667 1"}, summary(similar))
+	assert.Empty(t, similar[0].Deck.Cards)
+	assert.Equal(t, int64(2), similar[0].Deck.OwnerID)
+
+	similar, err = repo.Similar(ctx, target.ID, SimilarFilter{IgnoreBasicLands: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Close 1.0000 2", "Distant 0.2000 1"}, summary(similar))
+
+	similar, err = repo.Similar(ctx, target.ID, SimilarFilter{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Close 0.5455 3"}, summary(similar))
+
+	_, err = repo.Similar(ctx, 99, SimilarFilter{})
+	assert.EqualError(t, err, "deck not found")
+	_, err = repo.Similar(ctx, target.ID, SimilarFilter{Limit: MaxSimilarLimit + 1})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestInMemoryRepo_Analytics(t *testing.T) {
+	testRepoAnalytics(t, NewInMemoryRepo())
+}
+
+func testRepoAnalytics(t *testing.T, repo Repository) {
+	t.Helper()
+	ctx := context.Background()
+	card := func(oracleID, name, typeLine string, quantity int, board string) deckEntity.Card {
+		return deckEntity.Card{OracleID: oracleID, Name: name, TypeLine: typeLine, Quantity: quantity, Board: board}
+	}
+	solRing := card("6ad8011d-3471-4369-9d68-b264cc027487", "Sol Ring", "Artifact", 1, deckEntity.BoardMain)
+	signet := card("4e5b8a4c-2d3f-4a6b-9c8d-7e6f5a4b3c2d", "Arcane Signet", "Artifact", 1, deckEntity.BoardMain)
+	island := card("b2c6aa39-2d2a-459c-a555-fb48ba993373", "Island", "Basic Land — Island", 30, deckEntity.BoardMain)
+	thrasios := card("0e6ce3d8-0f4d-4c4b-9b1b-6f0f7d3c9b1a", "Thrasios, Triton Hero", "Legendary Creature", 1, deckEntity.BoardCommander)
+	maybeRing := solRing
+	maybeRing.Board = deckEntity.BoardMaybe
+
+	for _, d := range []*deckEntity.Deck{
+		{Name: "Partners", Color: "GU", Format: "commander", Commander: "Thrasios, Triton Hero / Tymna the Weaver", OwnerID: 1, Cards: []deckEntity.Card{thrasios, solRing, signet, island}},
+		{Name: "Thrasios", Color: "GU", Format: "commander", Commander: "Thrasios, Triton Hero", OwnerID: 2, Cards: []deckEntity.Card{thrasios, solRing, island}},
+		{Name: "Other Thrasios", Color: "GU", Format: "commander", Commander: "Thrasios, Triton Hero", OwnerID: 3, Cards: []deckEntity.Card{thrasios, solRing}},
+		{Name: "Maybe", Color: "U", Format: "commander", Commander: "Talrand, Sky Summoner", OwnerID: 1, Cards: []deckEntity.Card{maybeRing, signet}},
+		{Name: "Modern", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{card(solRing.OracleID, solRing.Name, "Artifact", 4, deckEntity.BoardMain)}},
+	} {
+		require.NoError(t, repo.Create(d))
+	}
+
+	usage, err := repo.CardUsage(ctx, solRing.OracleID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, &CardUsage{OracleID: solRing.OracleID, Name: "Sol Ring", Decks: 4, Copies: 7, Commanders: []CommanderUsage{
+		{Commander: "Thrasios, Triton Hero", Decks: 2},
+		{Commander: "Thrasios, Triton Hero / Tymna the Weaver", Decks: 1},
+	}}, usage)
+	_, err = repo.CardUsage(ctx, "00000000-0000-0000-0000-000000000000", 0)
+	assert.EqualError(t, err, "card not found")
+
+	summary := func(report *InclusionReport) []string {
+		result := make([]string, len(report.Cards))
+		for index, inclusion := range report.Cards {
+			result[index] = fmt.Sprintf("%s %d %.2f", inclusion.Name, inclusion.Decks, inclusion.InclusionRate)
+		}
+		return result
+	}
+
+	report, err := repo.CardInclusion(ctx, InclusionFilter{Format: "Commander"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Decks)
+	assert.Equal(t, []string{"Sol Ring 3 0.75", "Arcane Signet 2 0.50"}, summary(report))
+
+	report, err = repo.CardInclusion(ctx, InclusionFilter{Commander: "thrasios, triton hero", IncludeBasicLands: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Decks)
+	assert.Equal(t, []string{"Sol Ring 3 1.00", "Island 2 0.67", "Arcane Signet 1 0.33"}, summary(report))
+
+	report, err = repo.CardInclusion(ctx, InclusionFilter{Color: "u", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Decks)
+	assert.Equal(t, []string{"Arcane Signet 1 0.50"}, summary(report))
+
+	report, err = repo.CardInclusion(ctx, InclusionFilter{Format: "legacy"})
+	require.NoError(t, err)
+	assert.Equal(t, &InclusionReport{Cards: []CardInclusion{}}, report)
+
+	_, err = repo.CardInclusion(ctx, InclusionFilter{Limit: MaxInclusionLimit + 1})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestInMemoryRepo_Visibility(t *testing.T) {
+	testRepoVisibility(t, NewInMemoryRepo())
+}
+
+func testRepoVisibility(t *testing.T, repo Repository) {
+	t.Helper()
+	ctx := context.Background()
+	solRing := deckEntity.Card{OracleID: "6ad8011d-3471-4369-9d68-b264cc027487", Name: "Sol Ring", Quantity: 1}
+	decks := []*deckEntity.Deck{
+		{Name: "Public", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{solRing}},
+		{Name: "Unlisted", Color: "U", Format: "modern", OwnerID: 1, Visibility: deckEntity.VisibilityUnlisted, Cards: []deckEntity.Card{solRing}},
+		{Name: "Private", Color: "U", Format: "modern", OwnerID: 2, Visibility: deckEntity.VisibilityPrivate, Cards: []deckEntity.Card{solRing}},
+	}
+	for _, d := range decks {
+		require.NoError(t, repo.Create(d))
+	}
+	assert.Equal(t, deckEntity.VisibilityPublic, decks[0].Visibility, "decks are public by default")
+	names := func(page *ListPage) []string {
+		result := make([]string, len(page.Decks))
+		for index, d := range page.Decks {
+			result[index] = d.Name
+		}
+		return result
+	}
+
+	page, err := repo.List(ctx, ListFilter{ViewerID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Public", "Private"}, names(page))
+	page, err = repo.List(ctx, ListFilter{ViewerID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Public"}, names(page))
+
+	similar, err := repo.Similar(ctx, decks[0].ID, SimilarFilter{ViewerID: 3})
+	require.NoError(t, err)
+	assert.Empty(t, similar)
+	similar, err = repo.Similar(ctx, decks[0].ID, SimilarFilter{ViewerID: 1})
+	require.NoError(t, err)
+	require.Len(t, similar, 1)
+	assert.Equal(t, "Unlisted", similar[0].Deck.Name)
+
+	// Analytics only count the decks the viewer could list
+	usage, err := repo.CardUsage(ctx, solRing.OracleID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, usage.Decks)
+	usage, err = repo.CardUsage(ctx, solRing.OracleID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, usage.Decks, "owners count their own private decks")
+	inclusion, err := repo.CardInclusion(ctx, InclusionFilter{ViewerID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 1, inclusion.Decks)
+	assert.Equal(t, 1, inclusion.Cards[0].Decks)
+	inclusion, err = repo.CardInclusion(ctx, InclusionFilter{ViewerID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, inclusion.Decks)
+
+	require.NoError(t, repo.SetShareToken(decks[1].ID, "token-1"))
+	shared, err := repo.GetByShareToken("token-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Unlisted", shared.Name)
+	assert.Equal(t, "token-1", shared.ShareToken)
+	assert.Len(t, shared.Cards, 1)
+
+	update := &deckEntity.Deck{Name: "Renamed", Color: "U", Format: "modern", OwnerID: 1, Visibility: deckEntity.VisibilityPrivate, Cards: []deckEntity.Card{solRing}}
+	require.NoError(t, repo.Update(decks[1].ID, update))
+	assert.Equal(t, "token-1", update.ShareToken, "updates keep the share token")
+	modified, err := repo.Modify(decks[1].ID, func(d *deckEntity.Deck) error {
+		d.Name = "Modified"
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, deckEntity.VisibilityPrivate, modified.Visibility)
+	found, err := repo.GetByID(decks[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", found.ShareToken)
+
+	require.NoError(t, repo.SetShareToken(decks[1].ID, ""))
+	_, err = repo.GetByShareToken("token-1")
+	assert.EqualError(t, err, "deck not found")
+	_, err = repo.GetByShareToken("")
+	assert.EqualError(t, err, "deck not found")
+	assert.EqualError(t, repo.SetShareToken(99, "token-2"), "deck not found")
+}
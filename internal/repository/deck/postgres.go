@@ -1,10 +1,12 @@
 package deck
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	deckEntity "github.com/josofm/liliana/internal/entity/deck"
 )
@@ -19,48 +21,145 @@ func (r *postgresRepo) Create(d *deckEntity.Deck) error {
 		return err
 	}
 	defer tx.Rollback()
-	const query = `INSERT INTO decks (name, color, format, commander, commander_image_uri, owner_id, source_link) VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING id`
-	if err := tx.QueryRow(query, d.Name, d.Color, d.Format, d.Commander, d.CommanderImageURI, d.OwnerID, d.SourceLink).Scan(&d.ID); err != nil {
+	d.Visibility = deckEntity.NormalizeVisibility(d.Visibility)
+	const query = `INSERT INTO decks (name, color, format, commander, commander_image_uri, owner_id, source_link, visibility) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING id`
+	if err := tx.QueryRow(query, d.Name, d.Color, d.Format, d.Commander, d.CommanderImageURI, d.OwnerID, d.SourceLink, d.Visibility).Scan(&d.ID); err != nil {
 		return err
 	}
 	if err := saveCards(tx, d); err != nil {
 		return err
 	}
+	if err := saveRevision(tx, d); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
 func (r *postgresRepo) GetAll() ([]*deckEntity.Deck, error) {
-	rows, err := r.db.Query(`SELECT id, name, color, format, commander, commander_image_uri, owner_id, source_link FROM decks ORDER BY id`)
+	ctx := context.Background()
+	decks, err := queryDecks(ctx, r.db, `SELECT `+deckColumns+` FROM decks ORDER BY id`)
 	if err != nil {
 		return nil, err
 	}
-	decks := make([]*deckEntity.Deck, 0)
-	for rows.Next() {
-		d := &deckEntity.Deck{}
-		if err := rows.Scan(&d.ID, &d.Name, &d.Color, &d.Format, &d.Commander, &d.CommanderImageURI, &d.OwnerID, &d.SourceLink); err != nil {
-			rows.Close()
-			return nil, err
+	if err := loadDeckCards(ctx, r.db, decks); err != nil {
+		return nil, err
+	}
+	return decks, nil
+}
+
+const deckColumns = `id, name, color, format, commander, commander_image_uri, owner_id, source_link, visibility, COALESCE(share_token, '')`
+
+// deckFields returns the scan destinations of deckColumns.
+func deckFields(d *deckEntity.Deck) []any {
+	return []any{&d.ID, &d.Name, &d.Color, &d.Format, &d.Commander, &d.CommanderImageURI, &d.OwnerID, &d.SourceLink, &d.Visibility, &d.ShareToken}
+}
+
+// List pages with a keyset on (lower(name), id) or id, so deep pages cost the
+// same as the first one. Names are compared with the "C" collation to match
+// the byte order used by the cursor and by inMemoryRepo.
+func (r *postgresRepo) List(ctx context.Context, filter ListFilter) (*ListPage, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	cursor, _ := filter.cursor()
+
+	var conditions []string
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filter.OwnerID != 0 {
+		conditions = append(conditions, "owner_id="+arg(filter.OwnerID))
+	}
+	if len(filter.OwnerIDs) > 0 {
+		conditions = append(conditions, "owner_id=ANY("+arg(filter.OwnerIDs)+")")
+	}
+	if filter.ViewerID != 0 {
+		conditions = append(conditions, fmt.Sprintf("(visibility=%s OR owner_id=%s)", arg(deckEntity.VisibilityPublic), arg(filter.ViewerID)))
+	}
+	if filter.Format != "" {
+		conditions = append(conditions, "lower(format)=lower("+arg(filter.Format)+")")
+	}
+	if filter.Color != "" {
+		conditions = append(conditions, "lower(color)=lower("+arg(filter.Color)+")")
+	}
+	if filter.Commander != "" {
+		conditions = append(conditions, "commander ILIKE "+arg(containsPattern(filter.Commander)))
+	}
+	if filter.Query != "" {
+		conditions = append(conditions, "name ILIKE "+arg(containsPattern(filter.Query)))
+	}
+
+	operator, direction := ">", "ASC"
+	if filter.descending() {
+		operator, direction = "<", "DESC"
+	}
+	order := "id " + direction
+	byName := filter.Sort == SortName || filter.Sort == SortNameDesc
+	if byName {
+		order = `lower(name) COLLATE "C" ` + direction + ", " + order
+	}
+	if cursor != nil {
+		if byName {
+			conditions = append(conditions, fmt.Sprintf(`(lower(name) COLLATE "C", id) %s (%s, %s)`, operator, arg(cursor.Name), arg(cursor.ID)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("id %s %s", operator, arg(cursor.ID)))
 		}
-		decks = append(decks, d)
 	}
-	if err := rows.Err(); err != nil {
-		rows.Close()
+
+	query := `SELECT ` + deckColumns + ` FROM decks`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// One extra row tells whether there is a next page.
+	query += " ORDER BY " + order + " LIMIT " + arg(filter.Limit+1)
+
+	decks, err := queryDecks(ctx, r.db, query, args...)
+	if err != nil {
 		return nil, err
 	}
-	if err := rows.Close(); err != nil {
+	page := &ListPage{Decks: decks}
+	if len(decks) > filter.Limit {
+		page.Decks = decks[:filter.Limit]
+		page.NextCursor = filter.nextCursor(page.Decks[filter.Limit-1])
+	}
+	if !filter.WithoutCards {
+		if err := loadDeckCards(ctx, r.db, page.Decks); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func queryDecks(ctx context.Context, db *sql.DB, query string, args ...any) ([]*deckEntity.Deck, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
 		return nil, err
 	}
-	for _, d := range decks {
-		if err := loadCards(r.db, d); err != nil {
+	defer rows.Close()
+	decks := make([]*deckEntity.Deck, 0)
+	for rows.Next() {
+		d := &deckEntity.Deck{}
+		if err := rows.Scan(deckFields(d)...); err != nil {
 			return nil, err
 		}
+		decks = append(decks, d)
 	}
-	return decks, nil
+	return decks, rows.Err()
+}
+
+// containsPattern builds an ILIKE pattern matching value anywhere, with the
+// LIKE wildcards in value escaped.
+func containsPattern(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+	return "%" + escaped + "%"
 }
 
 func (r *postgresRepo) GetByID(id int64) (*deckEntity.Deck, error) {
 	d := &deckEntity.Deck{}
-	err := r.db.QueryRow(`SELECT id, name, color, format, commander, commander_image_uri, owner_id, source_link FROM decks WHERE id=$1`, id).Scan(&d.ID, &d.Name, &d.Color, &d.Format, &d.Commander, &d.CommanderImageURI, &d.OwnerID, &d.SourceLink)
+	err := r.db.QueryRow(`SELECT `+deckColumns+` FROM decks WHERE id=$1`, id).Scan(deckFields(d)...)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, errors.New("deck not found")
 	}
@@ -79,29 +178,103 @@ func (r *postgresRepo) Update(id int64, d *deckEntity.Deck) error {
 		return err
 	}
 	defer tx.Rollback()
-	result, err := tx.Exec(`UPDATE decks SET name=$1,color=$2,format=$3,commander=$4,commander_image_uri=$5,owner_id=$6,source_link=$7 WHERE id=$8`, d.Name, d.Color, d.Format, d.Commander, d.CommanderImageURI, d.OwnerID, d.SourceLink, id)
-	if err != nil {
-		return err
+	d.Visibility = deckEntity.NormalizeVisibility(d.Visibility)
+	err = tx.QueryRow(`UPDATE decks SET name=$1,color=$2,format=$3,commander=$4,commander_image_uri=$5,owner_id=$6,source_link=$7,visibility=$8 WHERE id=$9 RETURNING COALESCE(share_token, '')`,
+		d.Name, d.Color, d.Format, d.Commander, d.CommanderImageURI, d.OwnerID, d.SourceLink, d.Visibility, id).Scan(&d.ShareToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("deck not found")
 	}
-	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return errors.New("deck not found")
-	}
 	d.ID = id
 	if err := saveCards(tx, d); err != nil {
 		return err
 	}
+	if err := saveRevision(tx, d); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
+// Modify locks the deck row until the transaction ends, so concurrent
+// changes to the same deck are applied one after the other.
+func (r *postgresRepo) Modify(id int64, modify func(d *deckEntity.Deck) error) (*deckEntity.Deck, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	d := &deckEntity.Deck{}
+	err = tx.QueryRow(`SELECT `+deckColumns+` FROM decks WHERE id=$1 FOR UPDATE`, id).Scan(deckFields(d)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("deck not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadCards(tx, d); err != nil {
+		return nil, err
+	}
+	if err := modify(d); err != nil {
+		return nil, err
+	}
+	d.ID = id
+	d.Visibility = deckEntity.NormalizeVisibility(d.Visibility)
+	if err := tx.QueryRow(`UPDATE decks SET name=$1,color=$2,format=$3,commander=$4,commander_image_uri=$5,owner_id=$6,source_link=$7,visibility=$8 WHERE id=$9 RETURNING COALESCE(share_token, '')`,
+		d.Name, d.Color, d.Format, d.Commander, d.CommanderImageURI, d.OwnerID, d.SourceLink, d.Visibility, id).Scan(&d.ShareToken); err != nil {
+		return nil, err
+	}
+	if err := saveCards(tx, d); err != nil {
+		return nil, err
+	}
+	if err := saveRevision(tx, d); err != nil {
+		return nil, err
+	}
+	if err := loadCards(tx, d); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
 func (r *postgresRepo) Delete(id int64) error {
 	_, err := r.db.Exec(`DELETE FROM decks WHERE id=$1`, id)
 	return err
 }
 
+func (r *postgresRepo) SetShareToken(id int64, token string) error {
+	result, err := r.db.Exec(`UPDATE decks SET share_token=NULLIF($1, '') WHERE id=$2`, token, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("deck not found")
+	}
+	return nil
+}
+
+func (r *postgresRepo) GetByShareToken(token string) (*deckEntity.Deck, error) {
+	d := &deckEntity.Deck{}
+	err := r.db.QueryRow(`SELECT `+deckColumns+` FROM decks WHERE share_token=$1`, token).Scan(deckFields(d)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("deck not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadCards(r.db, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
 func saveCards(tx *sql.Tx, d *deckEntity.Deck) error {
 	if _, err := tx.Exec(`DELETE FROM deck_cards WHERE deck_id=$1`, d.ID); err != nil {
 		return err
@@ -118,48 +291,331 @@ func saveCards(tx *sql.Tx, d *deckEntity.Deck) error {
 		if err != nil {
 			return err
 		}
+		legalityMap := card.Legalities
+		if legalityMap == nil {
+			legalityMap = map[string]string{}
+		}
+		legalities, err := json.Marshal(legalityMap)
+		if err != nil {
+			return err
+		}
 		_, err = tx.Exec(`
-			INSERT INTO cards (oracle_id,name,mana_cost,type_line,color_identity,image_uri,updated_at)
-			VALUES ($1,$2,$3,$4,$5,$6,NOW())
+			INSERT INTO cards (oracle_id,name,mana_cost,type_line,color_identity,image_uri,legalities,updated_at)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,NOW())
 			ON CONFLICT (oracle_id) DO UPDATE SET
 				name=EXCLUDED.name,
 				mana_cost=CASE WHEN EXCLUDED.mana_cost<>'' THEN EXCLUDED.mana_cost ELSE cards.mana_cost END,
 				type_line=CASE WHEN EXCLUDED.type_line<>'' THEN EXCLUDED.type_line ELSE cards.type_line END,
 				color_identity=CASE WHEN jsonb_array_length(EXCLUDED.color_identity)>0 THEN EXCLUDED.color_identity ELSE cards.color_identity END,
 				image_uri=CASE WHEN EXCLUDED.image_uri<>'' THEN EXCLUDED.image_uri ELSE cards.image_uri END,
+				legalities=CASE WHEN EXCLUDED.legalities<>'{}'::jsonb THEN EXCLUDED.legalities ELSE cards.legalities END,
 				updated_at=NOW()`,
-			card.OracleID, card.Name, card.ManaCost, card.TypeLine, colors, card.ImageURI)
+			card.OracleID, card.Name, card.ManaCost, card.TypeLine, colors, card.ImageURI, legalities)
 		if err != nil {
 			return err
 		}
-		if _, err := tx.Exec(`INSERT INTO deck_cards (deck_id,oracle_id,quantity) VALUES ($1,$2,$3) ON CONFLICT (deck_id,oracle_id) DO UPDATE SET quantity=deck_cards.quantity+EXCLUDED.quantity`, d.ID, card.OracleID, card.Quantity); err != nil {
+		if _, err := tx.Exec(`INSERT INTO deck_cards (deck_id,oracle_id,board,quantity) VALUES ($1,$2,$3,$4) ON CONFLICT (deck_id,oracle_id,board) DO UPDATE SET quantity=deck_cards.quantity+EXCLUDED.quantity`, d.ID, card.OracleID, deckEntity.NormalizeBoard(card.Board), card.Quantity); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// saveRevision records d as written by tx. The cards are read back so merged
+// duplicates and catalog data match what GetByID returns.
+func saveRevision(tx *sql.Tx, d *deckEntity.Deck) error {
+	snapshot := *d
+	if err := loadCards(tx, &snapshot); err != nil {
+		return err
+	}
+	cards, err := json.Marshal(snapshot.Cards)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO deck_revisions (deck_id,number,name,color,format,commander,commander_image_uri,owner_id,source_link,cards)
+		SELECT $1,COALESCE(MAX(number),0)+1,$2,$3,$4,$5,$6,$7,$8,$9 FROM deck_revisions WHERE deck_id=$1`,
+		d.ID, d.Name, d.Color, d.Format, d.Commander, d.CommanderImageURI, d.OwnerID, d.SourceLink, cards)
+	return err
+}
+
+const revisionColumns = `deck_id,number,created_at,name,color,format,commander,commander_image_uri,owner_id,source_link,cards`
+
+func (r *postgresRepo) Revisions(deckID int64) ([]deckEntity.Revision, error) {
+	rows, err := r.db.Query(`SELECT `+revisionColumns+` FROM deck_revisions WHERE deck_id=$1 ORDER BY number`, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	revisions := make([]deckEntity.Revision, 0)
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+	return revisions, rows.Err()
+}
+
+func (r *postgresRepo) Revision(deckID int64, number int) (*deckEntity.Revision, error) {
+	revision, err := scanRevision(r.db.QueryRow(`SELECT `+revisionColumns+` FROM deck_revisions WHERE deck_id=$1 AND number=$2`, deckID, number))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("revision not found")
+	}
+	return revision, err
+}
+
+func scanRevision(row interface{ Scan(...any) error }) (*deckEntity.Revision, error) {
+	revision := &deckEntity.Revision{}
+	d := &revision.Deck
+	var cards []byte
+	if err := row.Scan(&revision.DeckID, &revision.Number, &revision.CreatedAt, &d.Name, &d.Color, &d.Format, &d.Commander, &d.CommanderImageURI, &d.OwnerID, &d.SourceLink, &cards); err != nil {
+		return nil, err
+	}
+	d.ID = revision.DeckID
+	if err := json.Unmarshal(cards, &d.Cards); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
 type cardQueryer interface {
 	Query(string, ...any) (*sql.Rows, error)
 }
 
 func loadCards(queryer cardQueryer, d *deckEntity.Deck) error {
-	rows, err := queryer.Query(`SELECT c.oracle_id,c.name,dc.quantity,c.mana_cost,c.type_line,c.color_identity,c.image_uri FROM deck_cards dc JOIN cards c ON c.oracle_id=dc.oracle_id WHERE dc.deck_id=$1 ORDER BY c.name`, d.ID)
+	rows, err := queryer.Query(`SELECT dc.deck_id,`+cardColumns+` FROM deck_cards dc JOIN cards c ON c.oracle_id=dc.oracle_id WHERE dc.deck_id=$1 ORDER BY c.name,dc.board`, d.ID)
 	if err != nil {
 		return err
 	}
+	return scanCards(rows, map[int64]*deckEntity.Deck{d.ID: d})
+}
+
+// loadDeckCards loads the cards of every deck in a single query.
+func loadDeckCards(ctx context.Context, db *sql.DB, decks []*deckEntity.Deck) error {
+	if len(decks) == 0 {
+		return nil
+	}
+	byID := make(map[int64]*deckEntity.Deck, len(decks))
+	ids := make([]int64, len(decks))
+	for index, d := range decks {
+		byID[d.ID] = d
+		ids[index] = d.ID
+	}
+	rows, err := db.QueryContext(ctx, `SELECT dc.deck_id,`+cardColumns+` FROM deck_cards dc JOIN cards c ON c.oracle_id=dc.oracle_id WHERE dc.deck_id=ANY($1) ORDER BY dc.deck_id,c.name,dc.board`, ids)
+	if err != nil {
+		return err
+	}
+	return scanCards(rows, byID)
+}
+
+const cardColumns = `c.oracle_id,c.name,dc.quantity,dc.board,c.mana_cost,c.type_line,c.color_identity,c.image_uri,c.legalities`
+
+func scanCards(rows *sql.Rows, decks map[int64]*deckEntity.Deck) error {
 	defer rows.Close()
-	d.Cards = make([]deckEntity.Card, 0)
+	for _, d := range decks {
+		d.Cards = make([]deckEntity.Card, 0)
+	}
 	for rows.Next() {
+		var deckID int64
 		var card deckEntity.Card
-		var colors []byte
-		if err := rows.Scan(&card.OracleID, &card.Name, &card.Quantity, &card.ManaCost, &card.TypeLine, &colors, &card.ImageURI); err != nil {
+		var colors, legalities []byte
+		if err := rows.Scan(&deckID, &card.OracleID, &card.Name, &card.Quantity, &card.Board, &card.ManaCost, &card.TypeLine, &colors, &card.ImageURI, &legalities); err != nil {
 			return err
 		}
 		if err := json.Unmarshal(colors, &card.ColorIdentity); err != nil {
 			return err
 		}
+		if err := json.Unmarshal(legalities, &card.Legalities); err != nil {
+			return err
+		}
+		if len(card.Legalities) == 0 {
+			card.Legalities = nil
+		}
+		d := decks[deckID]
 		d.Cards = append(d.Cards, card)
 	}
 	return rows.Err()
 }
+
+// similarQuery computes Similarity in SQL: the sum of the smaller quantities
+// of shared cards over the sum of both decks minus that overlap, which is the
+// sum of the larger quantities. The first %s takes the basic land condition
+// and the second the visibility condition.
+const similarQuery = `
+	WITH quantities AS (
+		SELECT dc.deck_id, dc.oracle_id, SUM(dc.quantity) AS quantity
+		FROM deck_cards dc JOIN cards c ON c.oracle_id=dc.oracle_id
+		WHERE dc.board<>$2 %s
+		GROUP BY dc.deck_id, dc.oracle_id
+	), totals AS (
+		SELECT deck_id, SUM(quantity) AS total FROM quantities GROUP BY deck_id
+	), shared AS (
+		SELECT q.deck_id, SUM(LEAST(q.quantity, t.quantity)) AS overlap, COUNT(*) AS cards
+		FROM quantities q JOIN quantities t ON t.oracle_id=q.oracle_id AND t.deck_id=$1
+		WHERE q.deck_id<>$1
+		GROUP BY q.deck_id
+	)
+	SELECT ` + deckColumns + `, s.overlap::float8/(a.total+b.total-s.overlap) AS score, s.cards
+	FROM shared s
+	JOIN totals a ON a.deck_id=s.deck_id
+	JOIN totals b ON b.deck_id=$1
+	JOIN decks ON decks.id=s.deck_id %s
+	ORDER BY score DESC, id
+	LIMIT $3`
+
+// basicLandCondition matches deckEntity.IsBasicLand on cards c, with the
+// names of deckEntity.BasicLandNames bound to placeholder.
+func basicLandCondition(placeholder string) string {
+	return `((c.type_line LIKE '%Basic%' AND c.type_line LIKE '%Land%') OR lower(c.name)=ANY(` + placeholder + `))`
+}
+
+func (r *postgresRepo) Similar(ctx context.Context, id int64, filter SimilarFilter) ([]SimilarDeck, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM decks WHERE id=$1)`, id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("deck not found")
+	}
+
+	args := []any{id, deckEntity.BoardMaybe, filter.Limit}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	basicLands, visibility := "", ""
+	if filter.IgnoreBasicLands {
+		basicLands = "AND NOT " + basicLandCondition(arg(deckEntity.BasicLandNames))
+	}
+	if filter.ViewerID != 0 {
+		visibility = fmt.Sprintf("AND (decks.visibility=%s OR decks.owner_id=%s)", arg(deckEntity.VisibilityPublic), arg(filter.ViewerID))
+	}
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(similarQuery, basicLands, visibility), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]SimilarDeck, 0)
+	for rows.Next() {
+		d := &deckEntity.Deck{}
+		similar := SimilarDeck{Deck: d}
+		if err := rows.Scan(append(deckFields(d), &similar.Score, &similar.SharedCards)...); err != nil {
+			return nil, err
+		}
+		result = append(result, similar)
+	}
+	return result, rows.Err()
+}
+
+func (r *postgresRepo) CardUsage(ctx context.Context, oracleID string, viewerID int64) (*CardUsage, error) {
+	usage := &CardUsage{OracleID: oracleID, Commanders: make([]CommanderUsage, 0)}
+	args := []any{oracleID, deckEntity.BoardMaybe}
+	visibility := ""
+	if viewerID != 0 {
+		args = append(args, deckEntity.VisibilityPublic, viewerID)
+		visibility = "AND (d.visibility=$3 OR d.owner_id=$4)"
+	}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT c.name, COUNT(DISTINCT dc.deck_id), SUM(dc.quantity)
+		FROM deck_cards dc JOIN cards c ON c.oracle_id=dc.oracle_id JOIN decks d ON d.id=dc.deck_id
+		WHERE dc.oracle_id=$1 AND dc.board<>$2 `+visibility+`
+		GROUP BY c.name`, args...).Scan(&usage.Name, &usage.Decks, &usage.Copies)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("card not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT d.commander, COUNT(*) AS decks
+		FROM decks d
+		WHERE COALESCE(d.commander,'')<>'' `+visibility+` AND EXISTS (
+			SELECT 1 FROM deck_cards dc WHERE dc.deck_id=d.id AND dc.oracle_id=$1 AND dc.board<>$2)
+		GROUP BY d.commander
+		ORDER BY decks DESC, d.commander COLLATE "C"`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var commander CommanderUsage
+		if err := rows.Scan(&commander.Commander, &commander.Decks); err != nil {
+			return nil, err
+		}
+		usage.Commanders = append(usage.Commanders, commander)
+	}
+	return usage, rows.Err()
+}
+
+func (r *postgresRepo) CardInclusion(ctx context.Context, filter InclusionFilter) (*InclusionReport, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions []string
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filter.Format != "" {
+		conditions = append(conditions, "lower(d.format)=lower("+arg(filter.Format)+")")
+	}
+	if filter.Color != "" {
+		conditions = append(conditions, "lower(d.color)=lower("+arg(filter.Color)+")")
+	}
+	if filter.Commander != "" {
+		commander := arg(filter.Commander)
+		conditions = append(conditions, fmt.Sprintf("(lower(d.commander)=lower(%[1]s) OR lower(%[1]s)=ANY(string_to_array(lower(d.commander),' / ')))", commander))
+	}
+	if filter.ViewerID != 0 {
+		conditions = append(conditions, fmt.Sprintf("(d.visibility=%s OR d.owner_id=%s)", arg(deckEntity.VisibilityPublic), arg(filter.ViewerID)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	report := &InclusionReport{Cards: make([]CardInclusion, 0)}
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM decks d`+where, args...).Scan(&report.Decks); err != nil {
+		return nil, err
+	}
+	if report.Decks == 0 {
+		return report, nil
+	}
+
+	conditions = append(conditions, "dc.board<>"+arg(deckEntity.BoardMaybe), "dc.board<>"+arg(deckEntity.BoardCommander))
+	if !filter.IncludeBasicLands {
+		conditions = append(conditions, "NOT "+basicLandCondition(arg(deckEntity.BasicLandNames)))
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.oracle_id, c.name, COUNT(DISTINCT dc.deck_id) AS decks
+		FROM deck_cards dc
+		JOIN cards c ON c.oracle_id=dc.oracle_id
+		JOIN decks d ON d.id=dc.deck_id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		GROUP BY c.oracle_id, c.name
+		ORDER BY decks DESC, c.name COLLATE "C", c.oracle_id
+		LIMIT `+arg(filter.Limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var inclusion CardInclusion
+		if err := rows.Scan(&inclusion.OracleID, &inclusion.Name, &inclusion.Decks); err != nil {
+			return nil, err
+		}
+		inclusion.InclusionRate = inclusionRate(inclusion.Decks, report.Decks)
+		report.Cards = append(report.Cards, inclusion)
+	}
+	return report, rows.Err()
+}
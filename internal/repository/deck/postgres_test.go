@@ -43,7 +43,7 @@ func setupPostgresRepo(t *testing.T) Repository {
 func truncatePostgresDecks(t *testing.T, db *sql.DB) {
 	t.Helper()
 
-	_, err := db.Exec(`TRUNCATE TABLE deck_cards, decks, cards RESTART IDENTITY`)
+	_, err := db.Exec(`TRUNCATE TABLE collection_items, deck_revisions, deck_cards, decks, cards RESTART IDENTITY`)
 	require.NoError(t, err)
 }
 
@@ -175,3 +175,62 @@ func TestPostgresRepo_SharedCardRelationshipAndCascade(t *testing.T) {
 	assert.Equal(t, 1, relationshipCount)
 	assert.Equal(t, 1, cardCount)
 }
+
+func TestPostgresRepo_KeepsCardBoards(t *testing.T) {
+	repo := setupPostgresRepo(t)
+	card := deckEntity.Card{OracleID: "0c4d6b7e-4cb9-4d7c-a4c4-0b9f0d4a5a11", Name: "Negate", Quantity: 2, Board: deckEntity.BoardMain}
+	sideboard := card
+	sideboard.Quantity = 1
+	sideboard.Board = deckEntity.BoardSide
+	deck := &deckEntity.Deck{Name: "Boards", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{card, sideboard}}
+	require.NoError(t, repo.Create(deck))
+
+	found, err := repo.GetByID(deck.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Cards, 2)
+	assert.Equal(t, deckEntity.BoardMain, found.Cards[0].Board)
+	assert.Equal(t, 2, found.Cards[0].Quantity)
+	assert.Equal(t, deckEntity.BoardSide, found.Cards[1].Board)
+	assert.Equal(t, 1, found.Cards[1].Quantity)
+}
+
+func TestPostgresRepo_KeepsCardLegalities(t *testing.T) {
+	repo := setupPostgresRepo(t)
+	card := deckEntity.Card{OracleID: "5d1a4a8e-5b0e-4c0f-9a1b-6f1f2e8a7c31", Name: "Sol Ring", Quantity: 1, Legalities: map[string]string{"commander": "legal", "vintage": "restricted"}}
+	deck := &deckEntity.Deck{Name: "Legalities", Color: "C", Format: "vintage", OwnerID: 1, Cards: []deckEntity.Card{card}}
+	require.NoError(t, repo.Create(deck))
+
+	// Saving the card again without legalities keeps the known ones.
+	card.Legalities = nil
+	deck.Cards = []deckEntity.Card{card}
+	require.NoError(t, repo.Update(deck.ID, deck))
+
+	found, err := repo.GetByID(deck.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Cards, 1)
+	assert.Equal(t, map[string]string{"commander": "legal", "vintage": "restricted"}, found.Cards[0].Legalities)
+}
+
+func TestPostgresRepo_List(t *testing.T) {
+	testRepoList(t, setupPostgresRepo(t))
+}
+
+func TestPostgresRepo_Revisions(t *testing.T) {
+	testRepoRevisions(t, setupPostgresRepo(t))
+}
+
+func TestPostgresRepo_Modify(t *testing.T) {
+	testRepoModify(t, setupPostgresRepo(t))
+}
+
+func TestPostgresRepo_Similar(t *testing.T) {
+	testRepoSimilar(t, setupPostgresRepo(t))
+}
+
+func TestPostgresRepo_Analytics(t *testing.T) {
+	testRepoAnalytics(t, setupPostgresRepo(t))
+}
+
+func TestPostgresRepo_Visibility(t *testing.T) {
+	testRepoVisibility(t, setupPostgresRepo(t))
+}
@@ -1,11 +1,38 @@
 package deck
 
-import "github.com/josofm/liliana/internal/entity/deck"
+import (
+	"context"
+
+	"github.com/josofm/liliana/internal/entity/deck"
+)
 
 type Repository interface {
 	Create(d *deck.Deck) error
 	GetAll() ([]*deck.Deck, error)
+	List(ctx context.Context, filter ListFilter) (*ListPage, error)
 	GetByID(id int64) (*deck.Deck, error)
 	Update(id int64, d *deck.Deck) error
 	Delete(id int64) error
+	// SetShareToken saves the share token of a deck; an empty token stops
+	// sharing it. Update and Modify keep the token.
+	SetShareToken(id int64, token string) error
+	// GetByShareToken fails with "deck not found" for an unknown token.
+	GetByShareToken(token string) (*deck.Deck, error)
+	// Modify loads a deck, lets modify change it and saves the result in one
+	// atomic step, recording a revision. An error from modify discards the change.
+	Modify(id int64, modify func(d *deck.Deck) error) (*deck.Deck, error)
+	// Revisions lists the revisions of a deck, oldest first. Create and Update
+	// record a new revision in the same operation.
+	Revisions(deckID int64) ([]deck.Revision, error)
+	Revision(deckID int64, number int) (*deck.Revision, error)
+	// Similar returns the decks sharing the most cards with a deck, by
+	// descending Score and then id. Decks without shared cards are left out.
+	Similar(ctx context.Context, id int64, filter SimilarFilter) ([]SimilarDeck, error)
+	// CardUsage counts the decks playing a card, or fails with "card not
+	// found" when no deck plays it. A viewerID other than zero keeps only the
+	// public decks and the decks of that user, as ListFilter.ViewerID does.
+	CardUsage(ctx context.Context, oracleID string, viewerID int64) (*CardUsage, error)
+	// CardInclusion returns the cards played by the most decks matching
+	// filter, then by name.
+	CardInclusion(ctx context.Context, filter InclusionFilter) (*InclusionReport, error)
 }
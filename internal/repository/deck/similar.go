@@ -0,0 +1,94 @@
+package deck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/josofm/liliana/internal/entity/deck"
+)
+
+const (
+	DefaultSimilarLimit = 10
+	MaxSimilarLimit     = 50
+)
+
+// SimilarFilter selects the decks compared by Similar.
+type SimilarFilter struct {
+	// IgnoreBasicLands leaves basic lands out of the comparison, so decks of
+	// the same colors do not look alike only because of their mana base.
+	IgnoreBasicLands bool
+	Limit            int
+	// ViewerID, when set, keeps only the public decks and every deck of
+	// this user, as in ListFilter.
+	ViewerID int64
+}
+
+// SimilarDeck is a deck sharing cards with another. Deck.Cards is empty.
+type SimilarDeck struct {
+	Deck *deck.Deck `json:"deck"`
+	// Score is the weighted Jaccard similarity: the sum of the smaller
+	// quantity of each card over the sum of the larger one, from 0 to 1.
+	Score       float64 `json:"score"`
+	SharedCards int     `json:"shared_cards"`
+}
+
+// Normalize applies the default limit and validates the filter.
+func (f SimilarFilter) Normalize() (SimilarFilter, error) {
+	if f.Limit == 0 {
+		f.Limit = DefaultSimilarLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxSimilarLimit {
+		return f, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, MaxSimilarLimit)
+	}
+	return f, nil
+}
+
+// visibleTo reports whether a deck passes the ViewerID of a filter.
+func visibleTo(d *deck.Deck, viewerID int64) bool {
+	return viewerID == 0 || d.OwnerID == viewerID || deck.NormalizeVisibility(d.Visibility) == deck.VisibilityPublic
+}
+
+// CardQuantities sums the copies of each card of a deck across boards,
+// leaving out the maybeboard. Cards are keyed by Oracle ID, or by name when
+// they have none.
+func CardQuantities(cards []deck.Card, ignoreBasicLands bool) map[string]int {
+	quantities := make(map[string]int, len(cards))
+	for _, card := range cards {
+		if deck.NormalizeBoard(card.Board) == deck.BoardMaybe || ignoreBasicLands && deck.IsBasicLand(card) {
+			continue
+		}
+		quantities[CardIdentity(card)] += card.Quantity
+	}
+	return quantities
+}
+
+// CardIdentity is the key of a card in CardQuantities.
+func CardIdentity(card deck.Card) string {
+	if card.OracleID != "" {
+		return card.OracleID
+	}
+	return "name:" + strings.ToLower(card.Name)
+}
+
+// Similarity returns the weighted Jaccard similarity of two card
+// quantities and the number of cards they share.
+func Similarity(a, b map[string]int) (float64, int) {
+	overlap, union, shared := 0, 0, 0
+	for key, quantity := range a {
+		other := b[key]
+		if other > 0 {
+			shared++
+		}
+		overlap += min(quantity, other)
+		union += max(quantity, other)
+	}
+	for key, quantity := range b {
+		if _, ok := a[key]; !ok {
+			union += quantity
+		}
+	}
+	if union == 0 {
+		return 0, 0
+	}
+	return float64(overlap) / float64(union), shared
+}
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/game"
+)
+
+type inMemoryRepo struct {
+	mu     sync.RWMutex
+	games  map[int64]*game.Game
+	nextID int64
+}
+
+func NewInMemoryRepo() Repository {
+	return &inMemoryRepo{
+		games:  make(map[int64]*game.Game),
+		nextID: 1,
+	}
+}
+
+func (r *inMemoryRepo) Create(g *game.Game) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g.ID = r.nextID
+	r.nextID++
+	g.CreatedAt = time.Now().UTC()
+	r.games[g.ID] = copyGame(g)
+	return nil
+}
+
+func (r *inMemoryRepo) GetByID(id int64) (*game.Game, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	g, ok := r.games[id]
+	if !ok {
+		return nil, errors.New("game not found")
+	}
+	return copyGame(g), nil
+}
+
+func (r *inMemoryRepo) List(filter ListFilter) ([]*game.Game, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]*game.Game, 0)
+	for _, g := range r.games {
+		if filter.matches(g) {
+			result = append(result, copyGame(g))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].PlayedAt.Equal(result[j].PlayedAt) {
+			return result[i].PlayedAt.After(result[j].PlayedAt)
+		}
+		return result[i].ID > result[j].ID
+	})
+	return result, nil
+}
+
+func (r *inMemoryRepo) Update(id int64, g *game.Game) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.games[id]
+	if !ok {
+		return errors.New("game not found")
+	}
+	g.ID, g.CreatedBy, g.CreatedAt = id, stored.CreatedBy, stored.CreatedAt
+	r.games[id] = copyGame(g)
+	return nil
+}
+
+func (r *inMemoryRepo) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.games[id]; !ok {
+		return errors.New("game not found")
+	}
+	delete(r.games, id)
+	return nil
+}
+
+func copyGame(g *game.Game) *game.Game {
+	result := *g
+	result.Players = append([]game.Player(nil), g.Players...)
+	return &result
+}
@@ -0,0 +1,78 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/game"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo(t *testing.T) {
+	testRepo(t, NewInMemoryRepo())
+}
+
+// testRepo is shared with the postgres tests.
+func testRepo(t *testing.T, repo Repository) {
+	t.Helper()
+	week := time.Date(2026, 3, 5, 20, 0, 0, 0, time.UTC)
+	first := &game.Game{PlayedAt: week, Turns: 9, CreatedBy: 1, Players: []game.Player{
+		{DeckID: 1, OwnerID: 1, DeckName: "Atraxa", Winner: true},
+		{DeckID: 2, OwnerID: 2, DeckName: "Thassa", Eliminated: 2},
+		{DeckID: 3, OwnerID: 3, DeckName: "Krenko", Eliminated: 1},
+	}}
+	require.NoError(t, repo.Create(first))
+	assert.NotZero(t, first.ID)
+	assert.False(t, first.CreatedAt.IsZero())
+
+	second := &game.Game{PlayedAt: week.AddDate(0, 0, 7), CreatedBy: 2, Players: []game.Player{
+		{DeckID: 2, OwnerID: 2, DeckName: "Thassa", Winner: true},
+		{DeckID: 4, OwnerID: 1, DeckName: "Edgar"},
+	}}
+	require.NoError(t, repo.Create(second))
+
+	found, err := repo.GetByID(first.ID)
+	require.NoError(t, err)
+	assert.True(t, found.PlayedAt.Equal(week))
+	assert.Equal(t, 9, found.Turns)
+	assert.Equal(t, first.Players, found.Players)
+
+	games, err := repo.List(ListFilter{})
+	require.NoError(t, err)
+	require.Len(t, games, 2)
+	assert.Equal(t, second.ID, games[0].ID, "most recent first")
+
+	games, err = repo.List(ListFilter{DeckID: 3})
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+	assert.Equal(t, first.ID, games[0].ID)
+
+	games, err = repo.List(ListFilter{OwnerID: 1})
+	require.NoError(t, err)
+	assert.Len(t, games, 2)
+
+	games, err = repo.List(ListFilter{DeckID: 4, OwnerID: 2})
+	require.NoError(t, err)
+	assert.Empty(t, games)
+
+	update := &game.Game{PlayedAt: week, Turns: 11, Players: []game.Player{
+		{DeckID: 1, OwnerID: 1, DeckName: "Atraxa", Eliminated: 1},
+		{DeckID: 3, OwnerID: 3, DeckName: "Krenko", Winner: true},
+	}}
+	require.NoError(t, repo.Update(first.ID, update))
+	found, err = repo.GetByID(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), found.CreatedBy, "the author never changes")
+	assert.Equal(t, 11, found.Turns)
+	assert.Equal(t, update.Players, found.Players)
+	games, err = repo.List(ListFilter{DeckID: 2})
+	require.NoError(t, err)
+	assert.Len(t, games, 1, "removed players no longer match")
+
+	require.NoError(t, repo.Delete(first.ID))
+	_, err = repo.GetByID(first.ID)
+	assert.EqualError(t, err, "game not found")
+	assert.EqualError(t, repo.Delete(first.ID), "game not found")
+	assert.EqualError(t, repo.Update(first.ID, update), "game not found")
+}
@@ -0,0 +1,155 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/josofm/liliana/internal/entity/game"
+)
+
+type postgresRepo struct{ db *sql.DB }
+
+func NewPostgresRepo(db *sql.DB) Repository { return &postgresRepo{db: db} }
+
+const gameColumns = `g.id,g.played_at,g.turns,g.created_by,g.created_at`
+
+func (r *postgresRepo) Create(g *game.Game) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = tx.QueryRow(`INSERT INTO games (played_at,turns,created_by) VALUES ($1,$2,$3) RETURNING id,created_at`,
+		g.PlayedAt, g.Turns, g.CreatedBy).Scan(&g.ID, &g.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if err := savePlayers(tx, g); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func savePlayers(tx *sql.Tx, g *game.Game) error {
+	if _, err := tx.Exec(`DELETE FROM game_players WHERE game_id=$1`, g.ID); err != nil {
+		return err
+	}
+	for position, player := range g.Players {
+		_, err := tx.Exec(`INSERT INTO game_players (game_id,position,deck_id,owner_id,deck_name,winner,eliminated) VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+			g.ID, position, player.DeckID, player.OwnerID, player.DeckName, player.Winner, player.Eliminated)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *postgresRepo) GetByID(id int64) (*game.Game, error) {
+	games, err := r.queryGames(`SELECT `+gameColumns+` FROM games g WHERE g.id=$1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, errors.New("game not found")
+	}
+	return games[0], nil
+}
+
+func (r *postgresRepo) List(filter ListFilter) ([]*game.Game, error) {
+	conditions := make([]string, 0, 2)
+	args := make([]any, 0, 2)
+	if filter.DeckID != 0 {
+		args = append(args, filter.DeckID)
+		conditions = append(conditions, fmt.Sprintf("p.deck_id=$%d", len(args)))
+	}
+	if filter.OwnerID != 0 {
+		args = append(args, filter.OwnerID)
+		conditions = append(conditions, fmt.Sprintf("p.owner_id=$%d", len(args)))
+	}
+	query := `SELECT ` + gameColumns + ` FROM games g`
+	if len(conditions) > 0 {
+		query += ` WHERE EXISTS (SELECT 1 FROM game_players p WHERE p.game_id=g.id AND ` + strings.Join(conditions, " AND ") + `)`
+	}
+	return r.queryGames(query+` ORDER BY g.played_at DESC,g.id DESC`, args...)
+}
+
+// queryGames runs a query selecting gameColumns and loads the players of
+// every game with one more query.
+func (r *postgresRepo) queryGames(query string, args ...any) ([]*game.Game, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	games := make([]*game.Game, 0)
+	byID := make(map[int64]*game.Game)
+	ids := make([]int64, 0)
+	for rows.Next() {
+		g := &game.Game{Players: make([]game.Player, 0)}
+		if err := rows.Scan(&g.ID, &g.PlayedAt, &g.Turns, &g.CreatedBy, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		games = append(games, g)
+		byID[g.ID] = g
+		ids = append(ids, g.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return games, nil
+	}
+
+	players, err := r.db.Query(`SELECT game_id,deck_id,owner_id,deck_name,winner,eliminated FROM game_players WHERE game_id=ANY($1) ORDER BY game_id,position`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer players.Close()
+	for players.Next() {
+		var gameID int64
+		var player game.Player
+		if err := players.Scan(&gameID, &player.DeckID, &player.OwnerID, &player.DeckName, &player.Winner, &player.Eliminated); err != nil {
+			return nil, err
+		}
+		byID[gameID].Players = append(byID[gameID].Players, player)
+	}
+	return games, players.Err()
+}
+
+func (r *postgresRepo) Update(id int64, g *game.Game) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = tx.QueryRow(`UPDATE games SET played_at=$1,turns=$2 WHERE id=$3 RETURNING created_by,created_at`,
+		g.PlayedAt, g.Turns, id).Scan(&g.CreatedBy, &g.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("game not found")
+	}
+	if err != nil {
+		return err
+	}
+	g.ID = id
+	if err := savePlayers(tx, g); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *postgresRepo) Delete(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM games WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("game not found")
+	}
+	return nil
+}
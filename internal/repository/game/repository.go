@@ -0,0 +1,32 @@
+package game
+
+import "github.com/josofm/liliana/internal/entity/game"
+
+// ListFilter selects games. Zero fields match every game.
+type ListFilter struct {
+	DeckID int64
+	// OwnerID matches games with a deck of this user.
+	OwnerID int64
+}
+
+type Repository interface {
+	Create(g *game.Game) error
+	GetByID(id int64) (*game.Game, error)
+	// List returns the matching games, most recent first.
+	List(filter ListFilter) ([]*game.Game, error)
+	// Update replaces the date, turns and players of a game.
+	Update(id int64, g *game.Game) error
+	Delete(id int64) error
+}
+
+func (f ListFilter) matches(g *game.Game) bool {
+	if f.DeckID == 0 && f.OwnerID == 0 {
+		return true
+	}
+	for _, player := range g.Players {
+		if (f.DeckID == 0 || player.DeckID == f.DeckID) && (f.OwnerID == 0 || player.OwnerID == f.OwnerID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package identity
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type inMemoryRepo struct {
+	mu         sync.RWMutex
+	logins     map[string]*auth.OIDCLogin
+	identities map[int64]*auth.Identity
+	nextID     int64
+}
+
+func NewInMemoryRepo() Repository {
+	return &inMemoryRepo{
+		logins:     make(map[string]*auth.OIDCLogin),
+		identities: make(map[int64]*auth.Identity),
+		nextID:     1,
+	}
+}
+
+func (r *inMemoryRepo) CreateLogin(l *auth.OIDCLogin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.logins[l.State]; exists {
+		return errors.New("login already exists")
+	}
+	l.CreatedAt = time.Now().UTC()
+	copied := *l
+	r.logins[l.State] = &copied
+	return nil
+}
+
+func (r *inMemoryRepo) ConsumeLogin(state string, now time.Time) (*auth.OIDCLogin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.logins[state]
+	if !ok {
+		return nil, errors.New("login not found")
+	}
+	delete(r.logins, state)
+	if !l.ExpiresAt.After(now) {
+		return nil, errors.New("login not found")
+	}
+	return l, nil
+}
+
+func (r *inMemoryRepo) DeleteExpiredLogins(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for state, l := range r.logins {
+		if !l.ExpiresAt.After(now) {
+			delete(r.logins, state)
+		}
+	}
+	return nil
+}
+
+func (r *inMemoryRepo) Create(i *auth.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.identities {
+		if existing.Issuer == i.Issuer && existing.Subject == i.Subject {
+			return errors.New("identity already exists")
+		}
+	}
+	i.ID = r.nextID
+	r.nextID++
+	i.CreatedAt = time.Now().UTC()
+	copied := *i
+	r.identities[i.ID] = &copied
+	return nil
+}
+
+func (r *inMemoryRepo) Get(issuer, subject string) (*auth.Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, i := range r.identities {
+		if i.Issuer == issuer && i.Subject == subject {
+			copied := *i
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("identity not found")
+}
+
+func (r *inMemoryRepo) DeleteByUser(userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, i := range r.identities {
+		if i.UserID == userID {
+			delete(r.identities, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package identity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo(t *testing.T) {
+	testRepo(t, NewInMemoryRepo())
+}
+
+// testRepo is shared with the postgres tests.
+func testRepo(t *testing.T, repo Repository) {
+	t.Helper()
+	now := time.Date(2026, 3, 5, 20, 0, 0, 0, time.UTC)
+
+	login := &auth.OIDCLogin{State: "state", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(10 * time.Minute)}
+	require.NoError(t, repo.CreateLogin(login))
+	assert.False(t, login.CreatedAt.IsZero())
+	assert.Error(t, repo.CreateLogin(&auth.OIDCLogin{State: "state", ExpiresAt: now}), "states are unique")
+	require.NoError(t, repo.CreateLogin(&auth.OIDCLogin{State: "expired", ExpiresAt: now.Add(-time.Minute)}))
+	require.NoError(t, repo.CreateLogin(&auth.OIDCLogin{State: "abandoned", ExpiresAt: now.Add(-time.Minute)}))
+
+	consumed, err := repo.ConsumeLogin("state", now)
+	require.NoError(t, err)
+	assert.Equal(t, "nonce", consumed.Nonce)
+	assert.Equal(t, "verifier", consumed.CodeVerifier)
+	_, err = repo.ConsumeLogin("state", now)
+	assert.EqualError(t, err, "login not found", "states are single use")
+	_, err = repo.ConsumeLogin("expired", now)
+	assert.EqualError(t, err, "login not found")
+	require.NoError(t, repo.DeleteExpiredLogins(now))
+	_, err = repo.ConsumeLogin("abandoned", now.Add(-time.Hour))
+	assert.EqualError(t, err, "login not found")
+
+	identity := &auth.Identity{UserID: 1, Issuer: "https://accounts.example.com", Subject: "abc", Email: "liliana@example.com"}
+	require.NoError(t, repo.Create(identity))
+	assert.NotZero(t, identity.ID)
+	assert.EqualError(t, repo.Create(&auth.Identity{UserID: 2, Issuer: "https://accounts.example.com", Subject: "abc"}), "identity already exists")
+	require.NoError(t, repo.Create(&auth.Identity{UserID: 2, Issuer: "https://other.example.com", Subject: "abc"}))
+
+	found, err := repo.Get("https://accounts.example.com", "abc")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), found.UserID)
+	assert.Equal(t, "liliana@example.com", found.Email)
+	_, err = repo.Get("https://accounts.example.com", "unknown")
+	assert.EqualError(t, err, "identity not found")
+
+	require.NoError(t, repo.DeleteByUser(1))
+	_, err = repo.Get("https://accounts.example.com", "abc")
+	assert.EqualError(t, err, "identity not found")
+	_, err = repo.Get("https://other.example.com", "abc")
+	assert.NoError(t, err)
+}
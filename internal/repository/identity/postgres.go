@@ -0,0 +1,75 @@
+package identity
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type postgresRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresRepo(db *sql.DB) Repository {
+	return &postgresRepo{db: db}
+}
+
+func (r *postgresRepo) CreateLogin(l *auth.OIDCLogin) error {
+	const query = `INSERT INTO oidc_logins (state, nonce, code_verifier, expires_at) VALUES ($1,$2,$3,$4) RETURNING created_at`
+	return r.db.QueryRow(query, l.State, l.Nonce, l.CodeVerifier, l.ExpiresAt).Scan(&l.CreatedAt)
+}
+
+// ConsumeLogin deletes the state in a single statement, so two concurrent
+// callbacks cannot both use it.
+func (r *postgresRepo) ConsumeLogin(state string, now time.Time) (*auth.OIDCLogin, error) {
+	const query = `DELETE FROM oidc_logins WHERE state=$1 RETURNING state, nonce, code_verifier, expires_at, created_at`
+	l := &auth.OIDCLogin{}
+	err := r.db.QueryRow(query, state).Scan(&l.State, &l.Nonce, &l.CodeVerifier, &l.ExpiresAt, &l.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("login not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !l.ExpiresAt.After(now) {
+		return nil, errors.New("login not found")
+	}
+	return l, nil
+}
+
+func (r *postgresRepo) DeleteExpiredLogins(now time.Time) error {
+	_, err := r.db.Exec(`DELETE FROM oidc_logins WHERE expires_at<=$1`, now)
+	return err
+}
+
+func (r *postgresRepo) Create(i *auth.Identity) error {
+	const query = `
+		INSERT INTO user_identities (user_id, issuer, subject, email) VALUES ($1,$2,$3,$4)
+		ON CONFLICT (issuer, subject) DO NOTHING
+		RETURNING id, created_at`
+	err := r.db.QueryRow(query, i.UserID, i.Issuer, i.Subject, i.Email).Scan(&i.ID, &i.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("identity already exists")
+	}
+	return err
+}
+
+func (r *postgresRepo) Get(issuer, subject string) (*auth.Identity, error) {
+	const query = `SELECT id, user_id, issuer, subject, email, created_at FROM user_identities WHERE issuer=$1 AND subject=$2`
+	i := &auth.Identity{}
+	err := r.db.QueryRow(query, issuer, subject).Scan(&i.ID, &i.UserID, &i.Issuer, &i.Subject, &i.Email, &i.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("identity not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func (r *postgresRepo) DeleteByUser(userID int64) error {
+	_, err := r.db.Exec(`DELETE FROM user_identities WHERE user_id=$1`, userID)
+	return err
+}
@@ -0,0 +1,23 @@
+package identity
+
+import (
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type Repository interface {
+	CreateLogin(l *auth.OIDCLogin) error
+	// ConsumeLogin removes the login and returns it. Expired and unknown
+	// states fail with "login not found", so a state works only once.
+	ConsumeLogin(state string, now time.Time) (*auth.OIDCLogin, error)
+	// DeleteExpiredLogins removes logins abandoned before the callback.
+	DeleteExpiredLogins(now time.Time) error
+
+	// Create fails with "identity already exists" when the issuer and
+	// subject are already linked.
+	Create(i *auth.Identity) error
+	// Get fails with "identity not found" for unlinked accounts.
+	Get(issuer, subject string) (*auth.Identity, error)
+	DeleteByUser(userID int64) error
+}
@@ -0,0 +1,68 @@
+package loginattempt
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type inMemoryRepo struct {
+	mu       sync.Mutex
+	attempts map[string]*auth.LoginAttempt
+}
+
+func NewInMemoryRepo() Repository {
+	return &inMemoryRepo{attempts: make(map[string]*auth.LoginAttempt)}
+}
+
+func (r *inMemoryRepo) Get(key string) (*auth.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.attempts[key]
+	if !ok {
+		return nil, errors.New("login attempt not found")
+	}
+	return copyAttempt(a), nil
+}
+
+func (r *inMemoryRepo) RecordFailure(key string, now, resetBefore time.Time) (*auth.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.attempts[key]
+	if !ok || a.LastFailure.Before(resetBefore) {
+		a = &auth.LoginAttempt{Key: key}
+		r.attempts[key] = a
+	}
+	a.Failures++
+	a.LastFailure = now
+	return copyAttempt(a), nil
+}
+
+func (r *inMemoryRepo) Lock(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.attempts[key]
+	if !ok {
+		return errors.New("login attempt not found")
+	}
+	a.LockedUntil = &until
+	return nil
+}
+
+func (r *inMemoryRepo) Reset(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.attempts, key)
+	return nil
+}
+
+func copyAttempt(a *auth.LoginAttempt) *auth.LoginAttempt {
+	copied := *a
+	if a.LockedUntil != nil {
+		lockedUntil := *a.LockedUntil
+		copied.LockedUntil = &lockedUntil
+	}
+	return &copied
+}
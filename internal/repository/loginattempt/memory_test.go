@@ -0,0 +1,55 @@
+package loginattempt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo(t *testing.T) {
+	testRepo(t, NewInMemoryRepo())
+}
+
+// testRepo is shared with the postgres tests.
+func testRepo(t *testing.T, repo Repository) {
+	t.Helper()
+	now := time.Date(2026, 3, 5, 20, 0, 0, 0, time.UTC)
+	window := 15 * time.Minute
+
+	_, err := repo.Get("email:liliana@example.com")
+	assert.EqualError(t, err, "login attempt not found")
+	assert.EqualError(t, repo.Lock("email:liliana@example.com", now), "login attempt not found")
+
+	for i := 1; i <= 3; i++ {
+		attempt, err := repo.RecordFailure("email:liliana@example.com", now.Add(time.Duration(i)*time.Minute), now.Add(-window))
+		require.NoError(t, err)
+		assert.Equal(t, i, attempt.Failures)
+	}
+	_, err = repo.RecordFailure("ip:127.0.0.1", now, now.Add(-window))
+	require.NoError(t, err)
+
+	lockedUntil := now.Add(time.Hour)
+	require.NoError(t, repo.Lock("email:liliana@example.com", lockedUntil))
+	attempt, err := repo.Get("email:liliana@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempt.Failures)
+	assert.True(t, attempt.LastFailure.Equal(now.Add(3*time.Minute)))
+	require.NotNil(t, attempt.LockedUntil)
+	assert.True(t, attempt.LockedUntil.Equal(lockedUntil))
+
+	// Failures older than the window start a new count
+	later := now.Add(2 * time.Hour)
+	attempt, err = repo.RecordFailure("email:liliana@example.com", later, later.Add(-window))
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+	assert.Nil(t, attempt.LockedUntil)
+
+	require.NoError(t, repo.Reset("email:liliana@example.com"))
+	_, err = repo.Get("email:liliana@example.com")
+	assert.EqualError(t, err, "login attempt not found")
+	attempt, err = repo.Get("ip:127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+}
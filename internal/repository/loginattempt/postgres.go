@@ -0,0 +1,69 @@
+package loginattempt
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+// postgresRepo shares the counters between the instances of the API.
+type postgresRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresRepo(db *sql.DB) Repository {
+	return &postgresRepo{db: db}
+}
+
+func (r *postgresRepo) Get(key string) (*auth.LoginAttempt, error) {
+	const query = `SELECT key, failures, last_failure, locked_until FROM login_attempts WHERE key=$1`
+	a := &auth.LoginAttempt{}
+	err := r.db.QueryRow(query, key).Scan(&a.Key, &a.Failures, &a.LastFailure, &a.LockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("login attempt not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// RecordFailure counts in a single statement, so concurrent failures are
+// never lost.
+func (r *postgresRepo) RecordFailure(key string, now, resetBefore time.Time) (*auth.LoginAttempt, error) {
+	const query = `
+		INSERT INTO login_attempts (key, failures, last_failure) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+			locked_until = CASE WHEN login_attempts.last_failure < $3 THEN NULL ELSE login_attempts.locked_until END,
+			last_failure = $2
+		RETURNING key, failures, last_failure, locked_until`
+	a := &auth.LoginAttempt{}
+	err := r.db.QueryRow(query, key, now, resetBefore).Scan(&a.Key, &a.Failures, &a.LastFailure, &a.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *postgresRepo) Lock(key string, until time.Time) error {
+	result, err := r.db.Exec(`UPDATE login_attempts SET locked_until=$1 WHERE key=$2`, until, key)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("login attempt not found")
+	}
+	return nil
+}
+
+func (r *postgresRepo) Reset(key string) error {
+	_, err := r.db.Exec(`DELETE FROM login_attempts WHERE key=$1`, key)
+	return err
+}
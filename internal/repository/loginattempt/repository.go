@@ -0,0 +1,20 @@
+package loginattempt
+
+import (
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type Repository interface {
+	// Get fails with "login attempt not found" for keys without failures.
+	Get(key string) (*auth.LoginAttempt, error)
+	// RecordFailure adds a failure and returns the updated counter. Counters
+	// whose last failure is before resetBefore start again from one.
+	RecordFailure(key string, now, resetBefore time.Time) (*auth.LoginAttempt, error)
+	// Lock blocks the key until the given time; it fails with "login attempt
+	// not found" for keys without failures.
+	Lock(key string, until time.Time) error
+	// Reset forgets the failures of the key.
+	Reset(key string) error
+}
@@ -0,0 +1,210 @@
+package playgroup
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/playgroup"
+)
+
+type inMemoryRepo struct {
+	mu               sync.RWMutex
+	playgroups       map[int64]*playgroup.Playgroup
+	invitations      map[int64]*playgroup.Invitation
+	nextID           int64
+	nextInvitationID int64
+}
+
+func NewInMemoryRepo() Repository {
+	return &inMemoryRepo{
+		playgroups:       make(map[int64]*playgroup.Playgroup),
+		invitations:      make(map[int64]*playgroup.Invitation),
+		nextID:           1,
+		nextInvitationID: 1,
+	}
+}
+
+func (r *inMemoryRepo) Create(p *playgroup.Playgroup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.ID = r.nextID
+	r.nextID++
+	p.CreatedAt = time.Now().UTC()
+	for index := range p.Members {
+		p.Members[index].JoinedAt = p.CreatedAt
+	}
+	r.playgroups[p.ID] = copyPlaygroup(p)
+	return nil
+}
+
+func (r *inMemoryRepo) GetByID(id int64) (*playgroup.Playgroup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.playgroups[id]
+	if !ok {
+		return nil, errors.New("playgroup not found")
+	}
+	return copyPlaygroup(p), nil
+}
+
+func (r *inMemoryRepo) ListByMember(userID int64) ([]*playgroup.Playgroup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]*playgroup.Playgroup, 0)
+	for _, p := range r.playgroups {
+		if p.Member(userID) != nil {
+			result = append(result, copyPlaygroup(p))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (r *inMemoryRepo) SharePlaygroup(userID, otherID int64) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.playgroups {
+		if p.Member(userID) != nil && p.Member(otherID) != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *inMemoryRepo) Update(id int64, p *playgroup.Playgroup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.playgroups[id]
+	if !ok {
+		return errors.New("playgroup not found")
+	}
+	stored.Name, stored.Description = p.Name, p.Description
+	*p = *copyPlaygroup(stored)
+	return nil
+}
+
+func (r *inMemoryRepo) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.playgroups[id]; !ok {
+		return errors.New("playgroup not found")
+	}
+	delete(r.playgroups, id)
+	for invitationID, invitation := range r.invitations {
+		if invitation.PlaygroupID == id {
+			delete(r.invitations, invitationID)
+		}
+	}
+	return nil
+}
+
+func (r *inMemoryRepo) SaveMember(playgroupID int64, member *playgroup.Member) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.playgroups[playgroupID]
+	if !ok {
+		return errors.New("playgroup not found")
+	}
+	if existing := p.Member(member.UserID); existing != nil {
+		existing.Role = member.Role
+		*member = *existing
+		return nil
+	}
+	member.JoinedAt = time.Now().UTC()
+	p.Members = append(p.Members, *member)
+	return nil
+}
+
+func (r *inMemoryRepo) RemoveMember(playgroupID, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.playgroups[playgroupID]
+	if !ok {
+		return errors.New("playgroup not found")
+	}
+	for index, member := range p.Members {
+		if member.UserID == userID {
+			p.Members = append(p.Members[:index], p.Members[index+1:]...)
+			return nil
+		}
+	}
+	return errors.New("member not found")
+}
+
+func (r *inMemoryRepo) CreateInvitation(invitation *playgroup.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.playgroups[invitation.PlaygroupID]; !ok {
+		return errors.New("playgroup not found")
+	}
+	for _, existing := range r.invitations {
+		if existing.PlaygroupID == invitation.PlaygroupID && existing.Email == invitation.Email {
+			return errors.New("invitation already exists")
+		}
+	}
+	invitation.ID = r.nextInvitationID
+	r.nextInvitationID++
+	invitation.CreatedAt = time.Now().UTC()
+	stored := *invitation
+	r.invitations[invitation.ID] = &stored
+	return nil
+}
+
+func (r *inMemoryRepo) GetInvitation(id int64) (*playgroup.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	invitation, ok := r.invitations[id]
+	if !ok {
+		return nil, errors.New("invitation not found")
+	}
+	result := *invitation
+	return &result, nil
+}
+
+func (r *inMemoryRepo) Invitations(playgroupID int64) ([]playgroup.Invitation, error) {
+	return r.listInvitations(func(invitation *playgroup.Invitation) bool {
+		return invitation.PlaygroupID == playgroupID
+	}), nil
+}
+
+func (r *inMemoryRepo) InvitationsByEmail(email string) ([]playgroup.Invitation, error) {
+	return r.listInvitations(func(invitation *playgroup.Invitation) bool {
+		return invitation.Email == email
+	}), nil
+}
+
+func (r *inMemoryRepo) listInvitations(match func(*playgroup.Invitation) bool) []playgroup.Invitation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]playgroup.Invitation, 0)
+	for _, invitation := range r.invitations {
+		if match(invitation) {
+			result = append(result, *invitation)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+func (r *inMemoryRepo) DeleteInvitation(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.invitations[id]; !ok {
+		return errors.New("invitation not found")
+	}
+	delete(r.invitations, id)
+	return nil
+}
+
+func copyPlaygroup(p *playgroup.Playgroup) *playgroup.Playgroup {
+	result := *p
+	result.Members = append([]playgroup.Member{}, p.Members...)
+	return &result
+}
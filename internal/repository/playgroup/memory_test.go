@@ -0,0 +1,93 @@
+package playgroup
+
+import (
+	"testing"
+
+	"github.com/josofm/liliana/internal/entity/playgroup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo(t *testing.T) {
+	testRepo(t, NewInMemoryRepo())
+}
+
+// testRepo is shared with the postgres tests.
+func testRepo(t *testing.T, repo Repository) {
+	t.Helper()
+	pod := &playgroup.Playgroup{Name: "Thursday pod", CreatedBy: 1, Members: []playgroup.Member{{UserID: 1, Role: playgroup.RoleOwner}}}
+	require.NoError(t, repo.Create(pod))
+	assert.NotZero(t, pod.ID)
+	assert.False(t, pod.Members[0].JoinedAt.IsZero())
+	other := &playgroup.Playgroup{Name: "Store league", CreatedBy: 2, Members: []playgroup.Member{{UserID: 2, Role: playgroup.RoleOwner}}}
+	require.NoError(t, repo.Create(other))
+
+	member := &playgroup.Member{UserID: 2, Role: playgroup.RoleMember}
+	require.NoError(t, repo.SaveMember(pod.ID, member))
+	assert.False(t, member.JoinedAt.IsZero())
+	member.Role = playgroup.RoleOwner
+	require.NoError(t, repo.SaveMember(pod.ID, member))
+
+	found, err := repo.GetByID(pod.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Thursday pod", found.Name)
+	assert.Equal(t, []int64{1, 2}, found.MemberIDs())
+	assert.Equal(t, 2, found.Owners())
+
+	playgroups, err := repo.ListByMember(2)
+	require.NoError(t, err)
+	require.Len(t, playgroups, 2)
+	assert.Equal(t, "Store league", playgroups[0].Name)
+	assert.Equal(t, "Thursday pod", playgroups[1].Name)
+
+	shared, err := repo.SharePlaygroup(1, 2)
+	require.NoError(t, err)
+	assert.True(t, shared)
+	shared, err = repo.SharePlaygroup(1, 3)
+	require.NoError(t, err)
+	assert.False(t, shared)
+
+	update := &playgroup.Playgroup{Name: "Friday pod", Description: "Casual EDH"}
+	require.NoError(t, repo.Update(pod.ID, update))
+	assert.Equal(t, int64(1), update.CreatedBy)
+	assert.Len(t, update.Members, 2)
+	assert.EqualError(t, repo.Update(99, update), "playgroup not found")
+
+	require.NoError(t, repo.RemoveMember(pod.ID, 2))
+	assert.EqualError(t, repo.RemoveMember(pod.ID, 2), "member not found")
+	shared, err = repo.SharePlaygroup(2, 1)
+	require.NoError(t, err)
+	assert.False(t, shared, "former members no longer share the playgroup")
+	playgroups, err = repo.ListByMember(2)
+	require.NoError(t, err)
+	assert.Len(t, playgroups, 1)
+
+	invitation := &playgroup.Invitation{PlaygroupID: pod.ID, Email: "thassa@example.com", InvitedBy: 1}
+	require.NoError(t, repo.CreateInvitation(invitation))
+	assert.NotZero(t, invitation.ID)
+	assert.EqualError(t, repo.CreateInvitation(&playgroup.Invitation{PlaygroupID: pod.ID, Email: "thassa@example.com", InvitedBy: 1}), "invitation already exists")
+	require.NoError(t, repo.CreateInvitation(&playgroup.Invitation{PlaygroupID: other.ID, Email: "thassa@example.com", InvitedBy: 2}))
+
+	foundInvitation, err := repo.GetInvitation(invitation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "thassa@example.com", foundInvitation.Email)
+	invitations, err := repo.Invitations(pod.ID)
+	require.NoError(t, err)
+	assert.Len(t, invitations, 1)
+	invitations, err = repo.InvitationsByEmail("thassa@example.com")
+	require.NoError(t, err)
+	assert.Len(t, invitations, 2)
+
+	require.NoError(t, repo.DeleteInvitation(invitation.ID))
+	assert.EqualError(t, repo.DeleteInvitation(invitation.ID), "invitation not found")
+	_, err = repo.GetInvitation(invitation.ID)
+	assert.EqualError(t, err, "invitation not found")
+
+	require.NoError(t, repo.Delete(other.ID))
+	assert.EqualError(t, repo.Delete(other.ID), "playgroup not found")
+	_, err = repo.GetByID(other.ID)
+	assert.EqualError(t, err, "playgroup not found")
+	invitations, err = repo.InvitationsByEmail("thassa@example.com")
+	require.NoError(t, err)
+	assert.Empty(t, invitations, "invitations are deleted with their playgroup")
+}
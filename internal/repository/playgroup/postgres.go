@@ -0,0 +1,216 @@
+package playgroup
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/josofm/liliana/internal/entity/playgroup"
+)
+
+type postgresRepo struct{ db *sql.DB }
+
+func NewPostgresRepo(db *sql.DB) Repository { return &postgresRepo{db: db} }
+
+const (
+	playgroupColumns  = `p.id,p.name,p.description,p.created_by,p.created_at`
+	invitationColumns = `id,playgroup_id,email,invited_by,created_at`
+)
+
+func (r *postgresRepo) Create(p *playgroup.Playgroup) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = tx.QueryRow(`INSERT INTO playgroups (name,description,created_by) VALUES ($1,$2,$3) RETURNING id,created_at`,
+		p.Name, p.Description, p.CreatedBy).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for index := range p.Members {
+		member := &p.Members[index]
+		err := tx.QueryRow(`INSERT INTO playgroup_members (playgroup_id,user_id,role,joined_at) VALUES ($1,$2,$3,$4) RETURNING joined_at`,
+			p.ID, member.UserID, member.Role, p.CreatedAt).Scan(&member.JoinedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *postgresRepo) GetByID(id int64) (*playgroup.Playgroup, error) {
+	playgroups, err := r.queryPlaygroups(`SELECT `+playgroupColumns+` FROM playgroups p WHERE p.id=$1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(playgroups) == 0 {
+		return nil, errors.New("playgroup not found")
+	}
+	return playgroups[0], nil
+}
+
+func (r *postgresRepo) ListByMember(userID int64) ([]*playgroup.Playgroup, error) {
+	return r.queryPlaygroups(`
+		SELECT `+playgroupColumns+` FROM playgroups p
+		WHERE EXISTS (SELECT 1 FROM playgroup_members m WHERE m.playgroup_id=p.id AND m.user_id=$1)
+		ORDER BY p.name COLLATE "C",p.id`, userID)
+}
+
+func (r *postgresRepo) SharePlaygroup(userID, otherID int64) (bool, error) {
+	var shared bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM playgroup_members a
+			JOIN playgroup_members b ON b.playgroup_id=a.playgroup_id
+			WHERE a.user_id=$1 AND b.user_id=$2)`, userID, otherID).Scan(&shared)
+	return shared, err
+}
+
+// queryPlaygroups runs a query selecting playgroupColumns and loads the
+// members of every playgroup with one more query.
+func (r *postgresRepo) queryPlaygroups(query string, args ...any) ([]*playgroup.Playgroup, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	playgroups := make([]*playgroup.Playgroup, 0)
+	byID := make(map[int64]*playgroup.Playgroup)
+	ids := make([]int64, 0)
+	for rows.Next() {
+		p := &playgroup.Playgroup{Members: make([]playgroup.Member, 0)}
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.CreatedBy, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		playgroups = append(playgroups, p)
+		byID[p.ID] = p
+		ids = append(ids, p.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(playgroups) == 0 {
+		return playgroups, nil
+	}
+
+	members, err := r.db.Query(`SELECT playgroup_id,user_id,role,joined_at FROM playgroup_members WHERE playgroup_id=ANY($1) ORDER BY playgroup_id,joined_at,user_id`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer members.Close()
+	for members.Next() {
+		var playgroupID int64
+		var member playgroup.Member
+		if err := members.Scan(&playgroupID, &member.UserID, &member.Role, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		byID[playgroupID].Members = append(byID[playgroupID].Members, member)
+	}
+	return playgroups, members.Err()
+}
+
+func (r *postgresRepo) Update(id int64, p *playgroup.Playgroup) error {
+	result, err := r.db.Exec(`UPDATE playgroups SET name=$1,description=$2 WHERE id=$3`, p.Name, p.Description, id)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result, "playgroup not found"); err != nil {
+		return err
+	}
+	updated, err := r.GetByID(id)
+	if err != nil {
+		return err
+	}
+	*p = *updated
+	return nil
+}
+
+func (r *postgresRepo) Delete(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM playgroups WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "playgroup not found")
+}
+
+func (r *postgresRepo) SaveMember(playgroupID int64, member *playgroup.Member) error {
+	return r.db.QueryRow(`
+		INSERT INTO playgroup_members (playgroup_id,user_id,role) VALUES ($1,$2,$3)
+		ON CONFLICT (playgroup_id,user_id) DO UPDATE SET role=EXCLUDED.role
+		RETURNING joined_at`, playgroupID, member.UserID, member.Role).Scan(&member.JoinedAt)
+}
+
+func (r *postgresRepo) RemoveMember(playgroupID, userID int64) error {
+	result, err := r.db.Exec(`DELETE FROM playgroup_members WHERE playgroup_id=$1 AND user_id=$2`, playgroupID, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "member not found")
+}
+
+func (r *postgresRepo) CreateInvitation(invitation *playgroup.Invitation) error {
+	err := r.db.QueryRow(`
+		INSERT INTO playgroup_invitations (playgroup_id,email,invited_by) VALUES ($1,$2,$3)
+		ON CONFLICT (playgroup_id,email) DO NOTHING
+		RETURNING id,created_at`,
+		invitation.PlaygroupID, invitation.Email, invitation.InvitedBy).Scan(&invitation.ID, &invitation.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("invitation already exists")
+	}
+	return err
+}
+
+func (r *postgresRepo) GetInvitation(id int64) (*playgroup.Invitation, error) {
+	invitations, err := r.queryInvitations(`SELECT `+invitationColumns+` FROM playgroup_invitations WHERE id=$1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return nil, errors.New("invitation not found")
+	}
+	return &invitations[0], nil
+}
+
+func (r *postgresRepo) Invitations(playgroupID int64) ([]playgroup.Invitation, error) {
+	return r.queryInvitations(`SELECT `+invitationColumns+` FROM playgroup_invitations WHERE playgroup_id=$1 ORDER BY id`, playgroupID)
+}
+
+func (r *postgresRepo) InvitationsByEmail(email string) ([]playgroup.Invitation, error) {
+	return r.queryInvitations(`SELECT `+invitationColumns+` FROM playgroup_invitations WHERE email=$1 ORDER BY id`, email)
+}
+
+func (r *postgresRepo) queryInvitations(query string, args ...any) ([]playgroup.Invitation, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	invitations := make([]playgroup.Invitation, 0)
+	for rows.Next() {
+		var invitation playgroup.Invitation
+		if err := rows.Scan(&invitation.ID, &invitation.PlaygroupID, &invitation.Email, &invitation.InvitedBy, &invitation.CreatedAt); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+func (r *postgresRepo) DeleteInvitation(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM playgroup_invitations WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "invitation not found")
+}
+
+func requireAffected(result sql.Result, notFound string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New(notFound)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package playgroup
+
+import "github.com/josofm/liliana/internal/entity/playgroup"
+
+type Repository interface {
+	// Create stores a playgroup with its members.
+	Create(p *playgroup.Playgroup) error
+	GetByID(id int64) (*playgroup.Playgroup, error)
+	// ListByMember returns the playgroups of a user, by name.
+	ListByMember(userID int64) ([]*playgroup.Playgroup, error)
+	// SharePlaygroup reports whether two users are members of a same playgroup.
+	SharePlaygroup(userID, otherID int64) (bool, error)
+	// Update replaces the name and description of a playgroup.
+	Update(id int64, p *playgroup.Playgroup) error
+	Delete(id int64) error
+
+	// SaveMember adds a member or changes the role of an existing one.
+	SaveMember(playgroupID int64, member *playgroup.Member) error
+	RemoveMember(playgroupID, userID int64) error
+
+	// CreateInvitation fails with "invitation already exists" when the email
+	// was already invited to the playgroup.
+	CreateInvitation(invitation *playgroup.Invitation) error
+	GetInvitation(id int64) (*playgroup.Invitation, error)
+	// Invitations lists the invitations of a playgroup, oldest first.
+	Invitations(playgroupID int64) ([]playgroup.Invitation, error)
+	// InvitationsByEmail lists the invitations sent to an email, oldest first.
+	InvitationsByEmail(email string) ([]playgroup.Invitation, error)
+	DeleteInvitation(id int64) error
+}
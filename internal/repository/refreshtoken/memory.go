@@ -0,0 +1,90 @@
+package refreshtoken
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type inMemoryRepo struct {
+	mu     sync.Mutex
+	tokens map[string]*auth.RefreshToken
+}
+
+func NewInMemoryRepo() Repository {
+	return &inMemoryRepo{tokens: make(map[string]*auth.RefreshToken)}
+}
+
+func (r *inMemoryRepo) Create(t *auth.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(t)
+}
+
+func (r *inMemoryRepo) create(t *auth.RefreshToken) error {
+	if _, exists := r.tokens[t.ID]; exists {
+		return errors.New("refresh token already exists")
+	}
+	t.CreatedAt = time.Now().UTC()
+	stored := *t
+	r.tokens[t.ID] = &stored
+	return nil
+}
+
+func (r *inMemoryRepo) Get(id string) (*auth.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[id]
+	if !ok {
+		return nil, errors.New("refresh token not found")
+	}
+	found := *t
+	return &found, nil
+}
+
+func (r *inMemoryRepo) Rotate(id string, next *auth.RefreshToken, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[id]
+	if !ok {
+		return errors.New("refresh token not found")
+	}
+	if t.RevokedAt != nil {
+		return errors.New("refresh token revoked")
+	}
+	if err := r.create(next); err != nil {
+		return err
+	}
+	revokedAt := now
+	t.RevokedAt = &revokedAt
+	t.ReplacedBy = next.ID
+	return nil
+}
+
+func (r *inMemoryRepo) RevokeFamily(familyID string, now time.Time) error {
+	r.revokeWhere(now, func(t *auth.RefreshToken) bool { return t.FamilyID == familyID })
+	return nil
+}
+
+func (r *inMemoryRepo) RevokeByUser(userID int64, now time.Time) error {
+	r.revokeWhere(now, func(t *auth.RefreshToken) bool { return t.UserID == userID })
+	return nil
+}
+
+func (r *inMemoryRepo) RevokeOtherFamilies(userID int64, familyID string, now time.Time) error {
+	r.revokeWhere(now, func(t *auth.RefreshToken) bool { return t.UserID == userID && t.FamilyID != familyID })
+	return nil
+}
+
+func (r *inMemoryRepo) revokeWhere(now time.Time, match func(*auth.RefreshToken) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.RevokedAt == nil && match(t) {
+			revokedAt := now
+			t.RevokedAt = &revokedAt
+		}
+	}
+}
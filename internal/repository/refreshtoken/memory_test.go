@@ -0,0 +1,68 @@
+package refreshtoken
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo(t *testing.T) {
+	testRepo(t, NewInMemoryRepo())
+}
+
+// testRepo is shared with the postgres tests.
+func testRepo(t *testing.T, repo Repository) {
+	t.Helper()
+	now := time.Date(2026, 3, 5, 20, 0, 0, 0, time.UTC)
+	first := &auth.RefreshToken{ID: "first", UserID: 1, FamilyID: "login", ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repo.Create(first))
+	assert.Error(t, repo.Create(&auth.RefreshToken{ID: "first", UserID: 1, FamilyID: "other", ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, repo.Create(&auth.RefreshToken{ID: "phone", UserID: 1, FamilyID: "phone", ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, repo.Create(&auth.RefreshToken{ID: "someone", UserID: 2, FamilyID: "someone", ExpiresAt: now.Add(time.Hour)}))
+
+	_, err := repo.Get("unknown")
+	assert.EqualError(t, err, "refresh token not found")
+	assert.EqualError(t, repo.Rotate("unknown", &auth.RefreshToken{ID: "x", UserID: 1, FamilyID: "login", ExpiresAt: now.Add(time.Hour)}, now), "refresh token not found")
+
+	second := &auth.RefreshToken{ID: "second", UserID: 1, FamilyID: "login", ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repo.Rotate("first", second, now))
+	rotated, err := repo.Get("first")
+	require.NoError(t, err)
+	require.NotNil(t, rotated.RevokedAt)
+	assert.True(t, rotated.RevokedAt.Equal(now))
+	assert.Equal(t, "second", rotated.ReplacedBy)
+	assert.EqualError(t, repo.Rotate("first", &auth.RefreshToken{ID: "third", UserID: 1, FamilyID: "login", ExpiresAt: now.Add(time.Hour)}, now), "refresh token revoked", "a token is renewed only once")
+	_, err = repo.Get("third")
+	assert.EqualError(t, err, "refresh token not found", "a failed rotation stores nothing")
+
+	require.NoError(t, repo.RevokeFamily("login", now))
+	found, err := repo.Get("second")
+	require.NoError(t, err)
+	assert.NotNil(t, found.RevokedAt)
+	found, err = repo.Get("phone")
+	require.NoError(t, err)
+	assert.Nil(t, found.RevokedAt, "other logins are kept")
+
+	require.NoError(t, repo.Create(&auth.RefreshToken{ID: "tablet", UserID: 1, FamilyID: "tablet", ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, repo.RevokeOtherFamilies(1, "phone", now))
+	found, err = repo.Get("tablet")
+	require.NoError(t, err)
+	assert.NotNil(t, found.RevokedAt)
+	found, err = repo.Get("phone")
+	require.NoError(t, err)
+	assert.Nil(t, found.RevokedAt, "the kept login stays valid")
+
+	require.NoError(t, repo.RevokeByUser(1, now))
+	found, err = repo.Get("phone")
+	require.NoError(t, err)
+	assert.NotNil(t, found.RevokedAt)
+	found, err = repo.Get("someone")
+	require.NoError(t, err)
+	assert.Nil(t, found.RevokedAt)
+	assert.Equal(t, int64(2), found.UserID)
+	assert.Equal(t, "someone", found.FamilyID)
+	assert.True(t, found.ExpiresAt.Equal(now.Add(time.Hour)))
+}
@@ -0,0 +1,84 @@
+package refreshtoken
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type postgresRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresRepo(db *sql.DB) Repository {
+	return &postgresRepo{db: db}
+}
+
+const insertQuery = `INSERT INTO refresh_tokens (id, user_id, family_id, expires_at) VALUES ($1,$2,$3,$4) RETURNING created_at`
+
+func (r *postgresRepo) Create(t *auth.RefreshToken) error {
+	return r.db.QueryRow(insertQuery, t.ID, t.UserID, t.FamilyID, t.ExpiresAt).Scan(&t.CreatedAt)
+}
+
+func (r *postgresRepo) Get(id string) (*auth.RefreshToken, error) {
+	const query = `SELECT id, user_id, family_id, expires_at, revoked_at, COALESCE(replaced_by, ''), created_at FROM refresh_tokens WHERE id=$1`
+	t := &auth.RefreshToken{}
+	err := r.db.QueryRow(query, id).Scan(&t.ID, &t.UserID, &t.FamilyID, &t.ExpiresAt, &t.RevokedAt, &t.ReplacedBy, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("refresh token not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Rotate revokes the old token in a single UPDATE, so two concurrent
+// requests cannot both renew it.
+func (r *postgresRepo) Rotate(id string, next *auth.RefreshToken, now time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at=$1, replaced_by=$2 WHERE id=$3 AND revoked_at IS NULL`, now, next.ID, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM refresh_tokens WHERE id=$1)`, id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return errors.New("refresh token not found")
+		}
+		return errors.New("refresh token revoked")
+	}
+	if err := tx.QueryRow(insertQuery, next.ID, next.UserID, next.FamilyID, next.ExpiresAt).Scan(&next.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *postgresRepo) RevokeFamily(familyID string, now time.Time) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at=$1 WHERE family_id=$2 AND revoked_at IS NULL`, now, familyID)
+	return err
+}
+
+func (r *postgresRepo) RevokeByUser(userID int64, now time.Time) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at=$1 WHERE user_id=$2 AND revoked_at IS NULL`, now, userID)
+	return err
+}
+
+func (r *postgresRepo) RevokeOtherFamilies(userID int64, familyID string, now time.Time) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at=$1 WHERE user_id=$2 AND family_id<>$3 AND revoked_at IS NULL`, now, userID, familyID)
+	return err
+}
@@ -0,0 +1,24 @@
+package refreshtoken
+
+import (
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type Repository interface {
+	Create(t *auth.RefreshToken) error
+	// Get fails with "refresh token not found" for unknown ids. Revoked
+	// tokens are returned, so callers can tell a reuse from a bad token.
+	Get(id string) (*auth.RefreshToken, error)
+	// Rotate revokes the token id, points it at next and stores next. It
+	// fails with "refresh token revoked" when id was already revoked, so only
+	// one of two concurrent rotations wins.
+	Rotate(id string, next *auth.RefreshToken, now time.Time) error
+	// RevokeFamily revokes every token renewed from the same login.
+	RevokeFamily(familyID string, now time.Time) error
+	// RevokeByUser revokes every token of a user.
+	RevokeByUser(userID int64, now time.Time) error
+	// RevokeOtherFamilies revokes every token of a user outside familyID.
+	RevokeOtherFamilies(userID int64, familyID string, now time.Time) error
+}
@@ -0,0 +1,57 @@
+package token
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type inMemoryRepo struct {
+	mu     sync.Mutex
+	tokens map[string]*auth.AccountToken
+	nextID int64
+}
+
+func NewInMemoryRepo() Repository {
+	return &inMemoryRepo{tokens: make(map[string]*auth.AccountToken), nextID: 1}
+}
+
+func (r *inMemoryRepo) Create(t *auth.AccountToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.tokens[t.TokenHash]; exists {
+		return errors.New("token already exists")
+	}
+	t.ID = r.nextID
+	r.nextID++
+	t.CreatedAt = time.Now().UTC()
+	stored := *t
+	r.tokens[t.TokenHash] = &stored
+	return nil
+}
+
+func (r *inMemoryRepo) Consume(purpose, tokenHash string, now time.Time) (*auth.AccountToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[tokenHash]
+	if !ok || t.Purpose != purpose || t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return nil, errors.New("token not found")
+	}
+	usedAt := now
+	t.UsedAt = &usedAt
+	consumed := *t
+	return &consumed, nil
+}
+
+func (r *inMemoryRepo) DeleteByUser(userID int64, purpose string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, t := range r.tokens {
+		if t.UserID == userID && t.Purpose == purpose {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
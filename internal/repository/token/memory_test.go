@@ -0,0 +1,42 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo(t *testing.T) {
+	testRepo(t, NewInMemoryRepo())
+}
+
+// testRepo is shared with the postgres tests.
+func testRepo(t *testing.T, repo Repository) {
+	t.Helper()
+	now := time.Date(2026, 3, 5, 20, 0, 0, 0, time.UTC)
+	reset := &auth.AccountToken{UserID: 1, Purpose: auth.PurposePasswordReset, TokenHash: "reset", ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repo.Create(reset))
+	assert.NotZero(t, reset.ID)
+	require.NoError(t, repo.Create(&auth.AccountToken{UserID: 1, Purpose: auth.PurposeEmailVerification, TokenHash: "verify", ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, repo.Create(&auth.AccountToken{UserID: 2, Purpose: auth.PurposePasswordReset, TokenHash: "expired", ExpiresAt: now}))
+
+	_, err := repo.Consume(auth.PurposeEmailVerification, "reset", now)
+	assert.EqualError(t, err, "token not found", "tokens only work for their purpose")
+	_, err = repo.Consume(auth.PurposePasswordReset, "expired", now)
+	assert.EqualError(t, err, "token not found")
+
+	consumed, err := repo.Consume(auth.PurposePasswordReset, "reset", now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), consumed.UserID)
+	require.NotNil(t, consumed.UsedAt)
+	assert.True(t, consumed.UsedAt.Equal(now))
+	_, err = repo.Consume(auth.PurposePasswordReset, "reset", now)
+	assert.EqualError(t, err, "token not found", "tokens are single use")
+
+	require.NoError(t, repo.DeleteByUser(1, auth.PurposeEmailVerification))
+	_, err = repo.Consume(auth.PurposeEmailVerification, "verify", now)
+	assert.EqualError(t, err, "token not found")
+}
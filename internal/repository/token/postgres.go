@@ -0,0 +1,45 @@
+package token
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type postgresRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresRepo(db *sql.DB) Repository {
+	return &postgresRepo{db: db}
+}
+
+func (r *postgresRepo) Create(t *auth.AccountToken) error {
+	const query = `INSERT INTO account_tokens (user_id, purpose, token_hash, expires_at) VALUES ($1,$2,$3,$4) RETURNING id, created_at`
+	return r.db.QueryRow(query, t.UserID, t.Purpose, t.TokenHash, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+}
+
+// Consume claims the token in a single UPDATE, so two concurrent requests
+// cannot both use it.
+func (r *postgresRepo) Consume(purpose, tokenHash string, now time.Time) (*auth.AccountToken, error) {
+	const query = `
+		UPDATE account_tokens SET used_at=$1
+		WHERE token_hash=$2 AND purpose=$3 AND used_at IS NULL AND expires_at>$1
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at`
+	t := &auth.AccountToken{}
+	err := r.db.QueryRow(query, now, tokenHash, purpose).Scan(&t.ID, &t.UserID, &t.Purpose, &t.TokenHash, &t.ExpiresAt, &t.UsedAt, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("token not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *postgresRepo) DeleteByUser(userID int64, purpose string) error {
+	_, err := r.db.Exec(`DELETE FROM account_tokens WHERE user_id=$1 AND purpose=$2`, userID, purpose)
+	return err
+}
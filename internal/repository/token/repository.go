@@ -0,0 +1,16 @@
+package token
+
+import (
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+type Repository interface {
+	Create(t *auth.AccountToken) error
+	// Consume marks a token as used and returns it. Used, expired and unknown
+	// tokens fail with "token not found", so a token works only once.
+	Consume(purpose, tokenHash string, now time.Time) (*auth.AccountToken, error)
+	// DeleteByUser removes the tokens of a user for a purpose, used or not.
+	DeleteByUser(userID int64, purpose string) error
+}
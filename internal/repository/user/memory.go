@@ -24,6 +24,22 @@ func (r *inMemoryRepo) Create(u *user.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	u.ID = r.nextID
+	if u.Role == "" {
+		u.Role = user.RoleUser
+	}
+	r.users[u.ID] = u
+	r.nextID++
+	return nil
+}
+
+func (r *inMemoryRepo) Register(u *user.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u.ID = r.nextID
+	u.Role = user.RoleUser
+	if len(r.users) == 0 {
+		u.Role = user.RoleAdmin
+	}
 	r.users[u.ID] = u
 	r.nextID++
 	return nil
@@ -63,14 +79,51 @@ func (r *inMemoryRepo) GetByEmail(email string) (*user.User, error) {
 func (r *inMemoryRepo) Update(id int64, u *user.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, exists := r.users[id]; !exists {
+	existing, exists := r.users[id]
+	if !exists {
 		return errors.New("user not found")
 	}
 	u.ID = id
+	u.EmailVerified = existing.EmailVerified && existing.Email == u.Email
+	u.Role = existing.Role
 	r.users[id] = u
 	return nil
 }
 
+func (r *inMemoryRepo) SetEmailVerified(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, exists := r.users[id]
+	if !exists {
+		return errors.New("user not found")
+	}
+	u.EmailVerified = true
+	return nil
+}
+
+func (r *inMemoryRepo) SetRole(id int64, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, exists := r.users[id]
+	if !exists {
+		return errors.New("user not found")
+	}
+	u.Role = role
+	return nil
+}
+
+func (r *inMemoryRepo) CountByRole(role string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	count := 0
+	for _, u := range r.users {
+		if u.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
+
 func (r *inMemoryRepo) Delete(id int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -1,10 +1,13 @@
 package user
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	userEntity "github.com/josofm/liliana/internal/entity/user"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewInMemoryRepo(t *testing.T) {
@@ -14,13 +17,13 @@ func TestNewInMemoryRepo(t *testing.T) {
 
 func TestInMemoryRepo_Create(t *testing.T) {
 	repo := NewInMemoryRepo()
-	
+
 	user := &userEntity.User{
 		Name:     "Test User",
 		Email:    "test@example.com",
 		Password: "password123",
 	}
-	
+
 	err := repo.Create(user)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), user.ID)
@@ -28,14 +31,14 @@ func TestInMemoryRepo_Create(t *testing.T) {
 
 func TestInMemoryRepo_GetAll(t *testing.T) {
 	repo := NewInMemoryRepo()
-	
+
 	// Create test users
 	user1 := &userEntity.User{Name: "User 1", Email: "user1@example.com", Password: "pass1"}
 	user2 := &userEntity.User{Name: "User 2", Email: "user2@example.com", Password: "pass2"}
-	
+
 	repo.Create(user1)
 	repo.Create(user2)
-	
+
 	users, err := repo.GetAll()
 	assert.NoError(t, err)
 	assert.Len(t, users, 2)
@@ -43,16 +46,16 @@ func TestInMemoryRepo_GetAll(t *testing.T) {
 
 func TestInMemoryRepo_GetByID(t *testing.T) {
 	repo := NewInMemoryRepo()
-	
+
 	user := &userEntity.User{Name: "Test User", Email: "test@example.com", Password: "password"}
 	repo.Create(user)
-	
+
 	// Test successful retrieval
 	found, err := repo.GetByID(1)
 	assert.NoError(t, err)
 	assert.Equal(t, user.Name, found.Name)
 	assert.Equal(t, user.Email, found.Email)
-	
+
 	// Test not found
 	notFound, err := repo.GetByID(999)
 	assert.Error(t, err)
@@ -62,22 +65,22 @@ func TestInMemoryRepo_GetByID(t *testing.T) {
 
 func TestInMemoryRepo_Update(t *testing.T) {
 	repo := NewInMemoryRepo()
-	
+
 	// Create user
 	user := &userEntity.User{Name: "Original Name", Email: "original@example.com", Password: "pass"}
 	repo.Create(user)
-	
+
 	// Update user
 	updatedUser := &userEntity.User{Name: "Updated Name", Email: "updated@example.com", Password: "newpass"}
 	err := repo.Update(1, updatedUser)
 	assert.NoError(t, err)
-	
+
 	// Verify update
 	found, err := repo.GetByID(1)
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Name", found.Name)
 	assert.Equal(t, "updated@example.com", found.Email)
-	
+
 	// Test update non-existent user
 	err = repo.Update(999, updatedUser)
 	assert.Error(t, err)
@@ -86,22 +89,109 @@ func TestInMemoryRepo_Update(t *testing.T) {
 
 func TestInMemoryRepo_Delete(t *testing.T) {
 	repo := NewInMemoryRepo()
-	
+
 	// Create user
 	user := &userEntity.User{Name: "Test User", Email: "test@example.com", Password: "password"}
 	repo.Create(user)
-	
+
 	// Verify user exists
 	found, err := repo.GetByID(1)
 	assert.NoError(t, err)
 	assert.NotNil(t, found)
-	
+
 	// Delete user
 	err = repo.Delete(1)
 	assert.NoError(t, err)
-	
+
 	// Verify user is deleted
 	found, err = repo.GetByID(1)
 	assert.Error(t, err)
 	assert.Nil(t, found)
-} 
\ No newline at end of file
+}
+
+func TestInMemoryRepo_SetEmailVerified(t *testing.T) {
+	testRepoEmailVerification(t, NewInMemoryRepo())
+}
+
+// testRepoEmailVerification is shared with the postgres tests.
+func testRepoEmailVerification(t *testing.T, repo Repository) {
+	t.Helper()
+	user := &userEntity.User{Name: "Test User", Email: "test@example.com", Password: "password"}
+	require.NoError(t, repo.Create(user))
+	require.NoError(t, repo.SetEmailVerified(user.ID))
+
+	found, err := repo.GetByEmail("test@example.com")
+	require.NoError(t, err)
+	assert.True(t, found.EmailVerified)
+
+	renamed := &userEntity.User{Name: "Renamed", Email: "test@example.com", Password: "password"}
+	require.NoError(t, repo.Update(user.ID, renamed))
+	assert.True(t, renamed.EmailVerified, "updates keep the verification")
+
+	moved := &userEntity.User{Name: "Renamed", Email: "new@example.com", Password: "password"}
+	require.NoError(t, repo.Update(user.ID, moved))
+	found, err = repo.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.False(t, found.EmailVerified, "a new email must be verified again")
+
+	assert.EqualError(t, repo.SetEmailVerified(999), "user not found")
+}
+
+func TestInMemoryRepo_SetRole(t *testing.T) {
+	testRepoRoles(t, NewInMemoryRepo())
+}
+
+// testRepoRoles is shared with the postgres tests.
+func testRepoRoles(t *testing.T, repo Repository) {
+	t.Helper()
+	count, err := repo.CountByRole(userEntity.RoleAdmin)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	user := &userEntity.User{Name: "Test User", Email: "test@example.com", Password: "password"}
+	require.NoError(t, repo.Create(user))
+	assert.Equal(t, userEntity.RoleUser, user.Role, "users start with the user role")
+	require.NoError(t, repo.Create(&userEntity.User{Name: "Admin", Email: "admin@example.com", Password: "password", Role: userEntity.RoleAdmin}))
+	count, err = repo.CountByRole(userEntity.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, repo.SetRole(user.ID, userEntity.RoleAdmin))
+	found, err := repo.GetByEmail("test@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, userEntity.RoleAdmin, found.Role)
+
+	renamed := &userEntity.User{Name: "Renamed", Email: "test@example.com", Password: "password"}
+	require.NoError(t, repo.Update(user.ID, renamed))
+	assert.Equal(t, userEntity.RoleAdmin, renamed.Role, "updates keep the role")
+	found, err = repo.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, userEntity.RoleAdmin, found.Role)
+
+	assert.EqualError(t, repo.SetRole(999, userEntity.RoleAdmin), "user not found")
+}
+
+func TestInMemoryRepo_Register(t *testing.T) {
+	testRepoRegister(t, NewInMemoryRepo())
+}
+
+// testRepoRegister is shared with the postgres tests.
+func testRepoRegister(t *testing.T, repo Repository) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for index := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, repo.Register(&userEntity.User{Name: "User", Email: fmt.Sprintf("user%d@example.com", index), Password: "password"}))
+		}()
+	}
+	wg.Wait()
+
+	admins, err := repo.CountByRole(userEntity.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, 1, admins, "concurrent signups get a single admin")
+	users, err := repo.CountByRole(userEntity.RoleUser)
+	require.NoError(t, err)
+	assert.Equal(t, 4, users)
+}
@@ -17,17 +17,47 @@ func NewPostgresRepo(db *sql.DB) Repository {
 
 func (r *postgresRepo) Create(u *userEntity.User) error {
 	const query = `
-		INSERT INTO users (name, email, password)
-		VALUES ($1, $2, $3)
+		INSERT INTO users (name, email, password, role)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id
 	`
 
-	return r.db.QueryRow(query, u.Name, u.Email, u.Password).Scan(&u.ID)
+	if u.Role == "" {
+		u.Role = userEntity.RoleUser
+	}
+	return r.db.QueryRow(query, u.Name, u.Email, u.Password, u.Role).Scan(&u.ID)
+}
+
+func (r *postgresRepo) Register(u *userEntity.User) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The lock serializes signups, so only one of them finds no users
+	if _, err := tx.Exec(`LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users)`).Scan(&exists); err != nil {
+		return err
+	}
+	u.Role = userEntity.RoleAdmin
+	if exists {
+		u.Role = userEntity.RoleUser
+	}
+	err = tx.QueryRow(`INSERT INTO users (name, email, password, role) VALUES ($1, $2, $3, $4) RETURNING id`,
+		u.Name, u.Email, u.Password, u.Role).Scan(&u.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *postgresRepo) GetAll() ([]*userEntity.User, error) {
 	const query = `
-		SELECT id, name, email, password
+		SELECT id, name, email, password, email_verified, role
 		FROM users
 		ORDER BY id
 	`
@@ -41,7 +71,7 @@ func (r *postgresRepo) GetAll() ([]*userEntity.User, error) {
 	users := make([]*userEntity.User, 0)
 	for rows.Next() {
 		u := &userEntity.User{}
-		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Password); err != nil {
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.EmailVerified, &u.Role); err != nil {
 			return nil, err
 		}
 		users = append(users, u)
@@ -56,13 +86,13 @@ func (r *postgresRepo) GetAll() ([]*userEntity.User, error) {
 
 func (r *postgresRepo) GetByID(id int64) (*userEntity.User, error) {
 	const query = `
-		SELECT id, name, email, password
+		SELECT id, name, email, password, email_verified, role
 		FROM users
 		WHERE id = $1
 	`
 
 	u := &userEntity.User{}
-	err := r.db.QueryRow(query, id).Scan(&u.ID, &u.Name, &u.Email, &u.Password)
+	err := r.db.QueryRow(query, id).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.EmailVerified, &u.Role)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, errors.New("user not found")
 	}
@@ -75,13 +105,13 @@ func (r *postgresRepo) GetByID(id int64) (*userEntity.User, error) {
 
 func (r *postgresRepo) GetByEmail(email string) (*userEntity.User, error) {
 	const query = `
-		SELECT id, name, email, password
+		SELECT id, name, email, password, email_verified, role
 		FROM users
 		WHERE email = $1
 	`
 
 	u := &userEntity.User{}
-	err := r.db.QueryRow(query, email).Scan(&u.ID, &u.Name, &u.Email, &u.Password)
+	err := r.db.QueryRow(query, email).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.EmailVerified, &u.Role)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, errors.New("user not found")
 	}
@@ -95,11 +125,27 @@ func (r *postgresRepo) GetByEmail(email string) (*userEntity.User, error) {
 func (r *postgresRepo) Update(id int64, u *userEntity.User) error {
 	const query = `
 		UPDATE users
-		SET name = $1, email = $2, password = $3
+		SET name = $1, email = $2, password = $3, email_verified = email_verified AND email = $2
 		WHERE id = $4
+		RETURNING email_verified, role
 	`
 
-	result, err := r.db.Exec(query, u.Name, u.Email, u.Password, id)
+	err := r.db.QueryRow(query, u.Name, u.Email, u.Password, id).Scan(&u.EmailVerified, &u.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("user not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	u.ID = id
+	return nil
+}
+
+func (r *postgresRepo) SetEmailVerified(id int64) error {
+	const query = `UPDATE users SET email_verified = TRUE WHERE id = $1`
+
+	result, err := r.db.Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -112,10 +158,34 @@ func (r *postgresRepo) Update(id int64, u *userEntity.User) error {
 		return errors.New("user not found")
 	}
 
-	u.ID = id
 	return nil
 }
 
+func (r *postgresRepo) SetRole(id int64, role string) error {
+	const query = `UPDATE users SET role = $1 WHERE id = $2`
+
+	result, err := r.db.Exec(query, role, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+func (r *postgresRepo) CountByRole(role string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM users WHERE role = $1`, role).Scan(&count)
+	return count, err
+}
+
 func (r *postgresRepo) Delete(id int64) error {
 	const query = `DELETE FROM users WHERE id = $1`
 	_, err := r.db.Exec(query, id)
@@ -151,3 +151,15 @@ func TestPostgresRepo_Delete(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, found)
 }
+
+func TestPostgresRepo_SetEmailVerified(t *testing.T) {
+	testRepoEmailVerification(t, setupPostgresRepo(t))
+}
+
+func TestPostgresRepo_SetRole(t *testing.T) {
+	testRepoRoles(t, setupPostgresRepo(t))
+}
+
+func TestPostgresRepo_Register(t *testing.T) {
+	testRepoRegister(t, setupPostgresRepo(t))
+}
@@ -4,9 +4,19 @@ import "github.com/josofm/liliana/internal/entity/user"
 
 type Repository interface {
 	Create(u *user.User) error
+	// Register creates a user who signed up. The first user becomes an admin
+	// and every later one a user; concurrent signups get one admin at most.
+	Register(u *user.User) error
 	GetAll() ([]*user.User, error)
 	GetByID(id int64) (*user.User, error)
 	GetByEmail(email string) (*user.User, error)
+	// Update keeps the role, and EmailVerified unless the email changes.
 	Update(id int64, u *user.User) error
+	// SetEmailVerified marks the email of a user as verified.
+	SetEmailVerified(id int64) error
+	// SetRole changes the role of a user.
+	SetRole(id int64, role string) error
+	// CountByRole returns how many users have role.
+	CountByRole(role string) (int, error)
 	Delete(id int64) error
 }
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/josofm/liliana/pkg/mailer"
+)
+
+// accountTokenBytes é o tamanho da parte aleatória dos tokens de conta
+const accountTokenBytes = 32
+
+var (
+	// ErrInvalidToken é retornado para tokens de conta desconhecidos, usados ou expirados
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrEmailAlreadyVerified é retornado ao pedir a verificação de um email já verificado
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+)
+
+// ForgotPassword envia um link de redefinição de senha. Emails desconhecidos
+// não geram erro, para não revelar quem tem conta.
+func (s *Service) ForgotPassword(ctx context.Context, email string) error {
+	u, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		return nil
+	}
+	token, err := s.issueToken(u.ID, auth.PurposePasswordReset, s.account.PasswordResetExpiry)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Redefinição de senha",
+		Body: fmt.Sprintf("Olá, %s.\n\nPara escolher uma nova senha, use o link abaixo em até %s:\n\n%s\n\nSe você não pediu a redefinição, ignore este email.",
+			u.Name, s.account.PasswordResetExpiry, s.accountLink("reset-password", token)),
+	})
+}
+
+// ResetPassword troca a senha do dono do token, encerra as sessões abertas,
+// desfaz o bloqueio de login do email e invalida os demais tokens de
+// redefinição dele.
+func (s *Service) ResetPassword(token, password string) error {
+	consumed, err := s.consumeToken(auth.PurposePasswordReset, token)
+	if err != nil {
+		return err
+	}
+	u, err := s.userRepo.GetByID(consumed.UserID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	hashedPassword, err := s.jwtService.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	updated := *u
+	updated.Password = hashedPassword
+	if err := s.userRepo.Update(u.ID, &updated); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if err := s.LogoutAll(u.ID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	// Quem provou ter acesso ao email não continua bloqueado pelas falhas
+	if err := s.loginAttempts.Reset(s.loginKeys(u.Email, "")[0].key); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return s.tokens.DeleteByUser(u.ID, auth.PurposePasswordReset)
+}
+
+// SendVerification envia um link de verificação para o email do usuário,
+// invalidando os links enviados antes.
+func (s *Service) SendVerification(ctx context.Context, userID int64) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	token, err := s.issueToken(u.ID, auth.PurposeEmailVerification, s.account.EmailVerificationExpiry)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Confirme seu email",
+		Body: fmt.Sprintf("Olá, %s.\n\nPara confirmar seu email, use o link abaixo em até %s:\n\n%s",
+			u.Name, s.account.EmailVerificationExpiry, s.accountLink("verify-email", token)),
+	})
+}
+
+// VerifyEmail marca como verificado o email do dono do token
+func (s *Service) VerifyEmail(token string) error {
+	consumed, err := s.consumeToken(auth.PurposeEmailVerification, token)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.SetEmailVerified(consumed.UserID); err != nil {
+		return ErrInvalidToken
+	}
+	return s.tokens.DeleteByUser(consumed.UserID, auth.PurposeEmailVerification)
+}
+
+// issueToken substitui os tokens do usuário para purpose por um novo
+func (s *Service) issueToken(userID int64, purpose string, expiry time.Duration) (string, error) {
+	token, err := s.jwtService.GenerateRandomString(accountTokenBytes)
+	if err != nil {
+		return "", err
+	}
+	if err := s.tokens.DeleteByUser(userID, purpose); err != nil {
+		return "", err
+	}
+	err = s.tokens.Create(&auth.AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: s.jwtService.timeProvider.Now().Add(expiry),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *Service) consumeToken(purpose, token string) (*auth.AccountToken, error) {
+	consumed, err := s.tokens.Consume(purpose, hashToken(token), s.jwtService.timeProvider.Now())
+	if err != nil {
+		if err.Error() == "token not found" {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	return consumed, nil
+}
+
+// accountLink monta o link do front-end para o token, ou devolve só o token
+// quando não há LinkBaseURL
+func (s *Service) accountLink(path, token string) string {
+	if s.account.LinkBaseURL == "" {
+		return token
+	}
+	return strings.TrimRight(s.account.LinkBaseURL, "/") + "/" + path + "?token=" + url.QueryEscape(token)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/josofm/liliana/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMailer guarda as mensagens enviadas
+type recordingMailer struct {
+	messages []mailer.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, message mailer.Message) error {
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// lastToken extrai o token do link da última mensagem
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	require.NotEmpty(t, m.messages)
+	body := m.messages[len(m.messages)-1].Body
+	_, after, found := strings.Cut(body, "?token=")
+	require.True(t, found, body)
+	escaped, _, _ := strings.Cut(after, "\n")
+	token, err := url.QueryUnescape(escaped)
+	require.NoError(t, err)
+	return token
+}
+
+func setupAccountService(t *testing.T) (*Service, *recordingMailer, *MockTimeProvider) {
+	t.Helper()
+	mockTime := NewMockTimeProvider()
+	jwtService := NewJWTServiceWithTimeProvider(JWTConfig{SecretKey: "test-secret", AccessExpiry: 15 * time.Minute, RefreshExpiry: 24 * time.Hour}, mockTime)
+	m := &recordingMailer{}
+	service := NewServiceWithDependencies(newMockUserRepo(), jwtService, NewInMemoryStores(), m, AccountConfig{LinkBaseURL: "https://liliana.example.com/"})
+	_, err := service.Register(&auth.RegisterRequest{Name: "Liliana", Email: "liliana@example.com", Password: "password123"})
+	require.NoError(t, err)
+	return service, m, mockTime
+}
+
+func TestService_VerifyEmail(t *testing.T) {
+	service, m, _ := setupAccountService(t)
+	require.Len(t, m.messages, 1, "registration sends the verification email")
+	assert.Equal(t, "liliana@example.com", m.messages[0].To)
+	assert.Contains(t, m.messages[0].Body, "https://liliana.example.com/verify-email?token=")
+	first := m.lastToken(t)
+
+	require.NoError(t, service.SendVerification(context.Background(), 1))
+	second := m.lastToken(t)
+	assert.ErrorIs(t, service.VerifyEmail(first), ErrInvalidToken, "a new email replaces the old link")
+
+	require.NoError(t, service.VerifyEmail(second))
+	u, err := service.GetUserByID(1)
+	require.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+	assert.ErrorIs(t, service.VerifyEmail(second), ErrInvalidToken, "tokens are single use")
+	assert.ErrorIs(t, service.SendVerification(context.Background(), 1), ErrEmailAlreadyVerified)
+
+	response, err := service.Login(&auth.LoginRequest{Email: "liliana@example.com", Password: "password123"})
+	require.NoError(t, err)
+	assert.True(t, response.User.EmailVerified)
+}
+
+func TestService_UpdateProfile_EmailChangeDropsTokens(t *testing.T) {
+	service, m, _ := setupAccountService(t)
+	verification := m.lastToken(t)
+	require.NoError(t, service.ForgotPassword(context.Background(), "liliana@example.com"))
+	reset := m.lastToken(t)
+	session, err := service.Login(&auth.LoginRequest{Email: "liliana@example.com", Password: "password123"})
+	require.NoError(t, err)
+	claims, err := service.ValidateToken(session.AccessToken)
+	require.NoError(t, err)
+
+	_, err = service.UpdateProfile(1, claims.SessionID, &auth.UpdateProfileRequest{Name: "Liliana", Email: "vess@example.com", CurrentPassword: "password123"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.VerifyEmail(verification), ErrInvalidToken, "links sent to the old email cannot verify the new one")
+	assert.ErrorIs(t, service.ResetPassword(reset, "new-password"), ErrInvalidToken)
+	u, err := service.GetUserByID(1)
+	require.NoError(t, err)
+	assert.False(t, u.EmailVerified)
+}
+
+func TestService_ResetPassword(t *testing.T) {
+	service, m, mockTime := setupAccountService(t)
+	session, err := service.Login(&auth.LoginRequest{Email: "liliana@example.com", Password: "password123"})
+	require.NoError(t, err)
+
+	require.NoError(t, service.ForgotPassword(context.Background(), "unknown@example.com"))
+	assert.Len(t, m.messages, 1, "unknown emails are silently ignored")
+
+	require.NoError(t, service.ForgotPassword(context.Background(), "liliana@example.com"))
+	token := m.lastToken(t)
+	assert.Contains(t, m.messages[1].Body, "/reset-password?token=")
+	assert.ErrorIs(t, service.VerifyEmail(token), ErrInvalidToken, "reset tokens do not verify emails")
+
+	require.NoError(t, service.ResetPassword(token, "new-password"))
+	_, err = service.RefreshToken(session.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken, "resetting the password ends the sessions")
+	_, err = service.Login(&auth.LoginRequest{Email: "liliana@example.com", Password: "password123"})
+	assert.Error(t, err)
+	_, err = service.Login(&auth.LoginRequest{Email: "liliana@example.com", Password: "new-password"})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, service.ResetPassword(token, "other-password"), ErrInvalidToken)
+
+	require.NoError(t, service.ForgotPassword(context.Background(), "liliana@example.com"))
+	expired := m.lastToken(t)
+	mockTime.Advance(time.Hour)
+	assert.ErrorIs(t, service.ResetPassword(expired, "other-password"), ErrInvalidToken)
+}
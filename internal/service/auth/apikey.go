@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+const (
+	// apiKeyPrefix identifica as chaves de API, inclusive no header Authorization
+	apiKeyPrefix = "lil_"
+	// apiKeyBytes é o tamanho da parte aleatória das chaves
+	apiKeyBytes = 32
+	// apiKeyVisibleChars é quantos caracteres da chave aparecem na listagem
+	apiKeyVisibleChars = 8
+)
+
+// ErrInvalidAPIKey é retornado para chaves de API desconhecidas ou revogadas
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// IsAPIKey diz se a credencial tem o formato de uma chave de API, e não de um JWT
+func (s *Service) IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
+
+// CreateAPIKey cria uma chave de API para o usuário. A chave completa só é
+// devolvida aqui; depois disso o servidor guarda apenas o hash.
+func (s *Service) CreateAPIKey(userID int64, req *auth.CreateAPIKeyRequest) (*auth.CreateAPIKeyResponse, error) {
+	random := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+	apiKey := &auth.APIKey{
+		UserID:  userID,
+		Name:    strings.TrimSpace(req.Name),
+		Prefix:  key[:len(apiKeyPrefix)+apiKeyVisibleChars],
+		KeyHash: hashToken(key),
+		Scopes:  slices.Compact(scopes),
+	}
+	if err := s.apiKeys.Create(apiKey); err != nil {
+		return nil, fmt.Errorf("failed to store api key: %w", err)
+	}
+	return &auth.CreateAPIKeyResponse{APIKey: apiKey, Key: key}, nil
+}
+
+// ListAPIKeys lista as chaves do usuário, sem a parte secreta
+func (s *Service) ListAPIKeys(userID int64) ([]*auth.APIKey, error) {
+	return s.apiKeys.ListByUser(userID)
+}
+
+// RevokeAPIKey apaga uma chave do usuário
+func (s *Service) RevokeAPIKey(userID, id int64) error {
+	return s.apiKeys.Delete(userID, id)
+}
+
+// AuthenticateAPIKey valida a chave e monta as claims do dono, com o papel
+// atual dele. Também registra o último uso da chave.
+func (s *Service) AuthenticateAPIKey(key string) (*auth.Claims, *auth.APIKey, error) {
+	if !s.IsAPIKey(key) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	apiKey, err := s.apiKeys.GetByHash(hashToken(key))
+	if err != nil {
+		if err.Error() == "api key not found" {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+	u, err := s.userRepo.GetByID(apiKey.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	// O último uso é informativo; uma falha aqui não barra a requisição
+	_ = s.apiKeys.Touch(apiKey.ID, s.jwtService.timeProvider.Now())
+
+	return &auth.Claims{
+		UserID:           u.ID,
+		Email:            u.Email,
+		Role:             u.Role,
+		Type:             auth.TokenTypeAPIKey,
+		RegisteredClaims: jwt.RegisteredClaims{ID: apiKey.Prefix},
+	}, apiKey, nil
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_APIKeys(t *testing.T) {
+	service, registered := setupSessionService(t)
+	userID := registered.User.ID
+
+	created, err := service.CreateAPIKey(userID, &auth.CreateAPIKeyRequest{Name: " Discord bot ", Scopes: []string{auth.ScopeDecksWrite, auth.ScopeDecksRead, auth.ScopeDecksRead}})
+	require.NoError(t, err)
+	assert.True(t, service.IsAPIKey(created.Key))
+	assert.False(t, service.IsAPIKey(registered.AccessToken))
+	assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+	assert.Equal(t, "Discord bot", created.Name)
+	assert.Equal(t, []string{auth.ScopeDecksRead, auth.ScopeDecksWrite}, created.Scopes)
+	assert.NotContains(t, created.KeyHash, created.Key[len(created.Prefix):], "only the hash is stored")
+
+	claims, apiKey, err := service.AuthenticateAPIKey(created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.Equal(t, "liliana@example.com", claims.Email)
+	assert.Equal(t, auth.TokenTypeAPIKey, claims.Type)
+	assert.Equal(t, created.ID, apiKey.ID)
+
+	keys, err := service.ListAPIKeys(userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt, "authenticating records the last use")
+
+	_, _, err = service.AuthenticateAPIKey("lil_unknown")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, _, err = service.AuthenticateAPIKey(registered.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	assert.EqualError(t, service.RevokeAPIKey(userID+1, created.ID), "api key not found")
+	require.NoError(t, service.RevokeAPIKey(userID, created.ID))
+	_, _, err = service.AuthenticateAPIKey(created.Key)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
@@ -4,6 +4,9 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -23,9 +26,22 @@ type DefaultTimeProvider struct{}
 func (d *DefaultTimeProvider) Now() time.Time { return time.Now() }
 func (d *DefaultTimeProvider) Unix() int64    { return time.Now().Unix() }
 
-// JWTConfig configuração do serviço JWT
+// tokenIDBytes é o tamanho do jti dos tokens
+const tokenIDBytes = 18
+
+// defaultTokenIssuer é o iss e o aud padrão dos tokens
+const defaultTokenIssuer = "liliana"
+
+// JWTConfig configuração do serviço JWT. Com ActiveKeyID, os tokens são
+// assinados pela chave assimétrica de mesmo kid; as demais Keys só verificam.
+// SecretKey assina em HS256 quando não há chave ativa e, havendo, continua
+// verificando os tokens HS256 emitidos antes da troca.
 type JWTConfig struct {
 	SecretKey     string
+	Keys          []SigningKey
+	ActiveKeyID   string
+	Issuer        string
+	Audience      string
 	AccessExpiry  time.Duration
 	RefreshExpiry time.Duration
 }
@@ -33,6 +49,10 @@ type JWTConfig struct {
 // JWTService serviço para gerenciar tokens JWT
 type JWTService struct {
 	secretKey     []byte
+	keys          map[string]SigningKey
+	activeKey     *SigningKey
+	issuer        string
+	audience      string
 	accessExpiry  time.Duration
 	refreshExpiry time.Duration
 	timeProvider  TimeProvider
@@ -40,126 +60,192 @@ type JWTService struct {
 
 // NewJWTService cria uma nova instância do serviço JWT
 func NewJWTService(config JWTConfig) *JWTService {
-	return &JWTService{
-		secretKey:     []byte(config.SecretKey),
-		accessExpiry:  config.AccessExpiry,
-		refreshExpiry: config.RefreshExpiry,
-		timeProvider:  &DefaultTimeProvider{},
-	}
+	return NewJWTServiceWithTimeProvider(config, &DefaultTimeProvider{})
 }
 
 // NewJWTServiceWithTimeProvider cria uma nova instância com time provider customizado (para testes)
 func NewJWTServiceWithTimeProvider(config JWTConfig, timeProvider TimeProvider) *JWTService {
-	return &JWTService{
+	if config.Issuer == "" {
+		config.Issuer = defaultTokenIssuer
+	}
+	if config.Audience == "" {
+		config.Audience = config.Issuer
+	}
+	s := &JWTService{
 		secretKey:     []byte(config.SecretKey),
+		keys:          make(map[string]SigningKey, len(config.Keys)),
+		issuer:        config.Issuer,
+		audience:      config.Audience,
 		accessExpiry:  config.AccessExpiry,
 		refreshExpiry: config.RefreshExpiry,
 		timeProvider:  timeProvider,
 	}
+	for _, key := range config.Keys {
+		s.keys[key.ID] = key
+	}
+	if key, ok := s.keys[config.ActiveKeyID]; ok && key.CanSign() {
+		s.activeKey = &key
+	}
+	return s
+}
+
+// GenerateTokenPair gera um par de tokens (access + refresh) de uma sessão
+// nova. Os tokens se distinguem pela claim typ, e o jti do refresh token é
+// registrado pelo chamador para poder renovar e revogar o token. O papel vai
+// no access token e é relido do usuário a cada renovação.
+func (s *JWTService) GenerateTokenPair(userID int64, email, role string) (*auth.TokenPair, error) {
+	return s.GenerateSessionTokenPair(userID, email, role, "")
 }
 
-// GenerateTokenPair gera um par de tokens (access + refresh)
-func (s *JWTService) GenerateTokenPair(userID int64, email string) (*auth.TokenPair, error) {
+// GenerateSessionTokenPair gera um par de tokens da sessão sessionID, que vai
+// na claim sid do access token. Sem sessionID, o par abre uma sessão nova,
+// identificada pelo jti do refresh token.
+func (s *JWTService) GenerateSessionTokenPair(userID int64, email, role, sessionID string) (*auth.TokenPair, error) {
 	now := s.timeProvider.Now()
-	nowUnix := s.timeProvider.Unix()
 
-	// Access token
-	accessClaims := auth.Claims{
+	// Refresh token
+	refreshTokenString, refreshID, err := s.signToken(auth.Claims{
 		UserID: userID,
 		Email:  email,
-		Exp:    now.Add(s.accessExpiry).Unix(),
-		Iat:    nowUnix,
-	}
-
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(s.secretKey)
+		Type:   auth.TokenTypeRefresh,
+	}, now, s.refreshExpiry)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign access token: %w", err)
+		return nil, fmt.Errorf("failed to sign refresh token: %w", err)
 	}
-
-	// Refresh token
-	refreshClaims := auth.Claims{
-		UserID: userID,
-		Email:  email,
-		Exp:    now.Add(s.refreshExpiry).Unix(),
-		Iat:    nowUnix,
+	if sessionID == "" {
+		sessionID = refreshID
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString(s.secretKey)
+	// Access token
+	accessTokenString, _, err := s.signToken(auth.Claims{
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		Type:      auth.TokenTypeAccess,
+		SessionID: sessionID,
+	}, now, s.accessExpiry)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign refresh token: %w", err)
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
 	}
 
 	return &auth.TokenPair{
-		AccessToken:  accessTokenString,
-		RefreshToken: refreshTokenString,
-		ExpiresAt:    now.Add(s.accessExpiry),
+		AccessToken:      accessTokenString,
+		RefreshToken:     refreshTokenString,
+		ExpiresAt:        now.Add(s.accessExpiry),
+		RefreshTokenID:   refreshID,
+		RefreshExpiresAt: now.Add(s.refreshExpiry),
 	}, nil
 }
 
-// ValidateToken valida um token JWT e retorna as claims
-func (s *JWTService) ValidateToken(tokenString string) (*auth.Claims, error) {
-	// Parse sem validação automática usando jwt.Parse
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.secretKey, nil
-	})
-
+// signToken preenche as claims registradas e assina com a chave ativa, que
+// vai no header kid. Retorna o token e o jti gerado.
+func (s *JWTService) signToken(claims auth.Claims, now time.Time, expiry time.Duration) (string, string, error) {
+	tokenID, err := s.GenerateRandomString(tokenIDBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
+		return "", "", err
+	}
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    s.issuer,
+		Subject:   strconv.FormatInt(claims.UserID, 10),
+		Audience:  jwt.ClaimStrings{s.audience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        tokenID,
 	}
 
-	// Extrair claims manualmente
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		// Converter para nossa estrutura Claims
-		userID, ok := claims["user_id"].(float64)
-		if !ok {
-			return nil, fmt.Errorf("invalid user_id claim")
-		}
-
-		email, ok := claims["email"].(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid email claim")
+	if s.activeKey == nil {
+		if len(s.secretKey) == 0 {
+			return "", "", fmt.Errorf("no signing key configured")
 		}
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secretKey)
+		return signed, tokenID, err
+	}
+	token := jwt.NewWithClaims(s.activeKey.Method, claims)
+	token.Header["kid"] = s.activeKey.ID
+	signed, err := token.SignedString(s.activeKey.PrivateKey)
+	return signed, tokenID, err
+}
 
-		exp, ok := claims["exp"].(float64)
-		if !ok {
-			return nil, fmt.Errorf("invalid exp claim")
-		}
+// ValidateToken valida a assinatura, o emissor, o público e a expiração do
+// token e retorna as claims. Tokens com kid são verificados pela chave de
+// mesmo kid e algoritmo; tokens sem kid, pelo SecretKey em HS256.
+func (s *JWTService) ValidateToken(tokenString string) (*auth.Claims, error) {
+	claims := &auth.Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, s.verificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.timeProvider.Now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
 
-		iat, ok := claims["iat"].(float64)
-		if !ok {
-			return nil, fmt.Errorf("invalid iat claim")
-		}
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sub claim")
+	}
+	claims.UserID = userID
+	return claims, nil
+}
 
-		// Validar expiração usando o timeProvider
-		now := s.timeProvider.Now()
-		if int64(exp) <= now.Unix() {
-			return nil, fmt.Errorf("token is expired")
+// verificationKey escolhe a chave do token pelo kid, exigindo o algoritmo da
+// própria chave
+func (s *JWTService) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if len(s.secretKey) == 0 || token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return s.secretKey, nil
+	}
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method != key.Method {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.PublicKey, nil
+}
 
-		return &auth.Claims{
-			UserID: int64(userID),
-			Email:  email,
-			Exp:    int64(exp),
-			Iat:    int64(iat),
-		}, nil
+// JWKS retorna as chaves públicas que verificam os tokens, a ativa e as que só
+// verificam. O SecretKey nunca é publicado.
+func (s *JWTService) JWKS() auth.JWKS {
+	jwks := auth.JWKS{Keys: []auth.JSONWebKey{}}
+	for _, id := range slices.Sorted(maps.Keys(s.keys)) {
+		jwks.Keys = append(jwks.Keys, s.keys[id].jsonWebKey())
 	}
+	return jwks
+}
 
-	return nil, fmt.Errorf("invalid token")
+// ValidateAccessToken valida um token e exige que seja um access token
+func (s *JWTService) ValidateAccessToken(tokenString string) (*auth.Claims, error) {
+	return s.validateTokenType(tokenString, auth.TokenTypeAccess)
 }
 
-// RefreshToken renova um access token usando o refresh token
-func (s *JWTService) RefreshToken(refreshTokenString string) (*auth.TokenPair, error) {
-	claims, err := s.ValidateToken(refreshTokenString)
+// ValidateRefreshToken valida um token e exige que seja um refresh token com jti
+func (s *JWTService) ValidateRefreshToken(tokenString string) (*auth.Claims, error) {
+	claims, err := s.validateTokenType(tokenString, auth.TokenTypeRefresh)
 	if err != nil {
-		return nil, fmt.Errorf("invalid refresh token: %w", err)
+		return nil, err
 	}
+	if claims.ID == "" {
+		return nil, fmt.Errorf("invalid jti claim")
+	}
+	return claims, nil
+}
 
-	return s.GenerateTokenPair(claims.UserID, claims.Email)
+func (s *JWTService) validateTokenType(tokenString, tokenType string) (*auth.Claims, error) {
+	claims, err := s.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != tokenType {
+		return nil, fmt.Errorf("expected %s token", tokenType)
+	}
+	return claims, nil
 }
 
 // HashPassword criptografa uma senha
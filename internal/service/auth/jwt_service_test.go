@@ -1,9 +1,13 @@
 package auth
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -57,7 +61,7 @@ func TestGenerateTokenPair(t *testing.T) {
 	userID := int64(123)
 	email := "test@example.com"
 
-	tokenPair, err := service.GenerateTokenPair(userID, email)
+	tokenPair, err := service.GenerateTokenPair(userID, email, "user")
 	require.NoError(t, err)
 	assert.NotNil(t, tokenPair)
 	assert.NotEmpty(t, tokenPair.AccessToken)
@@ -76,7 +80,7 @@ func TestValidateToken(t *testing.T) {
 	userID := int64(123)
 	email := "test@example.com"
 
-	tokenPair, err := service.GenerateTokenPair(userID, email)
+	tokenPair, err := service.GenerateTokenPair(userID, email, "user")
 	require.NoError(t, err)
 
 	// Validar access token
@@ -84,14 +88,23 @@ func TestValidateToken(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, userID, claims.UserID)
 	assert.Equal(t, email, claims.Email)
-	assert.True(t, claims.Exp > mockTime.Unix())
-	assert.True(t, claims.Iat <= mockTime.Unix())
+	assert.True(t, claims.ExpiresAt.After(mockTime.Now()))
+	assert.False(t, claims.IssuedAt.After(mockTime.Now()))
+	assert.Equal(t, "123", claims.Subject)
+	assert.Equal(t, "liliana", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"liliana"}, claims.Audience)
+	assert.NotEmpty(t, claims.ID, "access tokens carry a jti too")
+
+	assert.Equal(t, "access", claims.Type)
+	assert.Equal(t, "user", claims.Role)
 
 	// Validar refresh token
 	claims, err = service.ValidateToken(tokenPair.RefreshToken)
 	require.NoError(t, err)
 	assert.Equal(t, userID, claims.UserID)
 	assert.Equal(t, email, claims.Email)
+	assert.Equal(t, "refresh", claims.Type)
+	assert.Equal(t, tokenPair.RefreshTokenID, claims.ID)
 }
 
 func TestValidateToken_InvalidToken(t *testing.T) {
@@ -121,7 +134,7 @@ func TestValidateToken_ExpiredToken(t *testing.T) {
 	userID := int64(123)
 	email := "test@example.com"
 
-	tokenPair, err := service.GenerateTokenPair(userID, email)
+	tokenPair, err := service.GenerateTokenPair(userID, email, "user")
 	require.NoError(t, err)
 
 	// Token expirado deve falhar
@@ -129,51 +142,35 @@ func TestValidateToken_ExpiredToken(t *testing.T) {
 	assert.Error(t, err)
 }
 
-func TestRefreshToken(t *testing.T) {
-	mockTime := NewMockTimeProvider()
+func TestValidateTokenTypes(t *testing.T) {
 	service := NewJWTServiceWithTimeProvider(JWTConfig{
 		SecretKey:     "test-secret",
 		AccessExpiry:  15 * time.Minute,
 		RefreshExpiry: 24 * time.Hour,
-	}, mockTime)
-
-	userID := int64(123)
-	email := "test@example.com"
+	}, NewMockTimeProvider())
 
-	tokenPair, err := service.GenerateTokenPair(userID, email)
+	tokenPair, err := service.GenerateTokenPair(123, "test@example.com", "user")
 	require.NoError(t, err)
+	assert.NotEmpty(t, tokenPair.RefreshTokenID)
 
-	// Avançar o tempo para garantir timestamps diferentes
-	mockTime.Advance(1 * time.Second)
-
-	// Renovar token
-	newTokenPair, err := service.RefreshToken(tokenPair.RefreshToken)
+	// Cada token só vale para o seu tipo
+	claims, err := service.ValidateAccessToken(tokenPair.AccessToken)
 	require.NoError(t, err)
-	assert.NotNil(t, newTokenPair)
-	assert.NotEmpty(t, newTokenPair.AccessToken)
-	assert.NotEmpty(t, newTokenPair.RefreshToken)
-
-	// Verificar que os tokens são diferentes (devido ao timestamp diferente)
-	assert.NotEqual(t, tokenPair.AccessToken, newTokenPair.AccessToken)
-	assert.NotEqual(t, tokenPair.RefreshToken, newTokenPair.RefreshToken)
+	assert.Equal(t, "access", claims.Type)
+	_, err = service.ValidateAccessToken(tokenPair.RefreshToken)
+	assert.Error(t, err)
 
-	// Verificar que os novos tokens são válidos
-	claims, err := service.ValidateToken(newTokenPair.AccessToken)
+	claims, err = service.ValidateRefreshToken(tokenPair.RefreshToken)
 	require.NoError(t, err)
-	assert.Equal(t, userID, claims.UserID)
-	assert.Equal(t, email, claims.Email)
-}
-
-func TestRefreshToken_InvalidToken(t *testing.T) {
-	service := NewJWTService(JWTConfig{
-		SecretKey:     "test-secret",
-		AccessExpiry:  15 * time.Minute,
-		RefreshExpiry: 24 * time.Hour,
-	})
-
-	// Refresh token inválido
-	_, err := service.RefreshToken("invalid-refresh-token")
+	assert.Equal(t, "refresh", claims.Type)
+	assert.Equal(t, tokenPair.RefreshTokenID, claims.ID)
+	_, err = service.ValidateRefreshToken(tokenPair.AccessToken)
 	assert.Error(t, err)
+
+	// Cada par tem um jti novo
+	other, err := service.GenerateTokenPair(123, "test@example.com", "user")
+	require.NoError(t, err)
+	assert.NotEqual(t, tokenPair.RefreshTokenID, other.RefreshTokenID)
 }
 
 func TestHashPassword(t *testing.T) {
@@ -228,3 +225,111 @@ func TestGenerateRandomString(t *testing.T) {
 		assert.NotEmpty(t, randomString)
 	}
 }
+
+func TestValidateToken_IssuerAndAudience(t *testing.T) {
+	mockTime := NewMockTimeProvider()
+	config := JWTConfig{SecretKey: "test-secret", Issuer: "https://liliana.example.com", Audience: "decks", AccessExpiry: 15 * time.Minute, RefreshExpiry: time.Hour}
+	service := NewJWTServiceWithTimeProvider(config, mockTime)
+	tokenPair, err := service.GenerateTokenPair(123, "test@example.com", "user")
+	require.NoError(t, err)
+
+	claims, err := service.ValidateAccessToken(tokenPair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "https://liliana.example.com", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"decks"}, claims.Audience)
+
+	config.Audience = "other"
+	_, err = NewJWTServiceWithTimeProvider(config, mockTime).ValidateAccessToken(tokenPair.AccessToken)
+	assert.ErrorContains(t, err, "aud")
+
+	config.Audience, config.Issuer = "decks", "https://other.example.com"
+	_, err = NewJWTServiceWithTimeProvider(config, mockTime).ValidateAccessToken(tokenPair.AccessToken)
+	assert.ErrorContains(t, err, "iss")
+}
+
+func TestJWTService_KeyRotation(t *testing.T) {
+	mockTime := NewMockTimeProvider()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	oldKey := SigningKey{ID: "2029-07", Method: jwt.SigningMethodRS256, PrivateKey: rsaKey, PublicKey: rsaKey.Public()}
+	newKey := SigningKey{ID: "2030-01", Method: jwt.SigningMethodEdDSA, PrivateKey: edKey, PublicKey: edKey.Public()}
+
+	// Tokens HS256 antigos e tokens da chave RSA
+	legacy := NewJWTServiceWithTimeProvider(JWTConfig{SecretKey: "test-secret", AccessExpiry: time.Hour, RefreshExpiry: time.Hour}, mockTime)
+	legacyPair, err := legacy.GenerateTokenPair(1, "legacy@example.com", "user")
+	require.NoError(t, err)
+	before := NewJWTServiceWithTimeProvider(JWTConfig{SecretKey: "test-secret", Keys: []SigningKey{oldKey}, ActiveKeyID: "2029-07", AccessExpiry: time.Hour, RefreshExpiry: time.Hour}, mockTime)
+	oldPair, err := before.GenerateTokenPair(2, "old@example.com", "user")
+	require.NoError(t, err)
+	oldToken, _, err := jwt.NewParser().ParseUnverified(oldPair.AccessToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "RS256", oldToken.Header["alg"])
+	assert.Equal(t, "2029-07", oldToken.Header["kid"])
+
+	// A chave antiga passa a só verificar; a nova assina
+	verifyOnly := SigningKey{ID: oldKey.ID, Method: oldKey.Method, PublicKey: oldKey.PublicKey}
+	after := NewJWTServiceWithTimeProvider(JWTConfig{SecretKey: "test-secret", Keys: []SigningKey{verifyOnly, newKey}, ActiveKeyID: "2030-01", AccessExpiry: time.Hour, RefreshExpiry: time.Hour}, mockTime)
+	newPair, err := after.GenerateTokenPair(3, "new@example.com", "user")
+	require.NoError(t, err)
+	newToken, _, err := jwt.NewParser().ParseUnverified(newPair.AccessToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", newToken.Header["alg"])
+	assert.Equal(t, "2030-01", newToken.Header["kid"])
+
+	for userID, token := range map[int64]string{1: legacyPair.AccessToken, 2: oldPair.AccessToken, 3: newPair.AccessToken} {
+		claims, err := after.ValidateAccessToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, userID, claims.UserID)
+	}
+	_, err = before.ValidateAccessToken(newPair.AccessToken)
+	assert.ErrorContains(t, err, "unknown key id")
+
+	// Sem o segredo, os tokens HS256 deixam de valer
+	asymmetricOnly := NewJWTServiceWithTimeProvider(JWTConfig{Keys: []SigningKey{newKey}, ActiveKeyID: "2030-01"}, mockTime)
+	_, err = asymmetricOnly.ValidateAccessToken(legacyPair.AccessToken)
+	assert.Error(t, err)
+}
+
+func TestJWTService_RejectsForeignSignatures(t *testing.T) {
+	mockTime := NewMockTimeProvider()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key := SigningKey{ID: "k1", Method: jwt.SigningMethodEdDSA, PrivateKey: edKey, PublicKey: edKey.Public()}
+	service := NewJWTServiceWithTimeProvider(JWTConfig{SecretKey: "test-secret", Keys: []SigningKey{key}, ActiveKeyID: "k1", AccessExpiry: time.Hour}, mockTime)
+
+	claims := jwt.MapClaims{"sub": "1", "iss": "liliana", "aud": "liliana", "typ": "access", "exp": mockTime.Now().Add(time.Hour).Unix()}
+
+	// Um token HS256 que aponta para o kid de uma chave assimétrica
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "k1"
+	signed, err := forged.SignedString([]byte(edKey.Public().(ed25519.PublicKey)))
+	require.NoError(t, err)
+	_, err = service.ValidateToken(signed)
+	assert.ErrorContains(t, err, "unexpected signing method")
+
+	// Uma chave Ed25519 de fora com o mesmo kid
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	foreign := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	foreign.Header["kid"] = "k1"
+	signed, err = foreign.SignedString(otherKey)
+	require.NoError(t, err)
+	_, err = service.ValidateToken(signed)
+	assert.Error(t, err)
+
+	// Tokens sem exp não valem
+	delete(claims, "exp")
+	signed, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+	_, err = service.ValidateToken(signed)
+	assert.ErrorContains(t, err, "exp")
+}
+
+func TestJWTService_NoSigningKey(t *testing.T) {
+	service := NewJWTService(JWTConfig{AccessExpiry: time.Hour})
+
+	_, err := service.GenerateTokenPair(1, "test@example.com", "user")
+	assert.ErrorContains(t, err, "no signing key configured")
+}
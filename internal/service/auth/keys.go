@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josofm/liliana/internal/entity/auth"
+)
+
+// minRSAKeyBits é o menor tamanho aceito para chaves RSA
+const minRSAKeyBits = 2048
+
+// SigningKey é uma chave assimétrica dos tokens, identificada pelo kid.
+// Chaves sem a parte privada só verificam tokens já emitidos.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// CanSign diz se a chave tem a parte privada
+func (k SigningKey) CanSign() bool {
+	return k.PrivateKey != nil
+}
+
+// LoadSigningKeys lê as chaves de dir, uma por arquivo <kid>.pem. Chaves
+// privadas RSA (RS256) ou Ed25519 (EdDSA) assinam e verificam; chaves públicas
+// só verificam. activeKeyID, quando informado, precisa ser uma chave privada.
+func LoadSigningKeys(dir, activeKeyID string) ([]SigningKey, error) {
+	if dir == "" {
+		if activeKeyID != "" {
+			return nil, fmt.Errorf("active key %q needs a keys directory", activeKeyID)
+		}
+		return nil, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []SigningKey
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParseSigningKey(strings.TrimSuffix(filepath.Base(file), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if activeKeyID != "" {
+		i := slices.IndexFunc(keys, func(k SigningKey) bool { return k.ID == activeKeyID })
+		if i < 0 {
+			return nil, fmt.Errorf("active key %q not found in %s", activeKeyID, dir)
+		}
+		if !keys[i].CanSign() {
+			return nil, fmt.Errorf("active key %q has no private key", activeKeyID)
+		}
+	}
+	return keys, nil
+}
+
+// ParseSigningKey lê uma chave PEM: PKCS#8 ou PKCS#1 para chaves privadas,
+// PKIX ou PKCS#1 para chaves públicas
+func ParseSigningKey(kid string, data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, fmt.Errorf("no PEM data found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	key := SigningKey{ID: kid}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.PrivateKey = signer
+		parsed = signer.Public()
+	}
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSAKeyBits {
+			return SigningKey{}, fmt.Errorf("rsa keys must have at least %d bits", minRSAKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.PublicKey = public
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.PublicKey = public
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", parsed)
+	}
+	return key, nil
+}
+
+// jsonWebKey monta a chave pública publicada no JWKS
+func (k SigningKey) jsonWebKey() auth.JSONWebKey {
+	jwk := auth.JSONWebKey{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+	switch public := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKey grava a chave em dir/<kid>.pem
+func writeKey(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600))
+}
+
+func TestLoadSigningKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeKey(t, dir, "2029-07", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	writeKey(t, dir, "2030-01", "PRIVATE KEY", der)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err = x509.MarshalPKIXPublicKey(otherKey.Public())
+	require.NoError(t, err)
+	writeKey(t, dir, "partner", "PUBLIC KEY", der)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0o600))
+
+	keys, err := LoadSigningKeys(dir, "2030-01")
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	assert.Equal(t, "2029-07", keys[0].ID)
+	assert.Equal(t, jwt.SigningMethodRS256, keys[0].Method)
+	assert.True(t, keys[0].CanSign())
+	assert.Equal(t, jwt.SigningMethodEdDSA, keys[1].Method)
+	assert.Equal(t, edPublic, keys[1].PublicKey)
+	assert.False(t, keys[2].CanSign(), "public keys only verify")
+
+	_, err = LoadSigningKeys(dir, "2031-01")
+	assert.ErrorContains(t, err, "not found")
+	_, err = LoadSigningKeys(dir, "partner")
+	assert.ErrorContains(t, err, "has no private key")
+	_, err = LoadSigningKeys("", "2030-01")
+	assert.ErrorContains(t, err, "needs a keys directory")
+
+	keys, err = LoadSigningKeys("", "")
+	require.NoError(t, err)
+	assert.Empty(t, keys, "no directory keeps HS256")
+}
+
+func TestParseSigningKey_RejectsUnsupportedKeys(t *testing.T) {
+	_, err := ParseSigningKey("k", []byte("not pem"))
+	assert.ErrorContains(t, err, "no PEM data")
+
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = ParseSigningKey("k", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(smallKey)}))
+	assert.ErrorContains(t, err, "at least 2048 bits")
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+	_, err = ParseSigningKey("k", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.ErrorContains(t, err, "unsupported key type")
+
+	_, err = ParseSigningKey("k", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1}}))
+	assert.ErrorContains(t, err, "unsupported PEM block")
+}
+
+func TestJWTService_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	service := NewJWTService(JWTConfig{
+		SecretKey: "test-secret",
+		Keys: []SigningKey{
+			{ID: "rsa", Method: jwt.SigningMethodRS256, PublicKey: rsaKey.Public()},
+			{ID: "ed", Method: jwt.SigningMethodEdDSA, PrivateKey: edKey, PublicKey: edPublic},
+		},
+		ActiveKeyID: "ed",
+	})
+
+	jwks := service.JWKS()
+	require.Len(t, jwks.Keys, 2, "the secret key is never published")
+	assert.Equal(t, auth.JSONWebKey{Kty: "OKP", Kid: "ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(edPublic)}, jwks.Keys[0])
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	assert.Equal(t, "RS256", jwks.Keys[1].Alg)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+
+	// O módulo publicado reconstrói a chave
+	n, err := base64.RawURLEncoding.DecodeString(jwks.Keys[1].N)
+	require.NoError(t, err)
+	assert.Zero(t, new(big.Int).SetBytes(n).Cmp(rsaKey.N))
+
+	assert.Empty(t, NewJWTService(JWTConfig{SecretKey: "test-secret"}).JWKS().Keys)
+}
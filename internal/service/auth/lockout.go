@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/audit"
+)
+
+// LoginProtectionConfig limita as falhas de login seguidas por email e por IP.
+// Campos zerados usam os padrões de defaultLoginProtection.
+type LoginProtectionConfig struct {
+	MaxFailures     int
+	IPMaxFailures   int
+	LockoutDuration time.Duration
+	BackoffAfter    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	FailureWindow   time.Duration
+}
+
+// ErrLoginBlocked é retornado, dentro de um LoginBlockedError, quando o email
+// ou o IP precisam esperar antes de tentar de novo
+var ErrLoginBlocked = errors.New("too many failed login attempts")
+
+// LoginBlockedError diz quanto falta para a próxima tentativa. Locked indica
+// um bloqueio, e não apenas o intervalo crescente entre tentativas.
+type LoginBlockedError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginBlockedError) Error() string { return ErrLoginBlocked.Error() }
+
+func (e *LoginBlockedError) Unwrap() error { return ErrLoginBlocked }
+
+// loginKey é um contador de falhas: o email ou o IP da tentativa
+type loginKey struct {
+	key         string
+	maxFailures int
+	email       string
+	ip          string
+}
+
+func defaultLoginProtection() LoginProtectionConfig {
+	return LoginProtectionConfig{
+		MaxFailures:     5,
+		IPMaxFailures:   20,
+		LockoutDuration: 15 * time.Minute,
+		BackoffAfter:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		FailureWindow:   15 * time.Minute,
+	}
+}
+
+// ConfigureLoginProtection troca os limites de falhas de login
+func (s *Service) ConfigureLoginProtection(cfg LoginProtectionConfig) {
+	defaults := defaultLoginProtection()
+	if cfg.MaxFailures == 0 {
+		cfg.MaxFailures = defaults.MaxFailures
+	}
+	if cfg.IPMaxFailures == 0 {
+		cfg.IPMaxFailures = defaults.IPMaxFailures
+	}
+	if cfg.LockoutDuration == 0 {
+		cfg.LockoutDuration = defaults.LockoutDuration
+	}
+	if cfg.BackoffAfter == 0 {
+		cfg.BackoffAfter = defaults.BackoffAfter
+	}
+	if cfg.BaseDelay == 0 {
+		cfg.BaseDelay = defaults.BaseDelay
+	}
+	if cfg.MaxDelay == 0 {
+		cfg.MaxDelay = defaults.MaxDelay
+	}
+	if cfg.FailureWindow == 0 {
+		cfg.FailureWindow = defaults.FailureWindow
+	}
+	s.loginProtection = cfg
+}
+
+// loginKeys monta os contadores da tentativa. Sem IP conhecido, conta só o email.
+func (s *Service) loginKeys(email, ip string) []loginKey {
+	email = strings.ToLower(strings.TrimSpace(email))
+	keys := []loginKey{{key: "email:" + email, maxFailures: s.loginProtection.MaxFailures, email: email}}
+	if ip != "" {
+		keys = append(keys, loginKey{key: "ip:" + ip, maxFailures: s.loginProtection.IPMaxFailures, ip: ip})
+	}
+	return keys
+}
+
+// checkLoginAllowed recusa a tentativa enquanto algum contador estiver
+// bloqueado ou esperando o intervalo desde a última falha
+func (s *Service) checkLoginAllowed(keys []loginKey, now time.Time) error {
+	var retryAfter time.Duration
+	locked := false
+	for _, k := range keys {
+		attempt, err := s.loginAttempts.Get(k.key)
+		if err != nil {
+			if err.Error() == "login attempt not found" {
+				continue
+			}
+			return fmt.Errorf("failed to read login attempts: %w", err)
+		}
+		// O bloqueio vale até LockedUntil, mesmo que dure mais que a janela
+		if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+			locked = true
+			retryAfter = max(retryAfter, attempt.LockedUntil.Sub(now))
+			continue
+		}
+		if attempt.LastFailure.Before(now.Add(-s.loginProtection.FailureWindow)) {
+			continue
+		}
+		if next := attempt.LastFailure.Add(s.backoffDelay(attempt.Failures)); now.Before(next) {
+			retryAfter = max(retryAfter, next.Sub(now))
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginBlockedError{RetryAfter: retryAfter, Locked: locked}
+	}
+	return nil
+}
+
+// loginFailed conta a falha em cada contador e bloqueia os que chegaram ao
+// limite, registrando o bloqueio na auditoria
+func (s *Service) loginFailed(keys []loginKey, now time.Time) error {
+	for _, k := range keys {
+		attempt, err := s.loginAttempts.RecordFailure(k.key, now, now.Add(-s.loginProtection.FailureWindow))
+		if err != nil {
+			return fmt.Errorf("failed to record login attempt: %w", err)
+		}
+		if attempt.Failures < k.maxFailures {
+			continue
+		}
+		if err := s.loginAttempts.Lock(k.key, now.Add(s.loginProtection.LockoutDuration)); err != nil {
+			return fmt.Errorf("failed to lock login: %w", err)
+		}
+		err = s.audit.Create(&audit.Entry{
+			Event:  audit.EventLoginLocked,
+			Email:  k.email,
+			IP:     k.ip,
+			Detail: fmt.Sprintf("%d failed login attempts, locked for %s", attempt.Failures, s.loginProtection.LockoutDuration),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record audit entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// backoffDelay é o intervalo exigido depois de failures falhas: nenhum até
+// BackoffAfter, depois BaseDelay dobrando a cada falha até MaxDelay
+func (s *Service) backoffDelay(failures int) time.Duration {
+	if failures < s.loginProtection.BackoffAfter {
+		return 0
+	}
+	delay := s.loginProtection.BaseDelay
+	for i := s.loginProtection.BackoffAfter; i < failures && delay < s.loginProtection.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, s.loginProtection.MaxDelay)
+}
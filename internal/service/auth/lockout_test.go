@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/audit"
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLockoutService(t *testing.T) (*Service, Stores, *recordingMailer, *MockTimeProvider) {
+	t.Helper()
+	mockTime := NewMockTimeProvider()
+	jwtService := NewJWTServiceWithTimeProvider(JWTConfig{SecretKey: "test-secret", AccessExpiry: 15 * time.Minute, RefreshExpiry: 24 * time.Hour}, mockTime)
+	stores := NewInMemoryStores()
+	m := &recordingMailer{}
+	service := NewServiceWithDependencies(newMockUserRepo(), jwtService, stores, m, AccountConfig{LinkBaseURL: "https://liliana.example.com"})
+	service.ConfigureLoginProtection(LoginProtectionConfig{
+		MaxFailures:     4,
+		IPMaxFailures:   6,
+		LockoutDuration: 10 * time.Minute,
+		BackoffAfter:    2,
+		BaseDelay:       time.Second,
+		MaxDelay:        3 * time.Second,
+		FailureWindow:   15 * time.Minute,
+	})
+	_, err := service.Register(&auth.RegisterRequest{Name: "Liliana", Email: "liliana@example.com", Password: "password123"})
+	require.NoError(t, err)
+	return service, stores, m, mockTime
+}
+
+func login(service *Service, email, password, ip string) error {
+	_, err := service.Login(&auth.LoginRequest{Email: email, Password: password, IP: ip})
+	return err
+}
+
+func TestService_LoginBackoffAndLockout(t *testing.T) {
+	service, stores, _, mockTime := setupLockoutService(t)
+
+	assert.EqualError(t, login(service, "liliana@example.com", "wrong-password", "203.0.113.7"), "invalid credentials")
+	assert.EqualError(t, login(service, "liliana@example.com", "wrong-password", "203.0.113.7"), "invalid credentials")
+
+	// A partir da segunda falha, cada tentativa espera um intervalo crescente
+	var blocked *LoginBlockedError
+	err := login(service, "liliana@example.com", "password123", "203.0.113.7")
+	require.ErrorAs(t, err, &blocked)
+	assert.ErrorIs(t, err, ErrLoginBlocked)
+	assert.Equal(t, time.Second, blocked.RetryAfter)
+	assert.False(t, blocked.Locked)
+
+	mockTime.Advance(time.Second)
+	assert.EqualError(t, login(service, "LILIANA@example.com", "wrong-password", "203.0.113.7"), "invalid credentials", "emails are counted without case")
+	require.ErrorAs(t, login(service, "liliana@example.com", "password123", "203.0.113.8"), &blocked, "the email is throttled from any address")
+	assert.Equal(t, 2*time.Second, blocked.RetryAfter)
+
+	mockTime.Advance(2 * time.Second)
+	assert.EqualError(t, login(service, "liliana@example.com", "wrong-password", "203.0.113.7"), "invalid credentials")
+	require.ErrorAs(t, login(service, "liliana@example.com", "password123", "203.0.113.7"), &blocked)
+	assert.True(t, blocked.Locked)
+	assert.Equal(t, 10*time.Minute, blocked.RetryAfter)
+
+	entries, err := stores.Audit.List(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, audit.EventLoginLocked, entries[0].Event)
+	assert.Equal(t, "liliana@example.com", entries[0].Email)
+	assert.Contains(t, entries[0].Detail, "4 failed login attempts")
+
+	mockTime.Advance(10 * time.Minute)
+	require.NoError(t, login(service, "liliana@example.com", "password123", "203.0.113.7"), "the lockout expires")
+	assert.NoError(t, login(service, "liliana@example.com", "password123", "203.0.113.7"), "a successful login resets the email")
+}
+
+func TestService_LoginLocksAddresses(t *testing.T) {
+	service, stores, _, mockTime := setupLockoutService(t)
+
+	// Cada email tem poucas falhas, mas o IP passa do limite dele
+	for i := range 6 {
+		email := []string{"a@example.com", "b@example.com", "c@example.com"}[i%3]
+		assert.EqualError(t, login(service, email, "wrong-password", "203.0.113.7"), "invalid credentials")
+		mockTime.Advance(5 * time.Second)
+	}
+
+	var blocked *LoginBlockedError
+	require.ErrorAs(t, login(service, "liliana@example.com", "password123", "203.0.113.7"), &blocked)
+	assert.True(t, blocked.Locked)
+	assert.NoError(t, login(service, "liliana@example.com", "password123", "198.51.100.1"), "other addresses still log in")
+
+	entries, err := stores.Audit.List(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "203.0.113.7", entries[0].IP)
+	assert.Empty(t, entries[0].Email)
+}
+
+func TestService_LockoutOutlastsFailureWindow(t *testing.T) {
+	service, _, _, mockTime := setupLockoutService(t)
+	service.ConfigureLoginProtection(LoginProtectionConfig{
+		MaxFailures:     2,
+		IPMaxFailures:   6,
+		LockoutDuration: time.Hour,
+		BackoffAfter:    10,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Second,
+		FailureWindow:   15 * time.Minute,
+	})
+	for range 2 {
+		assert.EqualError(t, login(service, "liliana@example.com", "wrong-password", ""), "invalid credentials")
+	}
+
+	// Passada a janela de falhas, o bloqueio continua até LockedUntil
+	mockTime.Advance(30 * time.Minute)
+	var blocked *LoginBlockedError
+	require.ErrorAs(t, login(service, "liliana@example.com", "password123", ""), &blocked)
+	assert.True(t, blocked.Locked)
+	assert.Equal(t, 30*time.Minute, blocked.RetryAfter)
+
+	mockTime.Advance(30 * time.Minute)
+	assert.NoError(t, login(service, "liliana@example.com", "password123", ""))
+}
+
+func TestService_ResetPasswordUnlocksLogin(t *testing.T) {
+	service, _, m, mockTime := setupLockoutService(t)
+	for range 4 {
+		assert.EqualError(t, login(service, "liliana@example.com", "wrong-password", ""), "invalid credentials")
+		mockTime.Advance(5 * time.Second)
+	}
+	assert.ErrorIs(t, login(service, "liliana@example.com", "password123", ""), ErrLoginBlocked)
+
+	require.NoError(t, service.ForgotPassword(context.Background(), "liliana@example.com"))
+	require.NoError(t, service.ResetPassword(m.lastToken(t), "new-password"))
+	assert.NoError(t, login(service, "liliana@example.com", "new-password", ""))
+}
+
+func TestService_BackoffDelay(t *testing.T) {
+	service := NewService(newMockUserRepo(), NewJWTService(JWTConfig{SecretKey: "test-secret"}))
+
+	assert.Zero(t, service.backoffDelay(2))
+	assert.Equal(t, time.Second, service.backoffDelay(3))
+	assert.Equal(t, 4*time.Second, service.backoffDelay(5))
+	assert.Equal(t, 30*time.Second, service.backoffDelay(100), "delays stop growing at MaxDelay")
+}
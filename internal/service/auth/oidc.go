@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/josofm/liliana/internal/entity/user"
+	"github.com/josofm/liliana/pkg/oidc"
+)
+
+// oidcNameMaxLength acompanha o limite de nome do cadastro
+const oidcNameMaxLength = 50
+
+var (
+	// ErrOIDCDisabled é retornado quando não há provedor OIDC configurado
+	ErrOIDCDisabled = errors.New("oidc login is not enabled")
+	// ErrInvalidOIDCLogin é retornado para states desconhecidos, usados ou expirados
+	ErrInvalidOIDCLogin = errors.New("invalid or expired oidc login")
+	// ErrOIDCAuthentication é retornado quando a troca do código ou o ID token falham
+	ErrOIDCAuthentication = errors.New("oidc authentication failed")
+	// ErrOIDCEmailRequired é retornado quando o provedor não envia o email
+	ErrOIDCEmailRequired = errors.New("oidc provider did not return an email")
+	// ErrOIDCEmailNotVerified é retornado quando o email já tem conta aqui e o
+	// provedor não o verificou; vincular a conta nesse caso permitiria tomá-la
+	ErrOIDCEmailNotVerified = errors.New("email already exists and is not verified by the provider")
+)
+
+// EnableOIDC habilita o login OIDC com o provedor do client. loginExpiry é o
+// tempo que o usuário tem para voltar do provedor.
+func (s *Service) EnableOIDC(client *oidc.Client, loginExpiry time.Duration) {
+	if loginExpiry == 0 {
+		loginExpiry = 10 * time.Minute
+	}
+	s.oidc = client
+	s.oidcExpiry = loginExpiry
+}
+
+// OIDCAuthURL inicia um login OIDC e devolve o endereço do provedor. O state,
+// o nonce e o code verifier (PKCE) ficam guardados até o callback.
+func (s *Service) OIDCAuthURL(ctx context.Context) (string, error) {
+	if s.oidc == nil {
+		return "", ErrOIDCDisabled
+	}
+	state, err := oidc.NewState()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+
+	now := s.jwtService.timeProvider.Now()
+	if err := s.identities.DeleteExpiredLogins(now); err != nil {
+		return "", fmt.Errorf("failed to delete expired logins: %w", err)
+	}
+	err = s.identities.CreateLogin(&auth.OIDCLogin{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(s.oidcExpiry),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store login: %w", err)
+	}
+	return s.oidc.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier))
+}
+
+// OIDCCallback conclui o login: troca o código, valida o ID token e emite o
+// par de tokens do usuário vinculado à conta do provedor, criando-o no
+// primeiro acesso.
+func (s *Service) OIDCCallback(ctx context.Context, code, state string) (*auth.AuthResponse, error) {
+	if s.oidc == nil {
+		return nil, ErrOIDCDisabled
+	}
+	login, err := s.identities.ConsumeLogin(state, s.jwtService.timeProvider.Now())
+	if err != nil {
+		if err.Error() == "login not found" {
+			return nil, ErrInvalidOIDCLogin
+		}
+		return nil, err
+	}
+
+	token, err := s.oidc.Exchange(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCAuthentication, err)
+	}
+	idToken, err := s.oidc.VerifyIDToken(ctx, token.IDToken, login.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCAuthentication, err)
+	}
+
+	u, err := s.oidcUser(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(u)
+}
+
+// oidcUser encontra o usuário da conta do provedor. Contas novas são
+// vinculadas ao usuário com o mesmo email, quando o provedor o verificou, ou
+// a um usuário novo.
+func (s *Service) oidcUser(ctx context.Context, idToken *oidc.IDToken) (*user.User, error) {
+	identity, err := s.identities.Get(idToken.Issuer, idToken.Subject)
+	if err == nil {
+		u, err := s.userRepo.GetByID(identity.UserID)
+		if err == nil {
+			return u, nil
+		}
+		// O usuário foi removido; o vínculo antigo dá lugar a um novo
+		if err := s.identities.DeleteByUser(identity.UserID); err != nil {
+			return nil, fmt.Errorf("failed to unlink identity: %w", err)
+		}
+	} else if err.Error() != "identity not found" {
+		return nil, err
+	}
+
+	if idToken.Email == "" {
+		return nil, ErrOIDCEmailRequired
+	}
+	u, err := s.userRepo.GetByEmail(idToken.Email)
+	switch {
+	case err == nil && !idToken.EmailVerified:
+		return nil, ErrOIDCEmailNotVerified
+	case err == nil:
+		if !u.EmailVerified {
+			if err := s.userRepo.SetEmailVerified(u.ID); err != nil {
+				return nil, err
+			}
+			u.EmailVerified = true
+		}
+	case err.Error() != "user not found":
+		return nil, err
+	default:
+		u, err = s.createOIDCUser(ctx, idToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.identities.Create(&auth.Identity{
+		UserID:  u.ID,
+		Issuer:  idToken.Issuer,
+		Subject: idToken.Subject,
+		Email:   idToken.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+	return u, nil
+}
+
+// createOIDCUser cadastra o usuário com uma senha aleatória: ele entra pelo
+// provedor e, se quiser uma senha, usa a redefinição de senha
+func (s *Service) createOIDCUser(ctx context.Context, idToken *oidc.IDToken) (*user.User, error) {
+	password, err := s.jwtService.GenerateRandomString(accountTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.jwtService.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	newUser := &user.User{
+		Name:     oidcUserName(idToken),
+		Email:    idToken.Email,
+		Password: hashedPassword,
+	}
+	if err := s.userRepo.Register(newUser); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if !idToken.EmailVerified {
+		_ = s.SendVerification(ctx, newUser.ID)
+		return newUser, nil
+	}
+	if err := s.userRepo.SetEmailVerified(newUser.ID); err != nil {
+		return nil, err
+	}
+	newUser.EmailVerified = true
+	return newUser, nil
+}
+
+// oidcUserName usa o nome enviado pelo provedor ou, sem ele, o início do email
+func oidcUserName(idToken *oidc.IDToken) string {
+	name := strings.TrimSpace(idToken.Name)
+	if len([]rune(name)) < 2 {
+		name, _, _ = strings.Cut(idToken.Email, "@")
+	}
+	if runes := []rune(name); len(runes) > oidcNameMaxLength {
+		name = string(runes[:oidcNameMaxLength])
+	}
+	return name
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/josofm/liliana/internal/entity/user"
+	"github.com/josofm/liliana/pkg/oidc"
+	"github.com/josofm/liliana/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupOIDCService(t *testing.T) (*Service, *oidctest.Provider, *recordingMailer, *MockTimeProvider) {
+	t.Helper()
+	provider := oidctest.NewProvider("liliana", "client-secret")
+	t.Cleanup(provider.Close)
+
+	mockTime := NewMockTimeProvider()
+	jwtService := NewJWTServiceWithTimeProvider(JWTConfig{SecretKey: "test-secret", AccessExpiry: 15 * time.Minute, RefreshExpiry: 24 * time.Hour}, mockTime)
+	m := &recordingMailer{}
+	service := NewServiceWithDependencies(newMockUserRepo(), jwtService, NewInMemoryStores(), m, AccountConfig{})
+	service.EnableOIDC(oidc.New(oidc.Config{
+		IssuerURL:    provider.Issuer(),
+		ClientID:     "liliana",
+		ClientSecret: "client-secret",
+		RedirectURL:  "https://liliana.example.com/auth/oidc/callback",
+	}), 10*time.Minute)
+	return service, provider, m, mockTime
+}
+
+// oidcLogin passa pelo provedor e devolve o code e o state do callback
+func oidcLogin(t *testing.T, service *Service, provider *oidctest.Provider) (string, string) {
+	t.Helper()
+	authURL, err := service.OIDCAuthURL(context.Background())
+	require.NoError(t, err)
+	callback, err := provider.Authorize(authURL)
+	require.NoError(t, err)
+	return callback.Query().Get("code"), callback.Query().Get("state")
+}
+
+func TestService_OIDCCreatesAndLinksUsers(t *testing.T) {
+	service, provider, m, _ := setupOIDCService(t)
+	provider.SetIdentity(oidctest.Identity{Subject: "abc", Email: "liliana@example.com", EmailVerified: true, Name: "Liliana Vess"})
+
+	code, state := oidcLogin(t, service, provider)
+	response, err := service.OIDCCallback(context.Background(), code, state)
+	require.NoError(t, err)
+	assert.NotEmpty(t, response.AccessToken)
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.Equal(t, "Liliana Vess", response.User.Name)
+	assert.True(t, response.User.EmailVerified)
+	assert.Equal(t, user.RoleAdmin, response.User.Role, "the first user is an admin")
+	assert.Empty(t, m.messages, "verified emails need no confirmation")
+
+	_, err = service.OIDCCallback(context.Background(), code, state)
+	assert.ErrorIs(t, err, ErrInvalidOIDCLogin, "states are single use")
+
+	// O mesmo subject entra na mesma conta, mesmo com outro email
+	provider.SetIdentity(oidctest.Identity{Subject: "abc", Email: "vess@example.com", EmailVerified: true})
+	code, state = oidcLogin(t, service, provider)
+	again, err := service.OIDCCallback(context.Background(), code, state)
+	require.NoError(t, err)
+	assert.Equal(t, response.User.ID, again.User.ID)
+
+	claims, err := service.ValidateToken(again.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, response.User.ID, claims.UserID)
+
+	_, err = service.Login(&auth.LoginRequest{Email: "liliana@example.com", Password: ""})
+	assert.Error(t, err, "oidc users have no known password")
+}
+
+func TestService_OIDCLinksExistingUsers(t *testing.T) {
+	service, provider, m, _ := setupOIDCService(t)
+	registered, err := service.Register(&auth.RegisterRequest{Name: "Liliana", Email: "liliana@example.com", Password: "password123"})
+	require.NoError(t, err)
+	require.Len(t, m.messages, 1)
+
+	provider.SetIdentity(oidctest.Identity{Subject: "abc", Email: "liliana@example.com", EmailVerified: false})
+	code, state := oidcLogin(t, service, provider)
+	_, err = service.OIDCCallback(context.Background(), code, state)
+	assert.ErrorIs(t, err, ErrOIDCEmailNotVerified, "unverified emails do not take over accounts")
+
+	provider.SetIdentity(oidctest.Identity{Subject: "abc", Email: "liliana@example.com", EmailVerified: true})
+	code, state = oidcLogin(t, service, provider)
+	response, err := service.OIDCCallback(context.Background(), code, state)
+	require.NoError(t, err)
+	assert.Equal(t, registered.User.ID, response.User.ID)
+	assert.True(t, response.User.EmailVerified)
+
+	_, err = service.Login(&auth.LoginRequest{Email: "liliana@example.com", Password: "password123"})
+	assert.NoError(t, err, "the password keeps working")
+
+	// Contas novas com email não verificado recebem o email de confirmação
+	provider.SetIdentity(oidctest.Identity{Subject: "def", Email: "gideon@example.com", Name: "G"})
+	code, state = oidcLogin(t, service, provider)
+	created, err := service.OIDCCallback(context.Background(), code, state)
+	require.NoError(t, err)
+	assert.Equal(t, "gideon", created.User.Name, "short names fall back to the email")
+	assert.Equal(t, user.RoleUser, created.User.Role)
+	assert.False(t, created.User.EmailVerified)
+	assert.Len(t, m.messages, 2)
+
+	require.NoError(t, service.DeleteAccount(created.User.ID))
+	code, state = oidcLogin(t, service, provider)
+	recreated, err := service.OIDCCallback(context.Background(), code, state)
+	require.NoError(t, err)
+	assert.NotEqual(t, created.User.ID, recreated.User.ID, "deleted accounts are not restored")
+}
+
+func TestService_OIDCRejectsInvalidCallbacks(t *testing.T) {
+	service, provider, _, mockTime := setupOIDCService(t)
+
+	_, err := service.OIDCCallback(context.Background(), "code", "unknown")
+	assert.ErrorIs(t, err, ErrInvalidOIDCLogin)
+
+	code, state := oidcLogin(t, service, provider)
+	_, err = service.OIDCCallback(context.Background(), "wrong-code", state)
+	assert.ErrorIs(t, err, ErrOIDCAuthentication)
+	_, err = service.OIDCCallback(context.Background(), code, state)
+	assert.ErrorIs(t, err, ErrInvalidOIDCLogin, "a failed callback uses up the state")
+
+	code, state = oidcLogin(t, service, provider)
+	mockTime.Advance(11 * time.Minute)
+	_, err = service.OIDCCallback(context.Background(), code, state)
+	assert.ErrorIs(t, err, ErrInvalidOIDCLogin, "logins expire")
+
+	provider.SetIdentity(oidctest.Identity{Subject: "abc"})
+	code, state = oidcLogin(t, service, provider)
+	_, err = service.OIDCCallback(context.Background(), code, state)
+	assert.ErrorIs(t, err, ErrOIDCEmailRequired)
+}
+
+func TestService_OIDCDisabled(t *testing.T) {
+	service, _, _ := setupAccountService(t)
+
+	_, err := service.OIDCAuthURL(context.Background())
+	assert.ErrorIs(t, err, ErrOIDCDisabled)
+	_, err = service.OIDCCallback(context.Background(), "code", "state")
+	assert.ErrorIs(t, err, ErrOIDCDisabled)
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/josofm/liliana/internal/entity/user"
+	userService "github.com/josofm/liliana/internal/service/user"
+)
+
+// ErrWrongCurrentPassword é retornado quando a troca de senha ou de email
+// não traz a senha atual correta
+var ErrWrongCurrentPassword = errors.New("current password is incorrect")
+
+// UpdateProfile altera nome, email e, quando enviada, a senha do usuário.
+// Trocar a senha ou o email exige a senha atual e encerra as outras sessões
+// do usuário, mantendo a sessionID de quem fez a troca. Trocar o email exige
+// verificá-lo de novo.
+func (s *Service) UpdateProfile(userID int64, sessionID string, req *auth.UpdateProfileRequest) (*user.User, error) {
+	current, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing, err := s.userRepo.GetByEmail(req.Email); err == nil && existing.ID != userID {
+		return nil, fmt.Errorf("email already exists")
+	}
+	credentialsChanged := req.Password != "" || req.Email != current.Email
+	if credentialsChanged && !s.jwtService.CheckPassword(req.CurrentPassword, current.Password) {
+		return nil, ErrWrongCurrentPassword
+	}
+
+	updated := *current
+	updated.Name = req.Name
+	updated.Email = req.Email
+	if req.Password != "" {
+		hashedPassword, err := s.jwtService.HashPassword(req.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		updated.Password = hashedPassword
+	}
+	if err := s.userRepo.Update(userID, &updated); err != nil {
+		return nil, err
+	}
+	// Links enviados ao email antigo não valem para o novo
+	if req.Email != current.Email {
+		for _, purpose := range []string{auth.PurposeEmailVerification, auth.PurposePasswordReset} {
+			if err := s.tokens.DeleteByUser(userID, purpose); err != nil {
+				return nil, fmt.Errorf("failed to revoke tokens: %w", err)
+			}
+		}
+	}
+	if credentialsChanged {
+		if err := s.LogoutOthers(userID, sessionID); err != nil {
+			return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+	return &updated, nil
+}
+
+// DeleteAccount remove o usuário, encerra as sessões dele e desfaz os
+// vínculos com provedores OIDC. O último admin não pode se excluir.
+func (s *Service) DeleteAccount(userID int64) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := userService.RequireAnotherAdmin(s.userRepo, u); err != nil {
+		return err
+	}
+	if err := s.LogoutAll(userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := s.identities.DeleteByUser(userID); err != nil {
+		return fmt.Errorf("failed to unlink identities: %w", err)
+	}
+	return s.userRepo.Delete(userID)
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/josofm/liliana/internal/entity/user"
+	userService "github.com/josofm/liliana/internal/service/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister_FirstUserIsAdmin(t *testing.T) {
+	service, registered := setupSessionService(t)
+	assert.Equal(t, user.RoleAdmin, registered.User.Role)
+	claims, err := service.ValidateToken(registered.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.RoleAdmin, claims.Role)
+
+	other, err := service.Register(&auth.RegisterRequest{Name: "Jace", Email: "jace@example.com", Password: "password123"})
+	require.NoError(t, err)
+	assert.Equal(t, user.RoleUser, other.User.Role)
+}
+
+func TestService_UpdateProfile(t *testing.T) {
+	service, registered := setupSessionService(t)
+	_, err := service.Register(&auth.RegisterRequest{Name: "Jace", Email: "jace@example.com", Password: "password123"})
+	require.NoError(t, err)
+	userID := registered.User.ID
+	claims, err := service.ValidateToken(registered.AccessToken)
+	require.NoError(t, err)
+	sessionID := claims.SessionID
+	require.NotEmpty(t, sessionID)
+
+	_, err = service.UpdateProfile(userID, sessionID, &auth.UpdateProfileRequest{Name: "Liliana", Email: "jace@example.com", CurrentPassword: "password123"})
+	assert.EqualError(t, err, "email already exists")
+
+	updated, err := service.UpdateProfile(userID, sessionID, &auth.UpdateProfileRequest{Name: "Liliana Vess", Email: "liliana@example.com"})
+	require.NoError(t, err, "the name changes without the current password")
+	assert.Equal(t, "Liliana Vess", updated.Name)
+	assert.Equal(t, user.RoleAdmin, updated.Role, "users cannot change their own role")
+	phone, err := service.Login(&auth.LoginRequest{Email: "liliana@example.com", Password: "password123"})
+	assert.NoError(t, err, "the password is kept when omitted")
+
+	_, err = service.UpdateProfile(userID, sessionID, &auth.UpdateProfileRequest{Name: "Liliana Vess", Email: "vess@example.com"})
+	assert.ErrorIs(t, err, ErrWrongCurrentPassword, "changing the email needs the current password")
+	_, err = service.UpdateProfile(userID, sessionID, &auth.UpdateProfileRequest{Name: "Liliana Vess", Email: "liliana@example.com", Password: "new-password", CurrentPassword: "wrong"})
+	assert.ErrorIs(t, err, ErrWrongCurrentPassword)
+
+	_, err = service.UpdateProfile(userID, sessionID, &auth.UpdateProfileRequest{Name: "Liliana Vess", Email: "vess@example.com", Password: "new-password", CurrentPassword: "password123"})
+	require.NoError(t, err)
+	_, err = service.Login(&auth.LoginRequest{Email: "vess@example.com", Password: "new-password"})
+	assert.NoError(t, err)
+
+	_, err = service.RefreshToken(phone.RefreshToken)
+	assert.Error(t, err, "other sessions are revoked")
+	rotated, err := service.RefreshToken(registered.RefreshToken)
+	require.NoError(t, err, "the session that made the change is kept")
+	claims, err = service.ValidateToken(rotated.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, sessionID, claims.SessionID, "renewed tokens keep their session")
+}
+
+func TestService_DeleteAccount(t *testing.T) {
+	service, admin := setupSessionService(t)
+	registered, err := service.Register(&auth.RegisterRequest{Name: "Jace", Email: "jace@example.com", Password: "password123"})
+	require.NoError(t, err)
+
+	require.NoError(t, service.DeleteAccount(registered.User.ID))
+	_, err = service.GetUserByID(registered.User.ID)
+	assert.Error(t, err)
+	_, err = service.RefreshToken(registered.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.EqualError(t, service.DeleteAccount(registered.User.ID), "user not found")
+
+	assert.ErrorIs(t, service.DeleteAccount(admin.User.ID), userService.ErrLastAdmin, "the last admin cannot delete their account")
+	_, err = service.GetUserByID(admin.User.ID)
+	assert.NoError(t, err)
+	_, err = service.RefreshToken(admin.RefreshToken)
+	assert.NoError(t, err, "a refused deletion keeps the sessions")
+}
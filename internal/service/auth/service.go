@@ -1,22 +1,94 @@
 package auth
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/josofm/liliana/internal/entity/auth"
 	"github.com/josofm/liliana/internal/entity/user"
+	apiKeyRepo "github.com/josofm/liliana/internal/repository/apikey"
+	auditRepo "github.com/josofm/liliana/internal/repository/audit"
+	identityRepo "github.com/josofm/liliana/internal/repository/identity"
+	loginAttemptRepo "github.com/josofm/liliana/internal/repository/loginattempt"
+	refreshTokenRepo "github.com/josofm/liliana/internal/repository/refreshtoken"
+	tokenRepo "github.com/josofm/liliana/internal/repository/token"
 	userRepo "github.com/josofm/liliana/internal/repository/user"
+	"github.com/josofm/liliana/pkg/mailer"
+	"github.com/josofm/liliana/pkg/oidc"
 )
 
+// AccountConfig configura os tokens de redefinição de senha e de verificação de email
+type AccountConfig struct {
+	LinkBaseURL             string
+	PasswordResetExpiry     time.Duration
+	EmailVerificationExpiry time.Duration
+}
+
+// Stores agrupa os repositórios de credenciais do serviço, os contadores de
+// falhas de login e a auditoria
+type Stores struct {
+	AccountTokens tokenRepo.Repository
+	RefreshTokens refreshTokenRepo.Repository
+	APIKeys       apiKeyRepo.Repository
+	Identities    identityRepo.Repository
+	LoginAttempts loginAttemptRepo.Repository
+	Audit         auditRepo.Repository
+}
+
+// NewInMemoryStores cria os repositórios de credenciais em memória
+func NewInMemoryStores() Stores {
+	return Stores{
+		AccountTokens: tokenRepo.NewInMemoryRepo(),
+		RefreshTokens: refreshTokenRepo.NewInMemoryRepo(),
+		APIKeys:       apiKeyRepo.NewInMemoryRepo(),
+		Identities:    identityRepo.NewInMemoryRepo(),
+		LoginAttempts: loginAttemptRepo.NewInMemoryRepo(),
+		Audit:         auditRepo.NewInMemoryRepo(),
+	}
+}
+
 type Service struct {
-	userRepo   userRepo.Repository
-	jwtService *JWTService
+	userRepo      userRepo.Repository
+	jwtService    *JWTService
+	tokens        tokenRepo.Repository
+	refreshTokens refreshTokenRepo.Repository
+	apiKeys       apiKeyRepo.Repository
+	identities    identityRepo.Repository
+	loginAttempts loginAttemptRepo.Repository
+	audit         auditRepo.Repository
+	mailer        mailer.Mailer
+	account       AccountConfig
+	oidc          *oidc.Client
+	oidcExpiry    time.Duration
+	// loginProtection limita as falhas de login; veja ConfigureLoginProtection
+	loginProtection LoginProtectionConfig
 }
 
+// NewService guarda as credenciais em memória e descarta os emails
 func NewService(userRepo userRepo.Repository, jwtService *JWTService) *Service {
+	return NewServiceWithDependencies(userRepo, jwtService, NewInMemoryStores(), mailer.Discard, AccountConfig{})
+}
+
+func NewServiceWithDependencies(userRepo userRepo.Repository, jwtService *JWTService, stores Stores, m mailer.Mailer, account AccountConfig) *Service {
+	if account.PasswordResetExpiry == 0 {
+		account.PasswordResetExpiry = time.Hour
+	}
+	if account.EmailVerificationExpiry == 0 {
+		account.EmailVerificationExpiry = 48 * time.Hour
+	}
 	return &Service{
-		userRepo:   userRepo,
-		jwtService: jwtService,
+		userRepo:        userRepo,
+		jwtService:      jwtService,
+		tokens:          stores.AccountTokens,
+		refreshTokens:   stores.RefreshTokens,
+		apiKeys:         stores.APIKeys,
+		identities:      stores.Identities,
+		loginAttempts:   stores.LoginAttempts,
+		audit:           stores.Audit,
+		mailer:          m,
+		account:         account,
+		loginProtection: defaultLoginProtection(),
 	}
 }
 
@@ -34,105 +106,65 @@ func (s *Service) Register(req *auth.RegisterRequest) (*auth.AuthResponse, error
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	// Criar usuário
+	// Criar usuário; o primeiro cadastro vira admin, para a instalação nova
+	// ter quem gerencie os demais usuários
 	newUser := &user.User{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: hashedPassword,
 	}
 
-	err = s.userRepo.Create(newUser)
+	err = s.userRepo.Register(newUser)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// Gerar tokens
-	tokenPair, err := s.jwtService.GenerateTokenPair(newUser.ID, newUser.Email)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate tokens: %w", err)
-	}
+	// Uma falha no envio não desfaz o cadastro: o usuário pode pedir outro
+	// email de verificação em POST /me/verification
+	_ = s.SendVerification(context.Background(), newUser.ID)
 
-	return &auth.AuthResponse{
-		AccessToken:  tokenPair.AccessToken,
-		RefreshToken: tokenPair.RefreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    int64(tokenPair.ExpiresAt.Unix()),
-		User: auth.User{
-			ID:    newUser.ID,
-			Name:  newUser.Name,
-			Email: newUser.Email,
-		},
-	}, nil
+	// Gerar tokens
+	return s.issueTokens(newUser)
 }
 
-// Login autentica um usuário existente
+// Login autentica um usuário existente. Falhas seguidas do mesmo email ou IP
+// passam a exigir intervalos crescentes e, no limite, bloqueiam novas
+// tentativas com um LoginBlockedError.
 func (s *Service) Login(req *auth.LoginRequest) (*auth.AuthResponse, error) {
-	// Buscar usuário por email
-	user, err := s.userRepo.GetByEmail(req.Email)
-	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+	now := s.jwtService.timeProvider.Now()
+	keys := s.loginKeys(req.Email, req.IP)
+	if err := s.checkLoginAllowed(keys, now); err != nil {
+		return nil, err
 	}
 
-	// Verificar senha
-	if !s.jwtService.CheckPassword(req.Password, user.Password) {
+	// Buscar usuário por email e verificar senha
+	user, err := s.userRepo.GetByEmail(req.Email)
+	if err != nil || !s.jwtService.CheckPassword(req.Password, user.Password) {
+		if err := s.loginFailed(keys, now); err != nil {
+			return nil, err
+		}
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
-	// Gerar tokens
-	tokenPair, err := s.jwtService.GenerateTokenPair(user.ID, user.Email)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+	// O acerto zera as falhas do email; as do IP continuam, para uma conta
+	// própria não liberar tentativas contra as demais
+	if err := s.loginAttempts.Reset(keys[0].key); err != nil {
+		return nil, fmt.Errorf("failed to reset login attempts: %w", err)
 	}
 
-	return &auth.AuthResponse{
-		AccessToken:  tokenPair.AccessToken,
-		RefreshToken: tokenPair.RefreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    int64(tokenPair.ExpiresAt.Unix()),
-		User: auth.User{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-		},
-	}, nil
+	// Gerar tokens
+	return s.issueTokens(user)
 }
 
-// RefreshToken renova um access token usando o refresh token
-func (s *Service) RefreshToken(refreshToken string) (*auth.AuthResponse, error) {
-	// Validar refresh token
-	claims, err := s.jwtService.ValidateToken(refreshToken)
-	if err != nil {
-		return nil, fmt.Errorf("invalid refresh token: %w", err)
-	}
-
-	// Buscar usuário
-	user, err := s.userRepo.GetByID(claims.UserID)
-	if err != nil {
-		return nil, fmt.Errorf("user not found")
-	}
-
-	// Gerar novos tokens
-	tokenPair, err := s.jwtService.GenerateTokenPair(user.ID, user.Email)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate tokens: %w", err)
-	}
-
-	return &auth.AuthResponse{
-		AccessToken:  tokenPair.AccessToken,
-		RefreshToken: tokenPair.RefreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    int64(tokenPair.ExpiresAt.Unix()),
-		User: auth.User{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-		},
-	}, nil
+// ValidateToken valida um access token e retorna as claims. Refresh tokens
+// são recusados, para não servirem como bearer token.
+func (s *Service) ValidateToken(tokenString string) (*auth.Claims, error) {
+	return s.jwtService.ValidateAccessToken(tokenString)
 }
 
-// ValidateToken valida um access token e retorna as claims
-func (s *Service) ValidateToken(tokenString string) (*auth.Claims, error) {
-	return s.jwtService.ValidateToken(tokenString)
+// JWKS retorna as chaves públicas que verificam os tokens emitidos
+func (s *Service) JWKS() auth.JWKS {
+	return s.jwtService.JWKS()
 }
 
 // GetUserByID busca um usuário por ID
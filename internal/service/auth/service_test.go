@@ -26,6 +26,9 @@ func newMockUserRepo() *mockUserRepo {
 
 func (m *mockUserRepo) Create(u *userEntity.User) error {
 	u.ID = m.nextID
+	if u.Role == "" {
+		u.Role = userEntity.RoleUser
+	}
 	m.users[u.ID] = u
 	m.nextID++
 	return nil
@@ -60,10 +63,47 @@ func (m *mockUserRepo) Update(id int64, u *userEntity.User) error {
 		return errors.New("user not found")
 	}
 	u.ID = id
+	u.Role = m.users[id].Role
 	m.users[id] = u
 	return nil
 }
 
+func (m *mockUserRepo) SetEmailVerified(id int64) error {
+	u, exists := m.users[id]
+	if !exists {
+		return errors.New("user not found")
+	}
+	u.EmailVerified = true
+	return nil
+}
+
+func (m *mockUserRepo) SetRole(id int64, role string) error {
+	u, exists := m.users[id]
+	if !exists {
+		return errors.New("user not found")
+	}
+	u.Role = role
+	return nil
+}
+
+func (m *mockUserRepo) Register(u *userEntity.User) error {
+	u.Role = userEntity.RoleUser
+	if len(m.users) == 0 {
+		u.Role = userEntity.RoleAdmin
+	}
+	return m.Create(u)
+}
+
+func (m *mockUserRepo) CountByRole(role string) (int, error) {
+	count := 0
+	for _, u := range m.users {
+		if u.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
+
 func (m *mockUserRepo) Delete(id int64) error {
 	if _, exists := m.users[id]; !exists {
 		return errors.New("user not found")
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/josofm/liliana/internal/entity/user"
+)
+
+var (
+	// ErrInvalidRefreshToken é retornado para refresh tokens inválidos, expirados,
+	// desconhecidos ou revogados
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused é retornado quando um refresh token já renovado é
+	// usado de novo; a sessão inteira é revogada, pois o token pode ter vazado
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// RefreshToken troca o refresh token por um novo par. O token usado é revogado
+// e o novo fica na mesma família; usar de novo um token já renovado revoga a
+// família inteira.
+func (s *Service) RefreshToken(refreshToken string) (*auth.AuthResponse, error) {
+	stored, err := s.storedRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if stored.RevokedAt != nil {
+		return nil, s.revokeReusedFamily(stored)
+	}
+
+	// Buscar usuário
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	tokenPair, err := s.jwtService.GenerateSessionTokenPair(user.ID, user.Email, user.Role, stored.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+	}
+	next := &auth.RefreshToken{
+		ID:        tokenPair.RefreshTokenID,
+		UserID:    user.ID,
+		FamilyID:  stored.FamilyID,
+		ExpiresAt: tokenPair.RefreshExpiresAt,
+	}
+	if err := s.refreshTokens.Rotate(stored.ID, next, s.jwtService.timeProvider.Now()); err != nil {
+		switch err.Error() {
+		case "refresh token revoked":
+			// Outra requisição renovou o mesmo token antes desta
+			return nil, s.revokeReusedFamily(stored)
+		case "refresh token not found":
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	return authResponse(user, tokenPair), nil
+}
+
+// Logout revoga a sessão do refresh token. Sessões já encerradas não geram erro.
+func (s *Service) Logout(refreshToken string) error {
+	stored, err := s.storedRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+	return s.refreshTokens.RevokeFamily(stored.FamilyID, s.jwtService.timeProvider.Now())
+}
+
+// LogoutAll revoga todos os refresh tokens do usuário. Os access tokens já
+// emitidos continuam válidos até expirarem.
+func (s *Service) LogoutAll(userID int64) error {
+	return s.refreshTokens.RevokeByUser(userID, s.jwtService.timeProvider.Now())
+}
+
+// LogoutOthers revoga as sessões do usuário, menos a sessionID. Sem
+// sessionID, revoga todas.
+func (s *Service) LogoutOthers(userID int64, sessionID string) error {
+	if sessionID == "" {
+		return s.LogoutAll(userID)
+	}
+	return s.refreshTokens.RevokeOtherFamilies(userID, sessionID, s.jwtService.timeProvider.Now())
+}
+
+// issueTokens gera um par de tokens e registra o refresh token como o
+// primeiro de uma nova sessão
+func (s *Service) issueTokens(u *user.User) (*auth.AuthResponse, error) {
+	tokenPair, err := s.jwtService.GenerateTokenPair(u.ID, u.Email, u.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+	}
+	err = s.refreshTokens.Create(&auth.RefreshToken{
+		ID:        tokenPair.RefreshTokenID,
+		UserID:    u.ID,
+		FamilyID:  tokenPair.RefreshTokenID,
+		ExpiresAt: tokenPair.RefreshExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return authResponse(u, tokenPair), nil
+}
+
+// storedRefreshToken valida o JWT e busca o registro do refresh token
+func (s *Service) storedRefreshToken(refreshToken string) (*auth.RefreshToken, error) {
+	claims, err := s.jwtService.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	stored, err := s.refreshTokens.Get(claims.ID)
+	if err != nil {
+		if err.Error() == "refresh token not found" {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if stored.UserID != claims.UserID {
+		return nil, ErrInvalidRefreshToken
+	}
+	return stored, nil
+}
+
+// revokeReusedFamily revoga a família de um token já revogado. Tokens
+// revogados por logout só são recusados.
+func (s *Service) revokeReusedFamily(stored *auth.RefreshToken) error {
+	if stored.ReplacedBy == "" {
+		return ErrInvalidRefreshToken
+	}
+	if err := s.refreshTokens.RevokeFamily(stored.FamilyID, s.jwtService.timeProvider.Now()); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
+
+func authResponse(u *user.User, tokenPair *auth.TokenPair) *auth.AuthResponse {
+	return &auth.AuthResponse{
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tokenPair.ExpiresAt.Unix()),
+		User: auth.User{
+			ID:            u.ID,
+			Name:          u.Name,
+			Email:         u.Email,
+			EmailVerified: u.EmailVerified,
+			Role:          u.Role,
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSessionService(t *testing.T) (*Service, *auth.AuthResponse) {
+	t.Helper()
+	jwtService := NewJWTServiceWithTimeProvider(JWTConfig{SecretKey: "test-secret", AccessExpiry: 15 * time.Minute, RefreshExpiry: 24 * time.Hour}, NewMockTimeProvider())
+	service := NewService(newMockUserRepo(), jwtService)
+	response, err := service.Register(&auth.RegisterRequest{Name: "Liliana", Email: "liliana@example.com", Password: "password123"})
+	require.NoError(t, err)
+	return service, response
+}
+
+func TestService_RefreshTokenRotation(t *testing.T) {
+	service, registered := setupSessionService(t)
+
+	_, err := service.RefreshToken(registered.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken, "access tokens cannot be refreshed")
+
+	rotated, err := service.RefreshToken(registered.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, registered.RefreshToken, rotated.RefreshToken)
+
+	// Reusar o token antigo revoga a sessão inteira, inclusive o token novo
+	_, err = service.RefreshToken(registered.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, err = service.RefreshToken(rotated.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	// Outras sessões continuam válidas
+	login, err := service.Login(&auth.LoginRequest{Email: "liliana@example.com", Password: "password123"})
+	require.NoError(t, err)
+	_, err = service.RefreshToken(login.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestService_Logout(t *testing.T) {
+	service, registered := setupSessionService(t)
+	login, err := service.Login(&auth.LoginRequest{Email: "liliana@example.com", Password: "password123"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, service.Logout("invalid-token"), ErrInvalidRefreshToken)
+	require.NoError(t, service.Logout(registered.RefreshToken))
+	require.NoError(t, service.Logout(registered.RefreshToken), "logging out twice is fine")
+	_, err = service.RefreshToken(registered.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	refreshed, err := service.RefreshToken(login.RefreshToken)
+	require.NoError(t, err, "logout only ends its own session")
+
+	require.NoError(t, service.LogoutAll(registered.User.ID))
+	_, err = service.RefreshToken(refreshed.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
@@ -0,0 +1,98 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/josofm/liliana/internal/entity/collection"
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	r "github.com/josofm/liliana/internal/repository/collection"
+	deckService "github.com/josofm/liliana/internal/service/deck"
+)
+
+// ErrInvalidCondition is returned for a condition outside collection.Conditions.
+var ErrInvalidCondition = fmt.Errorf("condition must be one of %s", strings.Join(collection.Conditions, ", "))
+
+// Service manages the cards owned by each user. Cards are checked and
+// enriched by the same CardValidator used for decks.
+type Service struct {
+	repo      r.Repository
+	validator deckService.CardValidator
+}
+
+func NewService(repo r.Repository, validator deckService.CardValidator) *Service {
+	return &Service{repo: repo, validator: validator}
+}
+
+func (s *Service) List(userID int64) ([]collection.Item, error) {
+	return s.repo.List(userID)
+}
+
+func (s *Service) GetByID(userID, id int64) (*collection.Item, error) {
+	return s.repo.GetByID(userID, id)
+}
+
+// Add records cards, read by deckService.ParseCardList, with the same foil
+// flag and condition. Boards are ignored.
+func (s *Service) Add(userID int64, cards []deckEntity.Card, foil bool, condition string) ([]collection.Item, error) {
+	rows := make([]deckService.CSVCard, len(cards))
+	for index, card := range cards {
+		rows[index] = deckService.CSVCard{Card: card, Foil: foil, Condition: condition}
+	}
+	return s.Import(userID, rows)
+}
+
+// Import records the rows of a collection CSV, read by deckService.ParseCardCSV.
+func (s *Service) Import(userID int64, rows []deckService.CSVCard) ([]collection.Item, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("card list cannot be empty")
+	}
+	cards := make([]deckEntity.Card, len(rows))
+	conditions := make([]string, len(rows))
+	for index, row := range rows {
+		condition, ok := collection.NormalizeCondition(row.Condition)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", row.Name, ErrInvalidCondition)
+		}
+		conditions[index] = condition
+		cards[index] = deckEntity.Card{Name: row.Name, Quantity: row.Quantity, Board: deckEntity.BoardMain}
+	}
+	cards, err := s.validator.Validate(cards)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]collection.Item, len(cards))
+	for index, card := range cards {
+		items[index] = collection.Item{
+			UserID:        userID,
+			OracleID:      card.OracleID,
+			Name:          card.Name,
+			Quantity:      card.Quantity,
+			Foil:          rows[index].Foil,
+			Condition:     conditions[index],
+			ManaCost:      card.ManaCost,
+			TypeLine:      card.TypeLine,
+			ColorIdentity: card.ColorIdentity,
+			ImageURI:      card.ImageURI,
+		}
+	}
+	return s.repo.Add(userID, items)
+}
+
+// Update changes the quantity, foil flag and condition of an item, merging it
+// into another item that already has the new foil flag and condition.
+func (s *Service) Update(userID, id int64, quantity int, foil bool, condition string) (*collection.Item, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be greater than zero")
+	}
+	condition, ok := collection.NormalizeCondition(condition)
+	if !ok {
+		return nil, ErrInvalidCondition
+	}
+	return s.repo.Update(userID, &collection.Item{ID: id, Quantity: quantity, Foil: foil, Condition: condition})
+}
+
+func (s *Service) Delete(userID, id int64) error {
+	return s.repo.Delete(userID, id)
+}
@@ -0,0 +1,129 @@
+package collection
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/josofm/liliana/internal/entity/collection"
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	collectionRepo "github.com/josofm/liliana/internal/repository/collection"
+	deckService "github.com/josofm/liliana/internal/service/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCardValidator struct{}
+
+func (testCardValidator) ResolveCommander(name string) (deckEntity.Card, error) {
+	return deckEntity.Card{Name: name}, nil
+}
+
+func (testCardValidator) SearchCommanders(string) ([]deckService.CommanderSuggestion, error) {
+	return nil, nil
+}
+
+func (testCardValidator) Validate(cards []deckEntity.Card) ([]deckEntity.Card, error) {
+	result := make([]deckEntity.Card, len(cards))
+	for index, card := range cards {
+		if card.Name == "Invalid" {
+			return nil, errors.New("cards not found: Invalid")
+		}
+		// Canonical names, as Scryfall returns them.
+		card.Name = strings.ToUpper(card.Name[:1]) + card.Name[1:]
+		card.OracleID = "oracle-" + strings.ToLower(card.Name)
+		card.TypeLine = "Artifact"
+		result[index] = card
+	}
+	return result, nil
+}
+
+func TestService_Add(t *testing.T) {
+	service := NewService(collectionRepo.NewInMemoryRepo(), testCardValidator{})
+	cards, err := deckService.ParseCardList("2 sol ring\n1 Arcane Signet")
+	require.NoError(t, err)
+
+	items, err := service.Add(1, cards, true, "LP")
+
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "Sol ring", items[0].Name)
+	assert.Equal(t, "oracle-sol ring", items[0].OracleID)
+	assert.Equal(t, "Artifact", items[0].TypeLine)
+	assert.Equal(t, 2, items[0].Quantity)
+	assert.True(t, items[0].Foil)
+	assert.Equal(t, collection.ConditionLightlyPlayed, items[0].Condition)
+}
+
+func TestService_Import(t *testing.T) {
+	service := NewService(collectionRepo.NewInMemoryRepo(), testCardValidator{})
+	rows, err := deckService.ParseCardCSV(strings.NewReader("Count,Name,Foil,Condition\n2,Sol Ring,,Near Mint\n1,Sol Ring,,NM\n1,Sol Ring,foil,\n"))
+	require.NoError(t, err)
+
+	_, err = service.Import(1, rows)
+	require.NoError(t, err)
+
+	items, err := service.List(1)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, 3, items[0].Quantity)
+	assert.Equal(t, collection.ConditionNearMint, items[1].Condition)
+	assert.True(t, items[1].Foil)
+}
+
+func TestService_AddRejectsInvalidInput(t *testing.T) {
+	service := NewService(collectionRepo.NewInMemoryRepo(), testCardValidator{})
+
+	_, err := service.Add(1, []deckEntity.Card{{Name: "Sol Ring", Quantity: 1}}, false, "sleeved")
+	assert.ErrorIs(t, err, ErrInvalidCondition)
+
+	_, err = service.Add(1, []deckEntity.Card{{Name: "Invalid", Quantity: 1}}, false, "")
+	assert.EqualError(t, err, "cards not found: Invalid")
+
+	_, err = service.Add(1, nil, false, "")
+	assert.EqualError(t, err, "card list cannot be empty")
+
+	items, err := service.List(1)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestService_UpdateAndDelete(t *testing.T) {
+	service := NewService(collectionRepo.NewInMemoryRepo(), testCardValidator{})
+	items, err := service.Add(1, []deckEntity.Card{{Name: "Sol Ring", Quantity: 1}}, false, "")
+	require.NoError(t, err)
+	id := items[0].ID
+
+	updated, err := service.Update(1, id, 3, true, "heavily played")
+	require.NoError(t, err)
+	assert.Equal(t, 3, updated.Quantity)
+	assert.Equal(t, collection.ConditionHeavilyPlayed, updated.Condition)
+
+	_, err = service.Update(1, id, 0, false, "")
+	assert.EqualError(t, err, "quantity must be greater than zero")
+	_, err = service.Update(1, id, 1, false, "sleeved")
+	assert.ErrorIs(t, err, ErrInvalidCondition)
+
+	assert.EqualError(t, service.Delete(2, id), "collection item not found")
+	require.NoError(t, service.Delete(1, id))
+	_, err = service.GetByID(1, id)
+	assert.EqualError(t, err, "collection item not found")
+}
+
+func TestNormalizeCondition(t *testing.T) {
+	tests := map[string]string{
+		"":                      collection.ConditionNearMint,
+		"NM":                    collection.ConditionNearMint,
+		"near-mint":             collection.ConditionNearMint,
+		"Good (Lightly Played)": collection.ConditionLightlyPlayed,
+		"moderately_played":     collection.ConditionModeratelyPlayed,
+		"Poor":                  collection.ConditionDamaged,
+	}
+	for value, expected := range tests {
+		condition, ok := collection.NormalizeCondition(value)
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, condition, value)
+	}
+	_, ok := collection.NormalizeCondition("sleeved")
+	assert.False(t, ok)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+)
+
+// CardUsage tells how many of the decks userID may view play a card and
+// under which commanders.
+func (s *Service) CardUsage(ctx context.Context, oracleID string, userID int64) (*deckRepo.CardUsage, error) {
+	return s.repo.CardUsage(ctx, oracleID, userID)
+}
+
+// TopCards returns the cards played by the most decks of a format or color
+// among the decks userID may view. filter.Commander is ignored; see
+// CommanderStaples.
+func (s *Service) TopCards(ctx context.Context, userID int64, filter deckRepo.InclusionFilter) (*deckRepo.InclusionReport, error) {
+	filter.Commander = ""
+	filter.ViewerID = userID
+	return s.repo.CardInclusion(ctx, filter)
+}
+
+// CommanderStaples returns the cards played by the most decks of a
+// commander that userID may view, including the decks where it is one of
+// the partners.
+func (s *Service) CommanderStaples(ctx context.Context, userID int64, commander string, filter deckRepo.InclusionFilter) (*deckRepo.InclusionReport, error) {
+	filter.ViewerID = userID
+	filter.Commander = strings.TrimSpace(commander)
+	if filter.Commander == "" {
+		return nil, fmt.Errorf("%w: commander is required", deckRepo.ErrInvalidFilter)
+	}
+	return s.repo.CardInclusion(ctx, filter)
+}
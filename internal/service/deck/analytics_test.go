@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_CommanderStaples(t *testing.T) {
+	repo := deckRepo.NewInMemoryRepo()
+	solRing := deckEntity.Card{OracleID: "sol-ring", Name: "Sol Ring", Quantity: 1}
+	require.NoError(t, repo.Create(&deckEntity.Deck{Name: "Krenko", Color: "R", Format: "commander", Commander: "Krenko, Mob Boss", Cards: []deckEntity.Card{solRing}}))
+	require.NoError(t, repo.Create(&deckEntity.Deck{Name: "Talrand", Color: "U", Format: "commander", Commander: "Talrand, Sky Summoner"}))
+	service := NewService(repo)
+	ctx := context.Background()
+
+	report, err := service.CommanderStaples(ctx, 1, " krenko, mob boss ", deckRepo.InclusionFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Decks)
+	assert.Equal(t, []deckRepo.CardInclusion{{OracleID: "sol-ring", Name: "Sol Ring", Decks: 1, InclusionRate: 1}}, report.Cards)
+
+	_, err = service.CommanderStaples(ctx, 1, " ", deckRepo.InclusionFilter{})
+	assert.ErrorIs(t, err, deckRepo.ErrInvalidFilter)
+
+	report, err = service.TopCards(ctx, 1, deckRepo.InclusionFilter{Commander: "Krenko, Mob Boss"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Decks, "top cards are not limited to a commander")
+	assert.InDelta(t, 0.5, report.Cards[0].InclusionRate, 1e-9)
+}
+
+func TestService_AnalyticsSkipHiddenDecks(t *testing.T) {
+	repo := deckRepo.NewInMemoryRepo()
+	solRing := deckEntity.Card{OracleID: "sol-ring", Name: "Sol Ring", Quantity: 1}
+	require.NoError(t, repo.Create(&deckEntity.Deck{Name: "Krenko", Color: "R", Format: "commander", Commander: "Krenko, Mob Boss", OwnerID: 1, Cards: []deckEntity.Card{solRing}}))
+	require.NoError(t, repo.Create(&deckEntity.Deck{Name: "Secret", Color: "R", Format: "commander", Commander: "Krenko, Mob Boss", OwnerID: 2, Visibility: deckEntity.VisibilityPrivate, Cards: []deckEntity.Card{solRing}}))
+	service := NewService(repo)
+	ctx := context.Background()
+
+	usage, err := service.CardUsage(ctx, "sol-ring", 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, usage.Decks)
+	report, err := service.CommanderStaples(ctx, 3, "Krenko, Mob Boss", deckRepo.InclusionFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Decks, "private decks of other users are not counted")
+	report, err = service.TopCards(ctx, 2, deckRepo.InclusionFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Decks)
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+)
+
+// BuyListBoards are the boards compared by BuyList when none are requested,
+// and the boards counted as in use in other decks. Maybeboard cards are not
+// physically in a deck.
+var BuyListBoards = []string{deckEntity.BoardCommander, deckEntity.BoardCompanion, deckEntity.BoardMain, deckEntity.BoardSide}
+
+// BuyListOptions changes how BuyList counts the owned cards.
+type BuyListOptions struct {
+	// Boards are the deck boards to compare; empty means BuyListBoards.
+	Boards []string
+	// SubtractDecks makes copies used by the other decks of the user
+	// unavailable.
+	SubtractDecks bool
+}
+
+// BuyList holds the cards of a deck that the user does not own enough of.
+type BuyList struct {
+	DeckID  int64         `json:"deck_id"`
+	Cards   []BuyListCard `json:"cards"`
+	Missing int           `json:"missing"`
+}
+
+// BuyListCard is a short card. Copies of the card in different boards of the
+// deck are counted together.
+type BuyListCard struct {
+	OracleID string `json:"oracle_id"`
+	Name     string `json:"name"`
+	Needed   int    `json:"needed"`
+	Owned    int    `json:"owned"`
+	InUse    int    `json:"in_use"`
+	Missing  int    `json:"missing"`
+}
+
+// Text renders the missing cards as a "<quantity> <card name>" list, the
+// format read by ParseCardList.
+func (b *BuyList) Text() []byte {
+	var out strings.Builder
+	for _, card := range b.Cards {
+		fmt.Fprintf(&out, "%d %s\n", card.Missing, card.Name)
+	}
+	return []byte(out.String())
+}
+
+// BuyList compares the cards of a deck with the cards userID owns. Both
+// lists are resolved by the CardValidator, so cards match by Oracle ID no
+// matter how their names were written.
+func (s *Service) BuyList(ctx context.Context, id, userID int64, owned []deckEntity.Card, options BuyListOptions) (*BuyList, error) {
+	d, err := s.view(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	boards := options.Boards
+	if len(boards) == 0 {
+		boards = BuyListBoards
+	}
+	needed, err := FilterCards(d.Cards, boards)
+	if err != nil {
+		return nil, err
+	}
+	if len(needed) > 0 {
+		if needed, err = s.validator.Validate(needed); err != nil {
+			return nil, err
+		}
+	}
+	if len(owned) > 0 {
+		if owned, err = s.validator.Validate(owned); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := newBuyListCounts(needed)
+	for _, card := range owned {
+		if entry := counts.find(card); entry != nil {
+			entry.Owned += card.Quantity
+		}
+	}
+	if options.SubtractDecks {
+		inUse, err := s.cardsInOtherDecks(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, card := range inUse {
+			if entry := counts.find(card); entry != nil {
+				entry.InUse += card.Quantity
+			}
+		}
+	}
+
+	result := &BuyList{DeckID: id, Cards: make([]BuyListCard, 0)}
+	for _, entry := range counts.entries {
+		available := max(entry.Owned-entry.InUse, 0)
+		if entry.Needed <= available {
+			continue
+		}
+		entry.Missing = entry.Needed - available
+		result.Missing += entry.Missing
+		result.Cards = append(result.Cards, *entry)
+	}
+	sort.Slice(result.Cards, func(i, j int) bool {
+		return strings.ToLower(result.Cards[i].Name) < strings.ToLower(result.Cards[j].Name)
+	})
+	return result, nil
+}
+
+// cardsInOtherDecks returns the cards in BuyListBoards of every deck of
+// userID except exceptID.
+func (s *Service) cardsInOtherDecks(ctx context.Context, userID, exceptID int64) ([]deckEntity.Card, error) {
+	cards := make([]deckEntity.Card, 0)
+	filter := deckRepo.ListFilter{OwnerID: userID, Limit: deckRepo.MaxListLimit}
+	for {
+		page, err := s.repo.List(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range page.Decks {
+			if d.ID == exceptID {
+				continue
+			}
+			used, err := FilterCards(d.Cards, BuyListBoards)
+			if err != nil {
+				return nil, err
+			}
+			cards = append(cards, used...)
+		}
+		if page.NextCursor == "" {
+			return cards, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// buyListCounts sums the copies of each deck card. Cards are found by Oracle
+// ID, or by name for cards of other decks saved without one.
+type buyListCounts struct {
+	entries  []*BuyListCard
+	byOracle map[string]*BuyListCard
+	byName   map[string]*BuyListCard
+}
+
+func newBuyListCounts(cards []deckEntity.Card) *buyListCounts {
+	counts := &buyListCounts{byOracle: make(map[string]*BuyListCard), byName: make(map[string]*BuyListCard)}
+	for _, card := range cards {
+		entry := counts.find(card)
+		if entry == nil {
+			entry = &BuyListCard{OracleID: card.OracleID, Name: card.Name}
+			counts.entries = append(counts.entries, entry)
+			if card.OracleID != "" {
+				counts.byOracle[card.OracleID] = entry
+			}
+			counts.byName[strings.ToLower(card.Name)] = entry
+		}
+		entry.Needed += card.Quantity
+	}
+	return counts
+}
+
+func (c *buyListCounts) find(card deckEntity.Card) *BuyListCard {
+	if card.OracleID != "" {
+		if entry, ok := c.byOracle[card.OracleID]; ok {
+			return entry
+		}
+	}
+	return c.byName[strings.ToLower(card.Name)]
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_BuyList(t *testing.T) {
+	service := NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), NewArchidektImporter(), testCardValidator{})
+	d := &deckEntity.Deck{Name: "Thassa", Color: "U", Format: "commander", Commander: "Thassa, God of the Sea", OwnerID: 1, Cards: []deckEntity.Card{
+		{Name: "Thassa, God of the Sea", Quantity: 1, Board: deckEntity.BoardCommander},
+		{Name: "Sol Ring", Quantity: 1},
+		{Name: "Island", Quantity: 30},
+		{Name: "Counterspell", Quantity: 1},
+		{Name: "Counterspell", Quantity: 1, Board: deckEntity.BoardSide},
+		{Name: "Cyclonic Rift", Quantity: 1, Board: deckEntity.BoardMaybe},
+	}}
+	require.NoError(t, service.Create(d))
+	other := &deckEntity.Deck{Name: "Other", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{
+		{Name: "Sol Ring", Quantity: 1},
+		{Name: "Island", Quantity: 10, Board: deckEntity.BoardMaybe},
+	}}
+	require.NoError(t, service.Create(other))
+	require.NoError(t, service.Create(&deckEntity.Deck{Name: "Stranger", Color: "U", Format: "modern", OwnerID: 2, Cards: []deckEntity.Card{{Name: "Island", Quantity: 20}}}))
+
+	owned := []deckEntity.Card{
+		{Name: "Island", Quantity: 25},
+		{Name: "Sol Ring", Quantity: 1},
+		{Name: "Thassa, God of the Sea", Quantity: 1},
+		{Name: "Counterspell", Quantity: 1, Board: deckEntity.BoardSide},
+		{Name: "Lightning Bolt", Quantity: 4},
+	}
+
+	list, err := service.BuyList(context.Background(), d.ID, 1, owned, BuyListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &BuyList{DeckID: d.ID, Missing: 6, Cards: []BuyListCard{
+		{OracleID: "oracle-Counterspell", Name: "Counterspell", Needed: 2, Owned: 1, Missing: 1},
+		{OracleID: "oracle-Island", Name: "Island", Needed: 30, Owned: 25, Missing: 5},
+	}}, list)
+	assert.Equal(t, "1 Counterspell\n5 Island\n", string(list.Text()))
+
+	list, err = service.BuyList(context.Background(), d.ID, 1, owned, BuyListOptions{SubtractDecks: true})
+	require.NoError(t, err)
+	assert.Equal(t, 7, list.Missing)
+	require.Len(t, list.Cards, 3)
+	assert.Equal(t, BuyListCard{OracleID: "oracle-Sol Ring", Name: "Sol Ring", Needed: 1, Owned: 1, InUse: 1, Missing: 1}, list.Cards[2])
+	assert.Equal(t, 5, list.Cards[1].Missing, "maybeboard cards of other decks are not in use")
+
+	list, err = service.BuyList(context.Background(), d.ID, 1, nil, BuyListOptions{Boards: []string{deckEntity.BoardMaybe}})
+	require.NoError(t, err)
+	assert.Equal(t, []BuyListCard{{OracleID: "oracle-Cyclonic Rift", Name: "Cyclonic Rift", Needed: 1, Missing: 1}}, list.Cards)
+}
+
+func TestService_BuyList_Errors(t *testing.T) {
+	service := NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), NewArchidektImporter(), testCardValidator{})
+	d := &deckEntity.Deck{Name: "Manual", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{{Name: "Negate", Quantity: 4}}}
+	require.NoError(t, service.Create(d))
+
+	_, err := service.BuyList(context.Background(), 99, 1, nil, BuyListOptions{})
+	assert.EqualError(t, err, "deck not found")
+
+	_, err = service.BuyList(context.Background(), d.ID, 1, []deckEntity.Card{{Name: "Invalid", Quantity: 1}}, BuyListOptions{})
+	assert.EqualError(t, err, "cards not found: Invalid")
+
+	_, err = service.BuyList(context.Background(), d.ID, 1, nil, BuyListOptions{Boards: []string{"graveyard"}})
+	assert.Error(t, err)
+}
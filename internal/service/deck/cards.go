@@ -0,0 +1,152 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+)
+
+// Card operations accepted by ChangeCards.
+const (
+	// CardOpAdd adds Quantity copies of the card.
+	CardOpAdd = "add"
+	// CardOpRemove removes Quantity copies, or every copy when Quantity is zero.
+	CardOpRemove = "remove"
+	// CardOpSet sets the exact quantity; zero removes the card.
+	CardOpSet = "set"
+)
+
+// CardOperation changes the quantity of one card in one board.
+type CardOperation struct {
+	Op       string `json:"op"`
+	Name     string `json:"name"`
+	Board    string `json:"board"`
+	Quantity int    `json:"quantity"`
+}
+
+func (s *Service) AddCards(id, userID int64, cards []deckEntity.Card) (*deckEntity.Deck, error) {
+	return s.ChangeCards(id, userID, CardOperations(CardOpAdd, cards))
+}
+
+// ChangeCards applies operations to a deck of userID, in order, as a single
+// change. Card names are checked by the CardValidator first, so a typo fails
+// the whole request instead of being ignored. Removing a card that is not in
+// the deck is an error.
+func (s *Service) ChangeCards(id, userID int64, operations []CardOperation) (*deckEntity.Deck, error) {
+	if len(operations) == 0 {
+		return nil, errors.New("card list cannot be empty")
+	}
+	cards := make([]deckEntity.Card, len(operations))
+	for index, operation := range operations {
+		if err := checkCardOperation(operation); err != nil {
+			return nil, err
+		}
+		cards[index] = deckEntity.Card{Name: strings.TrimSpace(operation.Name), Board: deckEntity.NormalizeBoard(operation.Board), Quantity: operation.Quantity}
+	}
+	// Checked before validation so other users cannot trigger card lookups.
+	if _, err := s.authorize(id, userID); err != nil {
+		return nil, err
+	}
+	cards, err := s.validator.Validate(cards)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Modify(id, func(d *deckEntity.Deck) error {
+		if d.OwnerID != userID {
+			return ErrForbidden
+		}
+		return applyCardOperations(d, operations, cards)
+	})
+}
+
+func checkCardOperation(operation CardOperation) error {
+	if strings.TrimSpace(operation.Name) == "" {
+		return errors.New("card name cannot be empty")
+	}
+	if !deckEntity.IsBoard(operation.Board) {
+		return fmt.Errorf("invalid board %q for %s", operation.Board, operation.Name)
+	}
+	switch operation.Op {
+	case CardOpAdd:
+		if operation.Quantity <= 0 {
+			return fmt.Errorf("quantity of %s must be greater than zero", operation.Name)
+		}
+	case CardOpRemove, CardOpSet:
+		if operation.Quantity < 0 {
+			return fmt.Errorf("quantity of %s cannot be negative", operation.Name)
+		}
+	default:
+		return fmt.Errorf("invalid card operation %q: use %s, %s or %s", operation.Op, CardOpAdd, CardOpRemove, CardOpSet)
+	}
+	return nil
+}
+
+// applyCardOperations changes d.Cards in place. cards holds the validated
+// card of each operation. Cards are matched by board and Oracle ID, or by
+// board and name for cards saved without one.
+func applyCardOperations(d *deckEntity.Deck, operations []CardOperation, cards []deckEntity.Card) error {
+	find := func(card deckEntity.Card) int {
+		for index, existing := range d.Cards {
+			if deckEntity.NormalizeBoard(existing.Board) != card.Board {
+				continue
+			}
+			if card.OracleID != "" && existing.OracleID == card.OracleID {
+				return index
+			}
+			if cardKey(existing.Board, existing.Name) == cardKey(card.Board, card.Name) {
+				return index
+			}
+		}
+		return -1
+	}
+
+	notInDeck := make([]string, 0)
+	for index, operation := range operations {
+		card := cards[index]
+		position := find(card)
+		quantity := 0
+		if position >= 0 {
+			quantity = d.Cards[position].Quantity
+		}
+		switch operation.Op {
+		case CardOpAdd:
+			quantity += operation.Quantity
+		case CardOpRemove:
+			if position < 0 {
+				notInDeck = append(notInDeck, card.Name)
+				continue
+			}
+			quantity -= operation.Quantity
+			if operation.Quantity == 0 {
+				quantity = 0
+			}
+		case CardOpSet:
+			quantity = operation.Quantity
+		}
+
+		switch {
+		case position < 0 && quantity > 0:
+			card.Quantity = quantity
+			d.Cards = append(d.Cards, card)
+		case position >= 0 && quantity > 0:
+			d.Cards[position].Quantity = quantity
+		case position >= 0:
+			d.Cards = append(d.Cards[:position], d.Cards[position+1:]...)
+		}
+	}
+	if len(notInDeck) > 0 {
+		return fmt.Errorf("cards not in deck: %s", strings.Join(notInDeck, ", "))
+	}
+	return nil
+}
+
+// CardOperations applies the same operation to every card of a parsed list.
+func CardOperations(op string, cards []deckEntity.Card) []CardOperation {
+	operations := make([]CardOperation, len(cards))
+	for index, card := range cards {
+		operations[index] = CardOperation{Op: op, Name: card.Name, Board: card.Board, Quantity: card.Quantity}
+	}
+	return operations
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ChangeCards(t *testing.T) {
+	repo := deckRepo.NewInMemoryRepo()
+	service := NewServiceWithDependencies(repo, NewArchidektImporter(), testCardValidator{})
+	d := &deckEntity.Deck{Name: "Manual", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{
+		{Name: "Negate", Quantity: 4},
+		{Name: "Island", Quantity: 20},
+		{Name: "Dispel", Quantity: 2, Board: deckEntity.BoardSide},
+	}}
+	require.NoError(t, service.Create(d))
+
+	updated, err := service.ChangeCards(d.ID, 1, []CardOperation{
+		{Op: CardOpRemove, Name: "negate", Quantity: 1},
+		{Op: CardOpSet, Name: "Island", Quantity: 18},
+		{Op: CardOpRemove, Name: "Dispel", Board: deckEntity.BoardSide},
+		{Op: CardOpSet, Name: "Counterspell", Quantity: 4},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []deckEntity.Card{
+		{Name: "Negate", Quantity: 3},
+		{Name: "Island", Quantity: 18},
+		{OracleID: "oracle-Counterspell", Name: "Counterspell", Quantity: 4, Board: deckEntity.BoardMain, ImageURI: "https://example.com/counterspell.jpg"},
+	}, updated.Cards)
+
+	updated, err = service.ChangeCards(d.ID, 1, CardOperations(CardOpRemove, []deckEntity.Card{{Name: "Negate", Quantity: 5}}))
+	require.NoError(t, err)
+	assert.Len(t, updated.Cards, 2, "removing more copies than the deck has removes the card")
+
+	_, err = service.ChangeCards(d.ID, 1, []CardOperation{
+		{Op: CardOpSet, Name: "Island", Quantity: 1},
+		{Op: CardOpRemove, Name: "Brainstorm", Quantity: 1},
+	})
+	assert.EqualError(t, err, "cards not in deck: Brainstorm")
+	stored, err := repo.GetByID(d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 18, stored.Cards[0].Quantity, "a failed request changes nothing")
+
+	revisions, err := repo.Revisions(d.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 3)
+}
+
+func TestService_ChangeCards_Rejects(t *testing.T) {
+	service := NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), NewArchidektImporter(), testCardValidator{})
+	d := &deckEntity.Deck{Name: "Manual", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{{Name: "Negate", Quantity: 4}}}
+	require.NoError(t, service.Create(d))
+
+	tests := []struct {
+		name       string
+		userID     int64
+		operations []CardOperation
+		err        string
+	}{
+		{"empty", 1, nil, "card list cannot be empty"},
+		{"unknown operation", 1, []CardOperation{{Op: "double", Name: "Negate", Quantity: 1}}, `invalid card operation "double": use add, remove or set`},
+		{"negative quantity", 1, []CardOperation{{Op: CardOpSet, Name: "Negate", Quantity: -1}}, "quantity of Negate cannot be negative"},
+		{"add nothing", 1, []CardOperation{{Op: CardOpAdd, Name: "Negate"}}, "quantity of Negate must be greater than zero"},
+		{"invalid board", 1, []CardOperation{{Op: CardOpSet, Name: "Negate", Board: "graveyard", Quantity: 1}}, `invalid board "graveyard" for Negate`},
+		{"unknown card", 1, []CardOperation{{Op: CardOpSet, Name: "Invalid", Quantity: 1}}, "cards not found: Invalid"},
+		{"other user", 2, []CardOperation{{Op: CardOpSet, Name: "Negate", Quantity: 1}}, ErrForbidden.Error()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := service.ChangeCards(d.ID, test.userID, test.operations)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	cardRepo "github.com/josofm/liliana/internal/repository/card"
+)
+
+const (
+	catalogBatchSize   = 500
+	catalogSearchLimit = 50
+)
+
+// catalogSkippedLayouts are bulk data entries that cannot be played in a deck.
+var catalogSkippedLayouts = map[string]bool{
+	"art_series":         true,
+	"double_faced_token": true,
+	"emblem":             true,
+	"token":              true,
+}
+
+// SyncCatalog reads a Scryfall "Oracle Cards" bulk data file and upserts its
+// cards into repo. The file is decoded as a stream, so its size does not
+// matter. It returns the number of cards saved.
+func SyncCatalog(r io.Reader, repo cardRepo.Repository) (int, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return 0, fmt.Errorf("read Scryfall bulk data: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return 0, fmt.Errorf("read Scryfall bulk data: expected a JSON array")
+	}
+
+	total := 0
+	batch := make([]deckEntity.CatalogCard, 0, catalogBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := repo.Upsert(batch); err != nil {
+			return fmt.Errorf("save catalog cards: %w", err)
+		}
+		total += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for decoder.More() {
+		var source scryfallCard
+		if err := decoder.Decode(&source); err != nil {
+			return total, fmt.Errorf("decode Scryfall bulk data: %w", err)
+		}
+		card, ok := catalogCardFromScryfall(source)
+		if !ok {
+			continue
+		}
+		batch = append(batch, card)
+		if len(batch) == catalogBatchSize {
+			if err := flush(); err != nil {
+				return total, err
+			}
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return total, fmt.Errorf("read Scryfall bulk data: %w", err)
+	}
+	return total, flush()
+}
+
+func catalogCardFromScryfall(source scryfallCard) (deckEntity.CatalogCard, bool) {
+	if catalogSkippedLayouts[source.Layout] {
+		return deckEntity.CatalogCard{}, false
+	}
+	// Reversible cards keep the Oracle ID on their faces.
+	if source.OracleID == "" && len(source.CardFaces) > 0 {
+		source.OracleID = source.CardFaces[0].OracleID
+	}
+	if source.OracleID == "" || source.Name == "" {
+		return deckEntity.CatalogCard{}, false
+	}
+	faceNames := make([]string, 0, len(source.CardFaces))
+	for _, face := range source.CardFaces {
+		faceNames = append(faceNames, face.Name)
+	}
+	return deckEntity.CatalogCard{Card: cardFromScryfall(source), FaceNames: faceNames, CanBeCommander: canBeCommander(source)}, true
+}
+
+// CatalogValidator is a CardValidator backed by the offline catalog filled by
+// SyncCatalog. It never calls Scryfall.
+type CatalogValidator struct {
+	repo cardRepo.Repository
+}
+
+func NewCatalogValidator(repo cardRepo.Repository) *CatalogValidator {
+	return &CatalogValidator{repo: repo}
+}
+
+func (v *CatalogValidator) Validate(cards []deckEntity.Card) ([]deckEntity.Card, error) {
+	result := make([]deckEntity.Card, len(cards))
+	copy(result, cards)
+	names := make([]string, 0, len(cards)*2)
+	pending := make([]int, 0, len(cards))
+	for index, card := range result {
+		// Same rule as ScryfallValidator: enriched cards are kept as they are.
+		if card.OracleID != "" && card.ImageURI != "" {
+			continue
+		}
+		pending = append(pending, index)
+		names = append(names, card.Name, scryfallLookupName(card.Name))
+	}
+	if len(pending) == 0 {
+		return result, nil
+	}
+
+	found, err := v.repo.FindByNames(names)
+	if err != nil {
+		return nil, fmt.Errorf("find cards in catalog: %w", err)
+	}
+	notFound := make([]string, 0)
+	for _, index := range pending {
+		card, ok := found[strings.ToLower(result[index].Name)]
+		if !ok {
+			card, ok = found[strings.ToLower(scryfallLookupName(result[index].Name))]
+		}
+		if !ok {
+			notFound = append(notFound, result[index].Name)
+			continue
+		}
+		enriched := card.Card
+		enriched.Quantity = result[index].Quantity
+		enriched.Board = result[index].Board
+		result[index] = enriched
+	}
+	if len(notFound) > 0 {
+		return nil, fmt.Errorf("cards not found: %s", strings.Join(notFound, ", "))
+	}
+	return result, nil
+}
+
+func (v *CatalogValidator) ResolveCommander(name string) (deckEntity.Card, error) {
+	found, err := v.repo.FindByNames([]string{name})
+	if err != nil {
+		return deckEntity.Card{}, fmt.Errorf("find commander in catalog: %w", err)
+	}
+	card, ok := found[strings.ToLower(name)]
+	if !ok {
+		return deckEntity.Card{}, fmt.Errorf("commander not found: %s", name)
+	}
+	if !card.CanBeCommander {
+		return deckEntity.Card{}, fmt.Errorf("card cannot be a commander: %s", card.Name)
+	}
+	return card.Card, nil
+}
+
+func (v *CatalogValidator) SearchCommanders(query string) ([]CommanderSuggestion, error) {
+	cards, err := v.repo.SearchCommanders(strings.TrimSpace(query), catalogSearchLimit)
+	if err != nil {
+		return nil, fmt.Errorf("search commanders in catalog: %w", err)
+	}
+	result := make([]CommanderSuggestion, len(cards))
+	for index, card := range cards {
+		result[index] = CommanderSuggestion{Name: card.Name, ColorIdentity: card.ColorIdentity, ImageURI: card.ImageURI}
+	}
+	return result, nil
+}
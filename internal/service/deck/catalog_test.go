@@ -0,0 +1,100 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	cardRepo "github.com/josofm/liliana/internal/repository/card"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const oracleCardsFixture = `[
+	{"oracle_id":"id-thassa","name":"Thassa, God of the Sea","layout":"normal","mana_cost":"{2}{U}","type_line":"Legendary Enchantment Creature — God","color_identity":["U"],"image_uris":{"normal":"https://example.com/thassa.jpg"},"legalities":{"commander":"legal"}},
+	{"oracle_id":"id-aqueous","name":"Aqueous Form","layout":"normal","mana_cost":"{U}","type_line":"Enchantment — Aura","color_identity":["U"],"legalities":{"commander":"legal","modern":"legal"}},
+	{"oracle_id":"id-valki","name":"Valki, God of Lies // Tibalt, Cosmic Impostor","layout":"modal_dfc","color_identity":["B","R"],"card_faces":[
+		{"name":"Valki, God of Lies","mana_cost":"{1}{B}","type_line":"Legendary Creature — God","image_uris":{"normal":"https://example.com/valki.jpg"}},
+		{"name":"Tibalt, Cosmic Impostor","mana_cost":"{5}{B}{R}","type_line":"Legendary Planeswalker — Tibalt"}
+	]},
+	{"name":"Zndrsplt, Eye of Wisdom","layout":"reversible_card","card_faces":[{"oracle_id":"id-zndrsplt","name":"Zndrsplt, Eye of Wisdom","type_line":"Legendary Creature — Homunculus"}]},
+	{"oracle_id":"id-goblin","name":"Goblin","layout":"token","type_line":"Token Creature — Goblin"}
+]`
+
+func syncedCatalog(t *testing.T) cardRepo.Repository {
+	t.Helper()
+	repo := cardRepo.NewInMemoryRepo()
+	count, err := SyncCatalog(strings.NewReader(oracleCardsFixture), repo)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+	return repo
+}
+
+func TestSyncCatalog(t *testing.T) {
+	repo := syncedCatalog(t)
+	found, err := repo.FindByNames([]string{"Tibalt, Cosmic Impostor", "Zndrsplt, Eye of Wisdom", "Goblin"})
+	require.NoError(t, err)
+	assert.Equal(t, "id-valki", found["tibalt, cosmic impostor"].OracleID)
+	assert.Equal(t, "{1}{B}", found["tibalt, cosmic impostor"].ManaCost)
+	assert.True(t, found["tibalt, cosmic impostor"].CanBeCommander)
+	assert.Equal(t, "id-zndrsplt", found["zndrsplt, eye of wisdom"].OracleID)
+	assert.NotContains(t, found, "goblin")
+}
+
+func TestSyncCatalog_RejectsInvalidFile(t *testing.T) {
+	_, err := SyncCatalog(strings.NewReader(`{"object":"list"}`), cardRepo.NewInMemoryRepo())
+	assert.Error(t, err)
+	_, err = SyncCatalog(strings.NewReader(`[{"oracle_id":`), cardRepo.NewInMemoryRepo())
+	assert.Error(t, err)
+}
+
+func TestCatalogValidator_Validate(t *testing.T) {
+	validator := NewCatalogValidator(syncedCatalog(t))
+	cards, err := validator.Validate([]deckEntity.Card{
+		{Name: "aqueous form", Quantity: 2, Board: deckEntity.BoardSide},
+		{Name: "Valki, God of Lies", Quantity: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	assert.Equal(t, deckEntity.Card{
+		OracleID: "id-aqueous", Name: "Aqueous Form", Quantity: 2, Board: deckEntity.BoardSide,
+		ManaCost: "{U}", TypeLine: "Enchantment — Aura", ColorIdentity: []string{"U"},
+		Legalities: map[string]string{"commander": "legal", "modern": "legal"},
+	}, cards[0])
+	assert.Equal(t, "Valki, God of Lies // Tibalt, Cosmic Impostor", cards[1].Name)
+	assert.Equal(t, "https://example.com/valki.jpg", cards[1].ImageURI)
+
+	_, err = validator.Validate([]deckEntity.Card{{Name: "Aqueous Form", Quantity: 1}, {Name: "Invalid", Quantity: 1}})
+	assert.EqualError(t, err, "cards not found: Invalid")
+}
+
+func TestCatalogValidator_ResolveCommander(t *testing.T) {
+	validator := NewCatalogValidator(syncedCatalog(t))
+	commander, err := validator.ResolveCommander("thassa, god of the sea")
+	require.NoError(t, err)
+	assert.Equal(t, "Thassa, God of the Sea", commander.Name)
+	assert.Equal(t, "https://example.com/thassa.jpg", commander.ImageURI)
+
+	_, err = validator.ResolveCommander("Aqueous Form")
+	assert.EqualError(t, err, "card cannot be a commander: Aqueous Form")
+	_, err = validator.ResolveCommander("Atraxa")
+	assert.EqualError(t, err, "commander not found: Atraxa")
+}
+
+func TestCatalogValidator_SearchCommanders(t *testing.T) {
+	validator := NewCatalogValidator(syncedCatalog(t))
+	suggestions, err := validator.SearchCommanders("god")
+	require.NoError(t, err)
+	assert.Equal(t, []CommanderSuggestion{
+		{Name: "Thassa, God of the Sea", ColorIdentity: []string{"U"}, ImageURI: "https://example.com/thassa.jpg"},
+		{Name: "Valki, God of Lies // Tibalt, Cosmic Impostor", ColorIdentity: []string{"B", "R"}, ImageURI: "https://example.com/valki.jpg"},
+	}, suggestions)
+}
+
+func TestService_PrepareWithCatalog(t *testing.T) {
+	service := NewServiceWithDependencies(nil, NewDispatchImporter(nil), NewCatalogValidator(syncedCatalog(t)))
+	d := &deckEntity.Deck{Name: "Auras", Format: "commander", Commander: "Thassa, God of the Sea", OwnerID: 1, Cards: []deckEntity.Card{{Name: "Aqueous Form", Quantity: 1}}}
+	require.NoError(t, service.Prepare(d))
+	assert.Equal(t, "U", d.Color)
+	assert.Equal(t, "id-aqueous", d.Cards[0].OracleID)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+)
+
+// CSVCard is a row of a card CSV. Condition is kept as written in the file.
+type CSVCard struct {
+	deckEntity.Card
+	Foil      bool
+	Condition string
+}
+
+// csvColumns maps each field to the header names used by collection
+// trackers such as Moxfield, Deckbox, ManaBox and TCGplayer.
+var csvColumns = map[string][]string{
+	"quantity":  {"count", "quantity", "qty", "amount"},
+	"name":      {"name", "card name", "card"},
+	"foil":      {"foil", "printing", "finish"},
+	"condition": {"condition"},
+}
+
+// ParseCardCSV reads a CSV with a header row. Only the name column is
+// required; rows without a quantity count as one copy. Rows of the same card
+// are kept apart, since they may differ in foil or condition.
+func ParseCardCSV(r io.Reader) ([]CSVCard, error) {
+	buffered := bufio.NewReader(r)
+	// Spreadsheet exports often start with a UTF-8 byte order mark.
+	if bom, err := buffered.Peek(3); err == nil && string(bom) == "\ufeff" {
+		buffered.Discard(3)
+	}
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("csv is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read csv: %w", err)
+	}
+	columns := csvHeaderColumns(header)
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("csv has no name column")
+	}
+
+	cards := make([]CSVCard, 0)
+	field := func(record []string, name string) string {
+		index, ok := columns[name]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+		name := field(record, "name")
+		if name == "" {
+			continue
+		}
+		quantity := 1
+		if value := field(record, "quantity"); value != "" {
+			quantity, err = strconv.Atoi(value)
+			if err != nil || quantity <= 0 {
+				return nil, fmt.Errorf("invalid quantity at line %d: %q", line, value)
+			}
+		}
+		cards = append(cards, CSVCard{
+			Card:      deckEntity.Card{Name: name, Quantity: quantity, Board: deckEntity.BoardMain},
+			Foil:      isFoil(field(record, "foil")),
+			Condition: field(record, "condition"),
+		})
+	}
+	if len(cards) == 0 {
+		return nil, errors.New("csv has no cards")
+	}
+	return cards, nil
+}
+
+func csvHeaderColumns(header []string) map[string]int {
+	columns := make(map[string]int)
+	for index, title := range header {
+		title = strings.ToLower(strings.TrimSpace(title))
+		for field, names := range csvColumns {
+			if _, found := columns[field]; found {
+				continue
+			}
+			for _, name := range names {
+				if title == name {
+					columns[field] = index
+				}
+			}
+		}
+	}
+	return columns
+}
+
+// isFoil reads the foil column, written as a finish ("foil", "etched",
+// "normal") or as a flag ("true", "yes", "1").
+func isFoil(value string) bool {
+	switch strings.ToLower(value) {
+	case "foil", "etched", "true", "yes", "y", "1":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCardCSV_Moxfield(t *testing.T) {
+	input := "\ufeff\"Count\",\"Tradelist Count\",\"Name\",\"Edition\",\"Condition\",\"Language\",\"Foil\"\n" +
+		"\"2\",\"0\",\"Sol Ring\",\"c21\",\"Near Mint\",\"English\",\"\"\n" +
+		"\"1\",\"0\",\"Fire // Ice\",\"mh2\",\"LP\",\"English\",\"foil\"\n"
+
+	cards, err := ParseCardCSV(strings.NewReader(input))
+
+	require.NoError(t, err)
+	assert.Equal(t, []CSVCard{
+		{Card: deckEntity.Card{Name: "Sol Ring", Quantity: 2, Board: deckEntity.BoardMain}, Condition: "Near Mint"},
+		{Card: deckEntity.Card{Name: "Fire // Ice", Quantity: 1, Board: deckEntity.BoardMain}, Foil: true, Condition: "LP"},
+	}, cards)
+}
+
+func TestParseCardCSV_ManaBox(t *testing.T) {
+	input := "Name,Set code,Foil,Quantity,Condition\n" +
+		"Counterspell,7ed,normal,4,near_mint\n" +
+		",,,,\n" +
+		"Counterspell,7ed,etched,1,damaged\n"
+
+	cards, err := ParseCardCSV(strings.NewReader(input))
+
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	assert.Equal(t, 4, cards[0].Quantity)
+	assert.False(t, cards[0].Foil)
+	assert.True(t, cards[1].Foil)
+	assert.Equal(t, "damaged", cards[1].Condition)
+}
+
+func TestParseCardCSV_NameOnly(t *testing.T) {
+	cards, err := ParseCardCSV(strings.NewReader("Card Name\nIsland\n"))
+
+	require.NoError(t, err)
+	require.Len(t, cards, 1)
+	assert.Equal(t, 1, cards[0].Quantity)
+}
+
+func TestParseCardCSV_Errors(t *testing.T) {
+	tests := map[string]string{
+		"":                           "csv is empty",
+		"Count,Edition\n1,c21\n":     "csv has no name column",
+		"Count,Name\n":               "csv has no cards",
+		"Count,Name\nx,Sol Ring\n":   `invalid quantity at line 2: "x"`,
+		"Count,Name\n-1,Sol Ring\n":  `invalid quantity at line 2: "-1"`,
+		"Count,Name\n\"1,Sol Ring\n": "read csv: parse error on line 2, column 13: extraneous or missing \" in quoted-field",
+	}
+	for input, message := range tests {
+		_, err := ParseCardCSV(strings.NewReader(input))
+		assert.EqualError(t, err, message, input)
+	}
+}
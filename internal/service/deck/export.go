@@ -0,0 +1,275 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+)
+
+const (
+	ExportText       = "text"
+	ExportArena      = "arena"
+	ExportMTGO       = "mtgo"
+	ExportCockatrice = "cockatrice"
+	ExportCSV        = "csv"
+)
+
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+// ExportFormats lists the formats accepted by ExportDeck.
+var ExportFormats = []string{ExportText, ExportArena, ExportMTGO, ExportCockatrice, ExportCSV}
+
+// Export is a deck rendered in one of the ExportFormats.
+type Export struct {
+	ContentType string
+	Extension   string
+	Body        []byte
+}
+
+// exportSection groups the cards of one board under its heading.
+type exportSection struct {
+	board string
+	name  string
+	cards []deckEntity.Card
+}
+
+var exportSectionNames = map[string]string{
+	deckEntity.BoardCommander: "Commander",
+	deckEntity.BoardCompanion: "Companion",
+	deckEntity.BoardMain:      "Deck",
+	deckEntity.BoardSide:      "Sideboard",
+	deckEntity.BoardMaybe:     "Maybeboard",
+}
+
+func ExportDeck(d *deckEntity.Deck, format string) (*Export, error) {
+	sections := exportSections(d)
+	switch strings.ToLower(format) {
+	case "", ExportText:
+		return &Export{ContentType: "text/plain; charset=utf-8", Extension: "txt", Body: exportText(sections, "// ")}, nil
+	case ExportArena:
+		return &Export{ContentType: "text/plain; charset=utf-8", Extension: "txt", Body: exportText(playableSections(sections), "")}, nil
+	case ExportMTGO:
+		body, err := exportMTGO(playableSections(sections))
+		if err != nil {
+			return nil, err
+		}
+		return &Export{ContentType: "application/xml; charset=utf-8", Extension: "dek", Body: body}, nil
+	case ExportCockatrice:
+		body, err := exportCockatrice(d.Name, playableSections(sections))
+		if err != nil {
+			return nil, err
+		}
+		return &Export{ContentType: "application/xml; charset=utf-8", Extension: "cod", Body: body}, nil
+	case ExportCSV:
+		body, err := exportCSV(sections)
+		if err != nil {
+			return nil, err
+		}
+		return &Export{ContentType: "text/csv; charset=utf-8", Extension: "csv", Body: body}, nil
+	default:
+		return nil, fmt.Errorf("%w %q: expected one of %s", ErrUnsupportedExportFormat, format, strings.Join(ExportFormats, ", "))
+	}
+}
+
+func exportSections(d *deckEntity.Deck) []exportSection {
+	byBoard := make(map[string][]deckEntity.Card, len(deckEntity.Boards))
+	for _, card := range d.Cards {
+		board := deckEntity.NormalizeBoard(card.Board)
+		byBoard[board] = append(byBoard[board], card)
+	}
+	if len(byBoard[deckEntity.BoardCommander]) == 0 {
+		byBoard[deckEntity.BoardCommander], byBoard[deckEntity.BoardMain] = splitCommanders(d.Commander, byBoard[deckEntity.BoardMain])
+	}
+
+	sections := make([]exportSection, 0, len(deckEntity.Boards))
+	for _, board := range deckEntity.Boards {
+		if board != deckEntity.BoardMain && len(byBoard[board]) == 0 {
+			continue
+		}
+		sections = append(sections, exportSection{board: board, name: exportSectionNames[board], cards: byBoard[board]})
+	}
+	return sections
+}
+
+// playableSections drops the maybe-board, which game clients have no place for.
+func playableSections(sections []exportSection) []exportSection {
+	result := make([]exportSection, 0, len(sections))
+	for _, section := range sections {
+		if section.board != deckEntity.BoardMaybe {
+			result = append(result, section)
+		}
+	}
+	return result
+}
+
+// splitCommanders moves the cards named in Deck.Commander out of the main
+// deck for decks saved before boards existed. Commanders missing from the card
+// list, as in manual decks, are added with quantity 1.
+func splitCommanders(commander string, main []deckEntity.Card) ([]deckEntity.Card, []deckEntity.Card) {
+	names := commanderNames(commander)
+	commanders := make([]deckEntity.Card, 0, len(names))
+	rest := make([]deckEntity.Card, 0, len(main))
+	found := make(map[string]bool, len(names))
+	for _, card := range main {
+		if name, ok := matchCommander(card.Name, names); ok && !found[name] {
+			found[name] = true
+			commander := card
+			commander.Quantity = 1
+			commanders = append(commanders, commander)
+			if card.Quantity > 1 {
+				card.Quantity--
+				rest = append(rest, card)
+			}
+			continue
+		}
+		rest = append(rest, card)
+	}
+	for _, name := range names {
+		if !found[strings.ToLower(name)] {
+			commanders = append(commanders, deckEntity.Card{Name: name, Quantity: 1, Board: deckEntity.BoardCommander})
+		}
+	}
+	return commanders, rest
+}
+
+func commanderNames(commander string) []string {
+	names := make([]string, 0, 2)
+	for _, name := range strings.Split(commander, " / ") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func matchCommander(cardName string, commanders []string) (string, bool) {
+	frontFace := scryfallLookupName(cardName)
+	for _, commander := range commanders {
+		if strings.EqualFold(cardName, commander) || strings.EqualFold(frontFace, commander) {
+			return strings.ToLower(commander), true
+		}
+	}
+	return "", false
+}
+
+// exportText writes one "<quantity> <name>" line per card. Headings use the
+// given prefix; the plain text format uses "// " so ParseCardList skips them.
+func exportText(sections []exportSection, headingPrefix string) []byte {
+	var buffer bytes.Buffer
+	for index, section := range sections {
+		if index > 0 {
+			buffer.WriteString("\n")
+		}
+		if len(sections) > 1 || headingPrefix == "" {
+			buffer.WriteString(headingPrefix + section.name + "\n")
+		}
+		for _, card := range section.cards {
+			fmt.Fprintf(&buffer, "%d %s\n", card.Quantity, card.Name)
+		}
+	}
+	return buffer.Bytes()
+}
+
+type mtgoDeck struct {
+	XMLName          xml.Name   `xml:"Deck"`
+	XSD              string     `xml:"xmlns:xsd,attr"`
+	XSI              string     `xml:"xmlns:xsi,attr"`
+	NetDeckID        int        `xml:"NetDeckID"`
+	PreconstructedID int        `xml:"PreconstructedDeckID"`
+	Cards            []mtgoCard `xml:"Cards"`
+}
+
+type mtgoCard struct {
+	CatID     int    `xml:"CatID,attr"`
+	Quantity  int    `xml:"Quantity,attr"`
+	Sideboard bool   `xml:"Sideboard,attr"`
+	Name      string `xml:"Name,attr"`
+}
+
+// exportMTGO writes the .dek format. MTGO has no commander or companion zone,
+// so those cards go to the sideboard as most deck builders do.
+func exportMTGO(sections []exportSection) ([]byte, error) {
+	deck := mtgoDeck{XSD: "http://www.w3.org/2001/XMLSchema", XSI: "http://www.w3.org/2001/XMLSchema-instance"}
+	for _, section := range sections {
+		for _, card := range section.cards {
+			deck.Cards = append(deck.Cards, mtgoCard{Quantity: card.Quantity, Sideboard: section.board != deckEntity.BoardMain, Name: card.Name})
+		}
+	}
+	return marshalXML(deck)
+}
+
+type cockatriceDeck struct {
+	XMLName  xml.Name         `xml:"cockatrice_deck"`
+	Version  int              `xml:"version,attr"`
+	Name     string           `xml:"deckname"`
+	Comments string           `xml:"comments"`
+	Zones    []cockatriceZone `xml:"zone"`
+}
+
+type cockatriceZone struct {
+	Name  string           `xml:"name,attr"`
+	Cards []cockatriceCard `xml:"card"`
+}
+
+type cockatriceCard struct {
+	Number int    `xml:"number,attr"`
+	Name   string `xml:"name,attr"`
+}
+
+// exportCockatrice writes the .cod format. Cockatrice has no commander zone
+// either, so commanders go to the "side" zone.
+func exportCockatrice(name string, sections []exportSection) ([]byte, error) {
+	deck := cockatriceDeck{Version: 1, Name: name}
+	zones := map[string]int{}
+	for _, section := range sections {
+		zoneName := "main"
+		if section.board != deckEntity.BoardMain {
+			zoneName = "side"
+		}
+		position, ok := zones[zoneName]
+		if !ok {
+			position = len(deck.Zones)
+			zones[zoneName] = position
+			deck.Zones = append(deck.Zones, cockatriceZone{Name: zoneName})
+		}
+		for _, card := range section.cards {
+			deck.Zones[position].Cards = append(deck.Zones[position].Cards, cockatriceCard{Number: card.Quantity, Name: card.Name})
+		}
+	}
+	return marshalXML(deck)
+}
+
+func marshalXML(value any) ([]byte, error) {
+	body, err := xml.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode deck export: %w", err)
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
+
+func exportCSV(sections []exportSection) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.Write([]string{"board", "quantity", "name", "oracle_id", "mana_cost", "type_line"}); err != nil {
+		return nil, err
+	}
+	for _, section := range sections {
+		for _, card := range section.cards {
+			record := []string{section.board, strconv.Itoa(card.Quantity), card.Name, card.OracleID, card.ManaCost, card.TypeLine}
+			if err := writer.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("encode deck export: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
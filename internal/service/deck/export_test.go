@@ -0,0 +1,125 @@
+package service
+
+import (
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTestDeck() *deckEntity.Deck {
+	return &deckEntity.Deck{
+		Name: "Auras & Co", Format: "commander", Commander: "Thassa, God of the Sea",
+		Cards: []deckEntity.Card{
+			{OracleID: "oracle-aura", Name: "Aqueous Form", Quantity: 1, ManaCost: "{U}", TypeLine: "Enchantment — Aura"},
+			{OracleID: "oracle-island", Name: "Island", Quantity: 30},
+			{OracleID: "oracle-thassa", Name: "Thassa, God of the Sea", Quantity: 1},
+		},
+	}
+}
+
+func TestExportDeck_TextRoundTripsThroughParseCardList(t *testing.T) {
+	export, err := ExportDeck(exportTestDeck(), ExportText)
+	require.NoError(t, err)
+	assert.Equal(t, "txt", export.Extension)
+	assert.Equal(t, "// Commander\n1 Thassa, God of the Sea\n\n// Deck\n1 Aqueous Form\n30 Island\n", string(export.Body))
+
+	cards, err := ParseCardList(string(export.Body))
+	require.NoError(t, err)
+	assert.Equal(t, []deckEntity.Card{
+		{Name: "Thassa, God of the Sea", Quantity: 1, Board: deckEntity.BoardCommander},
+		{Name: "Aqueous Form", Quantity: 1, Board: deckEntity.BoardMain},
+		{Name: "Island", Quantity: 30, Board: deckEntity.BoardMain},
+	}, cards)
+}
+
+func TestExportDeck_TextWithoutCommanderHasNoHeadings(t *testing.T) {
+	d := &deckEntity.Deck{Name: "Burn", Format: "modern", Cards: []deckEntity.Card{{Name: "Lightning Bolt", Quantity: 4}}}
+	export, err := ExportDeck(d, "")
+	require.NoError(t, err)
+	assert.Equal(t, "4 Lightning Bolt\n", string(export.Body))
+}
+
+func TestExportDeck_Arena(t *testing.T) {
+	export, err := ExportDeck(exportTestDeck(), ExportArena)
+	require.NoError(t, err)
+	assert.Equal(t, "Commander\n1 Thassa, God of the Sea\n\nDeck\n1 Aqueous Form\n30 Island\n", string(export.Body))
+}
+
+func TestExportDeck_ManualCommanderNotInCards(t *testing.T) {
+	d := &deckEntity.Deck{Name: "Manual", Commander: "Tymna the Weaver / Kraum, Ludevic's Opus", Cards: []deckEntity.Card{{Name: "Sol Ring", Quantity: 1}}}
+	export, err := ExportDeck(d, ExportArena)
+	require.NoError(t, err)
+	assert.Equal(t, "Commander\n1 Tymna the Weaver\n1 Kraum, Ludevic's Opus\n\nDeck\n1 Sol Ring\n", string(export.Body))
+}
+
+func TestExportDeck_MTGO(t *testing.T) {
+	export, err := ExportDeck(exportTestDeck(), ExportMTGO)
+	require.NoError(t, err)
+	assert.Equal(t, "dek", export.Extension)
+	assert.Contains(t, string(export.Body), `<Cards CatID="0" Quantity="1" Sideboard="true" Name="Thassa, God of the Sea"></Cards>`)
+	assert.Contains(t, string(export.Body), `<Cards CatID="0" Quantity="30" Sideboard="false" Name="Island"></Cards>`)
+}
+
+func TestExportDeck_Cockatrice(t *testing.T) {
+	export, err := ExportDeck(exportTestDeck(), ExportCockatrice)
+	require.NoError(t, err)
+	assert.Equal(t, "cod", export.Extension)
+	assert.Contains(t, string(export.Body), `<deckname>Auras &amp; Co</deckname>`)
+	assert.Contains(t, string(export.Body), `<zone name="side">`)
+	assert.Contains(t, string(export.Body), `<card number="30" name="Island"></card>`)
+}
+
+func TestExportDeck_CSV(t *testing.T) {
+	export, err := ExportDeck(exportTestDeck(), ExportCSV)
+	require.NoError(t, err)
+	assert.Equal(t, "board,quantity,name,oracle_id,mana_cost,type_line\n"+
+		"commander,1,\"Thassa, God of the Sea\",oracle-thassa,,\n"+
+		"main,1,Aqueous Form,oracle-aura,{U},Enchantment — Aura\n"+
+		"main,30,Island,oracle-island,,\n", string(export.Body))
+}
+
+func TestExportDeck_Boards(t *testing.T) {
+	d := &deckEntity.Deck{Name: "Boards", Format: "modern", Cards: []deckEntity.Card{
+		{Name: "Lurrus of the Dream-Den", Quantity: 1, Board: deckEntity.BoardCompanion},
+		{Name: "Lightning Bolt", Quantity: 4, Board: deckEntity.BoardMain},
+		{Name: "Negate", Quantity: 2, Board: deckEntity.BoardSide},
+		{Name: "Sol Ring", Quantity: 1, Board: deckEntity.BoardMaybe},
+	}}
+
+	text, err := ExportDeck(d, ExportText)
+	require.NoError(t, err)
+	cards, err := ParseCardList(string(text.Body))
+	require.NoError(t, err)
+	assert.Equal(t, d.Cards, cards)
+
+	arena, err := ExportDeck(d, ExportArena)
+	require.NoError(t, err)
+	assert.Equal(t, "Companion\n1 Lurrus of the Dream-Den\n\nDeck\n4 Lightning Bolt\n\nSideboard\n2 Negate\n", string(arena.Body))
+
+	mtgo, err := ExportDeck(d, ExportMTGO)
+	require.NoError(t, err)
+	assert.Contains(t, string(mtgo.Body), `Quantity="2" Sideboard="true" Name="Negate"`)
+	assert.NotContains(t, string(mtgo.Body), "Sol Ring")
+}
+
+func TestExportDeck_UnsupportedFormat(t *testing.T) {
+	_, err := ExportDeck(exportTestDeck(), "pdf")
+	assert.ErrorIs(t, err, ErrUnsupportedExportFormat)
+}
+
+func TestService_Export(t *testing.T) {
+	repo := deckRepo.NewInMemoryRepo()
+	service := NewServiceWithDependencies(repo, NewDispatchImporter(nil), testCardValidator{})
+	d := exportTestDeck()
+	require.NoError(t, service.Create(d))
+
+	export, err := service.Export(d.ID, 1, ExportArena)
+	require.NoError(t, err)
+	assert.Contains(t, string(export.Body), "30 Island")
+
+	_, err = service.Export(999, 1, ExportText)
+	assert.Error(t, err)
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+)
+
+const (
+	RuleDeckSize      = "deck_size"
+	RuleSideboardSize = "sideboard_size"
+	RuleCopyLimit     = "copy_limit"
+	RuleColorIdentity = "color_identity"
+	RuleCommander     = "commander"
+	RuleBanned        = "banned"
+	RuleNotLegal      = "not_legal"
+	RuleUnknownFormat = "unknown_format"
+)
+
+// LegalityReport lists every rule a deck breaks in its format.
+type LegalityReport struct {
+	Format     string      `json:"format"`
+	Legal      bool        `json:"legal"`
+	Violations []Violation `json:"violations"`
+}
+
+type Violation struct {
+	Rule    string `json:"rule"`
+	Card    string `json:"card,omitempty"`
+	Message string `json:"message"`
+}
+
+// LegalityError is returned by Service.Prepare when legality is enforced and
+// the deck breaks a rule of its format.
+type LegalityError struct {
+	Report LegalityReport
+}
+
+func (e *LegalityError) Error() string {
+	messages := make([]string, len(e.Report.Violations))
+	for index, violation := range e.Report.Violations {
+		messages[index] = violation.Message
+	}
+	return fmt.Sprintf("deck is not legal in %s: %s", e.Report.Format, strings.Join(messages, "; "))
+}
+
+// formatRule describes deck construction for a format. Singleton formats play
+// a commander (or oathbreaker) and follow its color identity.
+type formatRule struct {
+	minCards     int
+	maxCards     int // 0 means no maximum
+	maxSideboard int // -1 means no limit
+	copyLimit    int // 0 means no limit
+	singleton    bool
+	legalityKey  string // Scryfall legalities key; empty skips banned checks
+}
+
+var formatRules = map[string]formatRule{
+	"commander":   {minCards: 100, maxCards: 100, maxSideboard: -1, copyLimit: 1, singleton: true, legalityKey: "commander"},
+	"brawl":       {minCards: 100, maxCards: 100, maxSideboard: -1, copyLimit: 1, singleton: true, legalityKey: "brawl"},
+	"oathbreaker": {minCards: 60, maxCards: 60, maxSideboard: -1, copyLimit: 1, singleton: true, legalityKey: "oathbreaker"},
+	"standard":    {minCards: 60, maxSideboard: 15, copyLimit: 4, legalityKey: "standard"},
+	"pioneer":     {minCards: 60, maxSideboard: 15, copyLimit: 4, legalityKey: "pioneer"},
+	"modern":      {minCards: 60, maxSideboard: 15, copyLimit: 4, legalityKey: "modern"},
+	"legacy":      {minCards: 60, maxSideboard: 15, copyLimit: 4, legalityKey: "legacy"},
+	"vintage":     {minCards: 60, maxSideboard: 15, copyLimit: 4, legalityKey: "vintage"},
+	"pauper":      {minCards: 60, maxSideboard: 15, copyLimit: 4, legalityKey: "pauper"},
+	"limited":     {minCards: 40, maxSideboard: -1},
+}
+
+// anyNumberCards override the copy limit, as printed on the cards themselves.
+// A limit of 0 means any number.
+var anyNumberCards = map[string]int{
+	"cid, timeless artificer": 0,
+	"dragon's approach":       0,
+	"hare apparent":           0,
+	"nazgûl":                  9,
+	"persistent petitioners":  0,
+	"rat colony":              0,
+	"relentless rats":         0,
+	"seven dwarves":           7,
+	"shadowborn apostle":      0,
+	"slime against humanity":  0,
+	"tempest hawk":            0,
+	"templar knight":          0,
+}
+
+var colorNames = map[string]string{"white": "W", "blue": "U", "black": "B", "red": "R", "green": "G"}
+
+// CheckLegality validates deck size, sideboard size, copy limits, color
+// identity and banned or not legal cards. Cards without known legalities are
+// not checked for bans, and the maybe-board is ignored.
+func CheckLegality(d *deckEntity.Deck) LegalityReport {
+	report := LegalityReport{Format: d.Format, Violations: make([]Violation, 0)}
+	rule, ok := formatRules[d.Format]
+	if !ok {
+		report.Violations = append(report.Violations, Violation{Rule: RuleUnknownFormat, Message: fmt.Sprintf("unknown format %q", d.Format)})
+		return report
+	}
+
+	cards := legalityCards(d, rule)
+	deckSize, sideboardSize := 0, 0
+	copies := make(map[string]int)
+	order := make([]string, 0, len(cards))
+	names := make(map[string]string)
+	hasCommander := false
+	for _, card := range cards {
+		board := deckEntity.NormalizeBoard(card.Board)
+		switch {
+		case board == deckEntity.BoardMaybe:
+			continue
+		case board == deckEntity.BoardMain, board == deckEntity.BoardCommander && rule.singleton:
+			deckSize += card.Quantity
+		case board == deckEntity.BoardSide, board == deckEntity.BoardCompanion, board == deckEntity.BoardCommander:
+			sideboardSize += card.Quantity
+		}
+		if board == deckEntity.BoardCommander {
+			hasCommander = true
+		}
+		// Singleton formats have no sideboard in the deck itself.
+		if rule.singleton && board == deckEntity.BoardSide {
+			continue
+		}
+		key := strings.ToLower(card.Name)
+		if _, seen := copies[key]; !seen {
+			order = append(order, key)
+			names[key] = card.Name
+		}
+		copies[key] += card.Quantity
+	}
+	if rule.singleton && !hasCommander {
+		report.Violations = append(report.Violations, Violation{Rule: RuleCommander, Message: fmt.Sprintf("%s decks need a commander", d.Format)})
+	}
+
+	switch {
+	case rule.maxCards > 0 && rule.minCards == rule.maxCards && deckSize != rule.minCards:
+		report.Violations = append(report.Violations, Violation{Rule: RuleDeckSize, Message: fmt.Sprintf("deck has %d cards; %s requires exactly %d", deckSize, d.Format, rule.minCards)})
+	case deckSize < rule.minCards:
+		report.Violations = append(report.Violations, Violation{Rule: RuleDeckSize, Message: fmt.Sprintf("deck has %d cards; %s requires at least %d", deckSize, d.Format, rule.minCards)})
+	case rule.maxCards > 0 && deckSize > rule.maxCards:
+		report.Violations = append(report.Violations, Violation{Rule: RuleDeckSize, Message: fmt.Sprintf("deck has %d cards; %s allows at most %d", deckSize, d.Format, rule.maxCards)})
+	}
+	if rule.maxSideboard >= 0 && sideboardSize > rule.maxSideboard {
+		report.Violations = append(report.Violations, Violation{Rule: RuleSideboardSize, Message: fmt.Sprintf("sideboard has %d cards; %s allows at most %d", sideboardSize, d.Format, rule.maxSideboard)})
+	}
+
+	unique := uniqueCards(cards)
+	for _, key := range order {
+		card := unique[key]
+		limit := copyLimit(rule, card)
+		if limit > 0 && copies[key] > limit {
+			report.Violations = append(report.Violations, Violation{Rule: RuleCopyLimit, Card: names[key], Message: fmt.Sprintf("%s has %d copies; at most %d allowed", names[key], copies[key], limit)})
+		}
+		switch legality := card.Legalities[rule.legalityKey]; {
+		case rule.legalityKey == "":
+		case legality == "banned":
+			report.Violations = append(report.Violations, Violation{Rule: RuleBanned, Card: names[key], Message: fmt.Sprintf("%s is banned in %s", names[key], d.Format)})
+		case legality == "not_legal":
+			report.Violations = append(report.Violations, Violation{Rule: RuleNotLegal, Card: names[key], Message: fmt.Sprintf("%s is not legal in %s", names[key], d.Format)})
+		}
+	}
+
+	if rule.singleton {
+		identity := commanderIdentity(d, cards)
+		for _, key := range order {
+			card := unique[key]
+			if outside := colorsOutside(card.ColorIdentity, identity); len(outside) > 0 {
+				report.Violations = append(report.Violations, Violation{Rule: RuleColorIdentity, Card: names[key], Message: fmt.Sprintf("%s has colors outside the commander's identity: %s", names[key], strings.Join(outside, ""))})
+			}
+		}
+	}
+
+	report.Legal = len(report.Violations) == 0
+	return report
+}
+
+// legalityCards moves the cards named in Deck.Commander to the commander board
+// when a singleton deck was saved without one, so they count towards its size.
+func legalityCards(d *deckEntity.Deck, rule formatRule) []deckEntity.Card {
+	if !rule.singleton {
+		return d.Cards
+	}
+	main := make([]deckEntity.Card, 0, len(d.Cards))
+	rest := make([]deckEntity.Card, 0, len(d.Cards))
+	for _, card := range d.Cards {
+		switch deckEntity.NormalizeBoard(card.Board) {
+		case deckEntity.BoardCommander:
+			return d.Cards
+		case deckEntity.BoardMain:
+			main = append(main, card)
+		default:
+			rest = append(rest, card)
+		}
+	}
+	commanders, main := splitCommanders(d.Commander, main)
+	for index := range commanders {
+		commanders[index].Board = deckEntity.BoardCommander
+	}
+	return append(append(commanders, main...), rest...)
+}
+
+// uniqueCards keeps the first card seen for each name, ignoring the maybe-board.
+func uniqueCards(cards []deckEntity.Card) map[string]deckEntity.Card {
+	result := make(map[string]deckEntity.Card, len(cards))
+	for _, card := range cards {
+		if deckEntity.NormalizeBoard(card.Board) == deckEntity.BoardMaybe {
+			continue
+		}
+		key := strings.ToLower(card.Name)
+		if _, exists := result[key]; !exists {
+			result[key] = card
+		}
+	}
+	return result
+}
+
+func copyLimit(rule formatRule, card deckEntity.Card) int {
+	key := strings.ToLower(card.Name)
+	if deckEntity.IsBasicLand(card) {
+		return 0
+	}
+	if limit, ok := anyNumberCards[key]; ok {
+		return limit
+	}
+	if card.Legalities[rule.legalityKey] == "restricted" {
+		return 1
+	}
+	return rule.copyLimit
+}
+
+// commanderIdentity uses the color identity of the commander cards and falls
+// back to Deck.Color, which is derived from the commander, when they carry none.
+func commanderIdentity(d *deckEntity.Deck, cards []deckEntity.Card) map[string]bool {
+	identity := make(map[string]bool, 5)
+	found := false
+	for _, card := range cards {
+		if deckEntity.NormalizeBoard(card.Board) != deckEntity.BoardCommander || len(card.ColorIdentity) == 0 {
+			continue
+		}
+		found = true
+		for _, color := range card.ColorIdentity {
+			identity[normalizeColor(color)] = true
+		}
+	}
+	if !found {
+		for _, color := range strings.Split(strings.TrimPrefix(d.Color, "C"), "") {
+			if color != "" {
+				identity[color] = true
+			}
+		}
+	}
+	return identity
+}
+
+func colorsOutside(colors []string, identity map[string]bool) []string {
+	outside := make([]string, 0)
+	for _, color := range colors {
+		if code := normalizeColor(color); !identity[code] {
+			outside = append(outside, code)
+		}
+	}
+	return outside
+}
+
+// normalizeColor accepts Scryfall color codes and the color names used by Archidekt.
+func normalizeColor(color string) string {
+	if code, ok := colorNames[strings.ToLower(color)]; ok {
+		return code
+	}
+	return strings.ToUpper(color)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func legalCommanderDeck() *deckEntity.Deck {
+	return &deckEntity.Deck{
+		Name: "Mono Blue", Color: "U", Format: "commander", Commander: "Thassa, God of the Sea",
+		Cards: []deckEntity.Card{
+			{Name: "Thassa, God of the Sea", Quantity: 1, Board: deckEntity.BoardCommander, ColorIdentity: []string{"U"}},
+			{Name: "Aqueous Form", Quantity: 1, ColorIdentity: []string{"U"}, Legalities: map[string]string{"commander": "legal"}},
+			{Name: "Island", Quantity: 98, TypeLine: "Basic Land — Island"},
+			{Name: "Lightning Bolt", Quantity: 1, Board: deckEntity.BoardMaybe, ColorIdentity: []string{"R"}},
+		},
+	}
+}
+
+func violationRules(report LegalityReport) []string {
+	rules := make([]string, len(report.Violations))
+	for index, violation := range report.Violations {
+		rules[index] = violation.Rule
+	}
+	return rules
+}
+
+func TestCheckLegality_LegalCommanderDeck(t *testing.T) {
+	report := CheckLegality(legalCommanderDeck())
+	assert.True(t, report.Legal)
+	assert.Empty(t, report.Violations)
+}
+
+func TestCheckLegality_CommanderViolations(t *testing.T) {
+	d := legalCommanderDeck()
+	d.Cards[1].Quantity = 2
+	d.Cards = append(d.Cards,
+		deckEntity.Card{Name: "Sol Ring", Quantity: 1, Legalities: map[string]string{"commander": "banned"}},
+		deckEntity.Card{Name: "Swords to Plowshares", Quantity: 1, ColorIdentity: []string{"W"}},
+	)
+
+	report := CheckLegality(d)
+	assert.False(t, report.Legal)
+	assert.Equal(t, []string{RuleDeckSize, RuleCopyLimit, RuleBanned, RuleColorIdentity}, violationRules(report))
+	assert.Equal(t, "deck has 103 cards; commander requires exactly 100", report.Violations[0].Message)
+	assert.Equal(t, "Aqueous Form", report.Violations[1].Card)
+	assert.Equal(t, "Swords to Plowshares has colors outside the commander's identity: W", report.Violations[3].Message)
+}
+
+func TestCheckLegality_CommanderOnlyNamedOnDeck(t *testing.T) {
+	d := &deckEntity.Deck{Name: "Manual", Color: "U", Format: "commander", Commander: "Thassa, God of the Sea", Cards: []deckEntity.Card{
+		{Name: "Island", Quantity: 99},
+		{Name: "Counterspell", Quantity: 1, ColorIdentity: []string{"Blue"}},
+	}}
+	report := CheckLegality(d)
+	assert.Equal(t, []string{RuleDeckSize}, violationRules(report))
+	assert.Equal(t, "deck has 101 cards; commander requires exactly 100", report.Violations[0].Message)
+
+	d.Commander = ""
+	report = CheckLegality(d)
+	assert.Equal(t, []string{RuleCommander}, violationRules(report))
+}
+
+func TestCheckLegality_ConstructedFormats(t *testing.T) {
+	d := &deckEntity.Deck{Name: "Burn", Color: "R", Format: "modern", Cards: []deckEntity.Card{
+		{Name: "Lightning Bolt", Quantity: 4},
+		{Name: "Mountain", Quantity: 40},
+		{Name: "Relentless Rats", Quantity: 12},
+		{Name: "Lurrus of the Dream-Den", Quantity: 1, Board: deckEntity.BoardCompanion},
+		{Name: "Lightning Bolt", Quantity: 1, Board: deckEntity.BoardSide},
+		{Name: "Mountain", Quantity: 14, Board: deckEntity.BoardSide},
+	}}
+
+	report := CheckLegality(d)
+	assert.Equal(t, []string{RuleDeckSize, RuleSideboardSize, RuleCopyLimit}, violationRules(report))
+	assert.Equal(t, "deck has 56 cards; modern requires at least 60", report.Violations[0].Message)
+	assert.Equal(t, "sideboard has 16 cards; modern allows at most 15", report.Violations[1].Message)
+	assert.Equal(t, "Lightning Bolt has 5 copies; at most 4 allowed", report.Violations[2].Message)
+}
+
+func TestCheckLegality_VintageRestricted(t *testing.T) {
+	d := &deckEntity.Deck{Name: "Power", Color: "U", Format: "vintage", Cards: []deckEntity.Card{
+		{Name: "Ancestral Recall", Quantity: 2, Legalities: map[string]string{"vintage": "restricted"}},
+		{Name: "Island", Quantity: 58},
+	}}
+	report := CheckLegality(d)
+	require.Len(t, report.Violations, 1)
+	assert.Equal(t, "Ancestral Recall has 2 copies; at most 1 allowed", report.Violations[0].Message)
+}
+
+func TestCheckLegality_Limited(t *testing.T) {
+	d := &deckEntity.Deck{Name: "Draft", Color: "G", Format: "limited", Cards: []deckEntity.Card{
+		{Name: "Grizzly Bears", Quantity: 23},
+		{Name: "Forest", Quantity: 17},
+	}}
+	assert.True(t, CheckLegality(d).Legal)
+}
+
+func TestService_Legality(t *testing.T) {
+	repo := deckRepo.NewInMemoryRepo()
+	service := NewServiceWithDependencies(repo, NewDispatchImporter(nil), testCardValidator{})
+	d := legalCommanderDeck()
+	require.NoError(t, service.Create(d))
+
+	report, err := service.Legality(d.ID, 1)
+	require.NoError(t, err)
+	assert.True(t, report.Legal)
+
+	_, err = service.Legality(999, 1)
+	assert.Error(t, err)
+}
+
+func TestService_PrepareEnforcesLegality(t *testing.T) {
+	service := NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), NewDispatchImporter(nil), testCardValidator{})
+	d := &deckEntity.Deck{Name: "Burn", Color: "R", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{{Name: "Lightning Bolt", Quantity: 4}}}
+	require.NoError(t, service.Prepare(d))
+
+	service.EnforceLegality(true)
+	err := service.Prepare(d)
+	var legalityErr *LegalityError
+	require.ErrorAs(t, err, &legalityErr)
+	assert.False(t, legalityErr.Report.Legal)
+	assert.Equal(t, []string{RuleDeckSize}, violationRules(legalityErr.Report))
+	assert.Equal(t, "deck is not legal in modern: deck has 4 cards; modern requires at least 60", err.Error())
+}
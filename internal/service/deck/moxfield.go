@@ -0,0 +1,142 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+)
+
+var moxfieldDeckPath = regexp.MustCompile(`^/decks/([A-Za-z0-9_-]+)/?$`)
+
+// moxfieldBoards maps Moxfield boards to deck boards. Custom boards are left out.
+var moxfieldBoards = map[string]string{
+	"commanders": deckEntity.BoardCommander,
+	"companions": deckEntity.BoardCompanion,
+	"mainboard":  deckEntity.BoardMain,
+	"sideboard":  deckEntity.BoardSide,
+	"maybeboard": deckEntity.BoardMaybe,
+}
+
+type MoxfieldImporter struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewMoxfieldImporter() *MoxfieldImporter {
+	return &MoxfieldImporter{
+		client:  &http.Client{Timeout: 15 * time.Second},
+		baseURL: "https://api2.moxfield.com",
+	}
+}
+
+func NewMoxfieldImporterWithBaseURL(client *http.Client, baseURL string) *MoxfieldImporter {
+	return &MoxfieldImporter{client: client, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+type moxfieldResponse struct {
+	Name   string                   `json:"name"`
+	Format string                   `json:"format"`
+	Boards map[string]moxfieldBoard `json:"boards"`
+}
+
+type moxfieldBoard struct {
+	Cards map[string]moxfieldBoardCard `json:"cards"`
+}
+
+type moxfieldBoardCard struct {
+	Quantity int `json:"quantity"`
+	Card     struct {
+		Name          string   `json:"name"`
+		ManaCost      string   `json:"mana_cost"`
+		TypeLine      string   `json:"type_line"`
+		ColorIdentity []string `json:"color_identity"`
+	} `json:"card"`
+}
+
+func (i *MoxfieldImporter) Import(sourceLink string) (*deckEntity.Deck, error) {
+	u, err := url.Parse(sourceLink)
+	if err != nil {
+		return nil, ErrUnsupportedSource
+	}
+	if normalizeSourceHost(u.Hostname()) != "moxfield.com" {
+		return nil, ErrUnsupportedSource
+	}
+
+	match := moxfieldDeckPath.FindStringSubmatch(u.Path)
+	if len(match) != 2 {
+		return nil, ErrUnsupportedSource
+	}
+
+	req, err := http.NewRequest(http.MethodGet, i.baseURL+"/v3/decks/all/"+match[1], nil)
+	if err != nil {
+		return nil, fmt.Errorf("build Moxfield request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "liliana/1.0")
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch Moxfield deck: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch Moxfield deck: status %d", resp.StatusCode)
+	}
+
+	var source moxfieldResponse
+	if err := json.NewDecoder(resp.Body).Decode(&source); err != nil {
+		return nil, fmt.Errorf("decode Moxfield deck: %w", err)
+	}
+
+	colors := make([]string, 0, 5)
+	cards := make([]deckEntity.Card, 0)
+	commanders := make([]string, 0, 2)
+	for boardName, board := range moxfieldBoards {
+		for _, sourceCard := range source.Boards[boardName].Cards {
+			if sourceCard.Quantity <= 0 || sourceCard.Card.Name == "" {
+				continue
+			}
+			cards = append(cards, deckEntity.Card{
+				Name: sourceCard.Card.Name, Quantity: sourceCard.Quantity, Board: board,
+				ManaCost: sourceCard.Card.ManaCost, TypeLine: sourceCard.Card.TypeLine,
+				ColorIdentity: sourceCard.Card.ColorIdentity,
+			})
+			if board == deckEntity.BoardMaybe || board == deckEntity.BoardSide {
+				continue
+			}
+			colors = append(colors, sourceCard.Card.ColorIdentity...)
+			if board == deckEntity.BoardCommander {
+				commanders = append(commanders, sourceCard.Card.Name)
+			}
+		}
+	}
+
+	cards = mergeCardsByOracleID(cards)
+	sortCards(cards)
+	sort.Strings(commanders)
+	return &deckEntity.Deck{
+		Name:      source.Name,
+		Color:     colorIdentityCode(colors),
+		Format:    moxfieldFormat(source.Format),
+		Commander: strings.Join(commanders, " / "),
+		Cards:     cards,
+	}, nil
+}
+
+func moxfieldFormat(value string) string {
+	switch format := strings.ToLower(value); format {
+	case "commander", "standard", "modern", "pioneer", "legacy", "vintage", "pauper", "brawl", "oathbreaker":
+		return format
+	case "edh":
+		return "commander"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoxfieldImporter_Import(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/decks/all/aBc-12_x", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"name":"Partners", "format":"commander",
+			"boards":{
+				"commanders":{"cards":{
+					"a":{"quantity":1,"card":{"name":"Tymna the Weaver","color_identity":["W","B"]}},
+					"b":{"quantity":1,"card":{"name":"Kraum, Ludevic's Opus","color_identity":["U"]}}
+				}},
+				"companions":{"cards":{"c":{"quantity":1,"card":{"name":"Lurrus of the Dream-Den","color_identity":["W","B"]}}}},
+				"mainboard":{"cards":{"d":{"quantity":2,"card":{"name":"Forest","type_line":"Basic Land — Forest","color_identity":["G"]}}}},
+				"sideboard":{"cards":{"e":{"quantity":1,"card":{"name":"Forest","color_identity":["G"]}}}},
+				"maybeboard":{"cards":{"f":{"quantity":1,"card":{"name":"Maybe Card","color_identity":["R"]}}}}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	importer := NewMoxfieldImporterWithBaseURL(server.Client(), server.URL)
+	deck, err := importer.Import("https://www.moxfield.com/decks/aBc-12_x")
+	require.NoError(t, err)
+	assert.Equal(t, "Partners", deck.Name)
+	assert.Equal(t, "commander", deck.Format)
+	assert.Equal(t, "WUBG", deck.Color)
+	assert.Equal(t, "Kraum, Ludevic's Opus / Tymna the Weaver", deck.Commander)
+	require.Len(t, deck.Cards, 6)
+	assert.Equal(t, deckEntity.Card{Name: "Forest", Quantity: 2, Board: deckEntity.BoardMain, TypeLine: "Basic Land — Forest", ColorIdentity: []string{"G"}}, deck.Cards[0])
+	assert.Equal(t, deckEntity.BoardSide, deck.Cards[1].Board)
+	assert.Equal(t, deckEntity.BoardCommander, deck.Cards[2].Board)
+	assert.Equal(t, deckEntity.BoardCompanion, deck.Cards[3].Board)
+	assert.Equal(t, deckEntity.Card{Name: "Maybe Card", Quantity: 1, Board: deckEntity.BoardMaybe, ColorIdentity: []string{"R"}}, deck.Cards[4])
+}
+
+func TestMoxfieldImporter_RejectsUnsupportedSource(t *testing.T) {
+	importer := NewMoxfieldImporter()
+	_, err := importer.Import("https://archidekt.com/decks/123")
+	assert.ErrorIs(t, err, ErrUnsupportedSource)
+	_, err = importer.Import("https://moxfield.com/users/someone")
+	assert.ErrorIs(t, err, ErrUnsupportedSource)
+}
+
+func TestMoxfieldFormat(t *testing.T) {
+	assert.Equal(t, "commander", moxfieldFormat("commander"))
+	assert.Equal(t, "modern", moxfieldFormat("Modern"))
+	assert.Equal(t, "", moxfieldFormat("historicBrawl"))
+}
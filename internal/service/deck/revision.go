@@ -0,0 +1,191 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+)
+
+// RevisionSummary describes a revision without its card list.
+type RevisionSummary struct {
+	Number    int       `json:"number"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	Format    string    `json:"format"`
+	Commander string    `json:"commander"`
+	Cards     int       `json:"cards"`
+}
+
+// RevisionDiff lists what changed from one revision to another. Fields holds
+// only the deck attributes that differ, keyed by their JSON name.
+type RevisionDiff struct {
+	From    int                    `json:"from"`
+	To      int                    `json:"to"`
+	Fields  map[string]FieldChange `json:"fields"`
+	Added   []CardChange           `json:"added"`
+	Removed []CardChange           `json:"removed"`
+	Changed []CardChange           `json:"changed"`
+}
+
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CardChange is the quantity of a card in each revision; zero means absent.
+type CardChange struct {
+	Name  string `json:"name"`
+	Board string `json:"board"`
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+}
+
+func (s *Service) Revisions(id, userID int64) ([]RevisionSummary, error) {
+	if _, err := s.view(id, userID); err != nil {
+		return nil, err
+	}
+	revisions, err := s.repo.Revisions(id)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]RevisionSummary, len(revisions))
+	for index, revision := range revisions {
+		cards := 0
+		for _, card := range revision.Deck.Cards {
+			cards += card.Quantity
+		}
+		summaries[index] = RevisionSummary{
+			Number:    revision.Number,
+			CreatedAt: revision.CreatedAt,
+			Name:      revision.Deck.Name,
+			Format:    revision.Deck.Format,
+			Commander: revision.Deck.Commander,
+			Cards:     cards,
+		}
+	}
+	return summaries, nil
+}
+
+func (s *Service) Revision(id, userID int64, number int) (*deckEntity.Revision, error) {
+	if _, err := s.view(id, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.Revision(id, number)
+}
+
+// DiffRevisions compares revision from with revision to. A zero to compares
+// with the latest revision.
+func (s *Service) DiffRevisions(id, userID int64, from, to int) (*RevisionDiff, error) {
+	if to == 0 {
+		revisions, err := s.Revisions(id, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(revisions) == 0 {
+			return nil, errors.New("revision not found")
+		}
+		to = revisions[len(revisions)-1].Number
+	}
+	older, err := s.Revision(id, userID, from)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := s.repo.Revision(id, to)
+	if err != nil {
+		return nil, err
+	}
+	diff := DiffDecks(&older.Deck, &newer.Deck)
+	diff.From, diff.To = from, to
+	return &diff, nil
+}
+
+// RestoreRevision makes a past revision the current deck of userID. History
+// is kept: the restored deck is recorded as a new revision.
+func (s *Service) RestoreRevision(id, userID int64, number int) (*deckEntity.Deck, error) {
+	existing, err := s.authorize(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.repo.Revision(id, number)
+	if err != nil {
+		return nil, err
+	}
+	restored := revision.Deck
+	restored.OwnerID = existing.OwnerID
+	restored.Visibility = existing.Visibility
+	if err := s.repo.Update(id, &restored); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// DiffDecks compares the attributes and cards of two decks. Cards are matched
+// by board and name, ignoring case.
+func DiffDecks(from, to *deckEntity.Deck) RevisionDiff {
+	diff := RevisionDiff{
+		Fields:  make(map[string]FieldChange),
+		Added:   make([]CardChange, 0),
+		Removed: make([]CardChange, 0),
+		Changed: make([]CardChange, 0),
+	}
+	for _, field := range []struct{ name, from, to string }{
+		{"name", from.Name, to.Name},
+		{"color", from.Color, to.Color},
+		{"format", from.Format, to.Format},
+		{"commander", from.Commander, to.Commander},
+		{"source_link", from.SourceLink, to.SourceLink},
+	} {
+		if field.from != field.to {
+			diff.Fields[field.name] = FieldChange{From: field.from, To: field.to}
+		}
+	}
+
+	changes := make(map[string]*CardChange)
+	var keys []string
+	count := func(cards []deckEntity.Card, quantity func(*CardChange) *int) {
+		for _, card := range cards {
+			key := cardKey(card.Board, card.Name)
+			change, ok := changes[key]
+			if !ok {
+				change = &CardChange{Name: card.Name, Board: deckEntity.NormalizeBoard(card.Board)}
+				changes[key] = change
+				keys = append(keys, key)
+			}
+			*quantity(change) += card.Quantity
+		}
+	}
+	count(from.Cards, func(change *CardChange) *int { return &change.From })
+	count(to.Cards, func(change *CardChange) *int { return &change.To })
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := changes[keys[i]], changes[keys[j]]
+		if a.Board != b.Board {
+			return boardOrder(a.Board) < boardOrder(b.Board)
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	for _, key := range keys {
+		change := *changes[key]
+		switch {
+		case change.From == 0:
+			diff.Added = append(diff.Added, change)
+		case change.To == 0:
+			diff.Removed = append(diff.Removed, change)
+		case change.From != change.To:
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	return diff
+}
+
+func boardOrder(board string) int {
+	for index, valid := range deckEntity.Boards {
+		if board == valid {
+			return index
+		}
+	}
+	return len(deckEntity.Boards)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffDecks(t *testing.T) {
+	from := &deckEntity.Deck{Name: "Control", Format: "modern", Cards: []deckEntity.Card{
+		{Name: "Negate", Quantity: 2},
+		{Name: "Island", Quantity: 10},
+		{Name: "Dispel", Quantity: 1, Board: deckEntity.BoardSide},
+	}}
+	to := &deckEntity.Deck{Name: "Control v2", Format: "modern", Cards: []deckEntity.Card{
+		{Name: "negate", Quantity: 3},
+		{Name: "Island", Quantity: 10},
+		{Name: "Dispel", Quantity: 1},
+		{Name: "Counterspell", Quantity: 4},
+	}}
+
+	diff := DiffDecks(from, to)
+
+	assert.Equal(t, map[string]FieldChange{"name": {From: "Control", To: "Control v2"}}, diff.Fields)
+	assert.Equal(t, []CardChange{
+		{Name: "Counterspell", Board: deckEntity.BoardMain, From: 0, To: 4},
+		{Name: "Dispel", Board: deckEntity.BoardMain, From: 0, To: 1},
+	}, diff.Added)
+	assert.Equal(t, []CardChange{{Name: "Dispel", Board: deckEntity.BoardSide, From: 1, To: 0}}, diff.Removed)
+	assert.Equal(t, []CardChange{{Name: "Negate", Board: deckEntity.BoardMain, From: 2, To: 3}}, diff.Changed)
+}
+
+func TestService_RevisionsAndRestore(t *testing.T) {
+	service := NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), NewArchidektImporter(), testCardValidator{})
+	d := &deckEntity.Deck{Name: "Manual", Color: "U", Format: "commander", Commander: "Thassa", OwnerID: 1, Cards: []deckEntity.Card{{Name: "Aqueous Form", Quantity: 1}}}
+	require.NoError(t, service.Create(d))
+	_, err := service.AddCards(d.ID, 1, []deckEntity.Card{{Name: "Vorrac Battlehorns", Quantity: 1}})
+	require.NoError(t, err)
+
+	revisions, err := service.Revisions(d.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 1, revisions[0].Cards)
+	assert.Equal(t, 2, revisions[1].Cards)
+
+	diff, err := service.DiffRevisions(d.ID, 1, 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, diff.To)
+	assert.Equal(t, []CardChange{{Name: "Vorrac Battlehorns", Board: deckEntity.BoardMain, From: 0, To: 1}}, diff.Added)
+
+	_, err = service.RestoreRevision(d.ID, 2, 1)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	restored, err := service.RestoreRevision(d.ID, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, restored.Cards, 1)
+	assert.Equal(t, "Aqueous Form", restored.Cards[0].Name)
+
+	revisions, err = service.Revisions(d.ID, 1)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 3, "restoring records a new revision")
+
+	_, err = service.Revision(d.ID, 1, 9)
+	assert.EqualError(t, err, "revision not found")
+	_, err = service.Revisions(999, 1)
+	assert.EqualError(t, err, "deck not found")
+}
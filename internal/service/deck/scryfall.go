@@ -68,8 +68,11 @@ type scryfallCard struct {
 	ColorIdentity []string           `json:"color_identity"`
 	ImageURIs     map[string]string  `json:"image_uris"`
 	CardFaces     []scryfallCardFace `json:"card_faces"`
+	Legalities    map[string]string  `json:"legalities"`
+	Layout        string             `json:"layout"`
 }
 type scryfallCardFace struct {
+	OracleID   string            `json:"oracle_id"`
 	Name       string            `json:"name"`
 	ManaCost   string            `json:"mana_cost"`
 	TypeLine   string            `json:"type_line"`
@@ -214,7 +217,7 @@ func cardFromScryfall(source scryfallCard) deckEntity.Card {
 		}
 		typeLine = strings.Join(faceTypes, " // ")
 	}
-	return deckEntity.Card{OracleID: source.OracleID, Name: source.Name, ManaCost: manaCost, TypeLine: typeLine, ColorIdentity: source.ColorIdentity, ImageURI: imageURI}
+	return deckEntity.Card{OracleID: source.OracleID, Name: source.Name, ManaCost: manaCost, TypeLine: typeLine, ColorIdentity: source.ColorIdentity, ImageURI: imageURI, Legalities: source.Legalities}
 }
 
 func (v *ScryfallValidator) Validate(cards []deckEntity.Card) ([]deckEntity.Card, error) {
@@ -233,6 +236,7 @@ func (v *ScryfallValidator) Validate(cards []deckEntity.Card) ([]deckEntity.Card
 		v.mu.Unlock()
 		if ok {
 			cached.Quantity = card.Quantity
+			cached.Board = card.Board
 			result[index] = cached
 			continue
 		}
@@ -255,6 +259,7 @@ func (v *ScryfallValidator) Validate(cards []deckEntity.Card) ([]deckEntity.Card
 				return nil, fmt.Errorf("card not found: %s", result[index].Name)
 			}
 			card.Quantity = result[index].Quantity
+			card.Board = result[index].Board
 			result[index] = card
 		}
 	}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -11,14 +12,28 @@ import (
 	deckRepo "github.com/josofm/liliana/internal/repository/deck"
 )
 
+// ErrForbidden is returned when a user changes a deck owned by someone else.
+var ErrForbidden = errors.New("deck belongs to another user")
+
+// ErrPrivateDeck is returned when a share link is requested for a private deck.
+var ErrPrivateDeck = errors.New("private decks cannot be shared")
+
+// Memberships reports whether two users share a playgroup. Members of a
+// playgroup view each other's decks whatever their visibility.
+type Memberships interface {
+	SharePlaygroup(userID, otherID int64) (bool, error)
+}
+
 type Service struct {
-	repo      deckRepo.Repository
-	importer  SourceImporter
-	validator CardValidator
+	repo            deckRepo.Repository
+	importer        SourceImporter
+	validator       CardValidator
+	enforceLegality bool
+	memberships     Memberships
 }
 
 func NewService(repo deckRepo.Repository) *Service {
-	return &Service{repo: repo, importer: NewArchidektImporter(), validator: NewScryfallValidator()}
+	return &Service{repo: repo, importer: NewDefaultImporter(), validator: NewScryfallValidator()}
 }
 
 func NewServiceWithImporter(repo deckRepo.Repository, importer SourceImporter) *Service {
@@ -29,7 +44,31 @@ func NewServiceWithDependencies(repo deckRepo.Repository, importer SourceImporte
 	return &Service{repo: repo, importer: importer, validator: validator}
 }
 
+// EnforceLegality makes Prepare reject decks that break the rules of their
+// format with a *LegalityError.
+func (s *Service) EnforceLegality(enforce bool) {
+	s.enforceLegality = enforce
+}
+
+// ShareWithPlaygroups lets members of a playgroup view each other's decks.
+func (s *Service) ShareWithPlaygroups(memberships Memberships) {
+	s.memberships = memberships
+}
+
 func (s *Service) Prepare(d *deckEntity.Deck) error {
+	if err := s.prepare(d); err != nil {
+		return err
+	}
+	if !s.enforceLegality {
+		return nil
+	}
+	if report := CheckLegality(d); !report.Legal {
+		return &LegalityError{Report: report}
+	}
+	return nil
+}
+
+func (s *Service) prepare(d *deckEntity.Deck) error {
 	if d.SourceLink == "" {
 		return s.prepareManual(d)
 	}
@@ -58,6 +97,9 @@ func (s *Service) prepareManual(d *deckEntity.Deck) error {
 	if d.Cards == nil {
 		d.Cards = make([]deckEntity.Card, 0)
 	}
+	if d.Commander == "" {
+		d.Commander = boardCardNames(d.Cards, deckEntity.BoardCommander)
+	}
 	if err := s.prepareCommander(d, true); err != nil {
 		return err
 	}
@@ -83,6 +125,16 @@ func (s *Service) prepareCommander(d *deckEntity.Deck, deriveColor bool) error {
 	return nil
 }
 
+func boardCardNames(cards []deckEntity.Card, board string) string {
+	names := make([]string, 0, 2)
+	for _, card := range cards {
+		if deckEntity.NormalizeBoard(card.Board) == board {
+			names = append(names, card.Name)
+		}
+	}
+	return strings.Join(names, " / ")
+}
+
 func colorIdentityCode(colors []string) string {
 	present := make(map[string]bool, len(colors))
 	for _, color := range colors {
@@ -124,6 +176,15 @@ func (s *Service) SearchCommanders(query string) ([]CommanderSuggestion, error)
 	return s.validator.SearchCommanders(query)
 }
 
+// SupportedSources returns the host patterns accepted in source links.
+func (s *Service) SupportedSources() []string {
+	lister, ok := s.importer.(SourceLister)
+	if !ok {
+		return []string{}
+	}
+	return lister.Hosts()
+}
+
 func (s *Service) GetAll() ([]*deckEntity.Deck, error) {
 	decks, err := s.repo.GetAll()
 	if err != nil {
@@ -139,29 +200,168 @@ func (s *Service) GetAll() ([]*deckEntity.Deck, error) {
 	return result, nil
 }
 
-func (s *Service) GetByID(id int64) (*deckEntity.Deck, error) {
-	return s.repo.GetByID(id)
+// List returns a page of decks matching filter.
+func (s *Service) List(ctx context.Context, filter deckRepo.ListFilter) (*deckRepo.ListPage, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// GetByID returns a deck that userID may view.
+func (s *Service) GetByID(id, userID int64) (*deckEntity.Deck, error) {
+	return s.view(id, userID)
+}
+
+// view loads a deck that userID is about to read. Decks hidden from userID
+// are reported as not found, so their existence is not revealed.
+func (s *Service) view(id, userID int64) (*deckEntity.Deck, error) {
+	d, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := CanView(d, userID, s.memberships)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, errors.New("deck not found")
+	}
+	return d, nil
+}
+
+// CanView reports whether userID may view d: through Deck.CanView or, when
+// memberships is not nil, by sharing a playgroup with its owner.
+func CanView(d *deckEntity.Deck, userID int64, memberships Memberships) (bool, error) {
+	if d.CanView(userID) {
+		return true, nil
+	}
+	if memberships == nil || userID == 0 {
+		return false, nil
+	}
+	return memberships.SharePlaygroup(userID, d.OwnerID)
+}
+
+func (s *Service) Export(id, userID int64, format string) (*Export, error) {
+	d, err := s.view(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return ExportDeck(d, format)
+}
+
+func (s *Service) Legality(id, userID int64) (*LegalityReport, error) {
+	d, err := s.view(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	report := CheckLegality(d)
+	return &report, nil
 }
 
-func (s *Service) Update(id int64, d *deckEntity.Deck) error {
+// Stats summarizes the cards of the given boards, or of StatsBoards when none are given.
+func (s *Service) Stats(id, userID int64, boards []string) (*DeckStats, error) {
+	d, err := s.view(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(boards) == 0 {
+		boards = StatsBoards
+	}
+	cards, err := FilterCards(d.Cards, boards)
+	if err != nil {
+		return nil, err
+	}
+	stats := CalculateStats(cards)
+	return &stats, nil
+}
+
+// authorize loads a deck that userID is about to change.
+func (s *Service) authorize(id, userID int64) (*deckEntity.Deck, error) {
+	d, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if d.OwnerID != userID {
+		return nil, ErrForbidden
+	}
+	return d, nil
+}
+
+// Authorize reports whether userID owns the deck. Handlers call it before
+// Prepare, so other users cannot trigger imports or card lookups.
+func (s *Service) Authorize(id, userID int64) error {
+	_, err := s.authorize(id, userID)
+	return err
+}
+
+// Update replaces a deck of userID. The owner never changes, an update
+// without visibility keeps the current one, and a manual update without cards
+// keeps the current list; ChangeCards removes cards.
+func (s *Service) Update(id, userID int64, d *deckEntity.Deck) error {
+	existing, err := s.authorize(id, userID)
+	if err != nil {
+		return err
+	}
+	d.OwnerID = existing.OwnerID
+	if d.Visibility == "" {
+		d.Visibility = existing.Visibility
+	}
+	if d.SourceLink == "" && len(d.Cards) == 0 {
+		d.Cards = existing.Cards
+	}
 	return s.repo.Update(id, d)
 }
 
-func (s *Service) Delete(id int64) error {
+func (s *Service) Delete(id, userID int64) error {
+	if _, err := s.authorize(id, userID); err != nil {
+		return err
+	}
 	return s.repo.Delete(id)
 }
 
+// boardHeadings maps the section titles used by common deck list formats to
+// boards. Headings may end with ":" and may be written as "//" comments.
+var boardHeadings = map[string]string{
+	"commander":   deckEntity.BoardCommander,
+	"commanders":  deckEntity.BoardCommander,
+	"companion":   deckEntity.BoardCompanion,
+	"companions":  deckEntity.BoardCompanion,
+	"deck":        deckEntity.BoardMain,
+	"main":        deckEntity.BoardMain,
+	"mainboard":   deckEntity.BoardMain,
+	"side":        deckEntity.BoardSide,
+	"sideboard":   deckEntity.BoardSide,
+	"maybe":       deckEntity.BoardMaybe,
+	"maybeboard":  deckEntity.BoardMaybe,
+	"considering": deckEntity.BoardMaybe,
+}
+
+// ParseCardList reads one "<quantity> <card name>" per line. Section headings
+// such as "Sideboard:" switch the board of the following cards, and a "SB:"
+// prefix puts a single line in the sideboard.
 func ParseCardList(value string) ([]deckEntity.Card, error) {
 	cards := make([]deckEntity.Card, 0)
 	positions := make(map[string]int)
 	scanner := bufio.NewScanner(strings.NewReader(value))
 	lineNumber := 0
+	board := deckEntity.BoardMain
 	for scanner.Scan() {
 		lineNumber++
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
 		}
+		if heading, ok := boardHeading(line); ok {
+			board = heading
+			continue
+		}
+		// Other lines starting with "//" are comments.
+		if strings.HasPrefix(line, "//") {
+			continue
+		}
+		lineBoard := board
+		if len(line) >= 3 && strings.EqualFold(line[:3], "SB:") {
+			line = strings.TrimSpace(line[3:])
+			lineBoard = deckEntity.BoardSide
+		}
 		fields := strings.Fields(line)
 		if len(fields) < 2 {
 			return nil, fmt.Errorf("invalid card at line %d: expected '<quantity> <card name>'", lineNumber)
@@ -172,13 +372,13 @@ func ParseCardList(value string) ([]deckEntity.Card, error) {
 		if err != nil || quantity <= 0 || name == "" {
 			return nil, fmt.Errorf("invalid card at line %d: expected '<quantity> <card name>'", lineNumber)
 		}
-		key := strings.ToLower(name)
+		key := cardKey(lineBoard, name)
 		if position, exists := positions[key]; exists {
 			cards[position].Quantity += quantity
 			continue
 		}
 		positions[key] = len(cards)
-		cards = append(cards, deckEntity.Card{Name: name, Quantity: quantity})
+		cards = append(cards, deckEntity.Card{Name: name, Quantity: quantity, Board: lineBoard})
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("read card list: %w", err)
@@ -186,33 +386,35 @@ func ParseCardList(value string) ([]deckEntity.Card, error) {
 	return cards, nil
 }
 
-func (s *Service) AddCards(id int64, cards []deckEntity.Card) (*deckEntity.Deck, error) {
-	if len(cards) == 0 {
-		return nil, errors.New("card list cannot be empty")
-	}
-	cards, err := s.validator.Validate(cards)
-	if err != nil {
-		return nil, err
-	}
-	d, err := s.repo.GetByID(id)
-	if err != nil {
-		return nil, err
+func boardHeading(line string) (string, bool) {
+	heading := strings.TrimSpace(strings.TrimPrefix(line, "//"))
+	heading = strings.TrimSpace(strings.TrimSuffix(heading, ":"))
+	board, ok := boardHeadings[strings.ToLower(heading)]
+	return board, ok
+}
+
+// cardKey identifies a card within a deck: the same card may appear once per board.
+func cardKey(board, name string) string {
+	return deckEntity.NormalizeBoard(board) + "\x00" + strings.ToLower(name)
+}
+
+// FilterCards keeps only the cards in the given boards. An empty list keeps every card.
+func FilterCards(cards []deckEntity.Card, boards []string) ([]deckEntity.Card, error) {
+	if len(boards) == 0 {
+		return cards, nil
 	}
-	positions := make(map[string]int, len(d.Cards))
-	for index, card := range d.Cards {
-		positions[strings.ToLower(card.Name)] = index
+	wanted := make(map[string]bool, len(boards))
+	for _, board := range boards {
+		if !deckEntity.IsBoard(board) {
+			return nil, fmt.Errorf("invalid board %q: expected one of %s", board, strings.Join(deckEntity.Boards, ", "))
+		}
+		wanted[deckEntity.NormalizeBoard(board)] = true
 	}
+	result := make([]deckEntity.Card, 0, len(cards))
 	for _, card := range cards {
-		key := strings.ToLower(card.Name)
-		if position, exists := positions[key]; exists {
-			d.Cards[position].Quantity += card.Quantity
-			continue
+		if wanted[deckEntity.NormalizeBoard(card.Board)] {
+			result = append(result, card)
 		}
-		positions[key] = len(d.Cards)
-		d.Cards = append(d.Cards, card)
-	}
-	if err := s.repo.Update(id, d); err != nil {
-		return nil, err
 	}
-	return d, nil
+	return result, nil
 }
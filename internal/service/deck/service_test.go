@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -85,6 +86,17 @@ func TestService_GetAll(t *testing.T) {
 	assert.Len(t, decks, 2)
 }
 
+func TestService_List(t *testing.T) {
+	service := NewService(deckRepo.NewInMemoryRepo())
+	require.NoError(t, service.Create(&deckEntity.Deck{Name: "Deck 1", Color: "WU", Format: "commander", OwnerID: 1}))
+	require.NoError(t, service.Create(&deckEntity.Deck{Name: "Deck 2", Color: "BR", Format: "modern", OwnerID: 2}))
+
+	page, err := service.List(context.Background(), deckRepo.ListFilter{Format: "modern"})
+	require.NoError(t, err)
+	require.Len(t, page.Decks, 1)
+	assert.Equal(t, "Deck 2", page.Decks[0].Name)
+}
+
 func TestService_GetByID(t *testing.T) {
 	repo := deckRepo.NewInMemoryRepo()
 	service := NewService(repo)
@@ -93,7 +105,7 @@ func TestService_GetByID(t *testing.T) {
 	service.Create(deck)
 
 	// Test successful retrieval
-	found, err := service.GetByID(1)
+	found, err := service.GetByID(1, 1)
 	assert.NoError(t, err)
 	assert.Equal(t, deck.Name, found.Name)
 	assert.Equal(t, deck.Color, found.Color)
@@ -101,7 +113,7 @@ func TestService_GetByID(t *testing.T) {
 	assert.Equal(t, deck.Commander, found.Commander)
 
 	// Test not found
-	notFound, err := service.GetByID(999)
+	notFound, err := service.GetByID(999, 1)
 	assert.Error(t, err)
 	assert.Nil(t, notFound)
 }
@@ -114,18 +126,33 @@ func TestService_Update(t *testing.T) {
 	deck := &deckEntity.Deck{Name: "Original Deck", Color: "WU", Format: "commander", Commander: "Azorius", OwnerID: 1}
 	service.Create(deck)
 
-	// Update deck
+	// Update deck; the owner in the payload is ignored
 	updatedDeck := &deckEntity.Deck{Name: "Updated Deck", Color: "BR", Format: "commander", Commander: "Rakdos", OwnerID: 2}
-	err := service.Update(1, updatedDeck)
+	err := service.Update(1, 1, updatedDeck)
 	assert.NoError(t, err)
 
 	// Verify update
-	found, err := service.GetByID(1)
+	found, err := service.GetByID(1, 1)
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Deck", found.Name)
 	assert.Equal(t, "BR", found.Color)
 	assert.Equal(t, "commander", found.Format)
 	assert.Equal(t, "Rakdos", found.Commander)
+	assert.Equal(t, int64(1), found.OwnerID)
+}
+
+func TestService_UpdateWithoutCardsKeepsCardList(t *testing.T) {
+	service := NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), NewArchidektImporter(), testCardValidator{})
+	d := &deckEntity.Deck{Name: "Manual", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{{Name: "Negate", Quantity: 4}}}
+	require.NoError(t, service.Create(d))
+
+	require.NoError(t, service.Update(d.ID, 1, &deckEntity.Deck{Name: "Renamed", Color: "U", Format: "modern"}))
+
+	found, err := service.GetByID(d.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", found.Name)
+	require.Len(t, found.Cards, 1)
+	assert.Equal(t, "Negate", found.Cards[0].Name)
 }
 
 func TestService_Delete(t *testing.T) {
@@ -137,29 +164,99 @@ func TestService_Delete(t *testing.T) {
 	service.Create(deck)
 
 	// Verify deck exists
-	found, err := service.GetByID(1)
+	found, err := service.GetByID(1, 1)
 	assert.NoError(t, err)
 	assert.NotNil(t, found)
 
 	// Delete deck
-	err = service.Delete(1)
+	err = service.Delete(1, 1)
 	assert.NoError(t, err)
 
 	// Verify deck is deleted
-	found, err = service.GetByID(1)
+	found, err = service.GetByID(1, 1)
 	assert.Error(t, err)
 	assert.Nil(t, found)
 }
 
+func TestService_OwnershipWithMemoryRepo(t *testing.T) {
+	testServiceOwnership(t, deckRepo.NewInMemoryRepo())
+}
+
+// testServiceOwnership checks that only the owner changes a deck. It is shared
+// by the memory and Postgres repository tests.
+func testServiceOwnership(t *testing.T, repo deckRepo.Repository) {
+	t.Helper()
+	service := NewServiceWithDependencies(repo, NewDispatchImporter(nil), testCardValidator{})
+	d := &deckEntity.Deck{Name: "Owned", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{}}
+	require.NoError(t, service.Create(d))
+
+	err := service.Update(d.ID, 2, &deckEntity.Deck{Name: "Stolen", Color: "R", Format: "modern", OwnerID: 2})
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = service.AddCards(d.ID, 2, []deckEntity.Card{{Name: "Lightning Bolt", Quantity: 4}})
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ErrorIs(t, service.Delete(d.ID, 2), ErrForbidden)
+
+	found, err := service.GetByID(d.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Owned", found.Name)
+	assert.Equal(t, int64(1), found.OwnerID)
+	assert.Empty(t, found.Cards)
+
+	updated := &deckEntity.Deck{Name: "Renamed", Color: "U", Format: "modern", OwnerID: 2}
+	require.NoError(t, service.Update(d.ID, 1, updated))
+	assert.Equal(t, int64(1), updated.OwnerID)
+	found, err = service.GetByID(d.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", found.Name)
+	assert.Equal(t, int64(1), found.OwnerID)
+
+	_, err = service.AddCards(d.ID, 1, []deckEntity.Card{{Name: "Lightning Bolt", Quantity: 4}})
+	require.NoError(t, err)
+	require.NoError(t, service.Delete(d.ID, 1))
+	assert.EqualError(t, service.Delete(d.ID, 1), "deck not found")
+}
+
 func TestParseCardList(t *testing.T) {
 	cards, err := ParseCardList("1 Aqueous Form\n1 Vorrac Battlehorns\n2 aqueous form\n")
 	assert.NoError(t, err)
 	assert.Equal(t, []deckEntity.Card{
-		{Name: "Aqueous Form", Quantity: 3},
-		{Name: "Vorrac Battlehorns", Quantity: 1},
+		{Name: "Aqueous Form", Quantity: 3, Board: deckEntity.BoardMain},
+		{Name: "Vorrac Battlehorns", Quantity: 1, Board: deckEntity.BoardMain},
 	}, cards)
 }
 
+func TestParseCardList_Boards(t *testing.T) {
+	cards, err := ParseCardList("Commander\n1 Thassa, God of the Sea\n\nDeck\n1 Aqueous Form\nSB: 2 Negate\n\nSideboard:\n1 Negate\n// Maybeboard\n1 Sol Ring\n// just a comment\n")
+	require.NoError(t, err)
+	assert.Equal(t, []deckEntity.Card{
+		{Name: "Thassa, God of the Sea", Quantity: 1, Board: deckEntity.BoardCommander},
+		{Name: "Aqueous Form", Quantity: 1, Board: deckEntity.BoardMain},
+		{Name: "Negate", Quantity: 3, Board: deckEntity.BoardSide},
+		{Name: "Sol Ring", Quantity: 1, Board: deckEntity.BoardMaybe},
+	}, cards)
+}
+
+func TestParseCardList_SameCardInDifferentBoards(t *testing.T) {
+	cards, err := ParseCardList("1 Negate\nSB: 1 Negate")
+	require.NoError(t, err)
+	assert.Len(t, cards, 2)
+}
+
+func TestFilterCards(t *testing.T) {
+	cards := []deckEntity.Card{{Name: "A", Board: deckEntity.BoardMain}, {Name: "B", Board: deckEntity.BoardSide}, {Name: "C"}}
+
+	filtered, err := FilterCards(cards, []string{"main"})
+	require.NoError(t, err)
+	assert.Equal(t, []deckEntity.Card{{Name: "A", Board: deckEntity.BoardMain}, {Name: "C"}}, filtered)
+
+	filtered, err = FilterCards(cards, nil)
+	require.NoError(t, err)
+	assert.Len(t, filtered, 3)
+
+	_, err = FilterCards(cards, []string{"graveyard"})
+	assert.EqualError(t, err, `invalid board "graveyard": expected one of commander, companion, main, side, maybe`)
+}
+
 func TestParseCardList_InvalidLine(t *testing.T) {
 	_, err := ParseCardList("Aqueous Form")
 	assert.EqualError(t, err, "invalid card at line 1: expected '<quantity> <card name>'")
@@ -199,13 +296,25 @@ func TestColorIdentityCode(t *testing.T) {
 	assert.Equal(t, "C", colorIdentityCode(nil))
 }
 
+func TestService_PrepareManualTakesCommanderFromCommanderBoard(t *testing.T) {
+	repo := deckRepo.NewInMemoryRepo()
+	service := NewServiceWithDependencies(repo, NewDispatchImporter(nil), testCardValidator{})
+	cards, err := ParseCardList("Commander\n1 Thassa\nDeck\n1 Aqueous Form")
+	require.NoError(t, err)
+	d := &deckEntity.Deck{Name: "Manual", Format: "commander", OwnerID: 1, Cards: cards}
+
+	require.NoError(t, service.Prepare(d))
+	assert.Equal(t, "Thassa", d.Commander)
+	assert.Equal(t, deckEntity.BoardCommander, d.Cards[0].Board)
+}
+
 func TestService_AddCards(t *testing.T) {
 	repo := deckRepo.NewInMemoryRepo()
 	service := NewServiceWithDependencies(repo, NewArchidektImporter(), testCardValidator{})
 	d := &deckEntity.Deck{Name: "Manual", Color: "U", Format: "commander", Commander: "Thassa", OwnerID: 1, Cards: []deckEntity.Card{{Name: "Aqueous Form", Quantity: 1}}}
 	assert.NoError(t, service.Create(d))
 
-	updated, err := service.AddCards(d.ID, []deckEntity.Card{{Name: "aqueous form", Quantity: 2}, {Name: "Vorrac Battlehorns", Quantity: 1}})
+	updated, err := service.AddCards(d.ID, 1, []deckEntity.Card{{Name: "aqueous form", Quantity: 2}, {Name: "Vorrac Battlehorns", Quantity: 1}})
 	assert.NoError(t, err)
 	assert.Equal(t, 3, updated.Cards[0].Quantity)
 	assert.Equal(t, "Vorrac Battlehorns", updated.Cards[1].Name)
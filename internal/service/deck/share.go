@@ -0,0 +1,89 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+)
+
+// shareTokenBytes is the size of the random part of a share token.
+const shareTokenBytes = 24
+
+// ShareLink is the token that lets anyone read a deck without signing in.
+type ShareLink struct {
+	DeckID     int64  `json:"deck_id"`
+	Token      string `json:"token"`
+	Visibility string `json:"visibility"`
+}
+
+// Share returns the share link of a deck of userID, creating it on the first
+// call. Private decks cannot be shared.
+func (s *Service) Share(id, userID int64) (*ShareLink, error) {
+	d, err := s.authorize(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Visibility == deckEntity.VisibilityPrivate {
+		return nil, ErrPrivateDeck
+	}
+	if d.ShareToken == "" {
+		token, err := newShareToken()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.SetShareToken(id, token); err != nil {
+			return nil, err
+		}
+		d.ShareToken = token
+	}
+	return shareLink(d), nil
+}
+
+// ShareLink returns the current share link of a deck of userID.
+func (s *Service) ShareLink(id, userID int64) (*ShareLink, error) {
+	d, err := s.authorize(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if d.ShareToken == "" {
+		return nil, errors.New("share link not found")
+	}
+	return shareLink(d), nil
+}
+
+// Unshare revokes the share link of a deck of userID.
+func (s *Service) Unshare(id, userID int64) error {
+	if _, err := s.authorize(id, userID); err != nil {
+		return err
+	}
+	return s.repo.SetShareToken(id, "")
+}
+
+// Shared returns the deck behind a share token. userID is zero for anonymous
+// readers. A deck made private after being shared is only readable by its
+// owner.
+func (s *Service) Shared(token string, userID int64) (*deckEntity.Deck, error) {
+	d, err := s.repo.GetByShareToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if d.Visibility == deckEntity.VisibilityPrivate && d.OwnerID != userID {
+		return nil, errors.New("deck not found")
+	}
+	return d, nil
+}
+
+func shareLink(d *deckEntity.Deck) *ShareLink {
+	return &ShareLink{DeckID: d.ID, Token: d.ShareToken, Visibility: d.Visibility}
+}
+
+func newShareToken() (string, error) {
+	bytes := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
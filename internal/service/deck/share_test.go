@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	"github.com/josofm/liliana/internal/entity/playgroup"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	playgroupRepo "github.com/josofm/liliana/internal/repository/playgroup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Visibility(t *testing.T) {
+	service := NewService(deckRepo.NewInMemoryRepo())
+	private := &deckEntity.Deck{Name: "Private", Color: "U", Format: "modern", OwnerID: 1, Visibility: deckEntity.VisibilityPrivate}
+	unlisted := &deckEntity.Deck{Name: "Unlisted", Color: "U", Format: "modern", OwnerID: 1, Visibility: deckEntity.VisibilityUnlisted}
+	require.NoError(t, service.Create(private))
+	require.NoError(t, service.Create(unlisted))
+
+	_, err := service.GetByID(private.ID, 1)
+	assert.NoError(t, err, "owners see their own decks")
+	_, err = service.GetByID(private.ID, 2)
+	assert.EqualError(t, err, "deck not found")
+	_, err = service.GetByID(unlisted.ID, 2)
+	assert.EqualError(t, err, "deck not found", "unlisted decks are only read through share links")
+	_, err = service.Stats(private.ID, 2, nil)
+	assert.EqualError(t, err, "deck not found")
+	_, err = service.Compare(unlisted.ID, private.ID, 2, false)
+	assert.EqualError(t, err, "deck not found")
+
+	require.NoError(t, service.Update(private.ID, 1, &deckEntity.Deck{Name: "Renamed", Color: "U", Format: "modern"}))
+	found, err := service.GetByID(private.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, deckEntity.VisibilityPrivate, found.Visibility, "updates without visibility keep it")
+}
+
+func TestService_VisibilityInPlaygroups(t *testing.T) {
+	service := NewService(deckRepo.NewInMemoryRepo())
+	private := &deckEntity.Deck{Name: "Private", Color: "U", Format: "modern", OwnerID: 1, Visibility: deckEntity.VisibilityPrivate}
+	require.NoError(t, service.Create(private))
+	playgroups := playgroupRepo.NewInMemoryRepo()
+	require.NoError(t, playgroups.Create(&playgroup.Playgroup{Name: "Pod", Members: []playgroup.Member{{UserID: 1, Role: playgroup.RoleOwner}, {UserID: 2, Role: playgroup.RoleMember}}}))
+	service.ShareWithPlaygroups(playgroups)
+
+	_, err := service.GetByID(private.ID, 2)
+	assert.NoError(t, err, "playgroup members see each other's decks")
+	_, err = service.Export(private.ID, 2, "text")
+	assert.NoError(t, err)
+	_, err = service.GetByID(private.ID, 3)
+	assert.EqualError(t, err, "deck not found")
+	assert.ErrorIs(t, service.Delete(private.ID, 2), ErrForbidden, "members still cannot change the deck")
+}
+
+func TestService_Share(t *testing.T) {
+	service := NewService(deckRepo.NewInMemoryRepo())
+	d := &deckEntity.Deck{Name: "Unlisted", Color: "U", Format: "modern", OwnerID: 1, Visibility: deckEntity.VisibilityUnlisted}
+	require.NoError(t, service.Create(d))
+
+	_, err := service.ShareLink(d.ID, 1)
+	assert.EqualError(t, err, "share link not found")
+	_, err = service.Share(d.ID, 2)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	link, err := service.Share(d.ID, 1)
+	require.NoError(t, err)
+	assert.NotEmpty(t, link.Token)
+	again, err := service.Share(d.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, link.Token, again.Token, "sharing twice keeps the link")
+	current, err := service.ShareLink(d.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, link, current)
+
+	shared, err := service.Shared(link.Token, 0)
+	require.NoError(t, err)
+	assert.Equal(t, d.ID, shared.ID)
+
+	require.NoError(t, service.Update(d.ID, 1, &deckEntity.Deck{Name: "Unlisted", Color: "U", Format: "modern", Visibility: deckEntity.VisibilityPrivate}))
+	_, err = service.Shared(link.Token, 2)
+	assert.EqualError(t, err, "deck not found", "private decks are not readable through old links")
+	_, err = service.Shared(link.Token, 1)
+	assert.NoError(t, err, "owners still read their private decks")
+	_, err = service.Share(d.ID, 1)
+	assert.ErrorIs(t, err, ErrPrivateDeck)
+
+	require.NoError(t, service.Unshare(d.ID, 1))
+	_, err = service.ShareLink(d.ID, 1)
+	assert.EqualError(t, err, "share link not found")
+}
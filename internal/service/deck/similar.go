@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+)
+
+// DeckComparison lists the cards two decks share and the cards only one of
+// them has. Quantities are summed across boards, leaving out the maybeboard.
+type DeckComparison struct {
+	A      int64             `json:"a"`
+	B      int64             `json:"b"`
+	Score  float64           `json:"score"`
+	Shared []SharedCard      `json:"shared"`
+	OnlyA  []deckEntity.Card `json:"only_a"`
+	OnlyB  []deckEntity.Card `json:"only_b"`
+}
+
+// SharedCard is a card in both decks, with the quantity in each.
+type SharedCard struct {
+	OracleID  string `json:"oracle_id"`
+	Name      string `json:"name"`
+	QuantityA int    `json:"quantity_a"`
+	QuantityB int    `json:"quantity_b"`
+}
+
+// Similar returns the decks userID may view sharing the most cards with a deck.
+func (s *Service) Similar(ctx context.Context, id, userID int64, filter deckRepo.SimilarFilter) ([]deckRepo.SimilarDeck, error) {
+	if _, err := s.view(id, userID); err != nil {
+		return nil, err
+	}
+	filter.ViewerID = userID
+	return s.repo.Similar(ctx, id, filter)
+}
+
+func (s *Service) Compare(a, b, userID int64, ignoreBasicLands bool) (*DeckComparison, error) {
+	deckA, err := s.view(a, userID)
+	if err != nil {
+		return nil, err
+	}
+	deckB, err := s.view(b, userID)
+	if err != nil {
+		return nil, err
+	}
+	return CompareDecks(deckA, deckB, ignoreBasicLands), nil
+}
+
+// CompareDecks compares two decks with the same weighted Jaccard score used
+// by Similar.
+func CompareDecks(a, b *deckEntity.Deck, ignoreBasicLands bool) *DeckComparison {
+	quantitiesA := deckRepo.CardQuantities(a.Cards, ignoreBasicLands)
+	quantitiesB := deckRepo.CardQuantities(b.Cards, ignoreBasicLands)
+	score, _ := deckRepo.Similarity(quantitiesA, quantitiesB)
+	comparison := &DeckComparison{
+		A:      a.ID,
+		B:      b.ID,
+		Score:  score,
+		Shared: make([]SharedCard, 0),
+		OnlyA:  make([]deckEntity.Card, 0),
+		OnlyB:  make([]deckEntity.Card, 0),
+	}
+
+	for key, card := range comparedCards(a.Cards, quantitiesA) {
+		if quantity, ok := quantitiesB[key]; ok {
+			comparison.Shared = append(comparison.Shared, SharedCard{OracleID: card.OracleID, Name: card.Name, QuantityA: card.Quantity, QuantityB: quantity})
+			continue
+		}
+		comparison.OnlyA = append(comparison.OnlyA, card)
+	}
+	for key, card := range comparedCards(b.Cards, quantitiesB) {
+		if _, ok := quantitiesA[key]; !ok {
+			comparison.OnlyB = append(comparison.OnlyB, card)
+		}
+	}
+	sort.Slice(comparison.Shared, func(i, j int) bool {
+		return strings.ToLower(comparison.Shared[i].Name) < strings.ToLower(comparison.Shared[j].Name)
+	})
+	sortCardsByName(comparison.OnlyA)
+	sortCardsByName(comparison.OnlyB)
+	return comparison
+}
+
+// comparedCards returns one card per key of quantities, with the summed
+// quantity and no board.
+func comparedCards(cards []deckEntity.Card, quantities map[string]int) map[string]deckEntity.Card {
+	result := make(map[string]deckEntity.Card, len(quantities))
+	for _, card := range cards {
+		key := deckRepo.CardIdentity(card)
+		quantity, ok := quantities[key]
+		if _, seen := result[key]; seen || !ok {
+			continue
+		}
+		card.Quantity, card.Board = quantity, ""
+		card.Legalities = nil
+		result[key] = card
+	}
+	return result
+}
+
+func sortCardsByName(cards []deckEntity.Card) {
+	sort.Slice(cards, func(i, j int) bool { return strings.ToLower(cards[i].Name) < strings.ToLower(cards[j].Name) })
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareDecks(t *testing.T) {
+	a := &deckEntity.Deck{ID: 1, Cards: []deckEntity.Card{
+		{OracleID: "sol-ring", Name: "Sol Ring", Quantity: 1},
+		{OracleID: "counterspell", Name: "Counterspell", Quantity: 2},
+		{OracleID: "counterspell", Name: "Counterspell", Quantity: 1, Board: deckEntity.BoardSide},
+		{OracleID: "island", Name: "Island", Quantity: 10, TypeLine: "Basic Land — Island"},
+		{OracleID: "brainstorm", Name: "Brainstorm", Quantity: 1, Board: deckEntity.BoardMaybe},
+	}}
+	b := &deckEntity.Deck{ID: 2, Cards: []deckEntity.Card{
+		{OracleID: "counterspell", Name: "Counterspell", Quantity: 1},
+		{OracleID: "island", Name: "Island", Quantity: 8, TypeLine: "Basic Land — Island"},
+		{OracleID: "arcane-signet", Name: "Arcane Signet", Quantity: 1},
+		{OracleID: "brainstorm", Name: "Brainstorm", Quantity: 4},
+	}}
+
+	comparison := CompareDecks(a, b, false)
+
+	assert.InDelta(t, 9.0/19, comparison.Score, 1e-9)
+	assert.Equal(t, []SharedCard{
+		{OracleID: "counterspell", Name: "Counterspell", QuantityA: 3, QuantityB: 1},
+		{OracleID: "island", Name: "Island", QuantityA: 10, QuantityB: 8},
+	}, comparison.Shared)
+	assert.Equal(t, []deckEntity.Card{{OracleID: "sol-ring", Name: "Sol Ring", Quantity: 1}}, comparison.OnlyA)
+	require.Len(t, comparison.OnlyB, 2)
+	assert.Equal(t, "Arcane Signet", comparison.OnlyB[0].Name)
+	assert.Equal(t, "Brainstorm", comparison.OnlyB[1].Name, "maybeboard cards are not compared")
+
+	comparison = CompareDecks(a, b, true)
+	assert.InDelta(t, 1.0/9, comparison.Score, 1e-9)
+	assert.Len(t, comparison.Shared, 1)
+}
+
+func TestService_Compare(t *testing.T) {
+	service := NewServiceWithDependencies(deckRepo.NewInMemoryRepo(), NewArchidektImporter(), testCardValidator{})
+	d := &deckEntity.Deck{Name: "Manual", Color: "U", Format: "modern", OwnerID: 1, Cards: []deckEntity.Card{{Name: "Negate", Quantity: 4}}}
+	require.NoError(t, service.Create(d))
+
+	comparison, err := service.Compare(d.ID, d.ID, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, comparison.Score)
+
+	_, err = service.Compare(d.ID, 99, 1, false)
+	assert.EqualError(t, err, "deck not found")
+}
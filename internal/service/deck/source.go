@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	deckEntity "github.com/josofm/liliana/internal/entity/deck"
@@ -22,6 +23,125 @@ type SourceImporter interface {
 	Import(sourceLink string) (*deckEntity.Deck, error)
 }
 
+// SourceLister is implemented by importers that can report the hosts they
+// accept, such as DispatchImporter.
+type SourceLister interface {
+	Hosts() []string
+}
+
+// UnsupportedSourceError reports a source link whose host has no registered
+// importer, together with the host patterns that are accepted.
+type UnsupportedSourceError struct {
+	Host           string
+	SupportedHosts []string
+}
+
+func (e *UnsupportedSourceError) Error() string {
+	if e.Host == "" {
+		return fmt.Sprintf("%s (supported: %s)", ErrUnsupportedSource, strings.Join(e.SupportedHosts, ", "))
+	}
+	return fmt.Sprintf("%s: %s (supported: %s)", ErrUnsupportedSource, e.Host, strings.Join(e.SupportedHosts, ", "))
+}
+
+func (e *UnsupportedSourceError) Is(target error) bool {
+	return target == ErrUnsupportedSource
+}
+
+// DispatchImporter picks the importer registered for the hostname of the
+// source link. Patterns are either an exact host ("archidekt.com") or a
+// wildcard for a domain and its subdomains ("*.example.com"). A leading "www."
+// is ignored when matching.
+type DispatchImporter struct {
+	mu      sync.RWMutex
+	entries []sourceEntry
+}
+
+type sourceEntry struct {
+	pattern  string
+	importer SourceImporter
+}
+
+// NewDispatchImporter registers each importer under its host pattern; more
+// importers can be added later with Register.
+func NewDispatchImporter(importers map[string]SourceImporter) *DispatchImporter {
+	d := &DispatchImporter{}
+	for pattern, importer := range importers {
+		d.Register(pattern, importer)
+	}
+	return d
+}
+
+// NewDefaultImporter dispatches to every deck site supported by Liliana.
+func NewDefaultImporter() *DispatchImporter {
+	return NewDispatchImporter(map[string]SourceImporter{
+		"archidekt.com": NewArchidektImporter(),
+		"moxfield.com":  NewMoxfieldImporter(),
+	})
+}
+
+// Register adds an importer for a host pattern, replacing any importer
+// previously registered for the same pattern.
+func (r *DispatchImporter) Register(pattern string, importer SourceImporter) {
+	pattern = normalizeSourceHost(pattern)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for index, entry := range r.entries {
+		if entry.pattern == pattern {
+			r.entries[index].importer = importer
+			return
+		}
+	}
+	r.entries = append(r.entries, sourceEntry{pattern: pattern, importer: importer})
+}
+
+// Hosts returns the registered host patterns in alphabetical order.
+func (r *DispatchImporter) Hosts() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	hosts := make([]string, len(r.entries))
+	for index, entry := range r.entries {
+		hosts[index] = entry.pattern
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func (r *DispatchImporter) Import(sourceLink string) (*deckEntity.Deck, error) {
+	u, err := url.Parse(sourceLink)
+	if err != nil || u.Hostname() == "" {
+		return nil, &UnsupportedSourceError{SupportedHosts: r.Hosts()}
+	}
+	host := normalizeSourceHost(u.Hostname())
+	importer, ok := r.lookup(host)
+	if !ok {
+		return nil, &UnsupportedSourceError{Host: host, SupportedHosts: r.Hosts()}
+	}
+	return importer.Import(sourceLink)
+}
+
+func (r *DispatchImporter) lookup(host string) (SourceImporter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, entry := range r.entries {
+		if hostMatches(entry.pattern, host) {
+			return entry.importer, true
+		}
+	}
+	return nil, false
+}
+
+func hostMatches(pattern, host string) bool {
+	domain, wildcard := strings.CutPrefix(pattern, "*.")
+	if !wildcard {
+		return host == pattern
+	}
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func normalizeSourceHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(host)), "www.")
+}
+
 type ArchidektImporter struct {
 	client  *http.Client
 	baseURL string
@@ -68,7 +188,7 @@ type archidektDeckCard struct {
 
 func (i *ArchidektImporter) Import(sourceLink string) (*deckEntity.Deck, error) {
 	u, err := url.Parse(sourceLink)
-	if err != nil || !strings.EqualFold(u.Hostname(), "archidekt.com") {
+	if err != nil || normalizeSourceHost(u.Hostname()) != "archidekt.com" {
 		return nil, ErrUnsupportedSource
 	}
 
@@ -107,26 +227,30 @@ func (i *ArchidektImporter) Import(sourceLink string) (*deckEntity.Deck, error)
 	cards := make([]deckEntity.Card, 0, len(source.Cards))
 	commanders := make([]string, 0, 2)
 	for _, sourceCard := range source.Cards {
-		if !cardIsIncluded(sourceCard.Categories, includedCategories) || sourceCard.Quantity <= 0 || sourceCard.Card.OracleCard.Name == "" {
+		if sourceCard.Quantity <= 0 || sourceCard.Card.OracleCard.Name == "" {
 			continue
 		}
+		board := archidektBoard(sourceCard.Categories, includedCategories)
 		card := deckEntity.Card{
-			Name: sourceCard.Card.OracleCard.Name, Quantity: sourceCard.Quantity,
+			Name: sourceCard.Card.OracleCard.Name, Quantity: sourceCard.Quantity, Board: board,
 			OracleID: sourceCard.Card.OracleCard.UID, ManaCost: sourceCard.Card.OracleCard.ManaCost,
 			TypeLine:      archidektTypeLine(sourceCard.Card.OracleCard.SuperTypes, sourceCard.Card.OracleCard.Types, sourceCard.Card.OracleCard.SubTypes),
 			ColorIdentity: sourceCard.Card.OracleCard.ColorIdentity,
 		}
 		cards = append(cards, card)
+		if board == deckEntity.BoardMaybe || board == deckEntity.BoardSide {
+			continue
+		}
 		for _, color := range sourceCard.Card.OracleCard.ColorIdentity {
 			colors[color] = true
 		}
-		if containsCategory(sourceCard.Categories, "Commander") {
+		if board == deckEntity.BoardCommander {
 			commanders = append(commanders, card.Name)
 		}
 	}
 
 	cards = mergeCardsByOracleID(cards)
-	sort.Slice(cards, func(a, b int) bool { return cards[a].Name < cards[b].Name })
+	sortCards(cards)
 	return &deckEntity.Deck{
 		Name:      source.Name,
 		Color:     colorCode(colors),
@@ -140,9 +264,9 @@ func mergeCardsByOracleID(cards []deckEntity.Card) []deckEntity.Card {
 	result := make([]deckEntity.Card, 0, len(cards))
 	positions := make(map[string]int, len(cards))
 	for _, card := range cards {
-		key := card.OracleID
-		if key == "" {
-			key = strings.ToLower(card.Name)
+		key := cardKey(card.Board, card.OracleID)
+		if card.OracleID == "" {
+			key = cardKey(card.Board, card.Name)
 		}
 		if position, exists := positions[key]; exists {
 			result[position].Quantity += card.Quantity
@@ -154,6 +278,17 @@ func mergeCardsByOracleID(cards []deckEntity.Card) []deckEntity.Card {
 	return result
 }
 
+// sortCards orders cards by name and then by board, the same order used by the
+// Postgres repository.
+func sortCards(cards []deckEntity.Card) {
+	sort.SliceStable(cards, func(a, b int) bool {
+		if cards[a].Name != cards[b].Name {
+			return cards[a].Name < cards[b].Name
+		}
+		return deckEntity.NormalizeBoard(cards[a].Board) < deckEntity.NormalizeBoard(cards[b].Board)
+	})
+}
+
 func archidektTypeLine(superTypes, types, subTypes []string) string {
 	left := strings.Join(append(append([]string{}, superTypes...), types...), " ")
 	if len(subTypes) == 0 {
@@ -162,6 +297,24 @@ func archidektTypeLine(superTypes, types, subTypes []string) string {
 	return left + " — " + strings.Join(subTypes, " ")
 }
 
+// archidektBoard maps Archidekt categories to a board. Cards outside the
+// categories included in the deck go to the sideboard when they are in a
+// "Sideboard" category and to the maybe-board otherwise.
+func archidektBoard(categories []string, included map[string]bool) string {
+	switch {
+	case containsCategory(categories, "Commander"):
+		return deckEntity.BoardCommander
+	case containsCategory(categories, "Companion"):
+		return deckEntity.BoardCompanion
+	case cardIsIncluded(categories, included):
+		return deckEntity.BoardMain
+	case containsCategory(categories, "Sideboard"):
+		return deckEntity.BoardSide
+	default:
+		return deckEntity.BoardMaybe
+	}
+}
+
 func cardIsIncluded(categories []string, included map[string]bool) bool {
 	for _, category := range categories {
 		if included[category] {
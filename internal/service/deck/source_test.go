@@ -21,13 +21,15 @@ func TestArchidektImporter_Import(t *testing.T) {
 			"categories":[
 				{"name":"Commander","includedInDeck":true},
 				{"name":"Mainboard","includedInDeck":true},
-				{"name":"Maybeboard","includedInDeck":false}
+				{"name":"Maybeboard","includedInDeck":false},
+				{"name":"Sideboard","includedInDeck":false}
 			],
 			"cards":[
 				{"categories":["Commander"],"quantity":1,"card":{"oracleCard":{"name":"Tymna the Weaver","uid":"id-1","colorIdentity":["White","Black"]}}},
 				{"categories":["Commander"],"quantity":1,"card":{"oracleCard":{"name":"Kraum, Ludevic's Opus","uid":"id-2","colorIdentity":["Blue","Red"]}}},
 				{"categories":["Mainboard"],"quantity":2,"card":{"oracleCard":{"name":"Forest","uid":"id-3","colorIdentity":["Green"]}}},
-				{"categories":["Maybeboard"],"quantity":1,"card":{"oracleCard":{"name":"Ignored Card","uid":"id-4","colorIdentity":[]}}}
+				{"categories":["Maybeboard"],"quantity":1,"card":{"oracleCard":{"name":"Maybe Card","uid":"id-4","colorIdentity":["Red"]}}},
+				{"categories":["Sideboard"],"quantity":1,"card":{"oracleCard":{"name":"Forest","uid":"id-3","colorIdentity":["Green"]}}}
 			]
 		}`))
 	}))
@@ -40,9 +42,47 @@ func TestArchidektImporter_Import(t *testing.T) {
 	assert.Equal(t, "commander", deck.Format)
 	assert.Equal(t, "WUBRG", deck.Color)
 	assert.Equal(t, "Tymna the Weaver / Kraum, Ludevic's Opus", deck.Commander)
-	assert.Len(t, deck.Cards, 3)
-	assert.Equal(t, "Forest", deck.Cards[0].Name)
-	assert.Equal(t, 2, deck.Cards[0].Quantity)
+	assert.Equal(t, []deckEntity.Card{
+		{OracleID: "id-3", Name: "Forest", Quantity: 2, Board: deckEntity.BoardMain, ColorIdentity: []string{"Green"}},
+		{OracleID: "id-3", Name: "Forest", Quantity: 1, Board: deckEntity.BoardSide, ColorIdentity: []string{"Green"}},
+		{OracleID: "id-2", Name: "Kraum, Ludevic's Opus", Quantity: 1, Board: deckEntity.BoardCommander, ColorIdentity: []string{"Blue", "Red"}},
+		{OracleID: "id-4", Name: "Maybe Card", Quantity: 1, Board: deckEntity.BoardMaybe, ColorIdentity: []string{"Red"}},
+		{OracleID: "id-1", Name: "Tymna the Weaver", Quantity: 1, Board: deckEntity.BoardCommander, ColorIdentity: []string{"White", "Black"}},
+	}, deck.Cards)
+}
+
+func TestArchidektImporter_MaybeBoardDoesNotAffectColor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"name":"Mono Green", "deckFormat":2,
+			"categories":[{"name":"Mainboard","includedInDeck":true},{"name":"Maybeboard","includedInDeck":false}],
+			"cards":[
+				{"categories":["Mainboard"],"quantity":4,"card":{"oracleCard":{"name":"Llanowar Elves","uid":"id-1","colorIdentity":["Green"]}}},
+				{"categories":["Maybeboard"],"quantity":1,"card":{"oracleCard":{"name":"Lightning Bolt","uid":"id-2","colorIdentity":["Red"]}}}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	deck, err := NewArchidektImporterWithBaseURL(server.Client(), server.URL).Import("https://archidekt.com/decks/1")
+	require.NoError(t, err)
+	assert.Equal(t, "G", deck.Color)
+	assert.Len(t, deck.Cards, 2)
+}
+
+func TestArchidektImporter_AcceptsWWWHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/decks/7/", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"Elves","deckFormat":2,"categories":[],"cards":[]}`))
+	}))
+	defer server.Close()
+
+	// The default importer routes www links here, so the importer accepts them too
+	deck, err := NewArchidektImporterWithBaseURL(server.Client(), server.URL).Import("https://www.archidekt.com/decks/7/elves")
+	require.NoError(t, err)
+	assert.Equal(t, "Elves", deck.Name)
 }
 
 func TestArchidektImporter_RejectsUnsupportedSource(t *testing.T) {
@@ -72,3 +112,69 @@ func TestServicePrepare_SourceOnlyRequiresSuccessfulImport(t *testing.T) {
 	d := &deckEntity.Deck{OwnerID: 1, SourceLink: "https://archidekt.com/decks/123"}
 	assert.EqualError(t, service.Prepare(d), "offline")
 }
+
+func TestDispatchImporter_PicksImporterByHost(t *testing.T) {
+	registry := NewDispatchImporter(nil)
+	registry.Register("archidekt.com", testSourceImporter{deck: &deckEntity.Deck{Name: "From Archidekt"}})
+	registry.Register("*.moxfield.com", testSourceImporter{deck: &deckEntity.Deck{Name: "From Moxfield"}})
+
+	deck, err := registry.Import("https://www.moxfield.com/decks/abc")
+	require.NoError(t, err)
+	assert.Equal(t, "From Moxfield", deck.Name)
+
+	deck, err = registry.Import("https://moxfield.com/decks/abc")
+	require.NoError(t, err)
+	assert.Equal(t, "From Moxfield", deck.Name)
+
+	deck, err = registry.Import("https://WWW.Archidekt.com/decks/123")
+	require.NoError(t, err)
+	assert.Equal(t, "From Archidekt", deck.Name)
+
+	assert.Equal(t, []string{"*.moxfield.com", "archidekt.com"}, registry.Hosts())
+}
+
+func TestDispatchImporter_FromMap(t *testing.T) {
+	archidekt := testSourceImporter{deck: &deckEntity.Deck{Name: "From Archidekt"}}
+	moxfield := testSourceImporter{deck: &deckEntity.Deck{Name: "From Moxfield"}}
+	importer := NewDispatchImporter(map[string]SourceImporter{"archidekt.com": archidekt, "moxfield.com": moxfield})
+
+	deck, err := importer.Import("https://www.moxfield.com/decks/abc")
+	require.NoError(t, err)
+	assert.Equal(t, "From Moxfield", deck.Name)
+
+	deck, err = importer.Import("https://archidekt.com/decks/123")
+	require.NoError(t, err)
+	assert.Equal(t, "From Archidekt", deck.Name)
+
+	_, err = importer.Import("https://tappedout.net/mtg-decks/abc")
+	assert.ErrorIs(t, err, ErrUnsupportedSource)
+}
+
+func TestDispatchImporter_RegisterReplacesPattern(t *testing.T) {
+	registry := NewDispatchImporter(nil)
+	registry.Register("archidekt.com", testSourceImporter{deck: &deckEntity.Deck{Name: "Old"}})
+	registry.Register("www.archidekt.com", testSourceImporter{deck: &deckEntity.Deck{Name: "New"}})
+
+	deck, err := registry.Import("https://archidekt.com/decks/123")
+	require.NoError(t, err)
+	assert.Equal(t, "New", deck.Name)
+	assert.Equal(t, []string{"archidekt.com"}, registry.Hosts())
+}
+
+func TestDispatchImporter_UnsupportedSourceListsHosts(t *testing.T) {
+	registry := NewDefaultImporter()
+	_, err := registry.Import("https://tappedout.net/mtg-decks/abc")
+
+	assert.ErrorIs(t, err, ErrUnsupportedSource)
+	var unsupported *UnsupportedSourceError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, "tappedout.net", unsupported.Host)
+	assert.Equal(t, []string{"archidekt.com", "moxfield.com"}, unsupported.SupportedHosts)
+	assert.EqualError(t, err, "unsupported deck source: tappedout.net (supported: archidekt.com, moxfield.com)")
+}
+
+func TestService_SupportedSources(t *testing.T) {
+	repo := deckRepo.NewInMemoryRepo()
+	assert.Equal(t, []string{"archidekt.com", "moxfield.com"}, NewService(repo).SupportedSources())
+	assert.Empty(t, NewServiceWithImporter(repo, failingImporter{}).SupportedSources())
+}
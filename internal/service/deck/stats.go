@@ -0,0 +1,161 @@
+package service
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+)
+
+// StatsBoards are the boards counted by Stats when none are requested.
+var StatsBoards = []string{deckEntity.BoardCommander, deckEntity.BoardMain}
+
+// statsTypes are the card types reported in DeckStats.Types, in display order.
+var statsTypes = []string{"creature", "planeswalker", "battle", "artifact", "enchantment", "instant", "sorcery", "land"}
+
+var permanentTypes = map[string]bool{
+	"creature": true, "planeswalker": true, "battle": true, "artifact": true, "enchantment": true, "land": true,
+}
+
+var manaSymbolPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// DeckStats summarizes the cards of a deck. Every count is weighted by quantity.
+type DeckStats struct {
+	Cards            int            `json:"cards"`
+	Lands            int            `json:"lands"`
+	AverageManaValue float64        `json:"average_mana_value"`
+	ManaCurve        []CurvePoint   `json:"mana_curve"`
+	ColorPips        map[string]int `json:"color_pips"`
+	Types            map[string]int `json:"types"`
+}
+
+// CurvePoint counts the nonland cards with a given mana value.
+type CurvePoint struct {
+	ManaValue    int `json:"mana_value"`
+	Permanent    int `json:"permanent"`
+	NonPermanent int `json:"non_permanent"`
+}
+
+// CalculateStats builds the mana curve, color pips and type distribution of
+// cards. Lands are left out of the curve and the average mana value. Hybrid
+// symbols count one pip for each of their colors, and Phyrexian symbols count
+// as a pip of their color.
+func CalculateStats(cards []deckEntity.Card) DeckStats {
+	stats := DeckStats{
+		ManaCurve: make([]CurvePoint, 0),
+		ColorPips: map[string]int{"W": 0, "U": 0, "B": 0, "R": 0, "G": 0, "C": 0},
+		Types:     make(map[string]int, len(statsTypes)),
+	}
+	for _, cardType := range statsTypes {
+		stats.Types[cardType] = 0
+	}
+
+	curve := make(map[int]*CurvePoint)
+	totalManaValue, spells := 0, 0
+	for _, card := range cards {
+		stats.Cards += card.Quantity
+		types := cardTypes(card.TypeLine)
+		for _, cardType := range statsTypes {
+			if types[cardType] {
+				stats.Types[cardType] += card.Quantity
+			}
+		}
+		for color, pips := range colorPips(card.ManaCost) {
+			stats.ColorPips[color] += pips * card.Quantity
+		}
+		if types["land"] {
+			stats.Lands += card.Quantity
+			continue
+		}
+
+		manaValue := ManaValue(card.ManaCost)
+		totalManaValue += manaValue * card.Quantity
+		spells += card.Quantity
+		point, ok := curve[manaValue]
+		if !ok {
+			point = &CurvePoint{ManaValue: manaValue}
+			curve[manaValue] = point
+		}
+		if isPermanent(types) {
+			point.Permanent += card.Quantity
+		} else {
+			point.NonPermanent += card.Quantity
+		}
+	}
+
+	for _, point := range curve {
+		stats.ManaCurve = append(stats.ManaCurve, *point)
+	}
+	sort.Slice(stats.ManaCurve, func(i, j int) bool { return stats.ManaCurve[i].ManaValue < stats.ManaCurve[j].ManaValue })
+	if spells > 0 {
+		stats.AverageManaValue = math.Round(float64(totalManaValue)/float64(spells)*100) / 100
+	}
+	return stats
+}
+
+// ManaValue adds up the symbols of a mana cost. X, Y and Z count as zero, and
+// the faces of split cards ("{1}{R} // {2}{U}") are added together.
+func ManaValue(manaCost string) int {
+	total := 0
+	for _, symbol := range manaSymbols(manaCost) {
+		if value, err := strconv.Atoi(symbol); err == nil {
+			total += value
+			continue
+		}
+		switch {
+		case symbol == "X", symbol == "Y", symbol == "Z":
+		case strings.HasPrefix(symbol, "2/"):
+			// Monocolored hybrid such as {2/W}.
+			total += 2
+		default:
+			total++
+		}
+	}
+	return total
+}
+
+func colorPips(manaCost string) map[string]int {
+	pips := make(map[string]int)
+	for _, symbol := range manaSymbols(manaCost) {
+		for _, part := range strings.Split(symbol, "/") {
+			switch part {
+			case "W", "U", "B", "R", "G", "C":
+				pips[part]++
+			}
+		}
+	}
+	return pips
+}
+
+func manaSymbols(manaCost string) []string {
+	matches := manaSymbolPattern.FindAllStringSubmatch(strings.ToUpper(manaCost), -1)
+	symbols := make([]string, len(matches))
+	for index, match := range matches {
+		symbols[index] = match[1]
+	}
+	return symbols
+}
+
+// cardTypes reads the card types of the front face, so a modal double-faced
+// "Sorcery // Land" is not counted as a land.
+func cardTypes(typeLine string) map[string]bool {
+	frontFace, _, _ := strings.Cut(typeLine, " // ")
+	types, _, _ := strings.Cut(frontFace, "—")
+	result := make(map[string]bool)
+	for _, word := range strings.Fields(strings.ToLower(types)) {
+		result[word] = true
+	}
+	return result
+}
+
+func isPermanent(types map[string]bool) bool {
+	for cardType := range types {
+		if permanentTypes[cardType] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManaValue(t *testing.T) {
+	cases := map[string]int{
+		"":                 0,
+		"{X}{R}":           1,
+		"{2}{U}{U}":        4,
+		"{W/U}{W/U}":       2,
+		"{2/G}{2/G}{2/G}":  6,
+		"{1}{B/P}":         2,
+		"{1}{R} // {2}{U}": 5,
+		"{10}":             10,
+		"{C}{C}{S}":        3,
+		"{1}{g/w}{g/w}{W}": 4,
+	}
+	for manaCost, want := range cases {
+		assert.Equal(t, want, ManaValue(manaCost), manaCost)
+	}
+}
+
+func TestCalculateStats(t *testing.T) {
+	stats := CalculateStats([]deckEntity.Card{
+		{Name: "Thassa, God of the Sea", Quantity: 1, ManaCost: "{2}{U}", TypeLine: "Legendary Enchantment Creature — God"},
+		{Name: "Counterspell", Quantity: 2, ManaCost: "{U}{U}", TypeLine: "Instant"},
+		{Name: "Kitchen Finks", Quantity: 1, ManaCost: "{1}{G/W}{G/W}", TypeLine: "Creature — Ouphe"},
+		{Name: "Gitaxian Probe", Quantity: 1, ManaCost: "{U/P}", TypeLine: "Sorcery"},
+		{Name: "Emeria's Call // Emeria, Shattered Skyclave", Quantity: 1, ManaCost: "{4}{W}{W}{W}", TypeLine: "Sorcery // Land"},
+		{Name: "Island", Quantity: 30, TypeLine: "Basic Land — Island"},
+		{Name: "Wastes", Quantity: 1, TypeLine: "Basic Land"},
+		{Name: "Eldrazi Scion", Quantity: 1, ManaCost: "{C}", TypeLine: "Creature — Eldrazi Scion"},
+	})
+
+	assert.Equal(t, 38, stats.Cards)
+	assert.Equal(t, 31, stats.Lands)
+	assert.Equal(t, []CurvePoint{
+		{ManaValue: 1, Permanent: 1, NonPermanent: 1},
+		{ManaValue: 2, NonPermanent: 2},
+		{ManaValue: 3, Permanent: 2},
+		{ManaValue: 7, NonPermanent: 1},
+	}, stats.ManaCurve)
+	assert.Equal(t, 2.71, stats.AverageManaValue)
+	assert.Equal(t, map[string]int{"W": 5, "U": 6, "B": 0, "R": 0, "G": 2, "C": 1}, stats.ColorPips)
+	assert.Equal(t, 3, stats.Types["creature"])
+	assert.Equal(t, 1, stats.Types["enchantment"])
+	assert.Equal(t, 2, stats.Types["instant"])
+	assert.Equal(t, 2, stats.Types["sorcery"])
+	assert.Equal(t, 31, stats.Types["land"])
+	assert.Equal(t, 0, stats.Types["planeswalker"])
+}
+
+func TestCalculateStats_Empty(t *testing.T) {
+	stats := CalculateStats(nil)
+	assert.Zero(t, stats.Cards)
+	assert.Zero(t, stats.AverageManaValue)
+	assert.Empty(t, stats.ManaCurve)
+	assert.Equal(t, 0, stats.ColorPips["W"])
+}
+
+func TestService_Stats(t *testing.T) {
+	repo := deckRepo.NewInMemoryRepo()
+	service := NewServiceWithDependencies(repo, NewDispatchImporter(nil), testCardValidator{})
+	d := &deckEntity.Deck{Name: "Boards", Format: "modern", Cards: []deckEntity.Card{
+		{Name: "Lightning Bolt", Quantity: 4, ManaCost: "{R}", TypeLine: "Instant"},
+		{Name: "Smash to Smithereens", Quantity: 3, Board: deckEntity.BoardSide, ManaCost: "{1}{R}", TypeLine: "Instant"},
+		{Name: "Fireball", Quantity: 1, Board: deckEntity.BoardMaybe, ManaCost: "{X}{R}", TypeLine: "Sorcery"},
+	}}
+	require.NoError(t, service.Create(d))
+
+	stats, err := service.Stats(d.ID, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.Cards)
+	assert.Equal(t, 4, stats.ColorPips["R"])
+
+	stats, err = service.Stats(d.ID, 1, []string{deckEntity.BoardMain, deckEntity.BoardSide})
+	require.NoError(t, err)
+	assert.Equal(t, 7, stats.Cards)
+
+	_, err = service.Stats(d.ID, 1, []string{"graveyard"})
+	assert.Error(t, err)
+	_, err = service.Stats(999, 1, nil)
+	assert.EqualError(t, err, "deck not found")
+}
@@ -0,0 +1,333 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/josofm/liliana/internal/entity/game"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	r "github.com/josofm/liliana/internal/repository/game"
+	deckService "github.com/josofm/liliana/internal/service/deck"
+)
+
+var (
+	// ErrInvalidGame wraps every validation error of a game.
+	ErrInvalidGame = errors.New("invalid game")
+	// ErrForbidden is returned when a user changes a game recorded by someone else.
+	ErrForbidden = errors.New("game was recorded by another user")
+)
+
+// Service records games and computes the results of each deck. Decks hidden
+// from the reader show up in games without their id and name.
+type Service struct {
+	repo        r.Repository
+	decks       deckRepo.Repository
+	memberships deckService.Memberships
+}
+
+func NewService(repo r.Repository, decks deckRepo.Repository) *Service {
+	return &Service{repo: repo, decks: decks}
+}
+
+// ShareWithPlaygroups lets members of a playgroup see each other's decks in
+// games.
+func (s *Service) ShareWithPlaygroups(memberships deckService.Memberships) {
+	s.memberships = memberships
+}
+
+// Create records a game by userID. Players only need DeckID, Winner and
+// Eliminated; the owner and name come from the deck.
+func (s *Service) Create(userID int64, g *game.Game) error {
+	if err := s.prepare(g, userID, nil); err != nil {
+		return err
+	}
+	g.CreatedBy = userID
+	return s.repo.Create(g)
+}
+
+// GetByID returns a game as userID sees it.
+func (s *Service) GetByID(id, userID int64) (*game.Game, error) {
+	g, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.hideDecks([]*game.Game{g}, userID); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// List returns the games matching filter as userID sees them. Filtering by a
+// deck hidden from userID matches no game.
+func (s *Service) List(filter r.ListFilter, userID int64) ([]*game.Game, error) {
+	if filter.DeckID != 0 {
+		visible, err := s.canView(filter.DeckID, userID)
+		if err != nil || !visible {
+			return make([]*game.Game, 0), err
+		}
+	}
+	games, err := s.repo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.hideDecks(games, userID); err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+// hideDecks clears the id and name of the decks userID may not view.
+func (s *Service) hideDecks(games []*game.Game, userID int64) error {
+	visible := make(map[int64]bool)
+	for _, g := range games {
+		for index := range g.Players {
+			player := &g.Players[index]
+			ok, checked := visible[player.DeckID]
+			if !checked {
+				var err error
+				if ok, err = s.canView(player.DeckID, userID); err != nil {
+					return err
+				}
+				visible[player.DeckID] = ok
+			}
+			if !ok {
+				player.DeckID, player.DeckName = 0, ""
+			}
+		}
+	}
+	return nil
+}
+
+// canView reports whether userID may view a deck. Deleted decks stay visible
+// through the name recorded in each game.
+func (s *Service) canView(deckID, userID int64) (bool, error) {
+	d, err := s.decks.GetByID(deckID)
+	switch {
+	case err == nil:
+		return deckService.CanView(d, userID, s.memberships)
+	case err.Error() == "deck not found":
+		return true, nil
+	default:
+		return false, err
+	}
+}
+
+// Update replaces a game recorded by userID. Players of decks deleted since
+// keep the owner and name recorded before.
+func (s *Service) Update(id, userID int64, g *game.Game) error {
+	existing, err := s.authorize(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.prepare(g, userID, existing); err != nil {
+		return err
+	}
+	return s.repo.Update(id, g)
+}
+
+func (s *Service) Delete(id, userID int64) error {
+	if _, err := s.authorize(id, userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *Service) authorize(id, userID int64) (*game.Game, error) {
+	g, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if g.CreatedBy != userID {
+		return nil, ErrForbidden
+	}
+	return g, nil
+}
+
+// prepare validates g and fills in the owner and name of each deck. Decks
+// hidden from userID count as not found.
+func (s *Service) prepare(g *game.Game, userID int64, previous *game.Game) error {
+	if len(g.Players) < 2 {
+		return fmt.Errorf("%w: a game needs at least two decks", ErrInvalidGame)
+	}
+	if g.Turns < 0 {
+		return fmt.Errorf("%w: turns cannot be negative", ErrInvalidGame)
+	}
+	if g.PlayedAt.IsZero() {
+		g.PlayedAt = time.Now().UTC()
+	}
+
+	recorded := make(map[int64]game.Player)
+	if previous != nil {
+		for _, player := range previous.Players {
+			recorded[player.DeckID] = player
+		}
+	}
+	seen := make(map[int64]bool, len(g.Players))
+	winners := 0
+	for index := range g.Players {
+		player := &g.Players[index]
+		if seen[player.DeckID] {
+			return fmt.Errorf("%w: deck %d is in the game twice", ErrInvalidGame, player.DeckID)
+		}
+		seen[player.DeckID] = true
+		d, err := s.decks.GetByID(player.DeckID)
+		if err == nil {
+			var visible bool
+			if visible, err = deckService.CanView(d, userID, s.memberships); err == nil && !visible {
+				err = errors.New("deck not found")
+			}
+		}
+		old, wasRecorded := recorded[player.DeckID]
+		switch {
+		case err == nil:
+			player.OwnerID, player.DeckName = d.OwnerID, d.Name
+		case err.Error() != "deck not found":
+			return err
+		case wasRecorded:
+			player.OwnerID, player.DeckName = old.OwnerID, old.DeckName
+		default:
+			return fmt.Errorf("%w: deck %d not found", ErrInvalidGame, player.DeckID)
+		}
+		if player.Winner {
+			winners++
+		}
+	}
+	if winners == 0 {
+		return fmt.Errorf("%w: a game needs at least one winner", ErrInvalidGame)
+	}
+	return checkEliminations(g.Players, len(g.Players)-winners)
+}
+
+// checkEliminations requires winners to stay in the game and every known
+// elimination to be a distinct position among the losers.
+func checkEliminations(players []game.Player, losers int) error {
+	positions := make(map[int]bool, losers)
+	for _, player := range players {
+		switch {
+		case player.Eliminated == 0:
+			continue
+		case player.Winner:
+			return fmt.Errorf("%w: winner %s cannot be eliminated", ErrInvalidGame, player.DeckName)
+		case player.Eliminated < 0 || player.Eliminated > losers:
+			return fmt.Errorf("%w: elimination of %s must be between 1 and %d", ErrInvalidGame, player.DeckName, losers)
+		case positions[player.Eliminated]:
+			return fmt.Errorf("%w: two decks were eliminated in position %d", ErrInvalidGame, player.Eliminated)
+		}
+		positions[player.Eliminated] = true
+	}
+	return nil
+}
+
+// DeckRecord sums the results of a deck over every recorded game.
+type DeckRecord struct {
+	DeckID  int64   `json:"deck_id"`
+	Games   int     `json:"games"`
+	Wins    int     `json:"wins"`
+	WinRate float64 `json:"win_rate"`
+	// AverageTurns only counts games with a known number of turns.
+	AverageTurns float64      `json:"average_turns"`
+	HeadToHead   []HeadToHead `json:"head_to_head"`
+}
+
+// HeadToHead is the record of a deck against one opponent. Wins and Losses
+// count the games won by the deck and by the opponent; games won by a third
+// deck only count in Games.
+type HeadToHead struct {
+	DeckID   int64  `json:"deck_id"`
+	DeckName string `json:"deck_name"`
+	OwnerID  int64  `json:"owner_id"`
+	Games    int    `json:"games"`
+	Wins     int    `json:"wins"`
+	Losses   int    `json:"losses"`
+}
+
+// DeckRecord returns the record of a deck that userID may view. Opponents
+// hidden from userID count in the totals but not in HeadToHead.
+func (s *Service) DeckRecord(deckID, userID int64) (*DeckRecord, error) {
+	visible, err := s.canView(deckID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, errors.New("deck not found")
+	}
+	games, err := s.repo.List(r.ListFilter{DeckID: deckID})
+	if err != nil {
+		return nil, err
+	}
+	record := CalculateDeckRecord(deckID, games)
+	opponents := make([]HeadToHead, 0, len(record.HeadToHead))
+	for _, opponent := range record.HeadToHead {
+		if visible, err = s.canView(opponent.DeckID, userID); err != nil {
+			return nil, err
+		}
+		if visible {
+			opponents = append(opponents, opponent)
+		}
+	}
+	record.HeadToHead = opponents
+	return record, nil
+}
+
+// CalculateDeckRecord computes the record of deckID from games, which must
+// all include it.
+func CalculateDeckRecord(deckID int64, games []*game.Game) *DeckRecord {
+	record := &DeckRecord{DeckID: deckID, HeadToHead: make([]HeadToHead, 0)}
+	opponents := make(map[int64]*HeadToHead)
+	turns, timed := 0, 0
+	for _, g := range games {
+		won := false
+		for _, player := range g.Players {
+			if player.DeckID == deckID {
+				won = player.Winner
+			}
+		}
+		record.Games++
+		if won {
+			record.Wins++
+		}
+		if g.Turns > 0 {
+			turns += g.Turns
+			timed++
+		}
+		for _, player := range g.Players {
+			if player.DeckID == deckID {
+				continue
+			}
+			opponent, ok := opponents[player.DeckID]
+			if !ok {
+				opponent = &HeadToHead{DeckID: player.DeckID}
+				opponents[player.DeckID] = opponent
+			}
+			// Games are most recent first, so the first name seen is the latest.
+			if opponent.DeckName == "" {
+				opponent.DeckName, opponent.OwnerID = player.DeckName, player.OwnerID
+			}
+			opponent.Games++
+			if won && !player.Winner {
+				opponent.Wins++
+			}
+			if player.Winner && !won {
+				opponent.Losses++
+			}
+		}
+	}
+	if record.Games > 0 {
+		record.WinRate = float64(record.Wins) / float64(record.Games)
+	}
+	if timed > 0 {
+		record.AverageTurns = float64(turns) / float64(timed)
+	}
+	for _, opponent := range opponents {
+		record.HeadToHead = append(record.HeadToHead, *opponent)
+	}
+	sort.Slice(record.HeadToHead, func(i, j int) bool {
+		if record.HeadToHead[i].Games != record.HeadToHead[j].Games {
+			return record.HeadToHead[i].Games > record.HeadToHead[j].Games
+		}
+		return record.HeadToHead[i].DeckID < record.HeadToHead[j].DeckID
+	})
+	return record
+}
@@ -0,0 +1,157 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	"github.com/josofm/liliana/internal/entity/game"
+	"github.com/josofm/liliana/internal/entity/playgroup"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	r "github.com/josofm/liliana/internal/repository/game"
+	playgroupRepo "github.com/josofm/liliana/internal/repository/playgroup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupService(t *testing.T) (*Service, deckRepo.Repository) {
+	t.Helper()
+	decks := deckRepo.NewInMemoryRepo()
+	for index, name := range []string{"Atraxa", "Thassa", "Krenko", "Edgar"} {
+		require.NoError(t, decks.Create(&deckEntity.Deck{Name: name, Color: "U", Format: "commander", OwnerID: int64(index + 1)}))
+	}
+	return NewService(r.NewInMemoryRepo(), decks), decks
+}
+
+func TestService_Create(t *testing.T) {
+	service, _ := setupService(t)
+	g := &game.Game{Turns: 8, Players: []game.Player{
+		{DeckID: 1, Winner: true},
+		{DeckID: 2, Eliminated: 2},
+		{DeckID: 3, Eliminated: 1},
+	}}
+
+	require.NoError(t, service.Create(7, g))
+
+	found, err := service.GetByID(g.ID, 7)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), found.CreatedBy)
+	assert.False(t, found.PlayedAt.IsZero(), "the date defaults to now")
+	assert.Equal(t, game.Player{DeckID: 2, OwnerID: 2, DeckName: "Thassa", Eliminated: 2}, found.Players[1])
+}
+
+func TestService_Create_Rejects(t *testing.T) {
+	service, _ := setupService(t)
+	tests := map[string][]game.Player{
+		"invalid game: a game needs at least two decks":               {{DeckID: 1, Winner: true}},
+		"invalid game: deck 1 is in the game twice":                   {{DeckID: 1, Winner: true}, {DeckID: 1}},
+		"invalid game: deck 9 not found":                              {{DeckID: 1, Winner: true}, {DeckID: 9}},
+		"invalid game: a game needs at least one winner":              {{DeckID: 1}, {DeckID: 2}},
+		"invalid game: winner Atraxa cannot be eliminated":            {{DeckID: 1, Winner: true, Eliminated: 1}, {DeckID: 2}},
+		"invalid game: elimination of Thassa must be between 1 and 2": {{DeckID: 1, Winner: true}, {DeckID: 2, Eliminated: 3}, {DeckID: 3}},
+		"invalid game: two decks were eliminated in position 1":       {{DeckID: 1, Winner: true}, {DeckID: 2, Eliminated: 1}, {DeckID: 3, Eliminated: 1}},
+		"invalid game: elimination of Thassa must be between 1 and 1": {{DeckID: 1, Winner: true}, {DeckID: 2, Eliminated: -1}},
+	}
+	for message, players := range tests {
+		err := service.Create(1, &game.Game{Players: players})
+		assert.ErrorIs(t, err, ErrInvalidGame, message)
+		assert.EqualError(t, err, message)
+	}
+	assert.ErrorIs(t, service.Create(1, &game.Game{Turns: -1, Players: []game.Player{{DeckID: 1, Winner: true}, {DeckID: 2}}}), ErrInvalidGame)
+}
+
+func TestService_UpdateAndDelete(t *testing.T) {
+	service, decks := setupService(t)
+	g := &game.Game{Players: []game.Player{{DeckID: 1, Winner: true}, {DeckID: 2}}}
+	require.NoError(t, service.Create(1, g))
+	require.NoError(t, decks.Delete(2))
+
+	update := &game.Game{Turns: 10, Players: []game.Player{{DeckID: 1}, {DeckID: 2, Winner: true}}}
+	assert.ErrorIs(t, service.Update(g.ID, 2, update), ErrForbidden)
+	require.NoError(t, service.Update(g.ID, 1, update))
+	found, err := service.GetByID(g.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, game.Player{DeckID: 2, OwnerID: 2, DeckName: "Thassa", Winner: true}, found.Players[1], "deleted decks keep their recorded name")
+
+	assert.ErrorIs(t, service.Delete(g.ID, 2), ErrForbidden)
+	require.NoError(t, service.Delete(g.ID, 1))
+	assert.EqualError(t, service.Delete(g.ID, 1), "game not found")
+}
+
+func TestService_HidesPrivateDecks(t *testing.T) {
+	service, decks := setupService(t)
+	require.NoError(t, decks.Update(4, &deckEntity.Deck{Name: "Edgar", Color: "B", Format: "commander", OwnerID: 4, Visibility: deckEntity.VisibilityPrivate}))
+
+	err := service.Create(1, &game.Game{Players: []game.Player{{DeckID: 1, Winner: true}, {DeckID: 4}}})
+	assert.EqualError(t, err, "invalid game: deck 4 not found", "private decks of others cannot be recorded")
+	g := &game.Game{Players: []game.Player{{DeckID: 1, Winner: true}, {DeckID: 4}}}
+	require.NoError(t, service.Create(4, g))
+
+	found, err := service.GetByID(g.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, game.Player{OwnerID: 4}, found.Players[1])
+	found, err = service.GetByID(g.ID, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "Edgar", found.Players[1].DeckName)
+
+	games, err := service.List(r.ListFilter{DeckID: 4}, 1)
+	require.NoError(t, err)
+	assert.Empty(t, games)
+	games, err = service.List(r.ListFilter{OwnerID: 1}, 1)
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+	assert.Zero(t, games[0].Players[1].DeckID)
+
+	_, err = service.DeckRecord(4, 1)
+	assert.EqualError(t, err, "deck not found")
+	record, err := service.DeckRecord(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, record.Wins)
+	assert.Empty(t, record.HeadToHead, "hidden opponents are left out")
+
+	playgroups := playgroupRepo.NewInMemoryRepo()
+	require.NoError(t, playgroups.Create(&playgroup.Playgroup{Name: "Pod", Members: []playgroup.Member{{UserID: 1, Role: playgroup.RoleOwner}, {UserID: 4, Role: playgroup.RoleMember}}}))
+	service.ShareWithPlaygroups(playgroups)
+	found, err = service.GetByID(g.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Edgar", found.Players[1].DeckName, "playgroup members see each other's decks")
+	record, err = service.DeckRecord(4, 1)
+	require.NoError(t, err)
+	require.Len(t, record.HeadToHead, 1)
+	assert.Equal(t, "Atraxa", record.HeadToHead[0].DeckName)
+}
+
+func TestCalculateDeckRecord(t *testing.T) {
+	week := time.Date(2026, 3, 5, 20, 0, 0, 0, time.UTC)
+	games := []*game.Game{
+		{PlayedAt: week.AddDate(0, 0, 14), Players: []game.Player{
+			{DeckID: 1, DeckName: "Atraxa", OwnerID: 1},
+			{DeckID: 2, DeckName: "Thassa, renamed", OwnerID: 2, Winner: true},
+		}},
+		{PlayedAt: week.AddDate(0, 0, 7), Turns: 12, Players: []game.Player{
+			{DeckID: 1, DeckName: "Atraxa", OwnerID: 1, Winner: true},
+			{DeckID: 2, DeckName: "Thassa", OwnerID: 2},
+			{DeckID: 3, DeckName: "Krenko", OwnerID: 3},
+		}},
+		{PlayedAt: week, Turns: 8, Players: []game.Player{
+			{DeckID: 1, DeckName: "Atraxa", OwnerID: 1},
+			{DeckID: 2, DeckName: "Thassa", OwnerID: 2},
+			{DeckID: 3, DeckName: "Krenko", OwnerID: 3, Winner: true},
+		}},
+	}
+
+	record := CalculateDeckRecord(1, games)
+
+	assert.Equal(t, &DeckRecord{
+		DeckID:       1,
+		Games:        3,
+		Wins:         1,
+		WinRate:      1.0 / 3,
+		AverageTurns: 10,
+		HeadToHead: []HeadToHead{
+			{DeckID: 2, DeckName: "Thassa, renamed", OwnerID: 2, Games: 3, Wins: 1, Losses: 1},
+			{DeckID: 3, DeckName: "Krenko", OwnerID: 3, Games: 2, Wins: 1, Losses: 1},
+		},
+	}, record)
+	assert.Equal(t, &DeckRecord{DeckID: 9, HeadToHead: []HeadToHead{}}, CalculateDeckRecord(9, nil))
+}
@@ -0,0 +1,283 @@
+package playgroup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/josofm/liliana/internal/entity/playgroup"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	r "github.com/josofm/liliana/internal/repository/playgroup"
+	userRepo "github.com/josofm/liliana/internal/repository/user"
+)
+
+var (
+	// ErrInvalidPlaygroup wraps every validation error of a playgroup,
+	// member or invitation.
+	ErrInvalidPlaygroup = errors.New("invalid playgroup")
+	// ErrNotMember is returned when a user reads a playgroup they are not in.
+	ErrNotMember = errors.New("user is not a member of the playgroup")
+	// ErrForbidden is returned when a member does something only owners can.
+	ErrForbidden = errors.New("only playgroup owners can do this")
+	// ErrEmailNotVerified is returned when an invitee has not verified the
+	// email the invitation was sent to.
+	ErrEmailNotVerified = errors.New("verify your email to see and accept invitations")
+)
+
+// Service manages playgroups, their members and invitations. Members see
+// each other's decks through Decks.
+type Service struct {
+	repo  r.Repository
+	users userRepo.Repository
+	decks deckRepo.Repository
+}
+
+func NewService(repo r.Repository, users userRepo.Repository, decks deckRepo.Repository) *Service {
+	return &Service{repo: repo, users: users, decks: decks}
+}
+
+// Create stores a playgroup with userID as its only owner.
+func (s *Service) Create(userID int64, p *playgroup.Playgroup) error {
+	if err := validate(p); err != nil {
+		return err
+	}
+	p.CreatedBy = userID
+	p.Members = []playgroup.Member{{UserID: userID, Role: playgroup.RoleOwner}}
+	return s.repo.Create(p)
+}
+
+// GetByID returns a playgroup to one of its members.
+func (s *Service) GetByID(id, userID int64) (*playgroup.Playgroup, error) {
+	p, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Member(userID) == nil {
+		return nil, ErrNotMember
+	}
+	return p, nil
+}
+
+// List returns the playgroups of userID.
+func (s *Service) List(userID int64) ([]*playgroup.Playgroup, error) {
+	return s.repo.ListByMember(userID)
+}
+
+// Update changes the name and description of a playgroup.
+func (s *Service) Update(id, userID int64, p *playgroup.Playgroup) error {
+	if _, err := s.authorizeOwner(id, userID); err != nil {
+		return err
+	}
+	if err := validate(p); err != nil {
+		return err
+	}
+	return s.repo.Update(id, p)
+}
+
+func (s *Service) Delete(id, userID int64) error {
+	if _, err := s.authorizeOwner(id, userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// Decks lists the decks of every member of a playgroup. Members view each
+// other's decks whatever their visibility; filter.OwnerID still narrows the
+// list to one member.
+func (s *Service) Decks(ctx context.Context, id, userID int64, filter deckRepo.ListFilter) (*deckRepo.ListPage, error) {
+	p, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	filter.OwnerIDs = p.MemberIDs()
+	return s.decks.List(ctx, filter)
+}
+
+// SetRole changes the role of a member. A playgroup always keeps an owner.
+func (s *Service) SetRole(id, userID, memberID int64, role string) (*playgroup.Member, error) {
+	p, err := s.authorizeOwner(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(playgroup.Roles, role) {
+		return nil, fmt.Errorf("%w: role must be one of %s", ErrInvalidPlaygroup, strings.Join(playgroup.Roles, ", "))
+	}
+	member := p.Member(memberID)
+	if member == nil {
+		return nil, errors.New("member not found")
+	}
+	if member.Role == playgroup.RoleOwner && role != playgroup.RoleOwner && p.Owners() == 1 {
+		return nil, fmt.Errorf("%w: the last owner cannot be demoted", ErrInvalidPlaygroup)
+	}
+	updated := &playgroup.Member{UserID: memberID, Role: role}
+	if err := s.repo.SaveMember(id, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// RemoveMember removes memberID from a playgroup. Owners remove anyone;
+// members can only leave. The last owner cannot leave while others remain.
+func (s *Service) RemoveMember(id, userID, memberID int64) error {
+	p, err := s.GetByID(id, userID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && !p.IsOwner(userID) {
+		return ErrForbidden
+	}
+	member := p.Member(memberID)
+	if member == nil {
+		return errors.New("member not found")
+	}
+	if member.Role == playgroup.RoleOwner && p.Owners() == 1 && len(p.Members) > 1 {
+		return fmt.Errorf("%w: the last owner cannot leave; promote another member first", ErrInvalidPlaygroup)
+	}
+	if len(p.Members) == 1 {
+		return s.repo.Delete(id)
+	}
+	return s.repo.RemoveMember(id, memberID)
+}
+
+// Invite invites an email to a playgroup.
+func (s *Service) Invite(id, userID int64, email string) (*playgroup.Invitation, error) {
+	p, err := s.authorizeOwner(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	email = playgroup.NormalizeEmail(email)
+	if email == "" {
+		return nil, fmt.Errorf("%w: email is required", ErrInvalidPlaygroup)
+	}
+	for _, member := range p.Members {
+		u, err := s.users.GetByID(member.UserID)
+		if err == nil && playgroup.NormalizeEmail(u.Email) == email {
+			return nil, fmt.Errorf("%w: %s is already a member", ErrInvalidPlaygroup, email)
+		}
+	}
+	invitation := &playgroup.Invitation{PlaygroupID: id, Email: email, InvitedBy: userID}
+	if err := s.repo.CreateInvitation(invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// Invitations lists the pending invitations of a playgroup to its owners.
+func (s *Service) Invitations(id, userID int64) ([]playgroup.Invitation, error) {
+	if _, err := s.authorizeOwner(id, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.Invitations(id)
+}
+
+// ReceivedInvitations lists the invitations sent to the email of userID.
+// The email must be verified.
+func (s *Service) ReceivedInvitations(userID int64) ([]playgroup.Invitation, error) {
+	u, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+	return s.repo.InvitationsByEmail(playgroup.NormalizeEmail(u.Email))
+}
+
+// AcceptInvitation makes userID a member of the playgroup the invitation
+// was sent for. Only the invited user can accept it.
+func (s *Service) AcceptInvitation(invitationID, userID int64) (*playgroup.Playgroup, error) {
+	invitation, err := s.receivedInvitation(invitationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.repo.GetByID(invitation.PlaygroupID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Member(userID) == nil {
+		if err := s.repo.SaveMember(p.ID, &playgroup.Member{UserID: userID, Role: playgroup.RoleMember}); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.DeleteInvitation(invitationID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(p.ID)
+}
+
+// DeleteInvitation declines an invitation, for the invited user, or revokes
+// it, for the owners of the playgroup.
+func (s *Service) DeleteInvitation(invitationID, userID int64) error {
+	invitation, err := s.repo.GetInvitation(invitationID)
+	if err != nil {
+		return err
+	}
+	invited, err := s.isInvited(invitation, userID)
+	if err != nil && !errors.Is(err, ErrEmailNotVerified) {
+		return err
+	}
+	if !invited {
+		if _, err := s.authorizeOwner(invitation.PlaygroupID, userID); err != nil {
+			return err
+		}
+	}
+	return s.repo.DeleteInvitation(invitationID)
+}
+
+// receivedInvitation returns an invitation sent to the email of userID.
+// Invitations to other emails are reported as not found.
+func (s *Service) receivedInvitation(invitationID, userID int64) (*playgroup.Invitation, error) {
+	invitation, err := s.repo.GetInvitation(invitationID)
+	if err != nil {
+		return nil, err
+	}
+	invited, err := s.isInvited(invitation, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !invited {
+		return nil, errors.New("invitation not found")
+	}
+	return invitation, nil
+}
+
+// isInvited reports whether the invitation was sent to the email of userID.
+// Anyone can sign up with any email, so an unverified match is refused with
+// ErrEmailNotVerified.
+func (s *Service) isInvited(invitation *playgroup.Invitation, userID int64) (bool, error) {
+	u, err := s.users.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	if playgroup.NormalizeEmail(u.Email) != invitation.Email {
+		return false, nil
+	}
+	if !u.EmailVerified {
+		return false, ErrEmailNotVerified
+	}
+	return true, nil
+}
+
+func (s *Service) authorizeOwner(id, userID int64) (*playgroup.Playgroup, error) {
+	p, err := s.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !p.IsOwner(userID) {
+		return nil, ErrForbidden
+	}
+	return p, nil
+}
+
+func validate(p *playgroup.Playgroup) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPlaygroup)
+	}
+	if len(p.Name) > 100 {
+		return fmt.Errorf("%w: name must have at most 100 characters", ErrInvalidPlaygroup)
+	}
+	return nil
+}
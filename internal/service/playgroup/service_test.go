@@ -0,0 +1,147 @@
+package playgroup
+
+import (
+	"context"
+	"testing"
+
+	deckEntity "github.com/josofm/liliana/internal/entity/deck"
+	"github.com/josofm/liliana/internal/entity/playgroup"
+	"github.com/josofm/liliana/internal/entity/user"
+	deckRepo "github.com/josofm/liliana/internal/repository/deck"
+	r "github.com/josofm/liliana/internal/repository/playgroup"
+	userRepo "github.com/josofm/liliana/internal/repository/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupService(t *testing.T) *Service {
+	t.Helper()
+	users := userRepo.NewInMemoryRepo()
+	decks := deckRepo.NewInMemoryRepo()
+	for index, name := range []string{"Liliana", "Thassa", "Krenko"} {
+		require.NoError(t, users.Create(&user.User{Name: name, Email: name + "@example.com", EmailVerified: true}))
+		require.NoError(t, decks.Create(&deckEntity.Deck{Name: name + " deck", Color: "B", Format: "commander", OwnerID: int64(index + 1)}))
+	}
+	require.NoError(t, decks.Create(&deckEntity.Deck{Name: "Liliana brew", Color: "B", Format: "commander", OwnerID: 1, Visibility: deckEntity.VisibilityPrivate}))
+	return NewService(r.NewInMemoryRepo(), users, decks)
+}
+
+func TestService_Membership(t *testing.T) {
+	service := setupService(t)
+	pod := &playgroup.Playgroup{Name: "  Thursday pod "}
+	require.NoError(t, service.Create(1, pod))
+	assert.Equal(t, "Thursday pod", pod.Name)
+	assert.True(t, pod.IsOwner(1))
+
+	_, err := service.GetByID(pod.ID, 2)
+	assert.ErrorIs(t, err, ErrNotMember)
+	_, err = service.Decks(context.Background(), pod.ID, 2, deckRepo.ListFilter{})
+	assert.ErrorIs(t, err, ErrNotMember, "non-members cannot see the decks of the group")
+
+	invitation, err := service.Invite(pod.ID, 1, " THASSA@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "thassa@example.com", invitation.Email)
+	_, err = service.Invite(pod.ID, 1, "thassa@example.com")
+	assert.EqualError(t, err, "invitation already exists")
+
+	received, err := service.ReceivedInvitations(2)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	_, err = service.AcceptInvitation(invitation.ID, 3)
+	assert.EqualError(t, err, "invitation not found", "only the invited user can accept")
+	joined, err := service.AcceptInvitation(invitation.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, joined.MemberIDs())
+
+	page, err := service.Decks(context.Background(), pod.ID, 2, deckRepo.ListFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.Decks, 3, "members see each other's private decks")
+	assert.Equal(t, "Liliana deck", page.Decks[0].Name)
+	assert.Equal(t, "Thassa deck", page.Decks[1].Name)
+	assert.Equal(t, "Liliana brew", page.Decks[2].Name)
+	page, err = service.Decks(context.Background(), pod.ID, 1, deckRepo.ListFilter{OwnerID: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Decks, 1)
+	assert.Equal(t, "Thassa deck", page.Decks[0].Name)
+
+	_, err = service.Invite(pod.ID, 2, "krenko@example.com")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = service.Invite(pod.ID, 1, "thassa@example.com")
+	assert.ErrorIs(t, err, ErrInvalidPlaygroup, "members are not invited again")
+	assert.ErrorIs(t, service.Update(pod.ID, 2, &playgroup.Playgroup{Name: "Mine"}), ErrForbidden)
+	assert.ErrorIs(t, service.Delete(pod.ID, 2), ErrForbidden)
+}
+
+func TestService_Roles(t *testing.T) {
+	service := setupService(t)
+	pod := &playgroup.Playgroup{Name: "Pod"}
+	require.NoError(t, service.Create(1, pod))
+	invitation, err := service.Invite(pod.ID, 1, "thassa@example.com")
+	require.NoError(t, err)
+	_, err = service.AcceptInvitation(invitation.ID, 2)
+	require.NoError(t, err)
+
+	_, err = service.SetRole(pod.ID, 1, 1, playgroup.RoleMember)
+	assert.ErrorIs(t, err, ErrInvalidPlaygroup, "the last owner cannot be demoted")
+	assert.ErrorIs(t, service.RemoveMember(pod.ID, 1, 1), ErrInvalidPlaygroup, "the last owner cannot leave")
+	_, err = service.SetRole(pod.ID, 1, 2, "admin")
+	assert.ErrorIs(t, err, ErrInvalidPlaygroup)
+	assert.ErrorIs(t, service.RemoveMember(pod.ID, 2, 1), ErrForbidden)
+
+	member, err := service.SetRole(pod.ID, 1, 2, playgroup.RoleOwner)
+	require.NoError(t, err)
+	assert.Equal(t, playgroup.RoleOwner, member.Role)
+	require.NoError(t, service.RemoveMember(pod.ID, 1, 1))
+
+	found, err := service.GetByID(pod.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, found.MemberIDs())
+	require.NoError(t, service.RemoveMember(pod.ID, 2, 2))
+	_, err = service.GetByID(pod.ID, 2)
+	assert.EqualError(t, err, "playgroup not found", "the group is deleted with its last member")
+}
+
+func TestService_DeleteInvitation(t *testing.T) {
+	service := setupService(t)
+	pod := &playgroup.Playgroup{Name: "Pod"}
+	require.NoError(t, service.Create(1, pod))
+	declined, err := service.Invite(pod.ID, 1, "thassa@example.com")
+	require.NoError(t, err)
+	revoked, err := service.Invite(pod.ID, 1, "krenko@example.com")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, service.DeleteInvitation(revoked.ID, 2), ErrNotMember)
+	require.NoError(t, service.DeleteInvitation(declined.ID, 2))
+	require.NoError(t, service.DeleteInvitation(revoked.ID, 1))
+	invitations, err := service.Invitations(pod.ID, 1)
+	require.NoError(t, err)
+	assert.Empty(t, invitations)
+}
+
+func TestService_UnverifiedInvitee(t *testing.T) {
+	users := userRepo.NewInMemoryRepo()
+	require.NoError(t, users.Create(&user.User{Name: "Liliana", Email: "liliana@example.com", EmailVerified: true}))
+	require.NoError(t, users.Create(&user.User{Name: "Impostor", Email: "thassa@example.com"}))
+	service := NewService(r.NewInMemoryRepo(), users, deckRepo.NewInMemoryRepo())
+	pod := &playgroup.Playgroup{Name: "Pod"}
+	require.NoError(t, service.Create(1, pod))
+	invitation, err := service.Invite(pod.ID, 1, "thassa@example.com")
+	require.NoError(t, err)
+
+	_, err = service.ReceivedInvitations(2)
+	assert.ErrorIs(t, err, ErrEmailNotVerified)
+	_, err = service.AcceptInvitation(invitation.ID, 2)
+	assert.ErrorIs(t, err, ErrEmailNotVerified, "an unverified email cannot accept the invitation")
+	assert.ErrorIs(t, service.DeleteInvitation(invitation.ID, 2), ErrNotMember)
+	_, err = service.GetByID(pod.ID, 2)
+	assert.ErrorIs(t, err, ErrNotMember)
+
+	require.NoError(t, users.SetEmailVerified(2))
+	_, err = service.AcceptInvitation(invitation.ID, 2)
+	assert.NoError(t, err)
+}
+
+func TestService_Create_Rejects(t *testing.T) {
+	service := setupService(t)
+	assert.ErrorIs(t, service.Create(1, &playgroup.Playgroup{Name: " "}), ErrInvalidPlaygroup)
+}
@@ -1,19 +1,34 @@
 package user
 
 import (
+	"errors"
+
 	"github.com/josofm/liliana/internal/entity/user"
 	r "github.com/josofm/liliana/internal/repository/user"
 )
 
+// ErrLastAdmin is returned when the only admin would be demoted or deleted.
+var ErrLastAdmin = errors.New("the last admin cannot be demoted or deleted")
+
+// PasswordHasher hashes the passwords set through the service, the same
+// way registration does.
+type PasswordHasher interface {
+	HashPassword(password string) (string, error)
+}
+
 type Service struct {
-	repo r.Repository
+	repo      r.Repository
+	passwords PasswordHasher
 }
 
-func NewService(r r.Repository) *Service {
-	return &Service{repo: r}
+func NewService(r r.Repository, passwords PasswordHasher) *Service {
+	return &Service{repo: r, passwords: passwords}
 }
 
 func (s *Service) Create(u *user.User) error {
+	if err := s.hashPassword(u); err != nil {
+		return err
+	}
 	return s.repo.Create(u)
 }
 
@@ -26,9 +41,63 @@ func (s *Service) GetByID(id int64) (*user.User, error) {
 }
 
 func (s *Service) Update(id int64, u *user.User) error {
+	if err := s.hashPassword(u); err != nil {
+		return err
+	}
 	return s.repo.Update(id, u)
 }
 
+// SetRole changes the role of a user. An installation always keeps an admin.
+func (s *Service) SetRole(id int64, role string) error {
+	u, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if role != user.RoleAdmin {
+		if err := RequireAnotherAdmin(s.repo, u); err != nil {
+			return err
+		}
+	}
+	return s.repo.SetRole(id, role)
+}
+
+// Delete removes a user. An installation always keeps an admin.
 func (s *Service) Delete(id int64) error {
+	u, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := RequireAnotherAdmin(s.repo, u); err != nil {
+		return err
+	}
 	return s.repo.Delete(id)
 }
+
+// RequireAnotherAdmin returns ErrLastAdmin when u is the only admin, so
+// demoting or deleting u would leave nobody to manage the users.
+func RequireAnotherAdmin(repo r.Repository, u *user.User) error {
+	if u.Role != user.RoleAdmin {
+		return nil
+	}
+	admins, err := repo.CountByRole(user.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if admins == 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// hashPassword replaces the plain password of u with its hash.
+func (s *Service) hashPassword(u *user.User) error {
+	if u.Password == "" {
+		return nil
+	}
+	hashed, err := s.passwords.HashPassword(u.Password)
+	if err != nil {
+		return err
+	}
+	u.Password = hashed
+	return nil
+}
@@ -10,15 +10,22 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// fakeHasher marks passwords as hashed without the cost of bcrypt
+type fakeHasher struct{}
+
+func (fakeHasher) HashPassword(password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
 func TestNewService(t *testing.T) {
 	repo := userRepo.NewInMemoryRepo()
-	service := NewService(repo)
+	service := NewService(repo, fakeHasher{})
 	assert.NotNil(t, service)
 }
 
 func TestService_Create(t *testing.T) {
 	repo := userRepo.NewInMemoryRepo()
-	service := NewService(repo)
+	service := NewService(repo, fakeHasher{})
 
 	user := &userEntity.User{
 		Name:     "Test User",
@@ -29,11 +36,12 @@ func TestService_Create(t *testing.T) {
 	err := service.Create(user)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), user.ID)
+	assert.Equal(t, "hashed:password123", user.Password, "passwords are never stored in plain text")
 }
 
 func TestService_GetAll(t *testing.T) {
 	repo := userRepo.NewInMemoryRepo()
-	service := NewService(repo)
+	service := NewService(repo, fakeHasher{})
 
 	// Create test users
 	user1 := &userEntity.User{Name: "User 1", Email: "user1@example.com", Password: "pass1"}
@@ -49,7 +57,7 @@ func TestService_GetAll(t *testing.T) {
 
 func TestService_GetByID(t *testing.T) {
 	repo := userRepo.NewInMemoryRepo()
-	service := NewService(repo)
+	service := NewService(repo, fakeHasher{})
 
 	user := &userEntity.User{Name: "Test User", Email: "test@example.com", Password: "password"}
 	service.Create(user)
@@ -68,7 +76,7 @@ func TestService_GetByID(t *testing.T) {
 
 func TestService_Update(t *testing.T) {
 	repo := userRepo.NewInMemoryRepo()
-	service := NewService(repo)
+	service := NewService(repo, fakeHasher{})
 
 	// Create user
 	user := &userEntity.User{Name: "Original Name", Email: "original@example.com", Password: "pass"}
@@ -84,11 +92,12 @@ func TestService_Update(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Name", found.Name)
 	assert.Equal(t, "updated@example.com", found.Email)
+	assert.Equal(t, "hashed:newpass", found.Password)
 }
 
 func TestService_Delete(t *testing.T) {
 	repo := userRepo.NewInMemoryRepo()
-	service := NewService(repo)
+	service := NewService(repo, fakeHasher{})
 
 	// Create user
 	user := &userEntity.User{Name: "Test User", Email: "test@example.com", Password: "password"}
@@ -108,3 +117,31 @@ func TestService_Delete(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, found)
 }
+
+func TestService_Delete_LastAdmin(t *testing.T) {
+	repo := userRepo.NewInMemoryRepo()
+	service := NewService(repo, fakeHasher{})
+
+	admin := &userEntity.User{Name: "Admin", Email: "admin@example.com", Role: userEntity.RoleAdmin}
+	service.Create(admin)
+
+	// The only admin cannot be deleted
+	err := service.Delete(admin.ID)
+	assert.ErrorIs(t, err, ErrLastAdmin)
+	found, err := service.GetByID(admin.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, found)
+
+	// Once another admin exists, it can
+	other := &userEntity.User{Name: "Other", Email: "other@example.com", Role: userEntity.RoleAdmin}
+	service.Create(other)
+	assert.NoError(t, service.Delete(admin.ID))
+}
+
+func TestService_Delete_NotFound(t *testing.T) {
+	repo := userRepo.NewInMemoryRepo()
+	service := NewService(repo, fakeHasher{})
+
+	err := service.Delete(99)
+	assert.Error(t, err)
+}
@@ -0,0 +1,6 @@
+ALTER TABLE deck_cards
+	ADD COLUMN board TEXT NOT NULL DEFAULT 'main'
+	CHECK (board IN ('main', 'side', 'maybe', 'commander', 'companion'));
+
+ALTER TABLE deck_cards DROP CONSTRAINT deck_cards_pkey;
+ALTER TABLE deck_cards ADD PRIMARY KEY (deck_id, oracle_id, board);
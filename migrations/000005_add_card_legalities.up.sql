@@ -0,0 +1 @@
+ALTER TABLE cards ADD COLUMN legalities JSONB NOT NULL DEFAULT '{}'::jsonb;
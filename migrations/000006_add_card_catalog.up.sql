@@ -0,0 +1,7 @@
+ALTER TABLE cards
+	ADD COLUMN face_names JSONB NOT NULL DEFAULT '[]'::jsonb,
+	ADD COLUMN lookup_names TEXT[] NOT NULL DEFAULT '{}',
+	ADD COLUMN can_be_commander BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX cards_lookup_names_idx ON cards USING GIN (lookup_names);
+CREATE INDEX cards_commander_name_idx ON cards (lower(name)) WHERE can_be_commander;
@@ -0,0 +1,34 @@
+CREATE TABLE deck_revisions (
+	deck_id BIGINT NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+	number INTEGER NOT NULL CHECK (number > 0),
+	name TEXT NOT NULL,
+	color TEXT NOT NULL,
+	format TEXT NOT NULL,
+	commander TEXT NOT NULL DEFAULT '',
+	commander_image_uri TEXT NOT NULL DEFAULT '',
+	owner_id BIGINT NOT NULL,
+	source_link TEXT NOT NULL DEFAULT '',
+	-- Cards are copied, not referenced, so later catalog updates do not rewrite history.
+	cards JSONB NOT NULL DEFAULT '[]'::jsonb,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (deck_id, number)
+);
+
+-- Existing decks start their history with their current list.
+INSERT INTO deck_revisions (deck_id, number, name, color, format, commander, commander_image_uri, owner_id, source_link, cards)
+SELECT d.id, 1, d.name, d.color, d.format, COALESCE(d.commander, ''), d.commander_image_uri, d.owner_id, d.source_link,
+	COALESCE((
+		SELECT jsonb_agg(jsonb_build_object(
+			'oracle_id', c.oracle_id,
+			'name', c.name,
+			'quantity', dc.quantity,
+			'board', dc.board,
+			'mana_cost', c.mana_cost,
+			'type_line', c.type_line,
+			'color_identity', c.color_identity,
+			'image_uri', c.image_uri
+		) ORDER BY c.name, dc.board)
+		FROM deck_cards dc JOIN cards c ON c.oracle_id = dc.oracle_id
+		WHERE dc.deck_id = d.id
+	), '[]'::jsonb)
+FROM decks d;
@@ -0,0 +1,10 @@
+CREATE TABLE collection_items (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	oracle_id TEXT NOT NULL REFERENCES cards(oracle_id),
+	quantity INTEGER NOT NULL CHECK (quantity > 0),
+	foil BOOLEAN NOT NULL DEFAULT FALSE,
+	condition TEXT NOT NULL DEFAULT 'near_mint'
+		CHECK (condition IN ('mint', 'near_mint', 'lightly_played', 'moderately_played', 'heavily_played', 'damaged')),
+	UNIQUE (user_id, oracle_id, foil, condition)
+);
@@ -0,0 +1,25 @@
+CREATE TABLE games (
+	id BIGSERIAL PRIMARY KEY,
+	played_at TIMESTAMPTZ NOT NULL,
+	turns INTEGER NOT NULL DEFAULT 0 CHECK (turns >= 0),
+	created_by BIGINT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX games_played_at_idx ON games (played_at DESC, id DESC);
+
+-- deck_id has no foreign key: games keep their results after a deck is
+-- deleted, with the owner and deck name recorded at the time.
+CREATE TABLE game_players (
+	game_id BIGINT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	deck_id BIGINT NOT NULL,
+	owner_id BIGINT NOT NULL,
+	deck_name TEXT NOT NULL,
+	winner BOOLEAN NOT NULL DEFAULT FALSE,
+	eliminated INTEGER NOT NULL DEFAULT 0 CHECK (eliminated >= 0),
+	PRIMARY KEY (game_id, deck_id)
+);
+
+CREATE INDEX game_players_deck_id_idx ON game_players (deck_id);
+CREATE INDEX game_players_owner_id_idx ON game_players (owner_id);
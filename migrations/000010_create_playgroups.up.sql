@@ -0,0 +1,29 @@
+CREATE TABLE playgroups (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	created_by BIGINT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE playgroup_members (
+	playgroup_id BIGINT NOT NULL REFERENCES playgroups(id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL,
+	role TEXT NOT NULL CHECK (role IN ('owner', 'member')),
+	joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (playgroup_id, user_id)
+);
+
+CREATE INDEX playgroup_members_user_id_idx ON playgroup_members (user_id);
+
+-- Emails are stored lowercased, see playgroup.NormalizeEmail.
+CREATE TABLE playgroup_invitations (
+	id BIGSERIAL PRIMARY KEY,
+	playgroup_id BIGINT NOT NULL REFERENCES playgroups(id) ON DELETE CASCADE,
+	email TEXT NOT NULL,
+	invited_by BIGINT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	UNIQUE (playgroup_id, email)
+);
+
+CREATE INDEX playgroup_invitations_email_idx ON playgroup_invitations (email);
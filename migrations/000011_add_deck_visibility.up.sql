@@ -0,0 +1,6 @@
+-- Existing decks stay public, as every deck was visible before.
+ALTER TABLE decks
+	ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('private', 'unlisted', 'public')),
+	ADD COLUMN share_token TEXT UNIQUE;
+
+CREATE INDEX decks_visibility_idx ON decks (visibility);
@@ -0,0 +1,15 @@
+-- Refresh tokens are identified by their jti claim. A renewed token is
+-- revoked and points at its successor; all tokens renewed from one login
+-- share a family_id.
+CREATE TABLE refresh_tokens (
+	id TEXT PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	family_id TEXT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	revoked_at TIMESTAMPTZ,
+	replaced_by TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
@@ -0,0 +1,20 @@
+-- Failed login counters, keyed by "email:<email>" or "ip:<address>". Used when
+-- LOGIN_STORE is postgres, so every instance sees the same counts.
+CREATE TABLE login_attempts (
+	key TEXT PRIMARY KEY,
+	failures INTEGER NOT NULL,
+	last_failure TIMESTAMPTZ NOT NULL,
+	locked_until TIMESTAMPTZ
+);
+
+-- Security events, such as login lockouts.
+CREATE TABLE audit_log (
+	id BIGSERIAL PRIMARY KEY,
+	event TEXT NOT NULL,
+	email TEXT NOT NULL DEFAULT '',
+	ip TEXT NOT NULL DEFAULT '',
+	detail TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_log_event_idx ON audit_log (event);
@@ -0,0 +1,81 @@
+// Package mailer sends the emails of the API through SMTP, or writes them to
+// the log or to a file for local development.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/josofm/liliana/pkg/logger"
+)
+
+// Message -.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer -.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// Discard drops every message.
+var Discard Mailer = discard{}
+
+type discard struct{}
+
+func (discard) Send(context.Context, Message) error { return nil }
+
+// LogMailer writes each message to the logger instead of sending it.
+type LogMailer struct {
+	logger logger.Interface
+}
+
+// NewLogMailer -.
+func NewLogMailer(l logger.Interface) *LogMailer {
+	return &LogMailer{logger: l}
+}
+
+// Send -.
+func (m *LogMailer) Send(_ context.Context, message Message) error {
+	m.logger.Info("mailer - to: %s - subject: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
+
+// FileMailer appends each message to a file, so local tests can read the
+// links that would have been emailed.
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileMailer -.
+func NewFileMailer(path string) *FileMailer {
+	return &FileMailer{path: path}
+}
+
+// Send -.
+func (m *FileMailer) Send(_ context.Context, message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	file, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("mailer - FileMailer - open: %w", err)
+	}
+	defer file.Close()
+	if err := writeMessage(file, message); err != nil {
+		return fmt.Errorf("mailer - FileMailer - write: %w", err)
+	}
+	return nil
+}
+
+func writeMessage(w io.Writer, message Message) error {
+	_, err := fmt.Fprintf(w, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().UTC().Format(time.RFC1123Z), message.To, message.Subject, message.Body)
+	return err
+}
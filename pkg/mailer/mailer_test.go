@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m := NewFileMailer(path)
+	require.NoError(t, m.Send(context.Background(), Message{To: "liliana@example.com", Subject: "First", Body: "token-1"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "liliana@example.com", Subject: "Second", Body: "token-2"}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "To: liliana@example.com\nSubject: First\n\ntoken-1")
+	assert.Contains(t, string(content), "Subject: Second\n\ntoken-2", "messages are appended")
+}
+
+func TestSMTPMailer(t *testing.T) {
+	m := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", Port: "587", Username: "liliana", Password: "secret", From: "noreply@example.com"})
+	var addr string
+	var to []string
+	var body []byte
+	m.send = func(a string, auth smtp.Auth, from string, recipients []string, msg []byte) error {
+		addr, to, body = a, recipients, msg
+		assert.NotNil(t, auth)
+		assert.Equal(t, "noreply@example.com", from)
+		return nil
+	}
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "liliana@example.com", Subject: "Redefinição de senha", Body: "line 1\nline 2"}))
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.Equal(t, []string{"liliana@example.com"}, to)
+	assert.Contains(t, string(body), "To: liliana@example.com\r\n")
+	assert.Contains(t, string(body), "Subject: =?utf-8?q?Redefini=C3=A7=C3=A3o_de_senha?=\r\n")
+	assert.Contains(t, string(body), "\r\n\r\nline 1\r\nline 2")
+
+	err := m.Send(context.Background(), Message{To: "liliana@example.com\r\nBcc: x@example.com"})
+	assert.Error(t, err)
+}
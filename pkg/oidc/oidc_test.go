@@ -0,0 +1,122 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/josofm/liliana/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupProvider(t *testing.T) (*oidctest.Provider, *Client) {
+	t.Helper()
+	provider := oidctest.NewProvider("liliana", "client-secret")
+	t.Cleanup(provider.Close)
+	client := New(Config{
+		IssuerURL:    provider.Issuer(),
+		ClientID:     "liliana",
+		ClientSecret: "client-secret",
+		RedirectURL:  "https://liliana.example.com/auth/oidc/callback",
+	})
+	return provider, client
+}
+
+// login runs the authorization step and returns the code sent back to the client
+func login(t *testing.T, provider *oidctest.Provider, client *Client, state, nonce, verifier string) string {
+	t.Helper()
+	authURL, err := client.AuthCodeURL(context.Background(), state, nonce, S256Challenge(verifier))
+	require.NoError(t, err)
+	callback, err := provider.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "liliana.example.com", callback.Host)
+	assert.Equal(t, state, callback.Query().Get("state"))
+	return callback.Query().Get("code")
+}
+
+func TestClient_AuthorizationCodeFlow(t *testing.T) {
+	provider, client := setupProvider(t)
+	provider.SetIdentity(oidctest.Identity{Subject: "abc", Email: "liliana@example.com", EmailVerified: true, Name: "Liliana"})
+
+	authURL, err := client.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+	code := login(t, provider, client, "state", "nonce", verifier)
+
+	token, err := client.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+	idToken, err := client.VerifyIDToken(context.Background(), token.IDToken, "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, provider.Issuer(), idToken.Issuer)
+	assert.Equal(t, "abc", idToken.Subject)
+	assert.Equal(t, "liliana@example.com", idToken.Email)
+	assert.True(t, idToken.EmailVerified)
+	assert.Equal(t, "Liliana", idToken.Name)
+
+	_, err = client.Exchange(context.Background(), code, verifier)
+	assert.ErrorContains(t, err, "invalid_grant", "codes are single use")
+}
+
+func TestClient_RejectsInvalidExchanges(t *testing.T) {
+	provider, client := setupProvider(t)
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+
+	code := login(t, provider, client, "state", "nonce", verifier)
+	_, err = client.Exchange(context.Background(), code, "another-verifier")
+	assert.ErrorContains(t, err, "invalid_grant", "the verifier must match the challenge")
+
+	wrongSecret := New(Config{IssuerURL: provider.Issuer(), ClientID: "liliana", ClientSecret: "wrong", RedirectURL: "https://liliana.example.com/auth/oidc/callback"})
+	code = login(t, provider, client, "state", "nonce", verifier)
+	_, err = wrongSecret.Exchange(context.Background(), code, verifier)
+	assert.ErrorContains(t, err, "invalid_client")
+}
+
+func TestClient_VerifyIDToken(t *testing.T) {
+	provider, client := setupProvider(t)
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+	code := login(t, provider, client, "state", "nonce", verifier)
+	token, err := client.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+
+	_, err = client.VerifyIDToken(context.Background(), token.IDToken, "other-nonce")
+	assert.ErrorContains(t, err, "nonce")
+
+	_, err = client.VerifyIDToken(context.Background(), token.IDToken[:len(token.IDToken)-4]+"AAAA", "nonce")
+	assert.Error(t, err, "tampered signatures are rejected")
+
+	otherClient := New(Config{IssuerURL: provider.Issuer(), ClientID: "other-client", RedirectURL: "https://liliana.example.com/auth/oidc/callback"})
+	_, err = otherClient.VerifyIDToken(context.Background(), token.IDToken, "nonce")
+	assert.Error(t, err, "tokens issued to other clients are rejected")
+
+	later := New(Config{
+		IssuerURL:   provider.Issuer(),
+		ClientID:    "liliana",
+		RedirectURL: "https://liliana.example.com/auth/oidc/callback",
+		Now:         func() time.Time { return time.Now().Add(2 * time.Hour) },
+	})
+	_, err = later.VerifyIDToken(context.Background(), token.IDToken, "nonce")
+	assert.ErrorContains(t, err, "expired")
+}
+
+func TestClient_DiscoveryIssuerMismatch(t *testing.T) {
+	provider, _ := setupProvider(t)
+	client := New(Config{IssuerURL: provider.Issuer() + "/", ClientID: "liliana"})
+
+	_, err := client.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	assert.ErrorContains(t, err, "does not match")
+}
+
+func TestS256Challenge(t *testing.T) {
+	// Example from RFC 7636, appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}